@@ -16,12 +16,28 @@ goredis "github.com/redis/go-redis/v9"
 	"appsite-go/internal/admin"
 	"appsite-go/internal/admin/ui"
 	"appsite-go/internal/apis"
+"appsite-go/internal/core/event"
 "appsite-go/internal/core/log"
+"appsite-go/internal/core/model"
+"appsite-go/internal/core/queue"
 "appsite-go/internal/core/route"
+"appsite-go/internal/core/scheduler"
 "appsite-go/internal/core/setting"
+"appsite-go/internal/services/access/apikey"
+"appsite-go/internal/services/access/lockout"
+"appsite-go/internal/services/access/mfa"
+"appsite-go/internal/services/access/oauth"
+"appsite-go/internal/services/access/oidc"
+"appsite-go/internal/services/access/operation"
+"appsite-go/internal/services/access/permission"
+"appsite-go/internal/services/access/session"
 "appsite-go/internal/services/access/token"
 "appsite-go/internal/services/access/verify"
+"appsite-go/internal/services/commerce/coupon"
+"appsite-go/internal/services/commerce/stock"
 "appsite-go/internal/services/contents"
+"appsite-go/internal/services/finance"
+"appsite-go/internal/services/message"
 "appsite-go/internal/services/user/account"
 "appsite-go/pkg/utils/orm"
 appsite_redis "appsite-go/pkg/utils/redis"
@@ -57,7 +73,22 @@ db, err := orm.InitDB(&cfg.Database)
 if err != nil {
 log.Fatal(ctx, "Failed to connect to database", "err", err)
 }
-log.Info(ctx, "Database connected")
+log.Info(ctx, "Database connected", "replicas", len(cfg.Database.Replicas))
+if err := db.Use(model.NewTenantPlugin()); err != nil {
+log.Fatal(ctx, "Failed to install tenant plugin", "err", err)
+}
+
+// "migrate" subcommand runs and exits before any server dependency is started
+if len(os.Args) > 1 && os.Args[1] == "migrate" {
+if err := runMigrate(db, os.Args[2:]); err != nil {
+fmt.Println(err)
+os.Exit(1)
+}
+return
+}
+if err := checkMigrations(ctx, db, cfg.Database.AutoMigrate); err != nil {
+log.Fatal(ctx, "Failed to migrate database", "err", err)
+}
 
 // 4. Initialize Redis
 // Try connecting to configured Redis
@@ -82,37 +113,155 @@ log.Info(ctx, "Redis connected")
 
 // 5. Initialize Services (DI)
 // Access Services
-tokenSvc := token.NewService(cfg.App)
-otpSvc := verify.NewOTPService(rdb)
+tokenSvc := token.NewService(cfg.App).WithStore(db, rdb)
+if err := tokenSvc.LoadKeys(ctx); err != nil {
+log.Fatal(ctx, "Failed to load token signing keys", "err", err)
+}
+smsSender, mailSender, err := newSenders(cfg)
+if err != nil {
+log.Fatal(ctx, "Failed to set up message senders", "err", err)
+}
+// Background jobs: services register handlers on jobs, one worker runs each configured queue.
+// Mail is sent from the default queue when this process serves it.
+jobs := queue.New(rdb)
+if mailSender != nil && cfg.Queue.Queues["default"] > 0 {
+mailSender = message.NewQueuedMailer(jobs, "default", mailSender)
+}
+otpSvc := verify.NewOTPService(rdb).WithSenders(smsSender, mailSender).WithOptions(verify.OTPOptions{
+Length:         cfg.OTP.Length,
+TTL:            cfg.OTP.TTL,
+MaxAttempts:    cfg.OTP.MaxAttempts,
+Lockout:        cfg.OTP.Lockout,
+TargetCooldown: cfg.OTP.TargetCooldown,
+IPCooldown:     cfg.OTP.IPCooldown,
+SMSTemplate:    cfg.OTP.SMSTemplate,
+})
 
 // User Services
-authSvc := account.NewAuthService(db, tokenSvc, otpSvc)
+sessionSvc := session.NewService(db, rdb, tokenSvc)
+mfaSvc := mfa.NewService(db, rdb, cfg.App.Name)
+authSvc := account.NewAuthService(db, tokenSvc, otpSvc).WithSessions(sessionSvc).WithMFA(mfaSvc).
+WithPasswordReset(account.ResetOptions{
+Secret: []byte(cfg.App.JwtSecret),
+URL:    cfg.Password.ResetURL,
+TTL:    cfg.Password.ResetTTL,
+Mailer: mailSender,
+}).
+WithLockout(lockout.NewService(rdb, operation.NewService(db), lockout.Options{
+MaxFailures:   cfg.Login.MaxFailures,
+IPMaxFailures: cfg.Login.IPMaxFailures,
+Window:        cfg.Login.Window,
+Lockout:       cfg.Login.Lockout,
+DelayAfter:    cfg.Login.DelayAfter,
+BaseDelay:     cfg.Login.BaseDelay,
+MaxDelay:      cfg.Login.MaxDelay,
+})).
+WithSocial(newSocial(cfg, rdb)).
+WithOIDC(oidc.NewService(db, rdb))
 
 // ... Init other services here ...
 
 // 6. Initialize API Container
+	// Apps signing users in through the site
+	var oauthSvc *oauth.Service
+	if cfg.OAuth.Issuer != "" {
+		oauthSvc = oauth.NewService(db, rdb, tokenSvc, cfg.OAuth.Issuer).WithAuthorizePage(cfg.OAuth.AuthorizePage)
+	}
+
+	// API keys of partner backends
+	apikeySvc := apikey.NewService(db, rdb)
+
 	// Content Services
 	articleSvc := contents.NewArticleService(db)
 	bannerSvc := contents.NewBannerService(db)
 
+	// Commerce Services; coupon issuance and stock changes also take a Redis
+	// lock, as row locks do not cover writers behind other replicas
+	couponSvc := coupon.NewService(db).WithLocker(rdb)
+	inventorySvc := stock.NewInventoryService(db).WithLocker(rdb)
+
 	// 6. Initialize API Container
 	container := &apis.Container{
 		TokenSvc:   tokenSvc,
+		SessionSvc: sessionSvc,
 		AuthSvc:    authSvc,
+		OAuthSvc:   oauthSvc,
+		APIKeySvc:  apikeySvc,
+		OTPSvc:     otpSvc,
 		ArticleSvc: articleSvc,
 		BannerSvc:  bannerSvc,
+
+		CouponSvc:    couponSvc,
+		InventorySvc: inventorySvc,
 	}
 
+	// Domain events: services publish into the outbox, the dispatcher delivers
+	bus := event.NewBus()
+	finance.NewLedgerService(db).Subscribe(bus)
+	message.NewNotificationService(db).WithMailer(mailSender).Subscribe(bus)
+	dispatcher := event.NewDispatcher(db, bus, event.Options{
+		Interval:    cfg.Events.Interval,
+		BatchSize:   cfg.Events.BatchSize,
+		MaxAttempts: cfg.Events.MaxAttempts,
+		Backoff:     cfg.Events.Backoff,
+	})
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+	go dispatcher.Run(dispatchCtx)
+
+	// Job workers, for the handlers registered above
+	workers := make([]*queue.Worker, 0, len(cfg.Queue.Queues))
+	for name, concurrency := range cfg.Queue.Queues {
+		w := queue.NewWorker(jobs, name, queue.Options{
+			Concurrency:  concurrency,
+			PollInterval: cfg.Queue.PollInterval,
+			MaxAttempts:  cfg.Queue.MaxAttempts,
+			Backoff:      cfg.Queue.Backoff,
+			Lease:        cfg.Queue.Lease,
+		})
+		w.Start()
+		workers = append(workers, w)
+	}
+	log.Info(ctx, "Job workers started", "queues", len(workers))
+
+	// Periodic maintenance; each tick runs on one instance only
+	sched := scheduler.New(db, rdb, scheduler.Options{Specs: cfg.Cron.Specs, Timeout: cfg.Cron.Timeout})
+	if err := registerTasks(sched, db, tokenSvc, sessionSvc, couponSvc, dispatcher, cfg); err != nil {
+		log.Fatal(ctx, "Failed to register cron jobs", "err", err)
+	}
+	sched.Start()
+
+	// Admin access policies, reloaded when another instance edits them
+	permissionSvc, err := permission.NewDBService(db)
+	if err != nil {
+		log.Fatal(ctx, "Failed to load access policies", "err", err)
+	}
+	permissionSvc.WithWatcher(rdb)
+	go permissionSvc.Run(dispatchCtx)
+
 	// Initialize Admin Container
 	adminContainer := &admin.Container{
-		AuthSvc:    authSvc,
-		ArticleSvc: articleSvc,
-		BannerSvc:  bannerSvc,
-		Config:     cfg,
+		TokenSvc:      tokenSvc,
+		PermissionSvc: permissionSvc,
+		AuthSvc:       authSvc,
+		SessionSvc:    sessionSvc,
+		MFASvc:        mfaSvc,
+		OAuthSvc:      oauthSvc,
+		APIKeySvc:     apikeySvc,
+		ArticleSvc:    articleSvc,
+		BannerSvc:     bannerSvc,
+		Config:        cfg,
+		DB:            db,
+		Dispatcher:    dispatcher,
+		Jobs:          jobs,
+		Scheduler:     sched,
 	}
 
 	// 7. Setup Router
-	r := route.NewEngine(cfg)
+	r, err := route.NewEngine(cfg)
+	if err != nil {
+		log.Fatal(ctx, "Invalid trusted proxies", "err", err)
+	}
 	apis.RegisterRoutes(r, container)
 	admin.RegisterRoutes(r, adminContainer)
     
@@ -149,5 +298,19 @@ if err := srv.Shutdown(shutdownCtx); err != nil {
 log.Fatal(ctx, "Server forced to shutdown", "err", err)
 }
 
+// Let running jobs finish; interrupted ones go back to their queue
+drainTimeout := cfg.Queue.DrainTimeout
+if drainTimeout <= 0 {
+drainTimeout = 30 * time.Second
+}
+drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+defer cancelDrain()
+if err := queue.Drain(drainCtx, workers...); err != nil {
+log.Warn(ctx, "Jobs interrupted by shutdown", "err", err)
+}
+if err := sched.Stop(drainCtx); err != nil {
+log.Warn(ctx, "Cron jobs still running at shutdown", "err", err)
+}
+
 log.Info(ctx, "Server exiting")
 }
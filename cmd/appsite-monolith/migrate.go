@@ -0,0 +1,103 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/migrations"
+)
+
+const migrateUsage = "usage: appsite-monolith migrate up | down [steps] | status"
+
+// runMigrate handles "appsite-monolith migrate up|down [steps]|status"
+func runMigrate(db *gorm.DB, args []string) error {
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		ids, err := m.Up()
+		for _, id := range ids {
+			fmt.Println("applied  ", id)
+		}
+		if err == nil && len(ids) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		ids, err := m.Down(steps)
+		for _, id := range ids {
+			fmt.Println("reverted ", id)
+		}
+		return err
+
+	case "status":
+		list, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tAPPLIED\tDESCRIPTION")
+		for _, st := range list {
+			applied := "pending"
+			if st.Applied {
+				applied = time.Unix(st.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", st.ID, applied, st.Description)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// checkMigrations applies pending migrations when auto is set,
+// otherwise it warns about them so the server never runs on a stale schema silently
+func checkMigrations(ctx context.Context, db *gorm.DB, auto bool) error {
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if auto {
+		ids, err := m.Up()
+		if len(ids) > 0 {
+			log.Info(ctx, "Migrations applied", "ids", ids)
+		}
+		return err
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		log.Warn(ctx, "Database has pending migrations, run: appsite-monolith migrate up", "count", len(pending))
+	}
+	return nil
+}
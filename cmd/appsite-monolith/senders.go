@@ -0,0 +1,47 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/pkg/extra/mail"
+	"appsite-go/pkg/extra/sms"
+)
+
+// newSenders builds the SMS and email senders delivering one-time codes.
+// Debug mode logs messages to the console; otherwise an unconfigured
+// channel is nil and codes cannot be sent through it.
+func newSenders(cfg *setting.Config) (sms.Sender, mail.Sender, error) {
+	if cfg.App.Mode == "debug" {
+		return &sms.ConsoleSender{Prefix: "sms"}, &mail.ConsoleSender{Prefix: "mail"}, nil
+	}
+
+	var smsSender sms.Sender
+	switch cfg.SMS.Provider {
+	case "":
+	case "aliyun":
+		s, err := sms.NewAliyunSender(cfg.SMS.RegionID, cfg.SMS.AccessKeyID, cfg.SMS.AccessKeySecret, cfg.SMS.SignName)
+		if err != nil {
+			return nil, nil, err
+		}
+		smsSender = s
+	default:
+		return nil, nil, fmt.Errorf("unknown sms provider %q", cfg.SMS.Provider)
+	}
+
+	var mailSender mail.Sender
+	if cfg.Mail.Host != "" {
+		mailSender = &mail.SMTPSender{
+			Host:     cfg.Mail.Host,
+			Port:     cfg.Mail.Port,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		}
+	}
+	return smsSender, mailSender, nil
+}
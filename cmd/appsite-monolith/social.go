@@ -0,0 +1,26 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/services/access/social"
+)
+
+// newSocial enables the social login providers that are configured
+func newSocial(cfg *setting.Config, rdb *redis.Client) *social.Service {
+	svc := social.NewService(rdb)
+	if w := cfg.Social.Wechat; w.AppID != "" {
+		svc.WithProvider(&social.Wechat{
+			AppID:       w.AppID,
+			Secret:      w.Secret,
+			RedirectURL: w.RedirectURL,
+			Scope:       w.Scope,
+		})
+	}
+	return svc
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/admin/trash"
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/scheduler"
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/commerce/coupon"
+	"appsite-go/internal/services/commerce/order"
+	centity "appsite-go/internal/services/contents/entity"
+	"appsite-go/internal/services/form"
+	uentity "appsite-go/internal/services/user/entity"
+	"appsite-go/internal/services/user/info"
+	"appsite-go/internal/services/world/saas"
+)
+
+// task is a maintenance job reporting the rows it changed
+type task struct {
+	name, spec, description string
+	run                     func(ctx context.Context) (int64, error)
+}
+
+// registerTasks schedules the periodic maintenance of the services.
+// Specs can be overridden per job name under cron.specs.
+func registerTasks(s *scheduler.Scheduler, db *gorm.DB, tokens *token.Service, sessions *session.Service, coupons *coupon.Service, dispatcher *event.Dispatcher, cfg *setting.Config) error {
+	payTimeout := cfg.Cron.OrderPayTimeout
+	if payTimeout <= 0 {
+		payTimeout = 30 * time.Minute
+	}
+	orders := order.NewService(db)
+
+	tasks := []task{
+		{"close_unpaid_orders", "*/5 * * * *", "Close orders left unpaid past the payment timeout",
+			func(ctx context.Context) (int64, error) {
+				n, err := orders.CloseUnpaid(ctx, time.Now().Add(-payTimeout))
+				return int64(n), err
+			}},
+		{"expire_user_coupons", "@hourly", "Expire unused coupons whose rule has ended",
+			coupons.ExpireUserCoupons},
+		{"purge_expired_forms", "@daily", "Delete pending form requests past their expiry",
+			form.NewSubmissionService(db).PurgeExpired},
+		{"expire_vip", "@hourly", "Drop the VIP level of expired memberships",
+			info.NewService(db).ExpireVIP},
+		{"disable_expired_tenants", "*/10 * * * *", "Disable tenants past their subscription expiry",
+			saas.NewTenantService(db).DisableExpired},
+		{"purge_refresh_tokens", "@daily", "Delete expired refresh tokens",
+			tokens.PurgeExpired},
+		{"purge_sessions", "30 0 * * *", "Delete sessions whose refresh tokens are gone",
+			sessions.PurgeExpired},
+	}
+
+	if retention := cfg.Trash.Retention; retention > 0 {
+		opens := []func(ctx context.Context) model.Bin{
+			model.BinOf[centity.Article](db),
+			model.BinOf[centity.Banner](db),
+			model.BinOf[uentity.User](db),
+		}
+		for _, open := range trash.Bins(db) {
+			opens = append(opens, open)
+		}
+		tasks = append(tasks, task{"purge_trash", "@hourly", "Permanently delete rows trashed longer than trash.retention",
+			func(ctx context.Context) (int64, error) {
+				bins := make([]model.Bin, len(opens))
+				for i, open := range opens {
+					bins[i] = open(ctx)
+				}
+				return model.PurgeTrash(ctx, retention, bins...)
+			}})
+	}
+
+	if retention := cfg.Events.Retention; retention > 0 {
+		tasks = append(tasks, task{"purge_outbox", "15 * * * *", "Delete delivered events older than events.retention",
+			func(ctx context.Context) (int64, error) {
+				return dispatcher.PurgeDelivered(ctx, time.Now().Add(-retention))
+			}})
+	}
+
+	if tokens.Asymmetric() {
+		tasks = append(tasks, task{"rotate_signing_keys", "@monthly", "Publish the next access token signing key and retire the current one",
+			func(ctx context.Context) (int64, error) { return 0, tokens.RotateKeys(ctx) }})
+	}
+
+	for _, t := range tasks {
+		name, run := t.name, t.run
+		err := s.Register(name, t.spec, t.description, 0, func(ctx context.Context) error {
+			n, err := run(ctx)
+			if n > 0 {
+				log.Info(ctx, "Cron job changed rows", "job", name, "rows", n)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
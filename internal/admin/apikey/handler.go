@@ -0,0 +1,71 @@
+package apikey
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/account"
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/apikey"
+)
+
+// Handler manages the API keys of users and tenants
+type Handler struct {
+	svc *apikey.Service
+}
+
+// NewHandler creates a new admin API key handler
+func NewHandler(svc *apikey.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ListKeys lists keys, by user or tenant
+func (h *Handler) ListKeys(c *gin.Context) {
+	var f apikey.Filter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	list, err := h.svc.List(c.Request.Context(), f)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// GetKey returns a key
+func (h *Handler) GetKey(c *gin.Context) {
+	k, err := h.svc.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, account.KeyError(err))
+		return
+	}
+	response.Success(c, k)
+}
+
+// CreateKey issues a key to a user, or to a tenant when no user is given.
+// The key is only shown in this answer.
+func (h *Handler) CreateKey(c *gin.Context) {
+	var req apikey.Input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	k, raw, err := h.svc.Create(c.Request.Context(), req, c.GetString(middleware.ContextUserID))
+	if err != nil {
+		response.Error(c, account.KeyError(err))
+		return
+	}
+	response.Success(c, gin.H{"key": k, "api_key": raw})
+}
+
+// RevokeKey revokes any key
+func (h *Handler) RevokeKey(c *gin.Context) {
+	if err := h.svc.Revoke(c.Request.Context(), c.Param("id"), ""); err != nil {
+		response.Error(c, account.KeyError(err))
+		return
+	}
+	response.Success(c, nil)
+}
@@ -3,6 +3,8 @@ package auth
 import (
 "github.com/gin-gonic/gin"
 
+apiauth "appsite-go/internal/apis/auth"
+"appsite-go/internal/apis/request"
 "appsite-go/internal/apis/response"
 "appsite-go/internal/services/user/account"
 )
@@ -31,17 +33,65 @@ response.Error(c, err)
 return
 }
 
-token, user, err := h.svc.Login(req.Username, req.Password)
+pair, user, err := h.svc.Login(c.Request.Context(), req.Username, req.Password, request.Device(c))
+if apiauth.MFAChallenge(c, err) {
+return
+}
 if err != nil {
-response.Error(c, err)
+response.Error(c, apiauth.LoginError(c, err))
 return
 }
 
-// Strict check: Admin should enforce permissions.
-// For now we assume if they can login here, we will check RBAC in middleware later.
+// Any user may log in here; the admin routes check the group of the
+// user against the access policies, see middleware.Permission.
 
 response.Success(c, gin.H{
-"token": token,
-"admin": user,
+"token":         pair.AccessToken,
+"refresh_token": pair.RefreshToken,
+"expires_in":    pair.ExpiresIn,
+"admin":         user,
 })
 }
+
+// LoginMFA completes an admin login challenge with a second factor code
+func (h *Handler) LoginMFA(c *gin.Context) {
+var req apiauth.MFALoginRequest
+if err := c.ShouldBindJSON(&req); err != nil {
+response.Error(c, err)
+return
+}
+
+pair, user, codes, err := h.svc.CompleteMFA(c.Request.Context(), req.Challenge, req.Code, request.Device(c))
+if err != nil {
+response.Error(c, apiauth.MFAError(err))
+return
+}
+
+data := gin.H{
+"token":         pair.AccessToken,
+"refresh_token": pair.RefreshToken,
+"expires_in":    pair.ExpiresIn,
+"admin":         user,
+}
+if codes != nil {
+data["recovery_codes"] = codes
+}
+response.Success(c, data)
+}
+
+// EnrollMFA returns the authenticator secret of an admin whose group
+// requires a second factor, during the login challenge
+func (h *Handler) EnrollMFA(c *gin.Context) {
+var req apiauth.MFAEnrollRequest
+if err := c.ShouldBindJSON(&req); err != nil {
+response.Error(c, err)
+return
+}
+
+enrollment, err := h.svc.EnrollMFA(c.Request.Context(), req.Challenge)
+if err != nil {
+response.Error(c, apiauth.MFAError(err))
+return
+}
+response.Success(c, enrollment)
+}
@@ -1,12 +1,12 @@
 package contents
 
 import (
+"appsite-go/internal/apis/request"
 "appsite-go/internal/apis/response"
 apperr "appsite-go/internal/core/error"
 "appsite-go/internal/services/contents"
 "appsite-go/internal/services/contents/entity"
 "github.com/gin-gonic/gin"
-"strconv"
 )
 
 type Handler struct {
@@ -21,12 +21,25 @@ bannerService:  bs,
 }
 }
 
+// articles binds the article service to the request, scoping it to the tenant
+func (h *Handler) articles(c *gin.Context) *contents.ArticleService {
+return h.articleService.WithContext(c.Request.Context())
+}
+
+// banners binds the banner service to the request, scoping it to the tenant
+func (h *Handler) banners(c *gin.Context) *contents.BannerService {
+return h.bannerService.WithContext(c.Request.Context())
+}
+
 // ---- Articles ----
 
 // ListArticles lists articles with pagination and filtering
 func (h *Handler) ListArticles(c *gin.Context) {
-page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+params, err := request.ListParams(c, 10)
+if err != nil {
+response.Error(c, err)
+return
+}
 
 filters := make(map[string]interface{})
 if status := c.Query("status"); status != "" {
@@ -36,28 +49,26 @@ if categoryId := c.Query("category_id"); categoryId != "" {
 filters["category_id"] = categoryId
 }
 
-list, total, err := h.articleService.List(page, size, filters)
+params.Filters = filters
+
+page, err := h.articles(c).Query(params)
 if err != nil {
-response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
+response.Error(c, err)
 return
 }
 
-response.Success(c, gin.H{
-"list":  list,
-"total": total,
-"page":  page,
-"size":  size,
-})
+response.List(c, page)
 }
 
 // GetArticle gets a single article
 func (h *Handler) GetArticle(c *gin.Context) {
 id := c.Param("id")
-article, err := h.articleService.Get(id)
+article, err := h.articles(c).Get(id)
 if err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Article not found"))
 return
 }
+response.ETag(c, article.Version)
 response.Success(c, article)
 }
 
@@ -69,7 +80,7 @@ response.Error(c, apperr.NewWithMessage(apperr.InvalidParams, "Invalid request")
 return
 }
 
-if err := h.articleService.Create(&article); err != nil {
+if err := h.articles(c).Create(&article); err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
 return
 }
@@ -85,29 +96,79 @@ response.Error(c, apperr.NewWithMessage(apperr.InvalidParams, "Invalid request")
 return
 }
 
-if err := h.articleService.Update(id, updates); err != nil {
-response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
+// If-Match takes precedence over a version in the body
+if version, ok, err := request.IfMatch(c); err != nil {
+response.Error(c, err)
+return
+} else if ok {
+updates["version"] = version
+}
+
+article, err := h.articles(c).Update(id, updates)
+if err != nil {
+response.Error(c, err)
 return
 }
+response.ETag(c, article.Version)
 response.Success(c, nil)
 }
 
 // DeleteArticle deletes an article
 func (h *Handler) DeleteArticle(c *gin.Context) {
 id := c.Param("id")
-if err := h.articleService.Delete(id); err != nil {
+if err := h.articles(c).Delete(id); err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
 return
 }
 response.Success(c, nil)
 }
 
+// ListTrashedArticles lists deleted articles
+func (h *Handler) ListTrashedArticles(c *gin.Context) {
+params, err := request.ListParams(c, 10)
+if err != nil {
+response.Error(c, err)
+return
+}
+
+page, err := h.articles(c).Trashed(params)
+if err != nil {
+response.Error(c, err)
+return
+}
+
+response.List(c, page)
+}
+
+// RestoreArticle brings back a deleted article
+func (h *Handler) RestoreArticle(c *gin.Context) {
+id := c.Param("id")
+if err := h.articles(c).Restore(id); err != nil {
+response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Article not found in trash"))
+return
+}
+response.Success(c, nil)
+}
+
+// PurgeArticle permanently deletes a article from the trash
+func (h *Handler) PurgeArticle(c *gin.Context) {
+id := c.Param("id")
+if err := h.articles(c).Purge(id); err != nil {
+response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Article not found in trash"))
+return
+}
+response.Success(c, nil)
+}
+
 // ---- Banners ----
 
 // ListBanners lists banners
 func (h *Handler) ListBanners(c *gin.Context) {
-page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+params, err := request.ListParams(c, 10)
+if err != nil {
+response.Error(c, err)
+return
+}
 
 filters := make(map[string]interface{})
 if status := c.Query("status"); status != "" {
@@ -117,27 +178,26 @@ if position := c.Query("position"); position != "" {
 filters["position"] = position
 }
 
-list, total, err := h.bannerService.List(page, size, filters)
+params.Filters = filters
+
+page, err := h.banners(c).Query(params)
 if err != nil {
-response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
+response.Error(c, err)
 return
 }
-response.Success(c, gin.H{
-"list":  list,
-"total": total,
-"page":  page,
-"size":  size,
-})
+
+response.List(c, page)
 }
 
 // GetBanner gets a single banner
 func (h *Handler) GetBanner(c *gin.Context) {
 id := c.Param("id")
-banner, err := h.bannerService.Get(id)
+banner, err := h.banners(c).Get(id)
 if err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Banner not found"))
 return
 }
+response.ETag(c, banner.Version)
 response.Success(c, banner)
 }
 
@@ -149,7 +209,7 @@ response.Error(c, apperr.NewWithMessage(apperr.InvalidParams, "Invalid request")
 return
 }
 
-if err := h.bannerService.Create(&banner); err != nil {
+if err := h.banners(c).Create(&banner); err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
 return
 }
@@ -165,19 +225,66 @@ response.Error(c, apperr.NewWithMessage(apperr.InvalidParams, "Invalid request")
 return
 }
 
-if err := h.bannerService.Update(id, updates); err != nil {
-response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
+// If-Match takes precedence over a version in the body
+if version, ok, err := request.IfMatch(c); err != nil {
+response.Error(c, err)
 return
+} else if ok {
+updates["version"] = version
 }
+
+banner, err := h.banners(c).Update(id, updates)
+if err != nil {
+response.Error(c, err)
+return
+}
+response.ETag(c, banner.Version)
 response.Success(c, nil)
 }
 
 // DeleteBanner deletes a banner
 func (h *Handler) DeleteBanner(c *gin.Context) {
 id := c.Param("id")
-if err := h.bannerService.Delete(id); err != nil {
+if err := h.banners(c).Delete(id); err != nil {
 response.Error(c, apperr.NewWithMessage(apperr.ServerError, err.Error()))
 return
 }
 response.Success(c, nil)
 }
+
+// ListTrashedBanners lists deleted banners
+func (h *Handler) ListTrashedBanners(c *gin.Context) {
+params, err := request.ListParams(c, 10)
+if err != nil {
+response.Error(c, err)
+return
+}
+
+page, err := h.banners(c).Trashed(params)
+if err != nil {
+response.Error(c, err)
+return
+}
+
+response.List(c, page)
+}
+
+// RestoreBanner brings back a deleted banner
+func (h *Handler) RestoreBanner(c *gin.Context) {
+id := c.Param("id")
+if err := h.banners(c).Restore(id); err != nil {
+response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Banner not found in trash"))
+return
+}
+response.Success(c, nil)
+}
+
+// PurgeBanner permanently deletes a banner from the trash
+func (h *Handler) PurgeBanner(c *gin.Context) {
+id := c.Param("id")
+if err := h.banners(c).Purge(id); err != nil {
+response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Banner not found in trash"))
+return
+}
+response.Success(c, nil)
+}
@@ -0,0 +1,90 @@
+package oauth
+
+import (
+	"github.com/gin-gonic/gin"
+
+	apioauth "appsite-go/internal/apis/oauth"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/oauth"
+)
+
+// Handler manages the registry of OAuth clients
+type Handler struct {
+	svc *oauth.Service
+}
+
+// NewHandler creates a new admin OAuth client handler
+func NewHandler(svc *oauth.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ListClients lists the registered apps
+func (h *Handler) ListClients(c *gin.Context) {
+	list, err := h.svc.Clients(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// GetClient returns an app
+func (h *Handler) GetClient(c *gin.Context) {
+	client, err := h.svc.Client(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, apioauth.ClientError(err))
+		return
+	}
+	response.Success(c, client)
+}
+
+// CreateClient registers an app. The secret of a confidential app is only
+// shown in this answer.
+func (h *Handler) CreateClient(c *gin.Context) {
+	var req oauth.ClientInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	client, secret, err := h.svc.CreateClient(c.Request.Context(), req)
+	if err != nil {
+		response.Error(c, apioauth.ClientError(err))
+		return
+	}
+	response.Success(c, gin.H{"client": client, "client_secret": secret})
+}
+
+// UpdateClient edits an app; disabling it signs its users out
+func (h *Handler) UpdateClient(c *gin.Context) {
+	var req oauth.ClientInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	client, err := h.svc.UpdateClient(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		response.Error(c, apioauth.ClientError(err))
+		return
+	}
+	response.Success(c, client)
+}
+
+// DeleteClient removes an app and revokes its tokens
+func (h *Handler) DeleteClient(c *gin.Context) {
+	if err := h.svc.DeleteClient(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, apioauth.ClientError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RotateSecret issues a new secret to a confidential app; the old one stops working
+func (h *Handler) RotateSecret(c *gin.Context) {
+	secret, err := h.svc.RotateSecret(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, apioauth.ClientError(err))
+		return
+	}
+	response.Success(c, gin.H{"client_secret": secret})
+}
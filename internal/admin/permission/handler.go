@@ -0,0 +1,70 @@
+package permission
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/permission"
+)
+
+// Handler edits the access policies of user groups. Changes apply at once
+// on every instance.
+type Handler struct {
+	svc *permission.Service
+}
+
+// NewHandler creates a new admin policy handler
+func NewHandler(svc *permission.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ListPolicies lists the policies, of one group with ?role=
+func (h *Handler) ListPolicies(c *gin.Context) {
+	list, err := h.svc.Policies(c.Query("role"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// GrantPolicy lets a group call a path with a method
+func (h *Handler) GrantPolicy(c *gin.Context) {
+	var req permission.Policy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	if err := h.svc.Grant(req); err != nil {
+		response.Error(c, policyError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RevokePolicy removes a policy of a group
+func (h *Handler) RevokePolicy(c *gin.Context) {
+	var req permission.Policy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	if err := h.svc.Revoke(req); err != nil {
+		response.Error(c, policyError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+func policyError(err error) error {
+	switch {
+	case errors.Is(err, permission.ErrInvalidPolicy):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	case errors.Is(err, permission.ErrPolicyNotFound):
+		return apperr.NewWithMessage(apperr.NotFound, err.Error())
+	}
+	return err
+}
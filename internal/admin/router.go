@@ -3,21 +3,49 @@ package admin
 import (
 	"github.com/gin-gonic/gin"
 
+	"appsite-go/internal/admin/apikey"
 	"appsite-go/internal/admin/auth"
 	"appsite-go/internal/admin/contents"
+	"appsite-go/internal/admin/oauth"
+	"appsite-go/internal/admin/permission"
 	"appsite-go/internal/admin/system"
+	"appsite-go/internal/admin/trash"
 	"appsite-go/internal/admin/user"
+	"appsite-go/internal/apis/middleware"
+	apikey_svc "appsite-go/internal/services/access/apikey"
+	"appsite-go/internal/services/access/mfa"
+	oauth_svc "appsite-go/internal/services/access/oauth"
+	permission_svc "appsite-go/internal/services/access/permission"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
 	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/group"
 	scontent "appsite-go/internal/services/contents"
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/queue"
+	"appsite-go/internal/core/scheduler"
 	"appsite-go/internal/core/setting"
+	"gorm.io/gorm"
 )
 
-// Container holds dependencies for admin handlers
+// Container holds dependencies for admin handlers. Everything but the
+// login needs TokenSvc and PermissionSvc; without PermissionSvc all of it
+// is refused.
 type Container struct {
-	AuthSvc    *account.AuthService
-	ArticleSvc *scontent.ArticleService
-	BannerSvc  *scontent.BannerService
-	Config     *setting.Config
+	TokenSvc      *token.Service
+	PermissionSvc *permission_svc.Service
+	AuthSvc       *account.AuthService
+	SessionSvc    *session.Service
+	MFASvc        *mfa.Service
+	OAuthSvc      *oauth_svc.Service
+	APIKeySvc     *apikey_svc.Service
+	ArticleSvc    *scontent.ArticleService
+	BannerSvc     *scontent.BannerService
+	Config        *setting.Config
+	DB            *gorm.DB
+	Dispatcher    *event.Dispatcher
+	Jobs          *queue.Queue
+	Scheduler     *scheduler.Scheduler
 }
 
 // RegisterRoutes registers admin routes
@@ -28,24 +56,87 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 	if c.AuthSvc != nil {
 		h := auth.NewHandler(c.AuthSvc)
 		v1.POST("/login", h.Login)
+		v1.POST("/login/mfa", h.LoginMFA)
+		v1.POST("/login/mfa/enroll", h.EnrollMFA)
+	}
+
+	// Everything else needs an admin whose group may call the route;
+	// admins of a tenant only reach its rows
+	guard := v1.Group("")
+	guard.Use(middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, nil), middleware.Permission(c.PermissionSvc), middleware.PlatformAdmin())
+
+	// Policies, groups, OAuth clients and the system are shared by all
+	// tenants; only admins of the platform may touch them
+	platform := guard.Group("")
+	platform.Use(middleware.PlatformOnly())
+
+	// Access policies
+	if c.PermissionSvc != nil {
+		h := permission.NewHandler(c.PermissionSvc)
+		g := platform.Group("/permissions/policies")
+		{
+			g.GET("", h.ListPolicies)
+			g.POST("", h.GrantPolicy)
+			g.DELETE("", h.RevokePolicy)
+		}
 	}
 
 	// Users
 	if c.AuthSvc != nil {
 		h := user.NewHandler(c.AuthSvc)
-		// TODO: Add Admin Middleware here
-		g := v1.Group("/users")
+		g := guard.Group("/users")
 		{
 			g.GET("", h.ListUsers)
+			g.GET("/trash", h.ListTrashedUsers)
 			g.GET("/:id", h.GetUserDetail)
 			g.PUT("/:id", h.UpdateUser)
+			g.DELETE("/:id", h.DeleteUser)
+			g.POST("/:id/restore", h.RestoreUser)
+			g.POST("/:id/unlock", h.UnlockUser)
+		}
+		platform.POST("/login/unlock-ip", h.UnlockIP)
+		if c.SessionSvc != nil {
+			sh := user.NewSessionHandler(c.SessionSvc)
+			g.GET("/:id/sessions", sh.ListUserSessions)
+			g.POST("/:id/logout", sh.ForceLogout)
+		}
+		if c.MFASvc != nil && c.DB != nil {
+			mh := user.NewMFAHandler(c.MFASvc, group.NewService(c.DB))
+			g.DELETE("/:id/mfa", mh.ResetUserMFA)
+			platform.PUT("/groups/:id/mfa", mh.RequireGroupMFA)
+		}
+	}
+
+	// OAuth clients
+	if c.OAuthSvc != nil {
+		h := oauth.NewHandler(c.OAuthSvc)
+		g := platform.Group("/oauth/clients")
+		{
+			g.GET("", h.ListClients)
+			g.POST("", h.CreateClient)
+			g.GET("/:id", h.GetClient)
+			g.PUT("/:id", h.UpdateClient)
+			g.DELETE("/:id", h.DeleteClient)
+			g.POST("/:id/secret", h.RotateSecret)
+		}
+	}
+
+	// API keys
+	if c.APIKeySvc != nil {
+		h := apikey.NewHandler(c.APIKeySvc)
+		g := guard.Group("/api-keys")
+		{
+			g.GET("", h.ListKeys)
+			g.POST("", h.CreateKey)
+			g.GET("/:id", h.GetKey)
+			g.DELETE("/:id", h.RevokeKey)
 		}
 	}
 
 	// Content
 	if c.ArticleSvc != nil && c.BannerSvc != nil {
 		h := contents.NewHandler(c.ArticleSvc, c.BannerSvc)
-		g := v1.Group("/contents") 
+		g := guard.Group("/contents") 
 		{
 			// Articles
 			g.GET("/articles", h.ListArticles)
@@ -53,6 +144,9 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 			g.GET("/articles/:id", h.GetArticle)
 			g.PUT("/articles/:id", h.UpdateArticle)
 			g.DELETE("/articles/:id", h.DeleteArticle)
+			g.GET("/articles/trash", h.ListTrashedArticles)
+			g.POST("/articles/:id/restore", h.RestoreArticle)
+			g.DELETE("/articles/:id/purge", h.PurgeArticle)
 			
 			// Banners
 			g.GET("/banners", h.ListBanners)
@@ -60,12 +154,61 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 			g.GET("/banners/:id", h.GetBanner)
 			g.PUT("/banners/:id", h.UpdateBanner)
 			g.DELETE("/banners/:id", h.DeleteBanner)
+			g.GET("/banners/trash", h.ListTrashedBanners)
+			g.POST("/banners/:id/restore", h.RestoreBanner)
+			g.DELETE("/banners/:id/purge", h.PurgeBanner)
+		}
+	}
+
+	// Trash of the entities without routes of their own
+	if c.DB != nil {
+		h := trash.NewHandler(c.DB)
+		g := guard.Group("/trash/:kind")
+		{
+			g.GET("", h.ListTrashed)
+			g.POST("/:id/restore", h.Restore)
+			g.DELETE("/:id/purge", h.Purge)
 		}
 	}
 
 	// System & Config
 	if c.Config != nil {
-		h := system.NewHandler(c.Config)
-		v1.GET("/menu", h.GetMenu)
+		h := system.NewHandler(c.Config, c.DB)
+		guard.GET("/menu", h.GetMenu)
+		if c.DB != nil {
+			platform.GET("/system/database", h.DatabaseStats)
+		}
+	}
+
+	// Domain events
+	if c.Dispatcher != nil {
+		h := system.NewEventHandler(c.Dispatcher)
+		g := platform.Group("/system/events")
+		{
+			g.GET("/dead", h.ListDeadLetters)
+			g.POST("/:id/requeue", h.RequeueEvent)
+		}
+	}
+
+	// Background jobs
+	if c.Jobs != nil {
+		h := system.NewJobHandler(c.Jobs)
+		g := platform.Group("/system/jobs/:queue")
+		{
+			g.GET("", h.QueueStats)
+			g.GET("/dead", h.ListDeadJobs)
+			g.POST("/dead/:id/retry", h.RetryJob)
+		}
+	}
+
+	// Cron jobs
+	if c.Scheduler != nil {
+		h := system.NewCronHandler(c.Scheduler)
+		g := platform.Group("/system/cron")
+		{
+			g.GET("", h.ListCronJobs)
+			g.GET("/:name/runs", h.ListCronRuns)
+			g.POST("/:name/run", h.TriggerCronJob)
+		}
 	}
 }
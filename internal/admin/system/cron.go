@@ -0,0 +1,67 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/scheduler"
+)
+
+// CronHandler exposes the scheduled jobs and their run history
+type CronHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewCronHandler(s *scheduler.Scheduler) *CronHandler {
+	return &CronHandler{scheduler: s}
+}
+
+// ListCronJobs lists the registered jobs with their next and latest run
+func (h *CronHandler) ListCronJobs(c *gin.Context) {
+	jobs, err := h.scheduler.Jobs()
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, jobs)
+}
+
+// ListCronRuns lists the run history of a job
+func (h *CronHandler) ListCronRuns(c *gin.Context) {
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	page, err := h.scheduler.History(c.Param("name"), params)
+	if err != nil {
+		response.Error(c, cronError(err))
+		return
+	}
+	response.List(c, page)
+}
+
+// TriggerCronJob starts a job now, outside its schedule
+func (h *CronHandler) TriggerCronJob(c *gin.Context) {
+	run, err := h.scheduler.Trigger(c.Param("name"))
+	if err != nil {
+		response.Error(c, cronError(err))
+		return
+	}
+	response.Success(c, run)
+}
+
+func cronError(err error) error {
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return apperr.NewWithMessage(apperr.NotFound, "Cron job not found")
+	case errors.Is(err, scheduler.ErrBusy):
+		return apperr.NewWithMessage(apperr.Conflict, "Cron job is already running")
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/event"
+)
+
+// EventHandler exposes the dead-lettered domain events
+type EventHandler struct {
+	dispatcher *event.Dispatcher
+}
+
+func NewEventHandler(dispatcher *event.Dispatcher) *EventHandler {
+	return &EventHandler{dispatcher: dispatcher}
+}
+
+// ListDeadLetters lists events whose delivery was given up
+func (h *EventHandler) ListDeadLetters(c *gin.Context) {
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	page, err := h.dispatcher.DeadLetters(c.Request.Context(), params)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.List(c, page)
+}
+
+// RequeueEvent schedules a dead-lettered event for another round of delivery
+func (h *EventHandler) RequeueEvent(c *gin.Context) {
+	if err := h.dispatcher.Requeue(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, event.ErrNotDead) {
+			response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Event not found in dead letters"))
+			return
+		}
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
@@ -4,17 +4,21 @@ import (
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"appsite-go/internal/apis/response"
 	"appsite-go/internal/core/setting"
+	"appsite-go/pkg/utils/orm"
 )
 
 type Handler struct {
 	cfg *setting.Config
+	db  *gorm.DB
 }
 
-func NewHandler(cfg *setting.Config) *Handler {
+func NewHandler(cfg *setting.Config, db *gorm.DB) *Handler {
 	return &Handler{
 		cfg: cfg,
+		db:  db,
 	}
 }
 
@@ -44,3 +48,13 @@ func (h *Handler) GetMenu(c *gin.Context) {
 
 	response.Success(c, menu)
 }
+
+// DatabaseStats reports the connection pools of the primary and any read replicas
+func (h *Handler) DatabaseStats(c *gin.Context) {
+	stats, err := orm.PoolStats(h.db)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
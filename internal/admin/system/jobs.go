@@ -0,0 +1,60 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/queue"
+)
+
+// JobHandler exposes the state of the background job queues
+type JobHandler struct {
+	jobs *queue.Queue
+}
+
+func NewJobHandler(jobs *queue.Queue) *JobHandler {
+	return &JobHandler{jobs: jobs}
+}
+
+// QueueStats counts the jobs of a queue by state
+func (h *JobHandler) QueueStats(c *gin.Context) {
+	stats, err := h.jobs.Stats(c.Request.Context(), c.Param("queue"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
+
+// ListDeadJobs lists jobs that ran out of attempts
+func (h *JobHandler) ListDeadJobs(c *gin.Context) {
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	page, err := h.jobs.Dead(c.Request.Context(), c.Param("queue"), params)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.List(c, page)
+}
+
+// RetryJob puts a dead-lettered job back on its queue
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	if err := h.jobs.Retry(c.Request.Context(), c.Param("queue"), c.Param("id")); err != nil {
+		if errors.Is(err, queue.ErrNotDead) {
+			response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Job not found in dead letters"))
+			return
+		}
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,106 @@
+package trash
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/model"
+	commerce "appsite-go/internal/services/commerce/entity"
+	contents "appsite-go/internal/services/contents/entity"
+)
+
+// Bins returns the trash of the entities without a trash of their own in the
+// admin API, by the kind used in its routes. Articles, banners and users have
+// theirs under their own routes.
+func Bins(db *gorm.DB) map[string]func(ctx context.Context) model.Bin {
+	return map[string]func(ctx context.Context) model.Bin{
+		"coupons":    model.BinOf[commerce.Coupon](db),
+		"products":   model.BinOf[commerce.Product](db),
+		"skus":       model.BinOf[commerce.SKU](db),
+		"categories": model.BinOf[contents.Category](db),
+		"comments":   model.BinOf[contents.Comment](db),
+		"media":      model.BinOf[contents.Media](db),
+		"pages":      model.BinOf[contents.Page](db),
+		"tags":       model.BinOf[contents.Tag](db),
+	}
+}
+
+// Handler lists, restores and purges trashed entities of any kind in Bins
+type Handler struct {
+	bins map[string]func(ctx context.Context) model.Bin
+}
+
+// NewHandler creates a new trash handler
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{bins: Bins(db)}
+}
+
+// bin resolves the :kind of the route, answering NotFound for unknown kinds
+func (h *Handler) bin(c *gin.Context) model.Bin {
+	open, ok := h.bins[c.Param("kind")]
+	if !ok {
+		response.Error(c, apperr.NewWithMessage(apperr.NotFound, "Unknown trash"))
+		return nil
+	}
+	return open(c.Request.Context())
+}
+
+// binError maps a trash result error to its API code
+func binError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperr.NewWithMessage(apperr.NotFound, "Not found in trash")
+	}
+	return err
+}
+
+// ListTrashed lists the trashed entities of a kind, most recently deleted first
+func (h *Handler) ListTrashed(c *gin.Context) {
+	bin := h.bin(c)
+	if bin == nil {
+		return
+	}
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	res := bin.ListTrashed(params)
+	if !res.Success {
+		response.Error(c, res.Error)
+		return
+	}
+	response.Success(c, res.Data)
+}
+
+// Restore brings back a trashed entity
+func (h *Handler) Restore(c *gin.Context) {
+	bin := h.bin(c)
+	if bin == nil {
+		return
+	}
+	if res := bin.Restore(c.Param("id")); !res.Success {
+		response.Error(c, binError(res.Error))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// Purge permanently deletes a trashed entity
+func (h *Handler) Purge(c *gin.Context) {
+	bin := h.bin(c)
+	if bin == nil {
+		return
+	}
+	if res := bin.Purge(c.Param("id")); !res.Success {
+		response.Error(c, binError(res.Error))
+		return
+	}
+	response.Success(c, nil)
+}
@@ -1,10 +1,9 @@
 package user
 
 import (
-	"strconv"
-
 	"github.com/gin-gonic/gin"
 
+	"appsite-go/internal/apis/request"
 	"appsite-go/internal/apis/response"
 	"appsite-go/internal/services/user/account"
 	"appsite-go/internal/services/user/dto"
@@ -18,6 +17,11 @@ func NewHandler(svc *account.AuthService) *Handler {
 	return &Handler{svc: svc}
 }
 
+// users binds the account service to the request, scoping it to the tenant
+func (h *Handler) users(c *gin.Context) *account.AuthService {
+	return h.svc.WithContext(c.Request.Context())
+}
+
 // ListUsers - Admin list users with full filters
 func (h *Handler) ListUsers(c *gin.Context) {
 	var req dto.UserFilterReq
@@ -26,36 +30,25 @@ func (h *Handler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	page := 1
-	pageSize := 20
-	
-    if p := c.Query("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
-	}
-	if ps := c.Query("page_size"); ps != "" {
-		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
-			pageSize = v
-		}
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
 	}
 
-	users, count, err := h.svc.ListUsers(req, page, pageSize)
+	page, err := h.users(c).ListUsers(req, params)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"list":  users,
-		"total": count,
-	})
+	response.List(c, page)
 }
 
 // GetUserDetail
 func (h *Handler) GetUserDetail(c *gin.Context) {
 	uid := c.Param("id")
-	user, err := h.svc.GetDetail(uid)
+	user, err := h.users(c).GetDetail(uid)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -72,7 +65,44 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.Update(uid, req); err != nil {
+	if err := h.users(c).Update(uid, req); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// DeleteUser moves a user to the trash
+func (h *Handler) DeleteUser(c *gin.Context) {
+	uid := c.Param("id")
+	if err := h.users(c).Delete(uid); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ListTrashedUsers lists deleted users
+func (h *Handler) ListTrashedUsers(c *gin.Context) {
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	page, err := h.users(c).TrashedUsers(params)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.List(c, page)
+}
+
+// RestoreUser brings back a deleted user
+func (h *Handler) RestoreUser(c *gin.Context) {
+	uid := c.Param("id")
+	if err := h.users(c).Restore(uid); err != nil {
 		response.Error(c, err)
 		return
 	}
@@ -0,0 +1,36 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+)
+
+// UnlockUser lifts the login lock of a user after too many failed passwords
+func (h *Handler) UnlockUser(c *gin.Context) {
+	if err := h.svc.UnlockLogin(c.Request.Context(), c.Param("id"), c.GetString(middleware.ContextUserID)); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// UnlockIPRequest represents the IP unlock payload
+type UnlockIPRequest struct {
+	IP string `json:"ip" binding:"required,ip"`
+}
+
+// UnlockIP lifts the login lock of a client IP
+func (h *Handler) UnlockIP(c *gin.Context) {
+	var req UnlockIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+	if err := h.svc.UnlockLoginIP(c.Request.Context(), req.IP, c.GetString(middleware.ContextUserID)); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/user/group"
+)
+
+// MFAHandler lets admins reset second factors and require them per group
+type MFAHandler struct {
+	mfa    *mfa.Service
+	groups *group.Service
+}
+
+// NewMFAHandler creates a new admin MFA handler
+func NewMFAHandler(m *mfa.Service, groups *group.Service) *MFAHandler {
+	return &MFAHandler{mfa: m, groups: groups}
+}
+
+// ResetUserMFA removes the second factor of a user who lost it; the user
+// enrolls again at the next login when the group requires one
+func (h *MFAHandler) ResetUserMFA(c *gin.Context) {
+	if err := h.mfa.Reset(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// GroupMFARequest represents the group MFA policy payload
+type GroupMFARequest struct {
+	Required bool `json:"required"`
+}
+
+// RequireGroupMFA turns the second factor requirement of a group on or off
+func (h *MFAHandler) RequireGroupMFA(c *gin.Context) {
+	var req GroupMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+	if err := h.groups.WithContext(c.Request.Context()).RequireMFA(c.Param("id"), req.Required); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	"appsite-go/internal/services/access/session"
+)
+
+// SessionHandler lets admins inspect and end the sessions of a user
+type SessionHandler struct {
+	sessions *session.Service
+}
+
+// NewSessionHandler creates a new admin session handler
+func NewSessionHandler(sessions *session.Service) *SessionHandler {
+	return &SessionHandler{sessions: sessions}
+}
+
+// ListUserSessions lists where a user is logged in
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	list, err := h.sessions.List(c.Request.Context(), c.Param("id"), "")
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// ForceLogout ends every session of a user
+func (h *SessionHandler) ForceLogout(c *gin.Context) {
+	if err := h.sessions.RevokeAll(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
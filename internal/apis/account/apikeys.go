@@ -0,0 +1,105 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/apikey"
+)
+
+// APIKeyHandler lets users manage the API keys of their backends
+type APIKeyHandler struct {
+	keys *apikey.Service
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(keys *apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// CreateAPIKeyRequest describes a key of the current user
+type CreateAPIKeyRequest struct {
+	Name       string   `json:"name" binding:"required,max=64"`
+	Scopes     []string `json:"scopes" binding:"required,min=1"`
+	AllowedIPs []string `json:"allowed_ips"`
+	ExpiresAt  int64    `json:"expires_at"`
+}
+
+// ListAPIKeys lists the keys of the current user
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	list, err := h.keys.List(c.Request.Context(), apikey.Filter{UserID: uid})
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// CreateAPIKey issues a key acting as the current user. The key is only
+// shown in this answer.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+
+	k, raw, err := h.keys.Create(c.Request.Context(), apikey.Input{
+		UserID:     uid,
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+	}, uid)
+	if err != nil {
+		response.Error(c, KeyError(err))
+		return
+	}
+
+	response.Success(c, gin.H{"key": k, "api_key": raw})
+}
+
+// RevokeAPIKey revokes a key of the current user
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	if err := h.keys.Revoke(c.Request.Context(), c.Param("id"), uid); err != nil {
+		response.Error(c, KeyError(err))
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// KeyError maps API key errors to API errors
+func KeyError(err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrKeyNotFound):
+		return apperr.NewWithMessage(apperr.NotFound, err.Error())
+	case errors.Is(err, apikey.ErrInvalidScope), errors.Is(err, apikey.ErrInvalidIP),
+		errors.Is(err, apikey.ErrInvalidOwner), errors.Is(err, apikey.ErrInvalidExpiry):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	}
+	return err
+}
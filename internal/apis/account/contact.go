@@ -0,0 +1,52 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/account"
+)
+
+// BindContactRequest represents the email or mobile binding payload
+type BindContactRequest struct {
+	Target string `json:"target" binding:"required"`
+	Code   string `json:"code"`
+}
+
+// BindContact sets the email or mobile of the current user with a code
+// sent to it for the bind purpose
+func (h *Handler) BindContact(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+	var req BindContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if err := h.users(c).BindContact(c.Request.Context(), uid, req.Target, req.Code); err != nil {
+		response.Error(c, contactError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// contactError maps the errors of binding or changing a contact to business codes
+func contactError(err error) error {
+	switch {
+	case errors.Is(err, account.ErrInvalidOTP), errors.Is(err, verify.ErrOTPTarget),
+		errors.Is(err, account.ErrBindRequired), errors.Is(err, account.ErrUnverifiable):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	case errors.Is(err, account.ErrContactTaken):
+		return apperr.Wrap(apperr.Conflict, err, err.Error())
+	}
+	return err
+}
@@ -2,9 +2,9 @@ package account
 
 import (
 	"github.com/gin-gonic/gin"
-	"strconv"
 
 	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/request"
 	"appsite-go/internal/apis/response"
 	apperr "appsite-go/internal/core/error"
 	"appsite-go/internal/services/user/account"
@@ -19,6 +19,11 @@ func NewHandler(svc *account.AuthService) *Handler {
 	return &Handler{svc: svc}
 }
 
+// users binds the account service to the request, scoping it to the tenant
+func (h *Handler) users(c *gin.Context) *account.AuthService {
+	return h.svc.WithContext(c.Request.Context())
+}
+
 // GetProfile returns current user detail
 func (h *Handler) GetProfile(c *gin.Context) {
 	uid := c.GetString(middleware.ContextUserID)
@@ -27,7 +32,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.svc.GetDetail(uid)
+	user, err := h.users(c).GetDetail(uid)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -44,51 +49,39 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	var req dto.UserUpdateReq
+	var req dto.ProfileUpdateReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	if err := h.svc.Update(uid, req); err != nil {
-		response.Error(c, err)
+	if err := h.users(c).UpdateProfile(uid, req); err != nil {
+		response.Error(c, contactError(err))
 		return
 	}
 
 	response.Success(c, nil)
 }
 
-// ListUsers retrieves users based on filter
+// ListUsers retrieves users based on the public filter fields
 func (h *Handler) ListUsers(c *gin.Context) {
-	var req dto.UserFilterReq
+	var req dto.UserPublicFilterReq
 	if err := c.ShouldBindQuery(&req); err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	// Pagination
-	page := 1
-	pageSize := 20
-	
-	if p := c.Query("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
-	}
-	if ps := c.Query("page_size"); ps != "" {
-		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
-			pageSize = v
-		}
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
 	}
 
-	users, count, err := h.svc.ListUsers(req, page, pageSize)
+	page, err := h.users(c).ListPublicUsers(req, params)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"list":  users,
-		"total": count,
-	})
+	response.List(c, page)
 }
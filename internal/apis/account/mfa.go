@@ -0,0 +1,111 @@
+package account
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/auth"
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+)
+
+// MFACodeRequest carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GetMFA reports whether the current user has a second factor and whether
+// the user group requires one
+func (h *Handler) GetMFA(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	enabled, required, err := h.svc.MFAStatus(c.Request.Context(), uid)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, gin.H{"enabled": enabled, "required": required})
+}
+
+// SetupMFA starts an enrollment, returning the secret and its QR code URI
+func (h *Handler) SetupMFA(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	enrollment, err := h.svc.SetupMFA(c.Request.Context(), uid)
+	if err != nil {
+		response.Error(c, auth.MFAError(err))
+		return
+	}
+	response.Success(c, enrollment)
+}
+
+// ConfirmMFA enables the enrolled factor with a first code and returns the
+// recovery codes, shown this once
+func (h *Handler) ConfirmMFA(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	codes, err := h.svc.ConfirmMFA(c.Request.Context(), uid, req.Code)
+	if err != nil {
+		response.Error(c, auth.MFAError(err))
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA removes the second factor after checking a code
+func (h *Handler) DisableMFA(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if err := h.svc.DisableMFA(c.Request.Context(), uid, req.Code); err != nil {
+		response.Error(c, auth.MFAError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a code
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	codes, err := h.svc.RegenerateRecoveryCodes(c.Request.Context(), uid, req.Code)
+	if err != nil {
+		response.Error(c, auth.MFAError(err))
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
@@ -0,0 +1,43 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/user/account"
+)
+
+// ChangePasswordRequest represents the change password payload
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ChangePassword replaces the password of the current user and signs out
+// every other session
+func (h *Handler) ChangePassword(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	err := h.svc.ChangePassword(c.Request.Context(), uid, req.OldPassword, req.NewPassword, c.GetString(middleware.ContextSession))
+	if errors.Is(err, account.ErrInvalidPwd) {
+		err = apperr.Wrap(apperr.InvalidPass, err, "old password is incorrect")
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,76 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/session"
+)
+
+// SessionHandler lets users see and end their device sessions
+type SessionHandler struct {
+	sessions *session.Service
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessions *session.Service) *SessionHandler {
+	return &SessionHandler{sessions: sessions}
+}
+
+// ListSessions lists where the current user is logged in
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	list, err := h.sessions.List(c.Request.Context(), uid, c.GetString(middleware.ContextSession))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// RevokeSession logs one of the user's sessions out
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	err := h.sessions.Revoke(c.Request.Context(), uid, c.Param("id"))
+	if errors.Is(err, session.ErrSessionNotFound) {
+		err = apperr.NewWithMessage(apperr.NotFound, err.Error())
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// RevokeOtherSessions logs the user out everywhere but the current session
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	n, err := h.sessions.RevokeOthers(c.Request.Context(), uid, c.GetString(middleware.ContextSession))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"revoked": n})
+}
@@ -0,0 +1,108 @@
+package account
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/auth"
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+)
+
+// ListSocial reports which social accounts are bound to the current user
+func (h *Handler) ListSocial(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	bound, err := h.svc.SocialBindings(c.Request.Context(), uid)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, bound)
+}
+
+// BindSocial returns the page of the provider where the user signs in; its
+// callback then binds the social account to the current user
+func (h *Handler) BindSocial(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	url, nonce, err := h.svc.SocialAuthURL(c.Request.Context(), c.Param("provider"), uid)
+	if err != nil {
+		response.Error(c, auth.SocialError(err))
+		return
+	}
+	auth.SetStateCookie(c, nonce)
+	response.Success(c, gin.H{"url": url})
+}
+
+// UnbindSocial unlinks a social account from the current user
+func (h *Handler) UnbindSocial(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	if err := h.svc.UnbindSocial(c.Request.Context(), uid, c.Param("provider")); err != nil {
+		response.Error(c, auth.SocialError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ListIdentities lists the external accounts linked to the current user
+func (h *Handler) ListIdentities(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	list, err := h.svc.Identities(c.Request.Context(), uid)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// LinkIdentity returns the page of a provider of the tenant of the current
+// user; its callback then links the account there to the user
+func (h *Handler) LinkIdentity(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	url, nonce, err := h.svc.LinkIdentityURL(c.Request.Context(), uid, c.Param("provider"))
+	if err != nil {
+		response.Error(c, auth.SocialError(err))
+		return
+	}
+	auth.SetStateCookie(c, nonce)
+	response.Success(c, gin.H{"url": url})
+}
+
+// UnlinkIdentity removes an external account of the current user
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	if err := h.svc.UnlinkIdentity(c.Request.Context(), uid, c.Param("id")); err != nil {
+		response.Error(c, auth.SocialError(err))
+		return
+	}
+	response.Success(c, nil)
+}
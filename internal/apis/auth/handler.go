@@ -1,9 +1,19 @@
 package auth
 
 import (
+"errors"
+"math"
+"strconv"
+
 "github.com/gin-gonic/gin"
 
+"appsite-go/internal/apis/middleware"
+"appsite-go/internal/apis/request"
 "appsite-go/internal/apis/response"
+apperr "appsite-go/internal/core/error"
+"appsite-go/internal/services/access/lockout"
+"appsite-go/internal/services/access/token"
+"appsite-go/internal/services/access/verify"
 "appsite-go/internal/services/user/account"
 )
 
@@ -19,11 +29,13 @@ return &Handler{svc: svc}
 
 // RegisterRequest represents the JSON body for registration
 type RegisterRequest struct {
-Username string `json:"username" binding:"required"`
-Password string `json:"password" binding:"required,min=6"`
-Email    string `json:"email" binding:"required,email"`
-Mobile   string `json:"mobile"`
-Nickname string `json:"nickname"`
+Username   string `json:"username" binding:"required"`
+Password   string `json:"password" binding:"required,min=6"`
+Email      string `json:"email" binding:"required,email"`
+Mobile     string `json:"mobile"`
+Nickname   string `json:"nickname"`
+EmailCode  string `json:"email_code"`  // Code sent to email, when it can receive codes
+MobileCode string `json:"mobile_code"` // Code sent to mobile, when it can receive codes
 }
 
 // Register handles user registration
@@ -35,13 +47,18 @@ response.Error(c, err)
 return
 }
 
-user, err := h.svc.Register(account.RegisterInput{
-Username: req.Username,
-Password: req.Password,
-Email:    req.Email,
-Mobile:   req.Mobile,
-Nickname: req.Nickname,
+user, err := h.svc.WithContext(c.Request.Context()).Register(account.RegisterInput{
+Username:   req.Username,
+Password:   req.Password,
+Email:      req.Email,
+Mobile:     req.Mobile,
+Nickname:   req.Nickname,
+EmailCode:  req.EmailCode,
+MobileCode: req.MobileCode,
 })
+if errors.Is(err, account.ErrInvalidOTP) || errors.Is(err, verify.ErrOTPTarget) {
+err = apperr.Wrap(apperr.InvalidParams, err, err.Error())
+}
 if err != nil {
 response.Error(c, err)
 return
@@ -64,14 +81,102 @@ response.Error(c, err)
 return
 }
 
-token, user, err := h.svc.Login(req.Identifier, req.Password)
+pair, user, err := h.svc.Login(c.Request.Context(), req.Identifier, req.Password, request.Device(c))
+if MFAChallenge(c, err) {
+return
+}
+if err != nil {
+response.Error(c, LoginError(c, err))
+return
+}
+
+response.Success(c, gin.H{
+"token":         pair.AccessToken,
+"refresh_token": pair.RefreshToken,
+"token_type":    pair.TokenType,
+"expires_in":    pair.ExpiresIn,
+"session_id":    pair.SessionID,
+"user":          user,
+})
+}
+
+// LoginError maps password login failures to uniform API errors, and sets
+// Retry-After while the account or IP is locked
+func LoginError(c *gin.Context, err error) error {
+var locked *lockout.LockedError
+switch {
+case errors.As(err, &locked):
+c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+return apperr.Wrap(apperr.TooManyRequests, err, lockout.ErrLocked.Error())
+case errors.Is(err, account.ErrInvalidCredentials), errors.Is(err, account.ErrUserDisabled):
+return apperr.Wrap(apperr.Unauthorized, err, err.Error())
+}
+return err
+}
+
+// RefreshRequest represents the refresh payload
+type RefreshRequest struct {
+RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+// The refresh token is single-use: the response carries its replacement.
+func (h *Handler) Refresh(c *gin.Context) {
+var req RefreshRequest
+if err := c.ShouldBindJSON(&req); err != nil {
+response.Error(c, err)
+return
+}
+
+pair, err := h.svc.Refresh(c.Request.Context(), req.RefreshToken)
 if err != nil {
+switch {
+case errors.Is(err, token.ErrRefreshInvalid), errors.Is(err, token.ErrRefreshExpired),
+errors.Is(err, token.ErrRefreshReused), errors.Is(err, account.ErrUserDisabled),
+errors.Is(err, account.ErrUserNotFound):
+err = apperr.Wrap(apperr.Unauthorized, err, err.Error())
+}
 response.Error(c, err)
 return
 }
 
 response.Success(c, gin.H{
-"token": token,
-"user":  user,
+"token":         pair.AccessToken,
+"refresh_token": pair.RefreshToken,
+"token_type":    pair.TokenType,
+"expires_in":    pair.ExpiresIn,
 })
 }
+
+// LogoutRequest represents the optional logout payload
+type LogoutRequest struct {
+All bool `json:"all"` // Sign out of every session, not only the current one
+}
+
+// Logout revokes the current session, or all sessions of the user
+func (h *Handler) Logout(c *gin.Context) {
+var req LogoutRequest
+if c.Request.ContentLength > 0 {
+if err := c.ShouldBindJSON(&req); err != nil {
+response.Error(c, err)
+return
+}
+}
+
+claims, ok := c.Value(middleware.ContextUser).(*token.Claims)
+if !ok {
+response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+return
+}
+
+err := h.svc.Logout(c.Request.Context(), claims)
+if err == nil && req.All {
+err = h.svc.LogoutAll(c.Request.Context(), claims.UserID)
+}
+if err != nil {
+response.Error(c, err)
+return
+}
+
+response.Success(c, nil)
+}
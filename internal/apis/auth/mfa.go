@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/user/account"
+)
+
+// MFAEnrollRequest represents the enrollment payload of a login challenge
+type MFAEnrollRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+}
+
+// EnrollMFA returns the authenticator secret of a user whose group
+// requires a second factor, during the login challenge
+func (h *Handler) EnrollMFA(c *gin.Context) {
+	var req MFAEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	enrollment, err := h.svc.EnrollMFA(c.Request.Context(), req.Challenge)
+	if err != nil {
+		response.Error(c, MFAError(err))
+		return
+	}
+	response.Success(c, enrollment)
+}
+
+// MFALoginRequest represents the second login step payload
+type MFALoginRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+	Code      string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+// LoginMFA completes a login challenge with a second factor code
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	pair, user, codes, err := h.svc.CompleteMFA(c.Request.Context(), req.Challenge, req.Code, request.Device(c))
+	if err != nil {
+		response.Error(c, MFAError(err))
+		return
+	}
+
+	data := gin.H{
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"token_type":    pair.TokenType,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user":          user,
+	}
+	if codes != nil {
+		data["recovery_codes"] = codes
+	}
+	response.Success(c, data)
+}
+
+// MFAChallenge answers a login that needs a second step with its challenge.
+// It reports false when err is not a challenge.
+func MFAChallenge(c *gin.Context, err error) bool {
+	var required *account.MFARequiredError
+	if !errors.As(err, &required) {
+		return false
+	}
+	response.Success(c, gin.H{
+		"mfa_required": true,
+		"enroll":       required.Enroll,
+		"challenge":    required.Challenge,
+		"expires_in":   required.ExpiresIn,
+	})
+	return true
+}
+
+// MFAError maps the second factor errors to business codes
+func MFAError(err error) error {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode), errors.Is(err, mfa.ErrChallengeInvalid),
+		errors.Is(err, account.ErrUserDisabled), errors.Is(err, account.ErrUserNotFound):
+		return apperr.Wrap(apperr.Unauthorized, err, err.Error())
+	case errors.Is(err, mfa.ErrNotEnrolled), errors.Is(err, mfa.ErrAlreadyEnabled):
+		return apperr.Wrap(apperr.Conflict, err, err.Error())
+	case errors.Is(err, mfa.ErrRequiredByGroup):
+		return apperr.Wrap(apperr.Forbidden, err, err.Error())
+	case errors.Is(err, mfa.ErrLocked):
+		return apperr.Wrap(apperr.TooManyRequests, err, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/request"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/account"
+)
+
+// OTPHandler sends one-time codes
+type OTPHandler struct {
+	otp *verify.OTPService
+}
+
+// NewOTPHandler creates a new OTP handler
+func NewOTPHandler(otp *verify.OTPService) *OTPHandler {
+	return &OTPHandler{otp: otp}
+}
+
+// SendOTPRequest represents the code request payload
+type SendOTPRequest struct {
+	Target  string `json:"target" binding:"required"` // Mobile or email
+	Purpose string `json:"purpose" binding:"required,oneof=login register reset bind"`
+}
+
+// SendOTP delivers a code to a mobile by SMS or to an email address
+func (h *OTPHandler) SendOTP(c *gin.Context) {
+	var req SendOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if err := h.otp.Send(c.Request.Context(), req.Purpose, req.Target, c.ClientIP()); err != nil {
+		response.Error(c, otpError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// OTPLoginRequest represents the code login payload
+type OTPLoginRequest struct {
+	Target string `json:"target" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+}
+
+// LoginByOTP logs in with a code sent for the login purpose
+func (h *Handler) LoginByOTP(c *gin.Context) {
+	var req OTPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	pair, user, err := h.svc.LoginByOTP(c.Request.Context(), req.Target, req.Code, request.Device(c))
+	if MFAChallenge(c, err) {
+		return
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrInvalidOTP), errors.Is(err, account.ErrUserNotFound),
+			errors.Is(err, account.ErrUserDisabled):
+			err = apperr.Wrap(apperr.Unauthorized, err, err.Error())
+		default:
+			err = otpError(err)
+		}
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"token_type":    pair.TokenType,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user":          user,
+	})
+}
+
+// otpError maps the OTP service errors to business codes
+func otpError(err error) error {
+	switch {
+	case errors.Is(err, verify.ErrOTPCooldown), errors.Is(err, verify.ErrOTPLocked):
+		return apperr.Wrap(apperr.TooManyRequests, err, err.Error())
+	case errors.Is(err, verify.ErrOTPPurpose), errors.Is(err, verify.ErrNoSender),
+		errors.Is(err, verify.ErrOTPTarget):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	case errors.Is(err, verify.ErrOTPInvalid):
+		return apperr.Wrap(apperr.Unauthorized, err, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/user/account"
+)
+
+// ForgotPasswordRequest represents the reset link request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword emails a reset link. It answers the same whether or not
+// the address has an account.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if err := h.svc.RequestPasswordReset(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		response.Error(c, otpError(err))
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ResetPasswordRequest resets with either a link token, or a target and
+// the code sent to it for the reset purpose
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Target   string `json:"target"`
+	Code     string `json:"code"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// ResetPassword sets a new password and signs the user out everywhere
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var err error
+	switch {
+	case req.Token != "":
+		err = h.svc.ResetPassword(c.Request.Context(), req.Token, req.Password)
+	case req.Target != "" && req.Code != "":
+		err = h.svc.ResetPasswordByOTP(c.Request.Context(), req.Target, req.Code, req.Password)
+	default:
+		err = apperr.NewWithMessage(apperr.InvalidParams, "token, or target and code, required")
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrInvalidReset), errors.Is(err, account.ErrInvalidOTP),
+			errors.Is(err, account.ErrUserNotFound), errors.Is(err, account.ErrUserDisabled):
+			err = apperr.Wrap(apperr.Unauthorized, err, err.Error())
+		default:
+			err = otpError(err)
+		}
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
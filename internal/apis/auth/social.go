@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/oidc"
+	"appsite-go/internal/services/access/social"
+	"appsite-go/internal/services/user/account"
+)
+
+// StateCookie holds the nonce of the social or OpenID Connect redirect
+// started by a browser, so its callback only completes in that browser
+const StateCookie = "appsite_login_state"
+
+// SetStateCookie gives the browser the nonce of a redirect it starts. It
+// is sent back on the top-level redirect from the provider, so SameSite
+// is Lax, and scripts never see it.
+func SetStateCookie(c *gin.Context, nonce string) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(StateCookie, nonce, int(social.StateTTL.Seconds()), "/", "", secure, true)
+}
+
+// TakeStateCookie returns the redirect nonce of the browser and clears it,
+// as a state is used once
+func TakeStateCookie(c *gin.Context) string {
+	nonce, _ := c.Cookie(StateCookie)
+	if nonce != "" {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(StateCookie, "", -1, "/", "", false, true)
+	}
+	return nonce
+}
+
+// SocialAuthURL returns the page of the provider where the user signs in;
+// the provider then redirects to its callback
+func (h *Handler) SocialAuthURL(c *gin.Context) {
+	url, nonce, err := h.svc.SocialAuthURL(c.Request.Context(), c.Param("provider"), "")
+	if err != nil {
+		response.Error(c, SocialError(err))
+		return
+	}
+	SetStateCookie(c, nonce)
+	response.Success(c, gin.H{"url": url})
+}
+
+// OIDCProviders lists the sign in providers of a tenant, by ID or code
+func (h *Handler) OIDCProviders(c *gin.Context) {
+	list, err := h.svc.OIDCProviders(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		response.Error(c, SocialError(err))
+		return
+	}
+	response.Success(c, list)
+}
+
+// OIDCAuthURL returns the page of a provider of a tenant where the user
+// signs in; the provider then redirects to /callback/oidc
+func (h *Handler) OIDCAuthURL(c *gin.Context) {
+	url, nonce, err := h.svc.OIDCAuthURL(c.Request.Context(), c.Param("tenant"), c.Param("provider"))
+	if err != nil {
+		response.Error(c, SocialError(err))
+		return
+	}
+	SetStateCookie(c, nonce)
+	response.Success(c, gin.H{"url": url})
+}
+
+// SocialError maps social and OpenID Connect sign in failures to API errors
+func SocialError(err error) error {
+	switch {
+	case errors.Is(err, social.ErrUnknownProvider), errors.Is(err, account.ErrSocialNotBound),
+		errors.Is(err, oidc.ErrUnknownTenant), errors.Is(err, oidc.ErrUnknownProvider),
+		errors.Is(err, account.ErrIdentityNotFound):
+		return apperr.Wrap(apperr.NotFound, err, err.Error())
+	case errors.Is(err, social.ErrStateInvalid), errors.Is(err, social.ErrExchange),
+		errors.Is(err, oidc.ErrStateInvalid), errors.Is(err, oidc.ErrExchange), errors.Is(err, oidc.ErrIDToken),
+		errors.Is(err, account.ErrUserDisabled), errors.Is(err, account.ErrUserNotFound):
+		return apperr.Wrap(apperr.Unauthorized, err, err.Error())
+	case errors.Is(err, account.ErrSocialBound), errors.Is(err, account.ErrSocialLastLogin):
+		return apperr.Wrap(apperr.Conflict, err, err.Error())
+	case errors.Is(err, oidc.ErrUnsafeEndpoint):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	}
+	return err
+}
@@ -1,13 +1,14 @@
 package content
 
 import (
-	"strconv"
-
 	"github.com/gin-gonic/gin"
 
+	"appsite-go/internal/apis/request"
 	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
 	"appsite-go/internal/services/contents"
 	"appsite-go/internal/services/contents/entity"
+	"appsite-go/pkg/dbs"
 )
 
 type Handler struct {
@@ -22,6 +23,16 @@ func NewHandler(articleSvc *contents.ArticleService, bannerSvc *contents.BannerS
 	}
 }
 
+// articles binds the article service to the request, scoping it to the tenant
+func (h *Handler) articles(c *gin.Context) *contents.ArticleService {
+	return h.articleSvc.WithContext(c.Request.Context())
+}
+
+// banners binds the banner service to the request, scoping it to the tenant
+func (h *Handler) banners(c *gin.Context) *contents.BannerService {
+	return h.bannerSvc.WithContext(c.Request.Context())
+}
+
 // --- Requests ---
 type CreateArticleReq struct {
 	Title       string `json:"title" binding:"required"`
@@ -39,6 +50,7 @@ type UpdateArticleReq struct {
 	Cover       string `json:"cover"`
 	Description string `json:"description"`
 	Status      string `json:"status"`
+	Version     int64  `json:"version"` // Alternative to the If-Match header
 }
 
 // --- Article Handlers ---
@@ -54,14 +66,14 @@ func (h *Handler) CreateArticle(c *gin.Context) {
 		Title:       req.Title,
 		Type:        req.Type,
 		Mode:        req.Mode,
-		Introduce:   req.Content,
+		Introduce:   dbs.Text(req.Content),
 		Cover:       req.Cover,
 		Description: req.Description,
 		Status:      req.Status,
 	}
 	// TODO: Assign AuthorID from context
 
-	if err := h.articleSvc.Create(article); err != nil {
+	if err := h.articles(c).Create(article); err != nil {
 		response.Error(c, err)
 		return
 	}
@@ -70,50 +82,40 @@ func (h *Handler) CreateArticle(c *gin.Context) {
 
 func (h *Handler) GetArticle(c *gin.Context) {
 	id := c.Param("id")
-	article, err := h.articleSvc.Get(id)
+	article, err := h.articles(c).Get(id)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
+	response.ETag(c, article.Version)
 	response.Success(c, article)
 }
 
 func (h *Handler) ListArticles(c *gin.Context) {
-	page := 1
-	pageSize := 20
-	if p := c.Query("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
-	}
-	if ps := c.Query("page_size"); ps != "" {
-		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
-			pageSize = v
-		}
+	params, err := request.ListParams(c, 20)
+	if err != nil {
+		response.Error(c, err)
+		return
 	}
 
-	filters := make(map[string]interface{})
 	if t := c.Query("type"); t != "" {
-		filters["type"] = t
+		params.Filters = map[string]interface{}{"type": t}
 	}
 
-	list, count, err := h.articleSvc.List(page, pageSize, filters)
+	page, err := h.articles(c).Query(params)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"list":  list,
-		"total": count,
-	})
+	response.List(c, page)
 }
 
 func (h *Handler) UpdateArticle(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateArticleReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, err)
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, "Invalid request"))
 		return
 	}
 
@@ -131,16 +133,29 @@ func (h *Handler) UpdateArticle(c *gin.Context) {
 		updates["status"] = req.Status
 	}
 
-	if err := h.articleSvc.Update(id, updates); err != nil {
+	version, ok, err := request.IfMatch(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	if ok {
+		updates["version"] = version
+	} else if req.Version > 0 {
+		updates["version"] = req.Version
+	}
+
+	article, err := h.articles(c).Update(id, updates)
+	if err != nil {
 		response.Error(c, err)
 		return
 	}
+	response.ETag(c, article.Version)
 	response.Success(c, nil)
 }
 
 func (h *Handler) DeleteArticle(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.articleSvc.Delete(id); err != nil {
+	if err := h.articles(c).Delete(id); err != nil {
 		response.Error(c, err)
 		return
 	}
@@ -155,7 +170,7 @@ func (h *Handler) CreateBanner(c *gin.Context) {
 		response.Error(c, err)
 		return
 	}
-	if err := h.bannerSvc.Create(&banner); err != nil {
+	if err := h.banners(c).Create(&banner); err != nil {
 		response.Error(c, err)
 		return
 	}
@@ -163,11 +178,16 @@ func (h *Handler) CreateBanner(c *gin.Context) {
 }
 
 func (h *Handler) ListBanners(c *gin.Context) {
-	// Simplified list
-	list, _, err := h.bannerSvc.List(1, 100, nil)
+	params, err := request.ListParams(c, 100)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	page, err := h.banners(c).Query(params)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
-	response.Success(c, list)
+	response.Success(c, page.List)
 }
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/services/access/apikey"
+)
+
+// ContextAPIKey holds the *apikey.Key of requests authenticated by one
+const ContextAPIKey = "api_key"
+
+// requestKey returns the API key of a request: the X-API-Key header, or a
+// Bearer token with the key prefix
+func requestKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.HasPrefix(bearer, apikey.Prefix) {
+		return bearer
+	}
+	return ""
+}
+
+// keyAuth authenticates a request by API key. The key must be allowed the
+// route group, the segment after /api/v1, and the action of the method.
+// A user key acts as its user; the request runs in the tenant of the key.
+func keyAuth(c *gin.Context, keys *apikey.Service, raw string) {
+	ctx := c.Request.Context()
+	key, err := keys.Authenticate(ctx, raw, c.ClientIP())
+	if errors.Is(err, apikey.ErrKeyInvalid) {
+		err = &apperr.AppError{Code: apperr.Unauthorized, Message: "invalid api key", Err: err}
+	}
+	if errors.Is(err, apikey.ErrIPNotAllowed) {
+		err = &apperr.AppError{Code: apperr.Forbidden, Message: err.Error(), Err: err}
+	}
+	if err == nil && !key.Allows(routeGroup(c.FullPath()), apikey.Action(c.Request.Method)) {
+		err = &apperr.AppError{Code: apperr.Forbidden, Message: apikey.ErrKeyNotAllowed.Error(), Err: apikey.ErrKeyNotAllowed}
+	}
+	if err != nil {
+		response.Error(c, err)
+		c.Abort()
+		return
+	}
+
+	if !bindTenant(c, key.SaasID) {
+		return
+	}
+	ctx = c.Request.Context()
+	if err := keys.Seen(ctx, key, c.ClientIP()); err != nil {
+		log.Warn(ctx, "API key use not recorded", "key", key.ID, "err", err)
+	}
+	c.Set(ContextUserID, key.UserID)
+	c.Set(ContextAPIKey, key)
+	c.Next()
+}
+
+// ViaAPIKey reports whether the request was authenticated by an API key
+func ViaAPIKey(c *gin.Context) bool {
+	_, ok := c.Get(ContextAPIKey)
+	return ok
+}
+
+// routeGroup is the group of a route, "content" for /api/v1/content/articles
+func routeGroup(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" {
+		return ""
+	}
+	return parts[2]
+}
@@ -1,23 +1,54 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"appsite-go/internal/apis/response"
 	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/route"
+	"appsite-go/internal/services/access/apikey"
+	"appsite-go/internal/services/access/session"
 	"appsite-go/internal/services/access/token"
 )
 
 const (
-	ContextUserID = "user_id"
-	ContextUser   = "user_claims"
+	ContextUserID  = "user_id"
+	ContextUser    = "user_claims"
+	ContextSession = "session_id"
 )
 
-// AuthMiddleware verifies JWT token
-func AuthMiddleware(svc *token.Service) gin.HandlerFunc {
+// AuthMiddleware verifies JWT token and rejects revoked ones, so a
+// revoked session is shut out at once. With sessions, the activity of
+// the session is recorded. Tokens issued to OAuth apps are refused, they
+// only pass AppAuthMiddleware. With keys, API keys are accepted too, see
+// keyAuth.
+func AuthMiddleware(svc *token.Service, sessions *session.Service, keys *apikey.Service) gin.HandlerFunc {
+	return authMiddleware(svc, sessions, keys, false)
+}
+
+// AppAuthMiddleware is AuthMiddleware also accepting the tokens of OAuth
+// apps; handlers behind it check the scope of those
+func AppAuthMiddleware(svc *token.Service, sessions *session.Service) gin.HandlerFunc {
+	return authMiddleware(svc, sessions, nil, true)
+}
+
+func authMiddleware(svc *token.Service, sessions *session.Service, keys *apikey.Service, apps bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if raw := requestKey(c); raw != "" {
+			if keys == nil {
+				response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "api keys are not accepted here"})
+				c.Abort()
+				return
+			}
+			keyAuth(c, keys, raw)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
@@ -39,8 +70,77 @@ func AuthMiddleware(svc *token.Service) gin.HandlerFunc {
 			return
 		}
 
+		if claims.ClientID != "" && !apps {
+			response.Error(c, &apperr.AppError{Code: apperr.Forbidden, Message: "app tokens are not accepted here"})
+			c.Abort()
+			return
+		}
+
+		if err := svc.Revoked(c.Request.Context(), claims); err != nil {
+			if errors.Is(err, token.ErrTokenRevoked) {
+				err = &apperr.AppError{Code: apperr.Unauthorized, Message: "token revoked", Err: err}
+			}
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		if sessions != nil && claims.Family != "" {
+			if err := sessions.Seen(c.Request.Context(), claims.Family, c.ClientIP()); err != nil {
+				log.Warn(c.Request.Context(), "Session activity not recorded", "session", claims.Family, "err", err)
+			}
+		}
+
+		if !bindTenant(c, claims.SaasID) {
+			return
+		}
 		c.Set(ContextUserID, claims.UserID)
 		c.Set(ContextUser, claims)
+		c.Set(ContextSession, claims.Family)
+		c.Next()
+	}
+}
+
+// bindTenant runs the request in the tenant of its credential. A tenant the
+// client asks for must be that one; it never widens what the credential
+// may reach.
+func bindTenant(c *gin.Context, saasID string) bool {
+	if asked := route.RequestTenant(c); asked != "" && asked != saasID {
+		response.Error(c, &apperr.AppError{Code: apperr.Forbidden, Message: "tenant does not match the credential"})
+		c.Abort()
+		return false
+	}
+	c.Set(route.ContextTenantID, saasID)
+	c.Request = c.Request.WithContext(model.WithTenant(c.Request.Context(), saasID))
+	return true
+}
+
+// PlatformAdmin lifts tenant scoping for the admins of the platform, the
+// users of the default tenant; admins of a tenant stay scoped to it. It
+// runs after Permission.
+func PlatformAdmin() gin.HandlerFunc {
+	platform := route.PlatformMiddleware()
+	return func(c *gin.Context) {
+		claims, _ := c.Get(ContextUser)
+		if user, _ := claims.(*token.Claims); user != nil && user.SaasID == "" {
+			platform(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// PlatformOnly refuses everyone but the admins of the platform. Routes
+// behind it change what all tenants share, such as access policies,
+// OAuth clients and background jobs. It runs after PlatformAdmin.
+func PlatformOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get(ContextUser)
+		if user, _ := claims.(*token.Claims); user == nil || user.SaasID != "" {
+			response.Error(c, &apperr.AppError{Code: apperr.Forbidden, Message: "only platform admins may do this"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/permission"
+	"appsite-go/internal/services/access/token"
+)
+
+// Permission lets a request through when the role of the user, the group
+// of Claims.Role, may call its path with its method. It runs after
+// AuthMiddleware; without a service every request is refused. Moving a
+// user to another group revokes their tokens, so a stale role is not
+// carried on.
+func Permission(svc *permission.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get(ContextUser)
+		user, _ := claims.(*token.Claims)
+		if svc == nil || user == nil || user.Role == "" {
+			response.Error(c, &apperr.AppError{Code: apperr.Forbidden, Message: "permission denied"})
+			c.Abort()
+			return
+		}
+
+		ok, err := svc.Check(user.Role, c.Request.URL.Path, c.Request.Method)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+		if !ok {
+			response.Error(c, &apperr.AppError{Code: apperr.Forbidden, Message: "permission denied"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
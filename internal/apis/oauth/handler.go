@@ -0,0 +1,194 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/token"
+)
+
+// Handler serves the OAuth2 and OpenID Connect provider endpoints
+type Handler struct {
+	svc *oauth.Service
+}
+
+// NewHandler creates a new OAuth provider handler
+func NewHandler(svc *oauth.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Discovery publishes the provider metadata apps configure themselves
+// from. Like the JWKS it is a plain document, not wrapped in the API
+// response envelope.
+func (h *Handler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.svc.Discovery())
+}
+
+// Authorize is called by the authorize page with the query of the app,
+// once the user is signed in. It answers with the consent screen to show,
+// or the URL to send the user back to.
+func (h *Handler) Authorize(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	var req oauth.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	d, err := h.svc.Authorize(c.Request.Context(), uid, req)
+	if err != nil {
+		response.Error(c, ClientError(err))
+		return
+	}
+	response.Success(c, d)
+}
+
+// ConsentRequest is the answer of the user on the consent screen, with
+// the query of the app
+type ConsentRequest struct {
+	oauth.AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// Consent approves or denies an app for the current user and returns the
+// URL to send the user back to
+func (h *Handler) Consent(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	var req ConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, apperr.Wrap(apperr.InvalidParams, err, err.Error()))
+		return
+	}
+	d, err := h.svc.Consent(c.Request.Context(), uid, req.AuthorizeRequest, req.Approve)
+	if err != nil {
+		response.Error(c, ClientError(err))
+		return
+	}
+	response.Success(c, d)
+}
+
+// Token is the token endpoint of apps. Its answers and errors follow RFC
+// 6749 instead of the API response envelope, so OAuth libraries work.
+func (h *Handler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req oauth.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &oauth.Error{Code: oauth.ErrInvalidRequest.Code, Description: err.Error()})
+		return
+	}
+	// client_secret_basic encodes the credentials before joining them
+	basic := false
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		basic = true
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	res, err := h.svc.Token(c.Request.Context(), req)
+	var oerr *oauth.Error
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, res)
+	case errors.As(err, &oerr) && errors.Is(err, oauth.ErrInvalidClient):
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		c.JSON(http.StatusUnauthorized, oerr)
+	case errors.As(err, &oerr):
+		c.JSON(http.StatusBadRequest, oerr)
+	default:
+		log.Error(c.Request.Context(), "OAuth token request failed", "err", err)
+		c.JSON(http.StatusInternalServerError, &oauth.Error{Code: "server_error"})
+	}
+}
+
+// UserInfo returns the claims of the user of the access token, per its scope
+func (h *Handler) UserInfo(c *gin.Context) {
+	claims, _ := c.Get(middleware.ContextUser)
+	tc, ok := claims.(*token.Claims)
+	if !ok {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	info, err := h.svc.UserInfo(c.Request.Context(), tc)
+	var oerr *oauth.Error
+	if errors.As(err, &oerr) {
+		status := http.StatusUnauthorized
+		if errors.Is(err, oauth.ErrInsufficientScope) {
+			status = http.StatusForbidden
+		}
+		c.Header("WWW-Authenticate", `Bearer error="`+oerr.Code+`"`)
+		c.JSON(status, oerr)
+		return
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// ListApps lists the apps the current user approved
+func (h *Handler) ListApps(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	list, err := h.svc.Consents(c.Request.Context(), uid)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, list)
+}
+
+// RevokeApp withdraws the approval of an app and signs the current user out of it
+func (h *Handler) RevokeApp(c *gin.Context) {
+	uid := c.GetString(middleware.ContextUserID)
+	if uid == "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "unauthorized"})
+		return
+	}
+
+	if err := h.svc.RevokeConsent(c.Request.Context(), uid, c.Param("client_id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ClientError maps OAuth client and authorization failures to API errors
+func ClientError(err error) error {
+	switch {
+	case errors.Is(err, oauth.ErrClientNotFound):
+		return apperr.Wrap(apperr.NotFound, err, err.Error())
+	case errors.Is(err, oauth.ErrRedirectURI), errors.Is(err, oauth.ErrClientConfig):
+		return apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	case errors.Is(err, oauth.ErrUserInactive):
+		return apperr.Wrap(apperr.Unauthorized, err, err.Error())
+	}
+	return err
+}
@@ -2,19 +2,85 @@ package redirect
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/auth"
+	"appsite-go/internal/apis/request"
 	"appsite-go/internal/apis/response"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/services/access/oidc"
+	"appsite-go/internal/services/access/social"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/entity"
 )
 
 type Handler struct {
+	svc *account.AuthService
 }
 
-func NewHandler() *Handler {
-	return &Handler{}
+// NewHandler creates a new callback handler; svc may be nil, disabling social logins
+func NewHandler(svc *account.AuthService) *Handler {
+	return &Handler{svc: svc}
 }
 
+// WechatCallback completes a WeChat login or binding
 func (h *Handler) WechatCallback(c *gin.Context) {
-	// TODO: Implement WeChat OAuth callback
-	response.Success(c, gin.H{"status": "mock_wechat_ok"})
+	h.socialCallback(c, social.ProviderWechat)
+}
+
+// socialCallback signs in with the code of the redirect, or binds the social
+// account when the redirect was started from the account of a user
+func (h *Handler) socialCallback(c *gin.Context, provider string) {
+	if h.svc == nil {
+		response.Error(c, &apperr.AppError{Code: apperr.NotFound, Message: social.ErrUnknownProvider.Error()})
+		return
+	}
+
+	nonce := auth.TakeStateCookie(c)
+	pair, user, err := h.svc.CompleteSocial(c.Request.Context(), provider, c.Query("code"), c.Query("state"), nonce, request.Device(c))
+	h.signedIn(c, pair, user, err, gin.H{"provider": provider, "bound": true, "user": user})
+}
+
+// OIDCCallback completes a sign in or identity link with an OpenID Connect
+// or OAuth2 provider of a tenant
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if h.svc == nil {
+		response.Error(c, &apperr.AppError{Code: apperr.NotFound, Message: oidc.ErrUnknownProvider.Error()})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		response.Error(c, &apperr.AppError{Code: apperr.Unauthorized, Message: "sign in was cancelled: " + e})
+		return
+	}
+
+	nonce := auth.TakeStateCookie(c)
+	pair, user, err := h.svc.CompleteOIDC(c.Request.Context(), c.Query("code"), c.Query("state"), nonce, request.Device(c))
+	h.signedIn(c, pair, user, err, gin.H{"linked": true, "user": user})
+}
+
+// signedIn answers a callback with the tokens of a sign in, or with linked
+// when the callback bound an account to a signed-in user
+func (h *Handler) signedIn(c *gin.Context, pair *token.Pair, user *entity.User, err error, linked gin.H) {
+	if auth.MFAChallenge(c, err) {
+		return
+	}
+	if err != nil {
+		response.Error(c, auth.SocialError(err))
+		return
+	}
+
+	if pair == nil {
+		response.Success(c, linked)
+		return
+	}
+	response.Success(c, gin.H{
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"token_type":    pair.TokenType,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user":          user,
+	})
 }
 
 func (h *Handler) OSSCallback(c *gin.Context) {
@@ -0,0 +1,23 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package request
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/services/access/session"
+)
+
+// HeaderDeviceID carries the client generated ID of the device
+const HeaderDeviceID = "X-Device-ID"
+
+// Device describes the client of the request for session tracking
+func Device(c *gin.Context) session.Device {
+	return session.Device{
+		DeviceID:  c.GetHeader(HeaderDeviceID),
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package request
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/model"
+)
+
+// ListParams builds model.ListParams from the common query parameters of a
+// list endpoint: page, page_size (or size), cursor and filter[...] expressions.
+// Column validation happens later in model.CRUD.List against the entity schema.
+func ListParams(c *gin.Context, defaultSize int) (*model.ListParams, error) {
+	params := &model.ListParams{
+		Page:     1,
+		PageSize: defaultSize,
+	}
+
+	if p := c.Query("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			params.Page = v
+		}
+	}
+
+	size := c.Query("page_size")
+	if size == "" {
+		size = c.Query("size")
+	}
+	if size != "" {
+		if v, err := strconv.Atoi(size); err == nil && v > 0 {
+			params.PageSize = v
+		}
+	}
+
+	// Presence of "cursor" (even empty, for the first page) selects keyset pagination
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		params.CursorMode = true
+		params.Cursor = cursor
+	}
+
+	filter, err := model.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		return nil, apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	}
+	if !filter.IsEmpty() {
+		params.Filter = filter
+	}
+
+	return params, nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package request
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apperr "appsite-go/internal/core/error"
+)
+
+// IfMatch reads the entity version a client expects from the If-Match header.
+// It accepts the ETag written by response.ETag ("3") and a bare number. Weak
+// tags (W/"3") are refused: If-Match compares strongly (RFC 9110 13.1.1).
+// ok is false when the header is absent or "*".
+func IfMatch(c *gin.Context) (version int64, ok bool, err error) {
+	tag := strings.TrimSpace(c.GetHeader("If-Match"))
+	if tag == "" || tag == "*" {
+		return 0, false, nil
+	}
+
+	if strings.HasPrefix(tag, "W/") {
+		return 0, false, apperr.NewWithMessage(apperr.InvalidParams, "If-Match needs a strong ETag")
+	}
+	tag = strings.Trim(tag, `"`)
+	version, err = strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 0 {
+		return 0, false, apperr.NewWithMessage(apperr.InvalidParams, "Invalid If-Match header")
+	}
+	return version, true, nil
+}
@@ -2,11 +2,14 @@ package response
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/model"
 )
 
 // Response standardizes the JSON response format
@@ -27,6 +30,14 @@ func Success(c *gin.Context, data interface{}) {
 
 // Error sends an error JSON response
 func Error(c *gin.Context, err error) {
+	// Map well-known model errors to business codes
+	if errors.Is(err, model.ErrInvalidFilter) || errors.Is(err, model.ErrInvalidCursor) || errors.Is(err, model.ErrInvalidVersion) {
+		err = apperr.Wrap(apperr.InvalidParams, err, err.Error())
+	}
+	if errors.Is(err, model.ErrConflict) {
+		err = apperr.Wrap(apperr.Conflict, err, "Resource was modified, reload and retry")
+	}
+
 	var e *apperr.AppError
 	if errors.As(err, &e) {
 		// We use 200 OK for business errors to allow frontend to parse the JSON body
@@ -45,3 +56,28 @@ func Error(c *gin.Context, err error) {
 		Msg:  err.Error(),
 	})
 }
+
+// ETag exposes an entity version so clients can send it back with If-Match
+func ETag(c *gin.Context, version int64) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(version, 10)))
+}
+
+// List sends a paginated list. Offset pages carry total/page/size;
+// cursor pages carry next_cursor instead of a total.
+func List[T any](c *gin.Context, page *model.Page[T]) {
+	if page.CursorMode {
+		Success(c, gin.H{
+			"list":        page.List,
+			"size":        page.Size,
+			"next_cursor": page.NextCursor,
+		})
+		return
+	}
+
+	Success(c, gin.H{
+		"list":  page.List,
+		"total": page.Total,
+		"page":  page.Page,
+		"size":  page.Size,
+	})
+}
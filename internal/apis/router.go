@@ -7,8 +7,16 @@ import (
 	"appsite-go/internal/apis/auth"
 	"appsite-go/internal/apis/content"
 	"appsite-go/internal/apis/middleware"
+	"appsite-go/internal/apis/oauth"
 	"appsite-go/internal/apis/redirect"
+	"appsite-go/internal/apis/wellknown"
+	"appsite-go/internal/services/access/apikey"
+	oauth_svc "appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/session"
 	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/commerce/coupon"
+	"appsite-go/internal/services/commerce/stock"
 	"appsite-go/internal/services/contents"
 	account_svc "appsite-go/internal/services/user/account"
 )
@@ -16,15 +24,51 @@ import (
 // Container holds all service dependencies for the API layer
 type Container struct {
 	TokenSvc   *token.Service
+	SessionSvc *session.Service
 	AuthSvc    *account_svc.AuthService
+	OAuthSvc   *oauth_svc.Service
+	APIKeySvc  *apikey.Service
+	OTPSvc     *verify.OTPService
 	ArticleSvc *contents.ArticleService
 	BannerSvc  *contents.BannerService
+
+	// Commerce, serialized per coupon and SKU across instances
+	CouponSvc    *coupon.Service
+	InventorySvc *stock.InventoryService
 }
 
 // RegisterRoutes registers all API routes
 func RegisterRoutes(r *gin.Engine, c *Container) {
 	v1 := r.Group("/api/v1")
 
+	// Public keys of the access tokens, for services verifying them
+	if c.TokenSvc != nil {
+		h := wellknown.NewHandler(c.TokenSvc)
+		r.GET("/.well-known/jwks.json", h.JWKS)
+	}
+
+	// OAuth2 and OpenID Connect provider of apps
+	if c.OAuthSvc != nil && c.TokenSvc != nil {
+		h := oauth.NewHandler(c.OAuthSvc)
+		auth := middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, nil)
+		r.GET("/.well-known/openid-configuration", h.Discovery)
+		g := v1.Group("/oauth")
+		{
+			g.GET("/authorize", auth, h.Authorize)
+			g.POST("/authorize", auth, h.Consent)
+			g.POST("/token", h.Token)
+			apps := middleware.AppAuthMiddleware(c.TokenSvc, c.SessionSvc)
+			g.GET("/userinfo", apps, h.UserInfo)
+			g.POST("/userinfo", apps, h.UserInfo)
+		}
+		a := v1.Group("/account/apps")
+		a.Use(auth)
+		{
+			a.GET("", h.ListApps)
+			a.DELETE("/:client_id", h.RevokeApp)
+		}
+	}
+
 	// Auth Routes (Public)
 	if c.AuthSvc != nil {
 		h := auth.NewHandler(c.AuthSvc)
@@ -32,22 +76,63 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 		{
 			g.POST("/register", h.Register)
 			g.POST("/login", h.Login)
+			g.POST("/login/otp", h.LoginByOTP)
+			g.POST("/login/mfa", h.LoginMFA)
+			g.POST("/login/mfa/enroll", h.EnrollMFA)
+			g.POST("/password/forgot", h.ForgotPassword)
+			g.POST("/password/reset", h.ResetPassword)
+			g.POST("/refresh", h.Refresh)
+			g.GET("/social/:provider", h.SocialAuthURL)
+			g.GET("/oidc/:tenant", h.OIDCProviders)
+			g.GET("/oidc/:tenant/:provider", h.OIDCAuthURL)
+		}
+		if c.OTPSvc != nil {
+			g.POST("/otp", auth.NewOTPHandler(c.OTPSvc).SendOTP)
+		}
+		if c.TokenSvc != nil {
+			g.POST("/logout", middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, nil), h.Logout)
 		}
 	}
 	
 	// Account Routes (Protected)
 	if c.AuthSvc != nil && c.TokenSvc != nil {
 		h := account.NewHandler(c.AuthSvc)
+		// Account settings are for the user only, never for API keys
 		g := v1.Group("/account")
-		g.Use(middleware.AuthMiddleware(c.TokenSvc))
+		g.Use(middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, nil))
 		{
 			g.GET("/profile", h.GetProfile)
 			g.PUT("/profile", h.UpdateProfile)
+			g.PUT("/password", h.ChangePassword)
+			g.POST("/contact", h.BindContact)
+			g.GET("/mfa", h.GetMFA)
+			g.POST("/mfa", h.SetupMFA)
+			g.POST("/mfa/confirm", h.ConfirmMFA)
+			g.DELETE("/mfa", h.DisableMFA)
+			g.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			g.GET("/social", h.ListSocial)
+			g.POST("/social/:provider", h.BindSocial)
+			g.DELETE("/social/:provider", h.UnbindSocial)
+			g.GET("/identities", h.ListIdentities)
+			g.POST("/identities/:provider", h.LinkIdentity)
+			g.DELETE("/identities/:id", h.UnlinkIdentity)
+		}
+		if c.APIKeySvc != nil {
+			kh := account.NewAPIKeyHandler(c.APIKeySvc)
+			g.GET("/api-keys", kh.ListAPIKeys)
+			g.POST("/api-keys", kh.CreateAPIKey)
+			g.DELETE("/api-keys/:id", kh.RevokeAPIKey)
+		}
+		if c.SessionSvc != nil {
+			sh := account.NewSessionHandler(c.SessionSvc)
+			g.GET("/sessions", sh.ListSessions)
+			g.DELETE("/sessions", sh.RevokeOtherSessions)
+			g.DELETE("/sessions/:id", sh.RevokeSession)
 		}
 
 		// Users (Admin/Public Directory)
 		u := v1.Group("/users")
-		u.Use(middleware.AuthMiddleware(c.TokenSvc))
+		u.Use(middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, c.APIKeySvc))
 		{
 			u.GET("", h.ListUsers)
 		}
@@ -67,7 +152,7 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 		// Protected
 		p := v1.Group("/content")
 		if c.TokenSvc != nil {
-			p.Use(middleware.AuthMiddleware(c.TokenSvc))
+			p.Use(middleware.AuthMiddleware(c.TokenSvc, c.SessionSvc, c.APIKeySvc))
 		}
 		{
 			p.POST("/articles", h.CreateArticle)
@@ -79,9 +164,10 @@ func RegisterRoutes(r *gin.Engine, c *Container) {
 
 	// Callback Routes
 	{
-		h := redirect.NewHandler()
+		h := redirect.NewHandler(c.AuthSvc)
 		g := v1.Group("/callback")
 		g.GET("/wechat", h.WechatCallback)
+		g.GET("/oidc", h.OIDCCallback)
 		g.POST("/oss", h.OSSCallback)
 	}
 }
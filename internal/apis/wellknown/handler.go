@@ -0,0 +1,34 @@
+package wellknown
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"appsite-go/internal/apis/response"
+	"appsite-go/internal/services/access/token"
+)
+
+// Handler serves the /.well-known documents
+type Handler struct {
+	tokens *token.Service
+}
+
+// NewHandler creates a new well-known handler
+func NewHandler(tokens *token.Service) *Handler {
+	return &Handler{tokens: tokens}
+}
+
+// JWKS publishes the public keys verifying access tokens. It is a plain
+// JWK Set, not wrapped in the API response envelope, so standard JWT
+// libraries can fetch it. Clients may cache it for a few minutes: a new
+// key is published long before it signs anything.
+func (h *Handler) JWKS(c *gin.Context) {
+	set, err := h.tokens.JWKS(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
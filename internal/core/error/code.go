@@ -18,6 +18,7 @@ const (
 	NotFound      ErrorCode = 404
 	MethodNotAllowed ErrorCode = 405
 	Conflict		 ErrorCode = 409
+	TooManyRequests  ErrorCode = 429
 
 	// Server Side Errors (5xx)
 	ServerError        ErrorCode = 500
@@ -52,6 +53,10 @@ func (e ErrorCode) String() string {
 		return "Forbidden"
 	case NotFound:
 		return "Not Found"
+	case Conflict:
+		return "Conflict"
+	case TooManyRequests:
+		return "Too Many Requests"
 	case ServerError:
 		return "Internal Server Error"
 	case TokenInvalid:
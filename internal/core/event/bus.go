@@ -0,0 +1,68 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Handler handles the JSON payload of an event.
+// Delivery is at least once, so handlers must be idempotent.
+type Handler func(ctx context.Context, payload []byte) error
+
+type subscriber struct {
+	name   string
+	handle Handler
+}
+
+// Bus routes events to their subscribers
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]subscriber
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]subscriber)}
+}
+
+// Subscribe registers handler for the named event. The subscriber name is
+// recorded once the event was handled, so a retry skips subscribers that
+// already succeeded; it must be unique per event and stable across releases.
+func (b *Bus) Subscribe(event, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range b.subs[event] {
+		if s.name == name {
+			panic(fmt.Sprintf("event: duplicate subscriber %q for %s", name, event))
+		}
+	}
+	b.subs[event] = append(b.subs[event], subscriber{name: name, handle: handler})
+}
+
+// subscribers returns the subscribers of the named event
+func (b *Bus) subscribers(event string) []subscriber {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.subs[event]
+}
+
+// On subscribes fn to events of type E, decoding the payload for it:
+//
+//	event.On(bus, "finance.order_income", func(ctx context.Context, e entity.OrderPaid) error { ... })
+func On[E Event](b *Bus, name string, fn func(ctx context.Context, e E) error) {
+	var zero E
+	b.Subscribe(zero.EventName(), name, func(ctx context.Context, payload []byte) error {
+		var e E
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return fmt.Errorf("decode %s: %w", zero.EventName(), err)
+		}
+		return fn(ctx, e)
+	})
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/core/model"
+)
+
+// ErrNotDead is returned by Requeue for an event that is not dead-lettered
+var ErrNotDead = errors.New("event is not dead-lettered")
+
+// Options tunes a Dispatcher; zero values fall back to the defaults
+type Options struct {
+	Interval    time.Duration // poll interval, default 1s
+	BatchSize   int           // events per poll, default 100
+	MaxAttempts int           // deliveries before an event is dead-lettered, default 10
+	Backoff     time.Duration // first retry delay, doubled per attempt up to 1h, default 5s
+	Lease       time.Duration // how long a claimed event is hidden from other dispatchers, default 5m
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 5 * time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	return o
+}
+
+// maxBackoff caps the retry delay
+const maxBackoff = time.Hour
+
+// Dispatcher delivers outbox events to the subscribers of a bus.
+// Several dispatchers may poll the same outbox: an event is claimed by
+// moving its next attempt past the lease, and whoever wins that update
+// delivers it. A crashed dispatcher's events become due again when the
+// lease runs out.
+type Dispatcher struct {
+	db   *gorm.DB
+	bus  *Bus
+	opts Options
+	repo *model.CRUD[Outbox]
+}
+
+// NewDispatcher creates a dispatcher
+func NewDispatcher(db *gorm.DB, bus *Bus, opts Options) *Dispatcher {
+	return &Dispatcher{
+		db:   db,
+		bus:  bus,
+		opts: opts.withDefaults(),
+		repo: model.NewCRUD[Outbox](db),
+	}
+}
+
+// Run dispatches due events every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches before sleeping
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil {
+				log.Error(ctx, "Event dispatch failed", "err", err)
+			}
+			if err != nil || n < d.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce delivers one batch of due events and returns how many were
+// claimed. The outbox holds the events of every tenant; each is delivered
+// in the tenant that published it.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	ctx = model.AsPlatform(ctx)
+	now := time.Now().Unix()
+
+	var due []Outbox
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+		Order("next_attempt_at, created_at").
+		Limit(d.opts.BatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	claimed := 0
+	for i := range due {
+		ok, err := d.claim(ctx, &due[i], now)
+		if err != nil {
+			return claimed, err
+		}
+		if !ok {
+			continue
+		}
+		claimed++
+		if err := d.deliver(ctx, &due[i]); err != nil {
+			return claimed, err
+		}
+	}
+	return claimed, nil
+}
+
+// claim leases an event to this dispatcher
+func (d *Dispatcher) claim(ctx context.Context, row *Outbox, now int64) (bool, error) {
+	lease := now + int64(d.opts.Lease/time.Second)
+	res := d.db.WithContext(ctx).Model(&Outbox{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", row.ID, StatusPending, row.NextAttemptAt).
+		UpdateColumn("next_attempt_at", lease)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	row.NextAttemptAt = lease
+	return res.RowsAffected == 1, nil
+}
+
+// deliver runs the subscribers that have not handled the event yet and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, row *Outbox) error {
+	hctx := model.WithTenant(ctx, row.SaasID)
+
+	done := make(map[string]bool, len(row.Delivered))
+	for _, name := range row.Delivered {
+		done[name] = true
+	}
+
+	var failures []string
+	for _, sub := range d.bus.subscribers(row.Name) {
+		if done[sub.name] {
+			continue
+		}
+		if err := call(hctx, sub.handle, []byte(row.Payload)); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+			continue
+		}
+		row.Delivered = append(row.Delivered, sub.name)
+	}
+
+	updates := map[string]interface{}{
+		"delivered": row.Delivered,
+	}
+	if len(failures) == 0 {
+		updates["status"] = StatusDone
+		updates["last_error"] = ""
+	} else {
+		row.Attempts++
+		updates["attempts"] = row.Attempts
+		updates["last_error"] = truncate(strings.Join(failures, "; "), 512)
+		if row.Attempts >= d.opts.MaxAttempts {
+			updates["status"] = StatusDead
+			log.Error(ctx, "Event dead-lettered", "id", row.ID, "event", row.Name, "err", updates["last_error"])
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(d.backoff(row.Attempts)).Unix()
+			log.Warn(ctx, "Event delivery failed, will retry", "id", row.ID, "event", row.Name, "attempt", row.Attempts, "err", updates["last_error"])
+		}
+	}
+	return d.db.WithContext(ctx).Model(&Outbox{}).Where("id = ?", row.ID).Updates(updates).Error
+}
+
+// backoff returns the delay before the given retry
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.opts.Backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// call runs a handler, turning a panic into an error
+func call(ctx context.Context, h Handler, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, payload)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// PurgeDelivered deletes the events every subscriber handled before
+// cutoff and returns how many were deleted. Dead letters are kept for
+// Requeue.
+func (d *Dispatcher) PurgeDelivered(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := d.db.WithContext(model.AsPlatform(ctx)).
+		Where("status = ? AND updated_at < ?", StatusDone, cutoff.Unix()).
+		Delete(&Outbox{})
+	return res.RowsAffected, res.Error
+}
+
+// DeadLetters lists the events that exhausted their attempts, those of the
+// tenant in ctx only unless it runs as platform
+func (d *Dispatcher) DeadLetters(ctx context.Context, params *model.ListParams) (*model.Page[Outbox], error) {
+	if params.Filters == nil {
+		params.Filters = map[string]interface{}{}
+	}
+	params.Filters["status"] = StatusDead
+	if params.Sort == "" {
+		params.Sort = "updated_at desc"
+	}
+	return model.PageOf[Outbox](d.repo.WithContext(ctx).List(params))
+}
+
+// Requeue schedules a dead-lettered event for immediate delivery with a fresh
+// attempt budget. Subscribers that already handled it are not called again.
+func (d *Dispatcher) Requeue(ctx context.Context, id string) error {
+	res := d.db.WithContext(ctx).Model(&Outbox{}).
+		Where("id = ? AND status = ?", id, StatusDead).
+		Updates(map[string]interface{}{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": 0,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotDead
+	}
+	return nil
+}
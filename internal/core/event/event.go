@@ -0,0 +1,100 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package event carries domain events between services through a
+// transactional outbox. Publishers write events with the same transaction
+// as their own changes, so an event exists if and only if the change was
+// committed; a Dispatcher then delivers them to the subscribers of a Bus
+// at least once.
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/pkg/dbs"
+)
+
+// Outbox statuses
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusDead    = "dead" // gave up after the maximum attempts
+)
+
+// ErrNoTransaction is returned when Publish is called without a transaction
+var ErrNoTransaction = errors.New("event: publish requires the transaction of the change")
+
+// Event is a typed domain event. Implementations are plain structs encoded as JSON;
+// the name identifies the event for subscribers, e.g. "order.paid".
+type Event interface {
+	EventName() string
+}
+
+// TenantEvent is an Event about a row of a tenant. It is delivered in that
+// tenant, also when published as platform.
+type TenantEvent interface {
+	Event
+	EventTenant() string
+}
+
+// Outbox stores a published event until every subscriber has handled it
+type Outbox struct {
+	model.Base
+	Name          string          `json:"name" gorm:"type:varchar(64);not null;index"`
+	Payload       dbs.Text        `json:"payload"`
+	SaasID        string          `json:"saas_id" gorm:"type:varchar(36);index;comment:Tenant of the publishing request"`
+	Status        string          `json:"status" gorm:"type:varchar(16);not null;default:'pending';index:idx_outbox_due,priority:1"`
+	NextAttemptAt int64           `json:"next_attempt_at" gorm:"not null;default:0;index:idx_outbox_due,priority:2"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	Delivered     dbs.StringArray `json:"delivered" gorm:"comment:Subscribers that handled the event"`
+	LastError     string          `json:"last_error" gorm:"type:varchar(512)"`
+}
+
+// TableName returns table name
+func (Outbox) TableName() string {
+	return "sys_outbox"
+}
+
+// Publish records events in the outbox using tx, the transaction of the change
+// they describe. The tenant of a TenantEvent, else that of the tx context, is
+// kept and restored for subscribers.
+func Publish(tx *gorm.DB, events ...Event) error {
+	if tx == nil {
+		return ErrNoTransaction
+	}
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return ErrNoTransaction
+	}
+
+	scoped, _ := model.TenantFrom(tx.Statement.Context)
+	for _, e := range events {
+		saasID := scoped
+		if te, ok := e.(TenantEvent); ok {
+			saasID = te.EventTenant()
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", e.EventName(), err)
+		}
+		row := &Outbox{
+			Base:    model.Base{ID: strings.ReplaceAll(uuid.New().String(), "-", "")},
+			Name:    e.EventName(),
+			Payload: dbs.Text(payload),
+			SaasID:  saasID,
+			Status:  StatusPending,
+		}
+		// The ID is set here as hooks may be skipped on the caller's session
+		if err := tx.Session(&gorm.Session{NewDB: true}).Create(row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
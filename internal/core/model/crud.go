@@ -5,9 +5,11 @@
 package model
 
 import (
+	"context"
 	"errors"
 
 	"gorm.io/gorm"
+
 	"appsite-go/pkg/utils/orm"
 )
 
@@ -23,26 +25,38 @@ func NewCRUD[T any](db *gorm.DB) *CRUD[T] {
 	return &CRUD[T]{DB: db}
 }
 
-// Add inserts a new entity
+// WithContext returns an operator whose queries run with ctx.
+// A tenant set with WithTenant scopes every operation once TenantPlugin is installed.
+func (c *CRUD[T]) WithContext(ctx context.Context) *CRUD[T] {
+	return &CRUD[T]{DB: c.DB.WithContext(ctx)}
+}
+
+// Add inserts a new entity.
+// BeforeAdd, the insert and AfterAdd run in one transaction; a hook error rolls it back.
 func (c *CRUD[T]) Add(entity *T) *Result {
-	// Application Layer Hooks
-	if hook, ok := any(entity).(interface{ BeforeAdd(*gorm.DB) error }); ok {
-		if err := hook.BeforeAdd(c.DB); err != nil {
-			return &Result{Success: false, Error: err, Message: err.Error()}
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		// Application Layer Hooks
+		if hook, ok := any(entity).(interface{ BeforeAdd(*gorm.DB) error }); ok {
+			if err := hook.BeforeAdd(tx); err != nil {
+				return &hookError{err}
+			}
 		}
-	}
 
-	// DB Operation
-	if err := c.DB.Create(entity).Error; err != nil {
-		return &Result{Success: false, Error: err, Message: "Database Create Failed"}
-	}
+		// DB Operation
+		if err := tx.Create(entity).Error; err != nil {
+			return err
+		}
 
-	// After Hook
-	if hook, ok := any(entity).(interface{ AfterAdd(*gorm.DB) error }); ok {
-		if err := hook.AfterAdd(c.DB); err != nil {
-			// Note: Entity is already saved. Error here is non-blocking for data, but reported.
-			return &Result{Success: true, Data: entity, Message: "Created with warning: " + err.Error()}
+		// After Hook (may roll back the insert)
+		if hook, ok := any(entity).(interface{ AfterAdd(*gorm.DB) error }); ok {
+			if err := hook.AfterAdd(tx); err != nil {
+				return &hookError{err}
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return failure(err, "Database Create Failed")
 	}
 
 	return &Result{Success: true, Data: entity}
@@ -60,50 +74,118 @@ func (c *CRUD[T]) Get(id string) *Result {
 	return &Result{Success: true, Data: &entity}
 }
 
-// Update updates fields of an entity by ID
+// Update updates fields of an entity by ID.
+// The row is locked and loaded, the updates are applied to a copy and
+// BeforeUpdate/AfterUpdate are called on that new state, all in one transaction.
+// Hooks can read the state before the change with Previous.
+//
+// For entities embedding Versioned the version is incremented on every write;
+// a "version" key in updates is the version the caller read, and a mismatch
+// fails with ErrConflict.
 func (c *CRUD[T]) Update(id string, updates map[string]interface{}) *Result {
-	var entity T
-	// Check existence
-	if err := c.DB.First(&entity, "id = ?", id).Error; err != nil {
-		return &Result{Success: false, Error: err, Message: "Not Found"}
-	}
+	var next T
+	_, isVersioned := any(&next).(versioned)
 
-	// Before Hook logic requires an instance.
-	// Since updates are a map, we can't easily call "BeforeUpdate" on the *struct* with the new values 
-	// unless we marshal them into the struct. 
-	// However, usually we might run logic on the *existing* entity or the *updates*.
-	// Simpler approach: If the ENTITY type supports BeforeUpdate, we call it on the FETCHED entity
-	// passing the DB transaction scope which might contain updates.
-	
-	if hook, ok := any(&entity).(interface{ BeforeUpdate(*gorm.DB) error }); ok {
-		if err := hook.BeforeUpdate(c.DB); err != nil {
-			return &Result{Success: false, Error: err, Message: err.Error()}
+	// Work on a copy: the version key is consumed and the increment added
+	values := make(map[string]interface{}, len(updates)+1)
+	for k, v := range updates {
+		values[k] = v
+	}
+	var expected int64
+	var check bool
+	if isVersioned {
+		var err error
+		if expected, check, err = takeVersion(values); err != nil {
+			return failure(err, "Update Failed")
 		}
 	}
 
-	if err := c.DB.Model(&entity).Updates(updates).Error; err != nil {
-		return &Result{Success: false, Error: err, Message: "Update Failed"}
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var old T
+		if err := tx.Scopes(orm.ForUpdate()).First(&old, "id = ?", id).Error; err != nil {
+			return &notFoundError{err}
+		}
+
+		next = old
+		if err := applyUpdates(tx, &next, values); err != nil {
+			return err
+		}
+		tx = withPrevious(tx, &old)
+
+		// CRUD drives the hooks itself, so GORM must not call BeforeUpdate/AfterUpdate again
+		write := tx.Session(&gorm.Session{SkipHooks: true}).Model(&next)
+		if isVersioned {
+			match, err := versionUpdate(any(&old).(versioned).versionField(), any(&next).(versioned).versionField(), values, expected, check)
+			if err != nil {
+				return err
+			}
+			write = write.Where("version = ?", match)
+		}
+
+		if hook, ok := any(&next).(interface{ BeforeUpdate(*gorm.DB) error }); ok {
+			if err := hook.BeforeUpdate(tx); err != nil {
+				return &hookError{err}
+			}
+		}
+
+		res := write.Updates(values)
+		if res.Error != nil {
+			return res.Error
+		}
+		if isVersioned && res.RowsAffected == 0 {
+			return ErrConflict
+		}
+
+		if hook, ok := any(&next).(interface{ AfterUpdate(*gorm.DB) error }); ok {
+			if err := hook.AfterUpdate(tx); err != nil {
+				return &hookError{err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return failure(err, "Update Failed")
 	}
 
-	return &Result{Success: true, Data: &entity}
+	return &Result{Success: true, Data: &next}
 }
 
-// Remove deletes an entity
+// Remove deletes an entity.
+// BeforeDelete, the delete and AfterDelete run in one transaction on the loaded entity.
 func (c *CRUD[T]) Remove(id string) *Result {
-	var entity T
-	// We need to find it first to trigger GORM hooks properly if they exist on the model
-	if err := c.DB.First(&entity, "id = ?", id).Error; err != nil {
-		return &Result{Success: false, Error: err, Message: "Not Found"}
-	}
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var entity T
+		if err := tx.Scopes(orm.ForUpdate()).First(&entity, "id = ?", id).Error; err != nil {
+			return &notFoundError{err}
+		}
+		tx = withPrevious(tx, &entity)
 
-	if hook, ok := any(&entity).(interface{ BeforeDelete(*gorm.DB) error }); ok {
-		if err := hook.BeforeDelete(c.DB); err != nil {
-			return &Result{Success: false, Error: err, Message: err.Error()}
+		if hook, ok := any(&entity).(interface{ BeforeDelete(*gorm.DB) error }); ok {
+			if err := hook.BeforeDelete(tx); err != nil {
+				return &hookError{err}
+			}
 		}
-	}
 
-	if err := c.DB.Delete(&entity).Error; err != nil {
-		return &Result{Success: false, Error: err, Message: "Delete Failed"}
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Delete(&entity).Error; err != nil {
+			return err
+		}
+		if _, soft := any(&entity).(softDeletable); soft && c.tombstoned() {
+			err := tx.Session(&gorm.Session{SkipHooks: true}).Unscoped().Model(new(T)).
+				Where("id = ?", id).Update(TombstoneColumn, id).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if hook, ok := any(&entity).(interface{ AfterDelete(*gorm.DB) error }); ok {
+			if err := hook.AfterDelete(tx); err != nil {
+				return &hookError{err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return failure(err, "Delete Failed")
 	}
 
 	return &Result{Success: true, Data: id}
@@ -111,10 +193,13 @@ func (c *CRUD[T]) Remove(id string) *Result {
 
 // List retrieves a paginated list
 func (c *CRUD[T]) List(params *ListParams) *Result {
+	return c.list(c.DB.Model(new(T)), params)
+}
+
+// list applies filters and pagination on top of a base query
+func (c *CRUD[T]) list(db *gorm.DB, params *ListParams) *Result {
 	var items []T
 	var total int64
-	
-	db := c.DB.Model(new(T))
 
 	// Apply Filters
 	if params.Filters != nil && len(params.Filters) > 0 {
@@ -123,6 +208,20 @@ func (c *CRUD[T]) List(params *ListParams) *Result {
 		db = db.Where(params.Filters)
 	}
 
+	// Apply client filter (validated against the entity schema)
+	if !params.Filter.IsEmpty() {
+		scope, err := params.Filter.Scope(c.DB, new(T))
+		if err != nil {
+			return &Result{Success: false, Error: err, Message: "Invalid Filter"}
+		}
+		db = db.Scopes(scope)
+	}
+
+	// Keyset pagination skips the count
+	if params.CursorMode {
+		return c.listByCursor(db, params, orm.PageSize(params.PageSize))
+	}
+
 	// Count
 	db.Count(&total)
 
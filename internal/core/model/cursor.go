@@ -0,0 +1,206 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or does not
+// match the sort columns of the list.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sortKey is one column of a keyset ordering
+type sortKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+// parseSortKeys turns "sort desc, created_at desc" into keyset columns.
+// The primary key is appended as a tie-breaker so the ordering is total.
+func parseSortKeys(sch *schema.Schema, sort string) ([]sortKey, error) {
+	var keys []sortKey
+	hasPK := false
+
+	for _, part := range strings.Split(sort, ",") {
+		tokens := strings.Fields(part)
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) > 2 {
+			return nil, fmt.Errorf("%w: bad sort %q", ErrInvalidCursor, part)
+		}
+
+		desc := false
+		if len(tokens) == 2 {
+			switch strings.ToLower(tokens[1]) {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return nil, fmt.Errorf("%w: bad sort %q", ErrInvalidCursor, part)
+			}
+		}
+
+		field := sch.LookUpField(strings.Trim(tokens[0], "`\""))
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%w: unknown sort column %q", ErrInvalidCursor, tokens[0])
+		}
+		if field == sch.PrioritizedPrimaryField {
+			hasPK = true
+		}
+		keys = append(keys, sortKey{field: field, desc: desc})
+	}
+
+	if !hasPK {
+		pk := sch.PrioritizedPrimaryField
+		if pk == nil {
+			return nil, fmt.Errorf("%w: %s has no primary key", ErrInvalidCursor, sch.Name)
+		}
+		desc := len(keys) > 0 && keys[len(keys)-1].desc
+		keys = append(keys, sortKey{field: pk, desc: desc})
+	}
+
+	return keys, nil
+}
+
+// encodeCursor serializes the sort column values of the last row
+func encodeCursor(keys []sortKey, row reflect.Value) (string, error) {
+	vals := make([]interface{}, len(keys))
+	for i, k := range keys {
+		v, zero := k.field.ValueOf(context.Background(), row)
+		if zero && k.field.FieldType.Kind() == reflect.Ptr {
+			return "", fmt.Errorf("%w: sort column %s is null", ErrInvalidCursor, k.field.DBName)
+		}
+		vals[i] = v
+	}
+
+	raw, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor parses a cursor back into typed values for the sort columns
+func decodeCursor(keys []sortKey, cursor string) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var items []interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&items); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if len(items) != len(keys) {
+		return nil, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(keys), len(items))
+	}
+
+	vals := make([]interface{}, len(keys))
+	for i, item := range items {
+		var s string
+		switch v := item.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("%w: bad value for %s", ErrInvalidCursor, keys[i].field.DBName)
+		}
+		val, err := convertFilterValue(keys[i].field, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad value for %s", ErrInvalidCursor, keys[i].field.DBName)
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+// keysetExpr builds the "rows after cursor" predicate:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... with < for descending columns.
+func keysetExpr(keys []sortKey, vals []interface{}) clause.Expression {
+	var ors []clause.Expression
+	for i := range keys {
+		var ands []clause.Expression
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: clause.Column{Name: keys[j].field.DBName}, Value: vals[j]})
+		}
+		col := clause.Column{Name: keys[i].field.DBName}
+		if keys[i].desc {
+			ands = append(ands, clause.Lt{Column: col, Value: vals[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: col, Value: vals[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+	return clause.Or(ors...)
+}
+
+// listByCursor runs a keyset paginated query without counting.
+// One extra row is fetched to decide whether a next cursor exists.
+func (c *CRUD[T]) listByCursor(db *gorm.DB, params *ListParams, size int) *Result {
+	stmt := &gorm.Statement{DB: c.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return &Result{Success: false, Error: err, Message: "List Failed"}
+	}
+
+	sort := params.Sort
+	if sort == "" {
+		sort = "created_at desc"
+	}
+	keys, err := parseSortKeys(stmt.Schema, sort)
+	if err != nil {
+		return &Result{Success: false, Error: err, Message: "Invalid Cursor"}
+	}
+
+	if params.Cursor != "" {
+		vals, err := decodeCursor(keys, params.Cursor)
+		if err != nil {
+			return &Result{Success: false, Error: err, Message: "Invalid Cursor"}
+		}
+		db = db.Where(keysetExpr(keys, vals))
+	}
+
+	for _, k := range keys {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: k.field.DBName}, Desc: k.desc})
+	}
+
+	var items []T
+	if err := db.Limit(size + 1).Find(&items).Error; err != nil {
+		return &Result{Success: false, Error: err, Message: "List Failed"}
+	}
+
+	next := ""
+	if len(items) > size {
+		items = items[:size]
+		next, err = encodeCursor(keys, reflect.ValueOf(&items[size-1]).Elem())
+		if err != nil {
+			return &Result{Success: false, Error: err, Message: "List Failed"}
+		}
+	}
+
+	return &Result{Success: true, Data: map[string]interface{}{
+		"list":        items,
+		"next_cursor": next,
+		"size":        size,
+	}}
+}
@@ -0,0 +1,448 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidFilter is returned when a filter references an unknown or
+// protected column, uses an unsupported operator or carries a bad value.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterOp is an operator of the list filter language
+type FilterOp string
+
+const (
+	OpEq      FilterOp = "eq"
+	OpNe      FilterOp = "ne"
+	OpIn      FilterOp = "in"
+	OpNotIn   FilterOp = "nin"
+	OpLike    FilterOp = "like"
+	OpGt      FilterOp = "gt"
+	OpGte     FilterOp = "gte"
+	OpLt      FilterOp = "lt"
+	OpLte     FilterOp = "lte"
+	OpBetween FilterOp = "between"
+	OpNull    FilterOp = "null" // value true => IS NULL, false => IS NOT NULL
+)
+
+// maxFilterValues caps the number of values accepted by in/nin
+const maxFilterValues = 100
+
+var filterOps = map[FilterOp]bool{
+	OpEq: true, OpNe: true, OpIn: true, OpNotIn: true, OpLike: true,
+	OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpBetween: true, OpNull: true,
+}
+
+// Condition is a single "field op value" predicate.
+// Value is a string, a []string (in, nin, between) or a bool (null);
+// it is converted to the column's Go type when the filter is applied.
+type Condition struct {
+	Field string
+	Op    FilterOp
+	Value interface{}
+}
+
+// Filter is a typed, whitelisted set of predicates for CRUD.List.
+// All conditions in And must match; within each Or group at least one must match.
+type Filter struct {
+	And []Condition
+	Or  [][]Condition
+}
+
+// NewFilter creates an empty filter
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// Where appends an AND condition
+func (f *Filter) Where(field string, op FilterOp, value interface{}) *Filter {
+	f.And = append(f.And, Condition{Field: field, Op: op, Value: value})
+	return f
+}
+
+// OrGroup appends a group of conditions of which at least one must match
+func (f *Filter) OrGroup(conds ...Condition) *Filter {
+	if len(conds) > 0 {
+		f.Or = append(f.Or, conds)
+	}
+	return f
+}
+
+// Clone returns a copy of the filter that can be extended without
+// changing f
+func (f *Filter) Clone() *Filter {
+	out := NewFilter()
+	if f == nil {
+		return out
+	}
+	out.And = append(out.And, f.And...)
+	for _, group := range f.Or {
+		out.Or = append(out.Or, append([]Condition(nil), group...))
+	}
+	return out
+}
+
+// Only returns ErrInvalidFilter when a condition uses a column outside
+// allowed, given by column name. Endpoints open to anyone use it to keep
+// private columns out of reach.
+func (f *Filter) Only(allowed ...string) error {
+	if f == nil {
+		return nil
+	}
+	check := func(c Condition) error {
+		for _, name := range allowed {
+			if c.Field == name {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: column %q cannot be filtered here", ErrInvalidFilter, c.Field)
+	}
+	for _, c := range f.And {
+		if err := check(c); err != nil {
+			return err
+		}
+	}
+	for _, group := range f.Or {
+		for _, c := range group {
+			if err := check(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.And) == 0 && len(f.Or) == 0)
+}
+
+// ParseFilter parses filter parameters from a query string.
+// Supported forms:
+//
+//	filter[title]=go                      // eq
+//	filter[title][like]=go
+//	filter[created_at][between]=100,200
+//	filter[status][in]=enabled,draft      // or repeated keys
+//	filter[cover][null]=true
+//	filter[or][q][title][like]=go&filter[or][q][description][like]=go
+//
+// Keys not starting with "filter[" are ignored.
+func ParseFilter(values url.Values) (*Filter, error) {
+	f := NewFilter()
+	groups := map[string]int{}
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+		parts, err := splitFilterKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		group := ""
+		if parts[0] == "or" {
+			if len(parts) < 3 {
+				return nil, fmt.Errorf("%w: malformed key %q", ErrInvalidFilter, key)
+			}
+			group, parts = parts[1], parts[2:]
+		}
+
+		field, op := parts[0], OpEq
+		switch len(parts) {
+		case 1:
+		case 2:
+			op = FilterOp(parts[1])
+		default:
+			return nil, fmt.Errorf("%w: malformed key %q", ErrInvalidFilter, key)
+		}
+
+		cond, err := newCondition(field, op, vals)
+		if err != nil {
+			return nil, err
+		}
+
+		if group == "" {
+			f.And = append(f.And, cond)
+			continue
+		}
+		idx, ok := groups[group]
+		if !ok {
+			idx = len(f.Or)
+			groups[group] = idx
+			f.Or = append(f.Or, nil)
+		}
+		f.Or[idx] = append(f.Or[idx], cond)
+	}
+
+	return f, nil
+}
+
+// splitFilterKey turns "filter[a][b][c]" into ["a", "b", "c"]
+func splitFilterKey(key string) ([]string, error) {
+	rest := strings.TrimPrefix(key, "filter")
+	var parts []string
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("%w: malformed key %q", ErrInvalidFilter, key)
+		}
+		end := strings.IndexByte(rest, ']')
+		if end <= 1 {
+			return nil, fmt.Errorf("%w: malformed key %q", ErrInvalidFilter, key)
+		}
+		parts = append(parts, rest[1:end])
+		rest = rest[end+1:]
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: malformed key %q", ErrInvalidFilter, key)
+	}
+	return parts, nil
+}
+
+// newCondition shapes raw query values according to the operator
+func newCondition(field string, op FilterOp, vals []string) (Condition, error) {
+	if !filterOps[op] {
+		return Condition{}, fmt.Errorf("%w: unsupported operator %q", ErrInvalidFilter, op)
+	}
+	if len(vals) == 0 {
+		return Condition{}, fmt.Errorf("%w: missing value for %s", ErrInvalidFilter, field)
+	}
+
+	cond := Condition{Field: field, Op: op}
+	switch op {
+	case OpIn, OpNotIn, OpBetween:
+		var list []string
+		for _, v := range vals {
+			list = append(list, strings.Split(v, ",")...)
+		}
+		cond.Value = list
+	case OpNull:
+		b, err := strconv.ParseBool(vals[0])
+		if err != nil {
+			return Condition{}, fmt.Errorf("%w: %s[null] expects a boolean", ErrInvalidFilter, field)
+		}
+		cond.Value = b
+	default:
+		cond.Value = vals[0]
+	}
+	return cond, nil
+}
+
+// Scope validates the filter against the schema of model and returns a
+// GORM scope applying it. Columns must exist on the schema and must not
+// carry the `filter:"-"` tag.
+func (f *Filter) Scope(db *gorm.DB, model interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	// LIKE is case-insensitive under the default MySQL and SQLite collations;
+	// PostgreSQL needs ILIKE for the same behaviour
+	ilike := db.Dialector != nil && db.Dialector.Name() == "postgres"
+
+	var exprs []clause.Expression
+	for _, cond := range f.And {
+		expr, err := cond.expression(stmt.Schema, ilike)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	for _, group := range f.Or {
+		var ors []clause.Expression
+		for _, cond := range group {
+			expr, err := cond.expression(stmt.Schema, ilike)
+			if err != nil {
+				return nil, err
+			}
+			ors = append(ors, expr)
+		}
+		if len(ors) > 0 {
+			exprs = append(exprs, clause.Or(ors...))
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		for _, expr := range exprs {
+			db = db.Where(expr)
+		}
+		return db
+	}, nil
+}
+
+// expression resolves the column and converts the value into a SQL expression
+func (c Condition) expression(sch *schema.Schema, ilike bool) (clause.Expression, error) {
+	field := sch.LookUpField(c.Field)
+	if field == nil || field.DBName == "" || field.Tag.Get("filter") == "-" {
+		return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFilter, c.Field)
+	}
+	col := clause.Column{Name: field.DBName}
+
+	if c.Op == OpNull {
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s[null] expects a boolean", ErrInvalidFilter, c.Field)
+		}
+		if isNull {
+			return clause.Eq{Column: col, Value: nil}, nil
+		}
+		return clause.Neq{Column: col, Value: nil}, nil
+	}
+
+	switch c.Op {
+	case OpIn, OpNotIn, OpBetween:
+		raw, err := toStrings(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s[%s] %v", ErrInvalidFilter, c.Field, c.Op, err)
+		}
+		if len(raw) == 0 || len(raw) > maxFilterValues {
+			return nil, fmt.Errorf("%w: %s[%s] expects 1-%d values", ErrInvalidFilter, c.Field, c.Op, maxFilterValues)
+		}
+		if c.Op == OpBetween && len(raw) != 2 {
+			return nil, fmt.Errorf("%w: %s[between] expects two values", ErrInvalidFilter, c.Field)
+		}
+		vals := make([]interface{}, len(raw))
+		for i, s := range raw {
+			v, err := convertFilterValue(field, s)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		switch c.Op {
+		case OpIn:
+			return clause.IN{Column: col, Values: vals}, nil
+		case OpNotIn:
+			return clause.Not(clause.IN{Column: col, Values: vals}), nil
+		default:
+			return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []interface{}{col, vals[0], vals[1]}}, nil
+		}
+	}
+
+	raw, err := toString(c.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s[%s] %v", ErrInvalidFilter, c.Field, c.Op, err)
+	}
+
+	if c.Op == OpLike {
+		if kindOf(field) != reflect.String {
+			return nil, fmt.Errorf("%w: %s does not support like", ErrInvalidFilter, c.Field)
+		}
+		like := "LIKE"
+		if ilike {
+			like = "ILIKE"
+		}
+		return clause.Expr{SQL: "? " + like + " ? ESCAPE '" + likeEscape + "'", Vars: []interface{}{col, likePattern(raw)}}, nil
+	}
+
+	v, err := convertFilterValue(field, raw)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Op {
+	case OpEq:
+		return clause.Eq{Column: col, Value: v}, nil
+	case OpNe:
+		return clause.Neq{Column: col, Value: v}, nil
+	case OpGt:
+		return clause.Gt{Column: col, Value: v}, nil
+	case OpGte:
+		return clause.Gte{Column: col, Value: v}, nil
+	case OpLt:
+		return clause.Lt{Column: col, Value: v}, nil
+	case OpLte:
+		return clause.Lte{Column: col, Value: v}, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported operator %q", ErrInvalidFilter, c.Op)
+}
+
+// likeEscape escapes LIKE wildcards. It is not the backslash, which MySQL
+// also treats as an escape inside string literals.
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// likePattern matches raw anywhere in a column, taking % and _ literally
+func likePattern(raw string) string {
+	return "%" + likeEscaper.Replace(raw) + "%"
+}
+
+// convertFilterValue parses s into the Go kind of the column
+func convertFilterValue(field *schema.Field, s string) (interface{}, error) {
+	var (
+		v   interface{}
+		err error
+	)
+	switch kindOf(field) {
+	case reflect.String:
+		v = s
+	case reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		return nil, fmt.Errorf("%w: column %q only supports null", ErrInvalidFilter, field.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad value %q for %s", ErrInvalidFilter, s, field.Name)
+	}
+	return v, nil
+}
+
+// kindOf returns the underlying kind of a field, dereferencing pointers
+func kindOf(field *schema.Field) reflect.Kind {
+	t := field.FieldType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+func toString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case fmt.Stringer:
+		return val.String(), nil
+	case nil:
+		return "", errors.New("missing value")
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
+
+func toStrings(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case []string:
+		return val, nil
+	case string:
+		return strings.Split(val, ","), nil
+	case []interface{}:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = fmt.Sprint(item)
+		}
+		return out, nil
+	default:
+		return nil, errors.New("expects a list of values")
+	}
+}
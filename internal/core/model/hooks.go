@@ -0,0 +1,93 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// previousKey is the context key holding the entity state before an Update/Remove
+type previousKey struct{}
+
+// withPrevious attaches the pre-change entity to the transaction context
+func withPrevious(tx *gorm.DB, old interface{}) *gorm.DB {
+	return tx.WithContext(context.WithValue(tx.Statement.Context, previousKey{}, old))
+}
+
+// Previous returns the state of the entity before the current Update or Remove.
+// It is meant to be called from ActionHooks with the tx they receive:
+//
+//	func (a *Article) AfterUpdate(tx *gorm.DB) error {
+//		if old, ok := model.Previous[Article](tx); ok && old.Status != a.Status { ... }
+//	}
+func Previous[T any](tx *gorm.DB) (*T, bool) {
+	if tx == nil || tx.Statement == nil || tx.Statement.Context == nil {
+		return nil, false
+	}
+	old, ok := tx.Statement.Context.Value(previousKey{}).(*T)
+	return old, ok
+}
+
+// applyUpdates mirrors a column->value map onto entity so hooks see the new state.
+// SQL expressions cannot be evaluated in memory and are left untouched.
+func applyUpdates(tx *gorm.DB, entity interface{}, updates map[string]interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(entity).Elem()
+	for key, value := range updates {
+		field := stmt.Schema.LookUpField(key)
+		if field == nil {
+			continue
+		}
+		switch value.(type) {
+		case clause.Expression, *gorm.DB:
+			continue
+		}
+		// Best effort: type mismatches are reported by the database write itself
+		_ = field.Set(tx.Statement.Context, rv, value)
+	}
+	return nil
+}
+
+// hookError marks an error returned by an ActionHooks method
+type hookError struct{ err error }
+
+func (e *hookError) Error() string { return e.err.Error() }
+func (e *hookError) Unwrap() error { return e.err }
+
+// notFoundError marks a failed lookup of the target entity
+type notFoundError struct{ err error }
+
+func (e *notFoundError) Error() string { return e.err.Error() }
+func (e *notFoundError) Unwrap() error { return e.err }
+
+// failure converts a transaction error into a Result.
+// Hook errors surface their own message; lookups report "Not Found"
+// and stale versions "Version Conflict".
+func failure(err error, msg string) *Result {
+	var he *hookError
+	if errors.As(err, &he) {
+		return &Result{Success: false, Error: he.err, Message: he.err.Error()}
+	}
+	if errors.Is(err, ErrConflict) {
+		return &Result{Success: false, Error: err, Message: "Version Conflict"}
+	}
+	if errors.Is(err, ErrInvalidVersion) {
+		return &Result{Success: false, Error: err, Message: "Invalid Version"}
+	}
+	var nf *notFoundError
+	if errors.As(err, &nf) {
+		return &Result{Success: false, Error: nf.err, Message: "Not Found"}
+	}
+	return &Result{Success: false, Error: err, Message: msg}
+}
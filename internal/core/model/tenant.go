@@ -0,0 +1,184 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrCrossTenant is returned when a write would put a row into another tenant.
+	ErrCrossTenant = errors.New("cross-tenant write")
+	// ErrNoTenant is returned for statements on tenant-aware tables whose
+	// context is neither scoped to a tenant nor running as platform.
+	ErrNoTenant = errors.New("tenant-aware query without a tenant")
+)
+
+// tenantColumn is the column shared by model.Tenant and the entities
+// declaring their own SaasID field
+const tenantColumn = "saas_id"
+
+type tenantKey struct{}
+type platformKey struct{}
+
+// WithTenant scopes every query run with ctx to the given tenant. The
+// default tenant is "", so WithTenant(ctx, "") scopes to rows without one.
+// It also ends an AsPlatform of ctx.
+func WithTenant(ctx context.Context, saasID string) context.Context {
+	return context.WithValue(context.WithValue(ctx, platformKey{}, false), tenantKey{}, saasID)
+}
+
+// AsPlatform lifts tenant scoping for queries run with ctx.
+// It is the escape hatch for platform administration and must only be used
+// after the caller has been authorized as a platform admin.
+func AsPlatform(ctx context.Context) context.Context {
+	return context.WithValue(ctx, platformKey{}, true)
+}
+
+// TenantFrom returns the tenant that queries run with ctx are scoped to.
+// ok is false when no tenant was set or the context runs as platform.
+func TenantFrom(ctx context.Context) (saasID string, ok bool) {
+	if ctx == nil || IsPlatform(ctx) {
+		return "", false
+	}
+	saasID, ok = ctx.Value(tenantKey{}).(string)
+	return saasID, ok
+}
+
+// IsPlatform reports whether ctx was lifted out of tenant scoping by AsPlatform
+func IsPlatform(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	platform, _ := ctx.Value(platformKey{}).(bool)
+	return platform
+}
+
+// TenantPlugin scopes tenant-aware tables to the tenant in the statement
+// context: saas_id is stamped on create and "saas_id = ?" is added to every
+// query, update and delete. Tables without a saas_id column and raw SQL
+// are left untouched; a statement on a tenant-aware table must carry a
+// tenant or run as platform, otherwise it fails with ErrNoTenant.
+//
+//	db.Use(model.NewTenantPlugin())
+//	repo.WithContext(model.WithTenant(ctx, "t1")).List(params)
+type TenantPlugin struct{}
+
+// NewTenantPlugin creates the plugin
+func NewTenantPlugin() *TenantPlugin {
+	return &TenantPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *TenantPlugin) Name() string {
+	return "appsite:tenant"
+}
+
+// Initialize implements gorm.Plugin
+func (p *TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("appsite:tenant_create", stampTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("appsite:tenant_query", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("appsite:tenant_row", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("appsite:tenant_update", guardTenantUpdate); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("appsite:tenant_delete", scopeTenant)
+}
+
+// tenantField returns the saas_id field and tenant for a scoped statement
+func tenantField(db *gorm.DB) (*schema.Field, string, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, "", false
+	}
+	field := db.Statement.Schema.LookUpField(tenantColumn)
+	if field == nil {
+		return nil, "", false
+	}
+	saasID, ok := TenantFrom(db.Statement.Context)
+	if !ok {
+		// Forgetting the tenant must not read or write every tenant's rows
+		if !IsPlatform(db.Statement.Context) {
+			db.AddError(ErrNoTenant)
+		}
+		return nil, "", false
+	}
+	return field, saasID, true
+}
+
+// scopeTenant restricts a statement to the current tenant's rows
+func scopeTenant(db *gorm.DB) {
+	field, saasID, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	// Statements can run more than once (Count then Find); add the condition once
+	if _, scoped := db.Statement.Settings.LoadOrStore("appsite:tenant_scoped", true); scoped {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: saasID},
+	}})
+}
+
+// stampTenant sets saas_id on new rows and rejects rows of another tenant
+func stampTenant(db *gorm.DB) {
+	field, saasID, ok := tenantField(db)
+	if !ok {
+		return
+	}
+
+	stamp := func(rv reflect.Value) {
+		current, zero := field.ValueOf(db.Statement.Context, rv)
+		if zero {
+			if err := field.Set(db.Statement.Context, rv, saasID); err != nil {
+				db.AddError(err)
+			}
+			return
+		}
+		if current != saasID {
+			db.AddError(ErrCrossTenant)
+		}
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			stamp(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+}
+
+// guardTenantUpdate scopes updates and forbids moving rows to another tenant
+func guardTenantUpdate(db *gorm.DB) {
+	field, saasID, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	if updates, isMap := db.Statement.Dest.(map[string]interface{}); isMap {
+		for _, key := range []string{field.DBName, field.Name} {
+			if v, exists := updates[key]; exists && v != saasID {
+				db.AddError(ErrCrossTenant)
+				return
+			}
+		}
+	}
+	scopeTenant(db)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/log"
+)
+
+// ErrNotSoftDeletable is returned by the trash operations on entities
+// that do not embed SoftDelete.
+var ErrNotSoftDeletable = errors.New("entity does not support soft delete")
+
+// TombstoneColumn is a column an entity with unique columns declares so that
+// trashed rows do not hold on to their values: it is empty while the row is
+// live and holds the row ID once trashed. Listing it last in each unique index
+// makes the index compare live rows only, and Remove and Restore keep it set.
+const TombstoneColumn = "deleted_key"
+
+// softDeletable is satisfied by any entity embedding SoftDelete
+type softDeletable interface {
+	softDeleteField() *SoftDelete
+}
+
+func (s *SoftDelete) softDeleteField() *SoftDelete { return s }
+
+// trashed scopes a query to soft-deleted rows only.
+// GORM hooks are skipped so ActionHooks methods are not mistaken for them.
+func (c *CRUD[T]) trashed() (*gorm.DB, error) {
+	if _, ok := any(new(T)).(softDeletable); !ok {
+		return nil, ErrNotSoftDeletable
+	}
+	return c.DB.Session(&gorm.Session{SkipHooks: true}).Unscoped().Model(new(T)).Where("deleted_at IS NOT NULL"), nil
+}
+
+// tombstoned reports whether T declares TombstoneColumn
+func (c *CRUD[T]) tombstoned() bool {
+	stmt := &gorm.Statement{DB: c.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return false
+	}
+	return stmt.Schema.LookUpField(TombstoneColumn) != nil
+}
+
+// Restore brings a soft-deleted entity back.
+// For entities with a TombstoneColumn it fails while a live row holds one of
+// its unique values.
+func (c *CRUD[T]) Restore(id string) *Result {
+	db, err := c.trashed()
+	if err != nil {
+		return &Result{Success: false, Error: err, Message: "Restore Failed"}
+	}
+
+	values := map[string]interface{}{"deleted_at": nil}
+	if c.tombstoned() {
+		values[TombstoneColumn] = ""
+	}
+	res := db.Where("id = ?", id).Updates(values)
+	if res.Error != nil {
+		return &Result{Success: false, Error: res.Error, Message: "Restore Failed"}
+	}
+	if res.RowsAffected == 0 {
+		return &Result{Success: false, Error: gorm.ErrRecordNotFound, Message: "Not Found"}
+	}
+	return &Result{Success: true, Data: id}
+}
+
+// ListTrashed lists soft-deleted entities, most recently deleted first
+// unless params.Sort says otherwise.
+func (c *CRUD[T]) ListTrashed(params *ListParams) *Result {
+	db, err := c.trashed()
+	if err != nil {
+		return &Result{Success: false, Error: err, Message: "List Failed"}
+	}
+	if params.Sort == "" {
+		p := *params
+		p.Sort = "deleted_at desc"
+		params = &p
+	}
+	return c.list(db, params)
+}
+
+// Purge permanently deletes a soft-deleted entity.
+// Delete hooks already ran when the entity was trashed and are not called again.
+func (c *CRUD[T]) Purge(id string) *Result {
+	db, err := c.trashed()
+	if err != nil {
+		return &Result{Success: false, Error: err, Message: "Purge Failed"}
+	}
+
+	res := db.Where("id = ?", id).Delete(new(T))
+	if res.Error != nil {
+		return &Result{Success: false, Error: res.Error, Message: "Purge Failed"}
+	}
+	if res.RowsAffected == 0 {
+		return &Result{Success: false, Error: gorm.ErrRecordNotFound, Message: "Not Found"}
+	}
+	return &Result{Success: true, Data: id}
+}
+
+// PurgeBefore permanently deletes entities trashed before cutoff.
+// Data holds the number of purged rows.
+func (c *CRUD[T]) PurgeBefore(cutoff time.Time) *Result {
+	db, err := c.trashed()
+	if err != nil {
+		return &Result{Success: false, Error: err, Message: "Purge Failed"}
+	}
+
+	res := db.Where("deleted_at < ?", cutoff).Delete(new(T))
+	if res.Error != nil {
+		return &Result{Success: false, Error: res.Error, Message: "Purge Failed"}
+	}
+	return &Result{Success: true, Data: res.RowsAffected}
+}
+
+// Bin is the trash of one entity type; every CRUD implements it.
+type Bin interface {
+	ListTrashed(params *ListParams) *Result
+	Restore(id string) *Result
+	Purge(id string) *Result
+	PurgeBefore(cutoff time.Time) *Result
+}
+
+// BinOf returns the trash of T bound to ctx, so it follows the tenant in ctx
+func BinOf[T any](db *gorm.DB) func(ctx context.Context) Bin {
+	crud := NewCRUD[T](db)
+	return func(ctx context.Context) Bin {
+		return crud.WithContext(ctx)
+	}
+}
+
+// PurgeTrash permanently deletes the entities of every bin trashed longer than
+// retention and returns how many rows went. A failing bin does not stop the
+// others; the first error is returned once all have run.
+func PurgeTrash(ctx context.Context, retention time.Duration, bins ...Bin) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	var total int64
+	var first error
+	for _, b := range bins {
+		res := b.PurgeBefore(cutoff)
+		if !res.Success {
+			log.Error(ctx, "Trash purge failed", "err", res.Error)
+			if first == nil {
+				first = res.Error
+			}
+			continue
+		}
+		n, _ := res.Data.(int64)
+		total += n
+	}
+	return total, first
+}
@@ -4,13 +4,55 @@
 
 package model
 
+import "errors"
+
 // ListParams defines parameters for listing data
 type ListParams struct {
 	Page     int
 	PageSize int
 	Sort     string
-	Filters  map[string]interface{}
+	Filters  map[string]interface{} // Trusted equality filters set by services
+	Filter   *Filter                // Client supplied filter, validated against the schema
 	IsPublic bool
+
+	// CursorMode switches to keyset pagination over the Sort columns.
+	// No count is run; Cursor is the next_cursor of the previous page.
+	CursorMode bool
+	Cursor     string
+}
+
+// Page is the typed payload of a List result
+type Page[T any] struct {
+	List       []T
+	Total      int64 // Not computed in cursor mode
+	Page       int
+	Size       int
+	NextCursor string // Empty when there are no more rows
+	CursorMode bool
+}
+
+// PageOf converts a List result into a typed page
+func PageOf[T any](res *Result) (*Page[T], error) {
+	if !res.Success {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, errors.New(res.Message)
+	}
+
+	data := res.Data.(map[string]interface{})
+	page := &Page[T]{List: data["list"].([]T)}
+	page.Size, _ = data["size"].(int)
+
+	if next, ok := data["next_cursor"].(string); ok {
+		page.CursorMode = true
+		page.NextCursor = next
+		return page, nil
+	}
+
+	page.Total = data["total"].(int64)
+	page.Page, _ = data["page"].(int)
+	return page, nil
 }
 
 // Result mimics the ASResult structure for standardized returns
@@ -0,0 +1,84 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrConflict is returned by Update when the entity was changed by someone else
+	// since the version the caller read.
+	ErrConflict = errors.New("version conflict")
+	// ErrInvalidVersion is returned by Update when the expected version is not
+	// a non-negative whole number.
+	ErrInvalidVersion = errors.New("invalid version")
+)
+
+// Versioned adds optimistic concurrency control.
+// CRUD.Update increments the version on every write and, when the updates
+// carry a "version" key, only writes if the stored version still matches it.
+type Versioned struct {
+	Version int64 `json:"version" gorm:"not null;default:1;comment:Optimistic lock version"`
+}
+
+// versioned is satisfied by any entity embedding Versioned
+type versioned interface {
+	versionField() *Versioned
+}
+
+func (v *Versioned) versionField() *Versioned { return v }
+
+// takeVersion removes the expected version from updates.
+// A value that cannot be read as a version is a client error, not a conflict.
+func takeVersion(updates map[string]interface{}) (expected int64, ok bool, err error) {
+	for _, key := range []string{"version", "Version"} {
+		raw, exists := updates[key]
+		if !exists {
+			continue
+		}
+		delete(updates, key)
+		ok = true
+
+		switch v := raw.(type) {
+		case int:
+			expected = int64(v)
+		case int64:
+			expected = v
+		case float64:
+			expected = int64(v)
+			if float64(expected) != v {
+				err = ErrInvalidVersion
+			}
+		case json.Number:
+			expected, err = v.Int64()
+		case string:
+			expected, err = strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		default:
+			err = errors.New("unsupported version type")
+		}
+		if err != nil || expected < 0 {
+			return 0, true, ErrInvalidVersion
+		}
+	}
+	return expected, ok, nil
+}
+
+// versionUpdate prepares a versioned write: it checks the expected version against
+// the stored one, bumps the in-memory version and adds the increment to updates.
+// It returns the version the conditional UPDATE must match.
+func versionUpdate(old, next *Versioned, updates map[string]interface{}, expected int64, check bool) (int64, error) {
+	if check && old.Version != expected {
+		return 0, ErrConflict
+	}
+	next.Version = old.Version + 1
+	updates["version"] = gorm.Expr("version + 1")
+	return old.Version, nil
+}
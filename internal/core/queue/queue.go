@@ -0,0 +1,260 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package queue runs background jobs from Redis. Jobs are enqueued on a
+// named queue, optionally delayed, and handled by the Workers of that queue.
+// A failed job is retried with exponential backoff and moved to the queue's
+// dead-letter list once it runs out of attempts.
+//
+// Keys of a queue share the {name} hash tag so they live in one cluster slot:
+//
+//	queue:{name}:ready    list of job IDs waiting for a worker
+//	queue:{name}:delayed  sorted set of job IDs by due time (ms)
+//	queue:{name}:active   sorted set of job IDs by lease expiry (ms)
+//	queue:{name}:dead     list of job IDs that exhausted their attempts
+//	queue:{name}:job:<id> the job itself, as JSON
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/model"
+)
+
+var (
+	ErrNoQueue   = errors.New("queue: name is required")
+	ErrNotDead   = errors.New("queue: job is not dead-lettered")
+	ErrNoHandler = errors.New("queue: no handler for job type")
+)
+
+// Handler runs a job. Returning an error schedules a retry.
+// A job may run more than once, so handlers must be idempotent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Job is a unit of background work
+type Job struct {
+	ID        string          `json:"id"`
+	Queue     string          `json:"queue"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	SaasID    string          `json:"saas_id,omitempty"` // Tenant of the enqueuing request
+	Attempts  int             `json:"attempts"`          // Failed runs so far
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// Decode unmarshals the job payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Queue enqueues jobs and holds the handlers run by its workers
+type Queue struct {
+	rdb *redis.Client
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// New creates a queue client on rdb
+func New(rdb *redis.Client) *Queue {
+	return &Queue{rdb: rdb, handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler of a job type, whichever queue the job is on
+func (q *Queue) Handle(jobType string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.handlers[jobType]; ok {
+		panic(fmt.Sprintf("queue: duplicate handler for %s", jobType))
+	}
+	q.handlers[jobType] = h
+}
+
+func (q *Queue) handler(jobType string) Handler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[jobType]
+}
+
+// Enqueue adds a job for immediate processing. payload is encoded as JSON.
+func (q *Queue) Enqueue(ctx context.Context, queue, jobType string, payload interface{}) (*Job, error) {
+	return q.Schedule(ctx, queue, jobType, payload, time.Time{})
+}
+
+// EnqueueIn adds a job that becomes due after delay
+func (q *Queue) EnqueueIn(ctx context.Context, queue, jobType string, payload interface{}, delay time.Duration) (*Job, error) {
+	return q.Schedule(ctx, queue, jobType, payload, time.Now().Add(delay))
+}
+
+// Schedule adds a job that becomes due at the given time; a zero or past time means now
+func (q *Queue) Schedule(ctx context.Context, queue, jobType string, payload interface{}, at time.Time) (*Job, error) {
+	if queue == "" {
+		return nil, ErrNoQueue
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", jobType, err)
+	}
+
+	job := &Job{
+		ID:        strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Queue:     queue,
+		Type:      jobType,
+		Payload:   raw,
+		CreatedAt: time.Now().Unix(),
+	}
+	job.SaasID, _ = model.TenantFrom(ctx)
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	k := keysOf(queue)
+	_, err = q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, k.job(job.ID), data, 0)
+		if at.After(time.Now()) {
+			pipe.ZAdd(ctx, k.delayed, redis.Z{Score: float64(at.UnixMilli()), Member: job.ID})
+		} else {
+			pipe.LPush(ctx, k.ready, job.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Stats counts the jobs of a queue by state
+type Stats struct {
+	Ready   int64 `json:"ready"`
+	Delayed int64 `json:"delayed"`
+	Active  int64 `json:"active"`
+	Dead    int64 `json:"dead"`
+}
+
+// Stats returns the job counts of a queue
+func (q *Queue) Stats(ctx context.Context, queue string) (*Stats, error) {
+	k := keysOf(queue)
+	pipe := q.rdb.Pipeline()
+	ready := pipe.LLen(ctx, k.ready)
+	delayed := pipe.ZCard(ctx, k.delayed)
+	active := pipe.ZCard(ctx, k.active)
+	dead := pipe.LLen(ctx, k.dead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return &Stats{Ready: ready.Val(), Delayed: delayed.Val(), Active: active.Val(), Dead: dead.Val()}, nil
+}
+
+// Dead lists the dead-lettered jobs of a queue, most recent first
+func (q *Queue) Dead(ctx context.Context, queue string, params *model.ListParams) (*model.Page[Job], error) {
+	page, size := params.Page, params.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 20
+	}
+
+	k := keysOf(queue)
+	total, err := q.rdb.LLen(ctx, k.dead).Result()
+	if err != nil {
+		return nil, err
+	}
+	start := int64((page - 1) * size)
+	ids, err := q.rdb.LRange(ctx, k.dead, start, start+int64(size)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Job, 0, len(ids))
+	if len(ids) > 0 {
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = k.job(id)
+		}
+		vals, err := q.rdb.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var job Job
+			if err := json.Unmarshal([]byte(s), &job); err == nil {
+				list = append(list, job)
+			}
+		}
+	}
+
+	return &model.Page[Job]{List: list, Total: total, Page: page, Size: size}, nil
+}
+
+// retryScript moves a job from the dead list back to ready
+var retryScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[3], ARGV[2])
+redis.call('LPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+// Retry puts a dead-lettered job back on its queue with a fresh attempt budget
+func (q *Queue) Retry(ctx context.Context, queue, id string) error {
+	k := keysOf(queue)
+	data, err := q.rdb.Get(ctx, k.job(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotDead
+	}
+	if err != nil {
+		return err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return err
+	}
+	job.Attempts = 0
+	if data, err = json.Marshal(&job); err != nil {
+		return err
+	}
+
+	n, err := retryScript.Run(ctx, q.rdb, []string{k.dead, k.ready, k.job(id)}, id, data).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotDead
+	}
+	return nil
+}
+
+type keys struct {
+	prefix, ready, delayed, active, dead string
+}
+
+func keysOf(queue string) keys {
+	p := "queue:{" + queue + "}:"
+	return keys{prefix: p, ready: p + "ready", delayed: p + "delayed", active: p + "active", dead: p + "dead"}
+}
+
+// job returns the key of a job, in the slot of its queue
+func (k keys) job(id string) string {
+	return k.prefix + "job:" + id
+}
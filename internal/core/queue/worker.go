@@ -0,0 +1,326 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/core/model"
+)
+
+// Options tunes a Worker; zero values fall back to the defaults
+type Options struct {
+	Concurrency  int           // jobs run in parallel, default 4
+	PollInterval time.Duration // wait when the queue is empty, default 1s
+	MaxAttempts  int           // failed runs before a job is dead-lettered, default 10
+	Backoff      time.Duration // first retry delay, doubled per attempt up to 1h, default 5s
+	Lease        time.Duration // time limit of a run; an unfinished job is handed out again after it, default 5m
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 5 * time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	return o
+}
+
+// maxBackoff caps the retry delay
+const maxBackoff = time.Hour
+
+// claimScript promotes due delayed jobs and expired leases to ready,
+// then leases the oldest ready job to the caller
+var claimScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('LPUSH', KEYS[1], id)
+end
+local expired = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[3], id)
+	redis.call('RPUSH', KEYS[1], id)
+end
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+redis.call('ZADD', KEYS[3], ARGV[2], id)
+return id
+`)
+
+// ackScript deletes a finished job, unless its lease was lost meanwhile
+var ackScript = redis.NewScript(`
+if tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1])) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+// nackScript stores a failed job and moves it to the delayed set,
+// or to the dead list when no due time is given
+var nackScript = redis.NewScript(`
+if tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1])) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('SET', KEYS[3], ARGV[3])
+if ARGV[4] == '' then
+	redis.call('LPUSH', KEYS[2], ARGV[1])
+else
+	redis.call('ZADD', KEYS[2], ARGV[4], ARGV[1])
+end
+return 1
+`)
+
+// Worker runs the jobs of one named queue with bounded concurrency.
+// Several workers, in one process or many, may serve the same queue.
+type Worker struct {
+	q    *Queue
+	name string
+	keys keys
+	opts Options
+
+	// ctx is cancelled only when a shutdown runs out of time
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewWorker creates a worker for the named queue
+func NewWorker(q *Queue, name string, opts Options) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		q:      q,
+		name:   name,
+		keys:   keysOf(name),
+		opts:   opts.withDefaults(),
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start launches the worker goroutines
+func (w *Worker) Start() {
+	w.startOnce.Do(func() {
+		for i := 0; i < w.opts.Concurrency; i++ {
+			w.wg.Add(1)
+			go w.loop()
+		}
+	})
+}
+
+// Shutdown stops taking new jobs and waits for the running ones to finish.
+// When ctx expires first, running jobs are cancelled and ctx.Err() returned;
+// jobs that give up on cancellation are put back on the queue without
+// using up an attempt.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		return ctx.Err()
+	}
+}
+
+// Drain shuts several workers down in parallel
+func Drain(ctx context.Context, workers ...*Worker) error {
+	errs := make([]error, len(workers))
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func(i int, w *Worker) {
+			defer wg.Done()
+			if err := w.Shutdown(ctx); err != nil {
+				errs[i] = fmt.Errorf("queue %s: %w", w.name, err)
+			}
+		}(i, w)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (w *Worker) loop() {
+	defer w.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		ok, err := w.ProcessOne(w.ctx)
+		if err != nil {
+			log.Error(w.ctx, "Job processing failed", "queue", w.name, "err", err)
+		}
+		if ok && err == nil {
+			continue
+		}
+
+		timer.Reset(w.opts.PollInterval)
+		select {
+		case <-w.stop:
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// ProcessOne runs the next due job, if any, and reports whether one was found
+func (w *Worker) ProcessOne(ctx context.Context) (bool, error) {
+	// Bookkeeping must outlive a cancelled run
+	bctx := context.WithoutCancel(ctx)
+
+	now := time.Now()
+	lease := now.Add(w.opts.Lease).UnixMilli()
+	id, err := claimScript.Run(bctx, w.q.rdb,
+		[]string{w.keys.ready, w.keys.delayed, w.keys.active},
+		now.UnixMilli(), lease).Text()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data, err := w.q.rdb.Get(bctx, w.keys.job(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The job record is gone, nothing left to run
+		return true, w.q.rdb.ZRem(bctx, w.keys.active, id).Err()
+	}
+	if err != nil {
+		return true, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		log.Error(bctx, "Job dead-lettered", "queue", w.name, "id", id, "err", fmt.Errorf("decode job: %w", err))
+		return true, nackScript.Run(bctx, w.q.rdb,
+			[]string{w.keys.active, w.keys.dead, w.keys.job(id)},
+			id, lease, data, "").Err()
+	}
+
+	runErr := w.run(ctx, &job)
+	switch {
+	case runErr == nil:
+		return true, ackScript.Run(bctx, w.q.rdb, []string{w.keys.active, w.keys.job(id)}, id, lease).Err()
+	case ctx.Err() != nil:
+		// Interrupted by shutdown: hand the job back as it was
+		return true, w.release(bctx, &job, lease)
+	default:
+		job.Attempts++
+		job.LastError = truncate(runErr.Error(), 512)
+		return true, w.fail(bctx, &job, lease, runErr, job.Attempts >= w.opts.MaxAttempts)
+	}
+}
+
+// run calls the job's handler within the lease, turning a panic into an error
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	h := w.q.handler(job.Type)
+	if h == nil {
+		return fmt.Errorf("%w %q", ErrNoHandler, job.Type)
+	}
+
+	// Jobs run in the tenant they were enqueued in
+	hctx, cancel := context.WithTimeout(model.WithTenant(ctx, job.SaasID), w.opts.Lease)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(hctx, job)
+}
+
+// fail schedules a retry of the job, or dead-letters it
+func (w *Worker) fail(ctx context.Context, job *Job, lease int64, cause error, dead bool) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	if dead {
+		log.Error(ctx, "Job dead-lettered", "queue", w.name, "id", job.ID, "type", job.Type, "err", cause)
+		return nackScript.Run(ctx, w.q.rdb,
+			[]string{w.keys.active, w.keys.dead, w.keys.job(job.ID)},
+			job.ID, lease, data, "").Err()
+	}
+
+	due := time.Now().Add(w.backoff(job.Attempts)).UnixMilli()
+	log.Warn(ctx, "Job failed, will retry", "queue", w.name, "id", job.ID, "type", job.Type, "attempt", job.Attempts, "err", cause)
+	return nackScript.Run(ctx, w.q.rdb,
+		[]string{w.keys.active, w.keys.delayed, w.keys.job(job.ID)},
+		job.ID, lease, data, due).Err()
+}
+
+// release makes the job due again immediately
+func (w *Worker) release(ctx context.Context, job *Job, lease int64) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return nackScript.Run(ctx, w.q.rdb,
+		[]string{w.keys.active, w.keys.delayed, w.keys.job(job.ID)},
+		job.ID, lease, data, time.Now().UnixMilli()).Err()
+}
+
+// backoff returns the delay before the given retry
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.opts.Backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
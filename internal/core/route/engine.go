@@ -5,8 +5,10 @@ import (
 	"appsite-go/internal/core/setting"
 )
 
-// NewEngine initializes the Gin engine with global middlewares
-func NewEngine(cfg *setting.Config) *gin.Engine {
+// NewEngine initializes the Gin engine with global middlewares. Client IPs
+// come from X-Forwarded-For only behind the configured trusted proxies,
+// else anyone could pick the IP that login and OTP limits count against.
+func NewEngine(cfg *setting.Config) (*gin.Engine, error) {
 	// Set Gin mode
 	switch cfg.App.Mode {
 	case "release":
@@ -18,6 +20,9 @@ func NewEngine(cfg *setting.Config) *gin.Engine {
 	}
 
 	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
 
 	// Apply Core Middlewares
 	r.Use(LoggerMiddleware())
@@ -25,5 +30,5 @@ func NewEngine(cfg *setting.Config) *gin.Engine {
 	r.Use(CORSMiddleware())
 	r.Use(SaasMiddleware())
 
-	return r
+	return r, nil
 }
@@ -7,6 +7,7 @@ import (
 
 
 "appsite-go/internal/core/log"
+"appsite-go/internal/core/model"
 )
 
 const (
@@ -84,19 +85,38 @@ c.Next()
 }
 }
 
-// SaasMiddleware extracts tenant information
-func SaasMiddleware() gin.HandlerFunc {
-return func(c *gin.Context) {
+// RequestTenant returns the tenant a client asks for: the X-Tenant-ID
+// header or the tenant_id query param. It is only a hint; authenticated
+// requests run in the tenant of their credential.
+func RequestTenant(c *gin.Context) string {
 tenantID := c.GetHeader(HeaderTenantID)
 if tenantID == "" {
 // Fallback: Check Query param
 tenantID = c.Query("tenant_id")
 }
+return tenantID
+}
 
+// SaasMiddleware extracts tenant information. Anonymous requests run in
+// the tenant asked for, the default one without; authentication replaces it.
+func SaasMiddleware() gin.HandlerFunc {
+return func(c *gin.Context) {
+tenantID := RequestTenant(c)
 if tenantID != "" {
 c.Set(ContextTenantID, tenantID)
 }
+// Queries run with the request context are scoped to this tenant
+c.Request = c.Request.WithContext(model.WithTenant(c.Request.Context(), tenantID))
 
 c.Next()
 }
 }
+
+// PlatformMiddleware lifts tenant scoping for the routes it guards.
+// Mount it only behind platform-admin authorization.
+func PlatformMiddleware() gin.HandlerFunc {
+return func(c *gin.Context) {
+c.Request = c.Request.WithContext(model.AsPlatform(c.Request.Context()))
+c.Next()
+}
+}
@@ -0,0 +1,396 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package scheduler runs periodic tasks on cron expressions across several
+// instances. Every instance keeps the same schedule; at each tick the
+// instances race for a Redis lock and the winner runs the task, so a tick
+// runs once and a slow run never overlaps the next one. Runs are recorded
+// in sys_cron_run.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/log"
+	"appsite-go/internal/core/model"
+	appsite_redis "appsite-go/pkg/utils/redis"
+)
+
+var (
+	ErrJobNotFound = errors.New("scheduler: job not found")
+	ErrBusy        = errors.New("scheduler: job is already running")
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run statuses
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Task is the work of a job. ctx expires after the job timeout.
+type Task func(ctx context.Context) error
+
+// Run records one execution of a job
+type Run struct {
+	model.Base
+	Name       string `json:"name" gorm:"type:varchar(64);not null;index;uniqueIndex:idx_cron_tick,priority:1"`
+	Trigger    string `json:"trigger" gorm:"type:varchar(16);not null"`
+	Tick       *int64 `json:"tick" gorm:"uniqueIndex:idx_cron_tick,priority:2;comment:Scheduled time of the tick, empty for manual runs"`
+	Instance   string `json:"instance" gorm:"type:varchar(128)"`
+	Status     string `json:"status" gorm:"type:varchar(16);not null;index"`
+	StartedAt  int64  `json:"started_at" gorm:"comment:Milliseconds"`
+	FinishedAt int64  `json:"finished_at" gorm:"comment:Milliseconds"`
+	Duration   int64  `json:"duration" gorm:"comment:Milliseconds"`
+	Error      string `json:"error" gorm:"type:varchar(512)"`
+}
+
+// TableName returns table name
+func (Run) TableName() string {
+	return "sys_cron_run"
+}
+
+// Options tunes a Scheduler
+type Options struct {
+	// Specs overrides the registered cron expression per job name; "-" disables a job
+	Specs map[string]string
+	// Timeout bounds a run unless the job sets its own, default 10m
+	Timeout time.Duration
+}
+
+// Job describes a registered job
+type Job struct {
+	Name        string        `json:"name"`
+	Spec        string        `json:"spec"`
+	Description string        `json:"description"`
+	Timeout     time.Duration `json:"timeout"`
+	Disabled    bool          `json:"disabled"`
+
+	task     Task
+	schedule cron.Schedule
+}
+
+// JobInfo is a job with its next tick and latest run
+type JobInfo struct {
+	Job
+	NextRun int64 `json:"next_run"` // Unix seconds, 0 when disabled or stopped
+	LastRun *Run  `json:"last_run"`
+}
+
+// Scheduler runs registered jobs on their schedule
+type Scheduler struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	opts     Options
+	instance string
+
+	mu      sync.RWMutex
+	jobs    map[string]*Job
+	next    map[string]time.Time
+	started bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a scheduler storing its history in db and its locks in rdb
+func New(db *gorm.DB, rdb *redis.Client, opts Options) *Scheduler {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Minute
+	}
+	host, _ := os.Hostname()
+	return &Scheduler{
+		db:       db,
+		rdb:      rdb,
+		opts:     opts,
+		instance: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		jobs:     make(map[string]*Job),
+		next:     make(map[string]time.Time),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register adds a job running task on the standard 5-field cron spec, or a
+// descriptor such as "@hourly" or "@every 5m". A zero timeout uses the default.
+func (s *Scheduler) Register(name, spec, description string, timeout time.Duration, task Task) error {
+	if override, ok := s.opts.Specs[name]; ok && override != "" {
+		spec = override
+	}
+	job := &Job{Name: name, Spec: spec, Description: description, Timeout: timeout, task: task}
+	if job.Timeout <= 0 {
+		job.Timeout = s.opts.Timeout
+	}
+
+	if spec == "-" {
+		job.Disabled = true
+	} else {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return fmt.Errorf("scheduler: job %s: %w", name, err)
+		}
+		job.schedule = schedule
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("scheduler: duplicate job %s", name)
+	}
+	s.jobs[name] = job
+	if s.started && !job.Disabled {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+	return nil
+}
+
+// Start begins ticking every enabled job
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, job := range s.jobs {
+		if !job.Disabled {
+			s.wg.Add(1)
+			go s.loop(job)
+		}
+	}
+}
+
+// Stop ends the schedule and waits for running jobs until ctx expires
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(job *Job) {
+	defer s.wg.Done()
+
+	for {
+		next := job.schedule.Next(time.Now())
+		s.mu.Lock()
+		s.next[job.Name] = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.tick(job, next)
+	}
+}
+
+// tick runs job for the scheduled time unless another instance holds it or already ran it
+func (s *Scheduler) tick(job *Job, at time.Time) {
+	ctx := context.Background()
+	token := s.instance + ":" + uuid.NewString()
+	ok, err := appsite_redis.AcquireLock(ctx, s.rdb, lockKey(job.Name), token, job.Timeout+time.Minute)
+	if err != nil {
+		log.Error(ctx, "Cron lock failed", "job", job.Name, "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer appsite_redis.ReleaseLock(ctx, s.rdb, lockKey(job.Name), token)
+
+	// The lock serializes instances, so this check cannot race; the unique
+	// index on (name, tick) backs it up if a lock ever expires early
+	tick := at.Unix()
+	var count int64
+	if err := s.db.Model(&Run{}).Where("name = ? AND tick = ?", job.Name, tick).Count(&count).Error; err != nil {
+		log.Error(ctx, "Cron history check failed", "job", job.Name, "err", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	run := &Run{Name: job.Name, Trigger: TriggerSchedule, Tick: &tick}
+	if err := s.begin(run); err != nil {
+		log.Error(ctx, "Cron run not recorded", "job", job.Name, "err", err)
+		return
+	}
+	s.execute(job, run)
+}
+
+// Trigger runs a job now, outside its schedule, and returns the started run.
+// It fails with ErrBusy while the job is running anywhere.
+func (s *Scheduler) Trigger(name string) (*Run, error) {
+	job, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	token := s.instance + ":" + uuid.NewString()
+	ok, err := appsite_redis.AcquireLock(ctx, s.rdb, lockKey(name), token, job.Timeout+time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBusy
+	}
+
+	run := &Run{Name: name, Trigger: TriggerManual}
+	if err := s.begin(run); err != nil {
+		appsite_redis.ReleaseLock(ctx, s.rdb, lockKey(name), token)
+		return nil, err
+	}
+
+	started := *run
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer appsite_redis.ReleaseLock(ctx, s.rdb, lockKey(name), token)
+		s.execute(job, run)
+	}()
+	return &started, nil
+}
+
+func (s *Scheduler) begin(run *Run) error {
+	run.Instance = s.instance
+	run.Status = StatusRunning
+	run.StartedAt = time.Now().UnixMilli()
+	return s.db.Create(run).Error
+}
+
+// execute runs the task and records the outcome on run
+func (s *Scheduler) execute(job *Job, run *Run) {
+	// Maintenance spans every tenant
+	ctx, cancel := context.WithTimeout(model.AsPlatform(context.Background()), job.Timeout)
+	defer cancel()
+
+	err := call(ctx, job.task)
+	run.FinishedAt = time.Now().UnixMilli()
+	run.Duration = run.FinishedAt - run.StartedAt
+	run.Status = StatusSuccess
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = truncate(err.Error(), 512)
+		log.Error(ctx, "Cron job failed", "job", job.Name, "trigger", run.Trigger, "err", err)
+	}
+
+	if err := s.db.Model(&Run{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"finished_at": run.FinishedAt,
+		"duration":    run.Duration,
+		"error":       run.Error,
+	}).Error; err != nil {
+		log.Error(ctx, "Cron run not recorded", "job", job.Name, "err", err)
+	}
+}
+
+// call runs a task, turning a panic into an error
+func call(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+func (s *Scheduler) job(name string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Jobs lists the registered jobs by name with their next tick and latest run
+func (s *Scheduler) Jobs() ([]JobInfo, error) {
+	s.mu.RLock()
+	list := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		info := JobInfo{Job: *job}
+		if next, ok := s.next[job.Name]; ok && !job.Disabled {
+			info.NextRun = next.Unix()
+		}
+		list = append(list, info)
+	}
+	s.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	for i := range list {
+		var last Run
+		err := s.db.Where("name = ?", list[i].Name).Order("started_at desc").Limit(1).Find(&last).Error
+		if err != nil {
+			return nil, err
+		}
+		if last.ID != "" {
+			list[i].LastRun = &last
+		}
+	}
+	return list, nil
+}
+
+// History lists the runs of a job, most recent first
+func (s *Scheduler) History(name string, params *model.ListParams) (*model.Page[Run], error) {
+	if _, err := s.job(name); err != nil {
+		return nil, err
+	}
+	if params.Filters == nil {
+		params.Filters = map[string]interface{}{}
+	}
+	params.Filters["name"] = name
+	if params.Sort == "" {
+		params.Sort = "started_at desc"
+	}
+	return model.PageOf[Run](model.NewCRUD[Run](s.db).List(params))
+}
+
+func lockKey(name string) string {
+	return "cron:lock:" + name
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -13,6 +13,17 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
 	Log      LogConfig      `mapstructure:"log"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Events   EventsConfig   `mapstructure:"events"`
+	Queue    QueueConfig    `mapstructure:"queue"`
+	Cron     CronConfig     `mapstructure:"cron"`
+	OTP      OTPConfig      `mapstructure:"otp"`
+	SMS      SMSConfig      `mapstructure:"sms"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	Password PasswordConfig `mapstructure:"password"`
+	Login    LoginConfig    `mapstructure:"login"`
+	Social   SocialConfig   `mapstructure:"social"`
+	OAuth    OAuthConfig    `mapstructure:"oauth"`
 	AdminMenu string        `mapstructure:"admin_menu"` // JSON string
 }
 
@@ -23,25 +34,40 @@ type AppConfig struct {
 	JwtSecret string        `mapstructure:"jwt_secret"`
 	JwtExpire time.Duration `mapstructure:"jwt_expire"`
 	JwtIssuer string        `mapstructure:"jwt_issuer"`
+	// JwtRefreshExpire is the lifetime of a refresh token, renewed at each rotation
+	JwtRefreshExpire time.Duration `mapstructure:"jwt_refresh_expire"`
+	// JwtAlgorithm is HS256 (signed with JwtSecret), RS256 or EdDSA (rotating key pairs)
+	JwtAlgorithm string `mapstructure:"jwt_algorithm"`
+	// JwtKeyOverlap is how long a key pair is published before signing and kept after
+	JwtKeyOverlap time.Duration `mapstructure:"jwt_key_overlap"`
 }
 
 type ServerConfig struct {
-	Port         int           `mapstructure:"port"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	Port           int           `mapstructure:"port"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
+	TrustedProxies []string      `mapstructure:"trusted_proxies"` // IPs or CIDRs of the reverse proxies whose X-Forwarded-For is believed; none by default
 }
 
 type DatabaseConfig struct {
-	Type         string `mapstructure:"type"` // mysql, postgres
+	Type         string `mapstructure:"type"` // mysql, postgres, sqlite
 	Host         string `mapstructure:"host"`
 	Port         string `mapstructure:"port"` // Changed to string to handle "3306" or 3306 loosely if strictly typed in yaml
 	User         string `mapstructure:"user"`
 	Password     string `mapstructure:"password"`
 	Name         string `mapstructure:"name"`
 	Charset      string `mapstructure:"charset"`
+	SSLMode      string `mapstructure:"ssl_mode"` // postgres only, defaults to disable
 	TablePrefix  string `mapstructure:"table_prefix"`
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns int    `mapstructure:"max_open_conns"`
+	AutoMigrate  bool   `mapstructure:"auto_migrate"` // Apply pending migrations on server start
+
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	LogLevel        string        `mapstructure:"log_level"`      // SQL log: silent, error, warn, info
+	SlowThreshold   time.Duration `mapstructure:"slow_threshold"` // Queries slower than this are logged at warn
+	Replicas        []string      `mapstructure:"replicas"`       // Read replica DSNs in the driver's format
 }
 
 type RedisConfig struct {
@@ -60,3 +86,101 @@ type LogConfig struct {
 	MaxAge     int    `mapstructure:"max_age"`
 	Compress   bool   `mapstructure:"compress"`
 }
+
+// TrashConfig controls how long soft-deleted rows are kept.
+// A zero retention disables the purge_trash cron job.
+type TrashConfig struct {
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// EventsConfig tunes the outbox dispatcher; zero values use its defaults.
+// A zero retention disables the purge_outbox cron job.
+type EventsConfig struct {
+	Interval    time.Duration `mapstructure:"interval"`
+	BatchSize   int           `mapstructure:"batch_size"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	Backoff     time.Duration `mapstructure:"backoff"`
+	Retention   time.Duration `mapstructure:"retention"`
+}
+
+// QueueConfig sets up the background job workers; zero values use their defaults.
+type QueueConfig struct {
+	Queues       map[string]int `mapstructure:"queues"` // Queue name -> jobs run in parallel
+	PollInterval time.Duration  `mapstructure:"poll_interval"`
+	MaxAttempts  int            `mapstructure:"max_attempts"`
+	Backoff      time.Duration  `mapstructure:"backoff"`
+	Lease        time.Duration  `mapstructure:"lease"`         // Time limit of a job run
+	DrainTimeout time.Duration  `mapstructure:"drain_timeout"` // How long shutdown waits for running jobs
+}
+
+// CronConfig tunes the scheduler and its maintenance jobs
+type CronConfig struct {
+	Timeout         time.Duration     `mapstructure:"timeout"`           // Default time limit of a run
+	OrderPayTimeout time.Duration     `mapstructure:"order_pay_timeout"` // Pending orders older than this are closed
+	Specs           map[string]string `mapstructure:"specs"`             // Job name -> cron spec, "-" disables the job
+}
+
+// OTPConfig limits one-time codes; zero values use their defaults
+type OTPConfig struct {
+	Length         int           `mapstructure:"length"`
+	TTL            time.Duration `mapstructure:"ttl"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`    // Failed checks before the target is locked
+	Lockout        time.Duration `mapstructure:"lockout"`         // How long failed checks are counted and a lock lasts
+	TargetCooldown time.Duration `mapstructure:"target_cooldown"` // Delay between codes sent to a mobile or email
+	IPCooldown     time.Duration `mapstructure:"ip_cooldown"`     // Delay between codes requested by a client IP
+	SMSTemplate    string        `mapstructure:"sms_template"`    // Template code with a "code" param, plain text when empty
+}
+
+// SMSConfig selects the SMS provider. Debug mode always logs to the console.
+type SMSConfig struct {
+	Provider        string `mapstructure:"provider"` // aliyun, or empty to disable
+	RegionID        string `mapstructure:"region_id"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	AccessKeySecret string `mapstructure:"access_key_secret"`
+	SignName        string `mapstructure:"sign_name"`
+}
+
+// MailConfig is the SMTP server sending email. Debug mode always logs to the console.
+type MailConfig struct {
+	Host     string `mapstructure:"host"` // Empty disables email
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// PasswordConfig sets up password reset links sent by email
+type PasswordConfig struct {
+	ResetURL string        `mapstructure:"reset_url"` // Client page receiving the token query parameter
+	ResetTTL time.Duration `mapstructure:"reset_ttl"`
+}
+
+// LoginConfig limits failed password logins; zero values use their defaults
+type LoginConfig struct {
+	MaxFailures   int           `mapstructure:"max_failures"`    // Failures of an account before it is locked
+	IPMaxFailures int           `mapstructure:"ip_max_failures"` // Failures from a client IP before it is locked
+	Window        time.Duration `mapstructure:"window"`          // How long failures are counted
+	Lockout       time.Duration `mapstructure:"lockout"`         // How long a lock lasts
+	DelayAfter    int           `mapstructure:"delay_after"`     // Failures of an account before each retry must wait
+	BaseDelay     time.Duration `mapstructure:"base_delay"`      // First wait, doubled per further failure
+	MaxDelay      time.Duration `mapstructure:"max_delay"`
+}
+
+// SocialConfig enables social login providers
+type SocialConfig struct {
+	Wechat WechatConfig `mapstructure:"wechat"`
+}
+
+// WechatConfig is a WeChat web application; an empty AppID disables it
+type WechatConfig struct {
+	AppID       string `mapstructure:"app_id"`
+	Secret      string `mapstructure:"secret"`
+	RedirectURL string `mapstructure:"redirect_url"` // Callback registered with WeChat, /api/v1/callback/wechat
+	Scope       string `mapstructure:"scope"`        // snsapi_login (QR code, default) or snsapi_userinfo (inside WeChat)
+}
+
+// OAuthConfig makes the site the OAuth2 and OpenID Connect provider of its apps
+type OAuthConfig struct {
+	Issuer        string `mapstructure:"issuer"`         // Public base URL of the site; empty disables the provider
+	AuthorizePage string `mapstructure:"authorize_page"` // Front-end page asking for consent, <issuer>/oauth/authorize by default
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "gorm.io/gorm"
+
+// Tables as created by 202601010001_user
+
+type userAccount struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string         `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	Username    string         `gorm:"size:64;uniqueIndex;comment:Login username"`
+	Password    string         `gorm:"size:255;comment:Hashed password"`
+	Email       *string        `gorm:"size:64;uniqueIndex;comment:Login email"`
+	Mobile      *string        `gorm:"size:24;uniqueIndex;comment:Login mobile"`
+	Nickname    string         `gorm:"size:64"`
+	Avatar      string         `gorm:"size:255"`
+	Status      string         `gorm:"size:12;default:'enabled'"` // enabled, disabled
+	Cover       string         `gorm:"size:255;comment:Cover URL"`
+	Description string         `gorm:"size:255;comment:Short description"`
+	Introduce   string         `gorm:"type:text;comment:Rich text introduction"`
+	Birthday    int64          `gorm:"comment:Birthday timestamp"`
+	Gender      string         `gorm:"size:16;default:'private';comment:female/male/private"`
+	AreaID      string         `gorm:"size:32;default:'1';comment:Area ID"`
+	GroupID     string         `gorm:"size:32;default:'100'"`
+}
+
+func (userAccount) TableName() string {
+	return "user_account"
+}
+
+type userInfo struct {
+	UserID    string `gorm:"primaryKey;size:32;comment:User ID FK"`
+	RealName  string `gorm:"size:64;comment:Real Name"`
+	IDNumber  string `gorm:"size:32;comment:ID Card Number"`
+	Country   string `gorm:"size:64"`
+	Province  string `gorm:"size:64"`
+	City      string `gorm:"size:64"`
+	Company   string `gorm:"size:128"`
+	WechatID  string `gorm:"size:64;index"`
+	WeiboID   string `gorm:"size:64;index"`
+	QQID      string `gorm:"size:64;index"`
+	AppleUUID string `gorm:"size:64;index"`
+	DeviceID  string `gorm:"size:64;index"`
+	VIP       int    `gorm:"default:0"`
+	VIPExpire int64  `gorm:"default:0"`
+	Gallery   string `gorm:"type:text;comment:JSON array of image URLs"` // JSON
+}
+
+func (userInfo) TableName() string {
+	return "user_info"
+}
+
+type userGroup struct {
+	ID          string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	ParentID    string `gorm:"size:32;default:'0';index"`
+	Type        string `gorm:"size:32"`
+	Level       int    `gorm:"default:0"`
+	GroupName   string `gorm:"size:64"`
+	Description string `gorm:"size:255"`
+	MenuAccess  string `gorm:"type:text;comment:JSON of accessible menu IDs"`
+	Status      string `gorm:"size:12;default:'enabled'"`
+	Sort        int    `gorm:"default:0"`
+}
+
+func (userGroup) TableName() string {
+	return "user_group"
+}
+
+type userPreference struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID    string `gorm:"size:32;index"`
+	SaasID    string `gorm:"size:32;index"`
+	KeyID     string `gorm:"size:64;index"`
+	Content   string `gorm:"type:text"`
+	Desc      string `gorm:"size:255"`
+	Version   string `gorm:"size:32"`
+	Status    string `gorm:"size:12;default:'enabled'"`
+}
+
+func (userPreference) TableName() string {
+	return "user_preference"
+}
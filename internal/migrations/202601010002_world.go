@@ -0,0 +1,27 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601010002_world
+
+type sysTenant struct {
+	ID        string  `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64   `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64   `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Version   int64   `gorm:"not null;default:1;comment:Optimistic lock version"`
+	Title     string  `gorm:"type:varchar(64);not null;comment:Tenant Name"`
+	Domain    string  `gorm:"type:varchar(128);uniqueIndex;comment:Custom Domain"`
+	Code      string  `gorm:"type:varchar(32);uniqueIndex;comment:Subdomain code"`
+	Status    string  `gorm:"type:varchar(16);default:'enabled';index"`
+	ExpireAt  int64   `gorm:"index;comment:Subscription Expiry"`
+	OwnerID   string  `gorm:"type:varchar(36);index;comment:Admin User ID"`
+	Config    dbs.Map `gorm:"comment:Tenant specific settings"`
+}
+
+func (sysTenant) TableName() string {
+	return "sys_tenant"
+}
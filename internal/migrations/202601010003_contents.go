@@ -0,0 +1,173 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/pkg/dbs"
+)
+
+// Tables as created by 202601010003_contents
+
+type itemArticle struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Version     int64          `gorm:"not null;default:1;comment:Optimistic lock version"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	CategoryID  string         `gorm:"type:varchar(36);index"`
+	AuthorID    string         `gorm:"type:varchar(36);index"`
+	AreaID      string         `gorm:"type:varchar(36);index"`
+	RegionID    string         `gorm:"type:varchar(36);index"`
+	SaasID      string         `gorm:"type:varchar(36);index"`
+	Type        string         `gorm:"type:varchar(32)"`
+	Mode        string         `gorm:"type:varchar(32)"`
+	Title       string         `gorm:"type:varchar(255);not null;index"`
+	Cover       string         `gorm:"type:varchar(255)"`
+	Gallery     dbs.Slice
+	Attachments dbs.Slice
+	Video       string `gorm:"type:varchar(255)"`
+	Link        string `gorm:"type:varchar(255)"`
+	Tags        dbs.StringArray
+	Description string `gorm:"type:varchar(255)"`
+	Introduce   dbs.Text
+	ViewTimes   int    `gorm:"default:0"`
+	Status      string `gorm:"type:varchar(32);default:'enabled';index"`
+	Featured    bool   `gorm:"default:false;index"`
+	Sort        int    `gorm:"default:0;index"`
+}
+
+func (itemArticle) TableName() string {
+	return "item_article"
+}
+
+type itemBanner struct {
+	ID         string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Version    int64          `gorm:"not null;default:1;comment:Optimistic lock version"`
+	DeletedAt  gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	SaasID     string         `gorm:"type:varchar(36);index"`
+	Position   string         `gorm:"type:varchar(32);index"`
+	Title      string         `gorm:"type:varchar(255);not null"`
+	Cover      string         `gorm:"type:varchar(255)"`
+	Link       string         `gorm:"type:varchar(255)"`
+	ClickTimes int            `gorm:"default:0"`
+	ViewTimes  int            `gorm:"default:0"`
+	Status     string         `gorm:"type:varchar(32);default:'enabled';index"`
+	Featured   bool           `gorm:"default:false;index"`
+	Sort       int            `gorm:"default:0;index"`
+}
+
+func (itemBanner) TableName() string {
+	return "item_banner"
+}
+
+type itemCategory struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string         `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	Title       string         `gorm:"size:64;index;comment:Category Name"`
+	Alias       string         `gorm:"size:24;index;comment:Unique Alias/Slug"`
+	AuthorID    string         `gorm:"size:32;index"`
+	ParentID    string         `gorm:"size:32;index;default:''"`
+	Type        string         `gorm:"size:32;index;comment:Category Type (e.g. article, video)"`
+	Description string         `gorm:"size:255"`
+	Cover       string         `gorm:"size:255"` // Image URL
+	Sort        int            `gorm:"default:0;index"`
+	Featured    bool           `gorm:"default:false;index"`
+	Status      string         `gorm:"size:12;default:'enabled'"`
+}
+
+func (itemCategory) TableName() string {
+	return "item_category"
+}
+
+type userComment struct {
+	ID        string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	DeletedAt gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	UserID    string         `gorm:"type:varchar(36);index;not null"`
+	ItemID    string         `gorm:"type:varchar(36);index"`
+	ItemType  string         `gorm:"type:varchar(32);index"`
+	Title     string         `gorm:"type:varchar(64)"`
+	Content   string         `gorm:"type:varchar(511)"`
+	Details   dbs.Map
+	Status    string `gorm:"type:varchar(32);default:'enabled';index"`
+	Featured  bool   `gorm:"default:false;index"`
+	Sort      int    `gorm:"default:0;index"`
+}
+
+func (userComment) TableName() string {
+	return "user_comment"
+}
+
+type itemMedia struct {
+	ID         string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	DeletedAt  gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	SaasID     string         `gorm:"type:varchar(36);index"`
+	CategoryID string         `gorm:"type:varchar(36);index"`
+	AuthorID   string         `gorm:"type:varchar(36);index"`
+	Type       string         `gorm:"type:varchar(16);index"` // image, video, file, etc.
+	Server     int            `gorm:"default:0"`              // 0: Local, 1: OSS
+	URL        string         `gorm:"type:varchar(255);not null"`
+	Size       int64          `gorm:"default:0"`
+	Meta       dbs.Map
+	Password   string `gorm:"type:varchar(255)"`
+	Status     string `gorm:"type:varchar(32);default:'enabled'"`
+	Featured   bool   `gorm:"default:false;index"`
+	Sort       int    `gorm:"default:0;index"`
+}
+
+func (itemMedia) TableName() string {
+	return "item_media"
+}
+
+type itemPage struct {
+	ID        string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	DeletedAt gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	Alias     string         `gorm:"type:varchar(32);uniqueIndex"`
+	SaasID    string         `gorm:"type:varchar(36);index"`
+	AuthorID  string         `gorm:"type:varchar(36);index"`
+	Title     string         `gorm:"type:varchar(64);not null"`
+	Cover     string         `gorm:"type:varchar(255)"`
+	Introduce dbs.Text
+	Status    string `gorm:"type:varchar(32);default:'enabled';index"`
+	ViewTimes int    `gorm:"default:0"`
+	Featured  bool   `gorm:"default:false;index"`
+	Sort      int    `gorm:"default:0;index"`
+}
+
+func (itemPage) TableName() string {
+	return "item_page"
+}
+
+type itemTag struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	SaasID      string         `gorm:"type:varchar(36);index"`
+	AuthorID    string         `gorm:"type:varchar(36);index"`
+	Type        string         `gorm:"type:varchar(32);index"`
+	Title       string         `gorm:"type:varchar(64);not null"`
+	Cover       string         `gorm:"type:varchar(255)"`
+	Description string         `gorm:"type:varchar(255)"`
+	Status      string         `gorm:"type:varchar(32);default:'enabled';index"`
+	Featured    bool           `gorm:"default:false;index"`
+	Sort        int            `gorm:"default:0;index"`
+}
+
+func (itemTag) TableName() string {
+	return "item_tag"
+}
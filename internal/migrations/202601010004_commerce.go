@@ -0,0 +1,151 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/pkg/dbs"
+)
+
+// Tables as created by 202601010004_commerce
+
+type shopProduct struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string         `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	Version     int64          `gorm:"not null;default:1;comment:Optimistic lock version"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	Title       string         `gorm:"type:varchar(128);not null;index"`
+	SubTitle    string         `gorm:"type:varchar(255)"`
+	CategoryID  string         `gorm:"type:varchar(36);index"`
+	BrandID     string         `gorm:"type:varchar(36);index"`
+	Cover       string         `gorm:"type:varchar(255)"`
+	Images      dbs.Map        // Array of strings
+	Price       int64          `gorm:"comment:Display Price in cents"`
+	MarketPrice int64          `gorm:"comment:Original Price"`
+	Status      string         `gorm:"type:varchar(16);default:'offline';index"` // on_sale, offline, sold_out
+	Content     string         `gorm:"type:text"`
+	Specs       dbs.Map        `gorm:"comment:Specification Template"` // e.g. [{"name":"Color", "values":["Red","Blue"]}]
+}
+
+func (shopProduct) TableName() string {
+	return "shop_product"
+}
+
+type shopSku struct {
+	ID        string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID    string         `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	DeletedAt gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	ProductID string         `gorm:"type:varchar(36);index;not null"`
+	Code      string         `gorm:"type:varchar(64);index;comment:Unique SKU Code"`
+	Title     string         `gorm:"type:varchar(128);comment:Specific Name"`
+	Cover     string         `gorm:"type:varchar(255)"`
+	Price     int64          `gorm:"not null"`
+	CostPrice int64
+	Stock     int     `gorm:"default:0"`
+	Specs     dbs.Map `gorm:"comment:Specific Spec Values"` // e.g. {"Color":"Red", "Size":"XL"}
+	Status    string  `gorm:"type:varchar(16);default:'enabled'"`
+}
+
+func (shopSku) TableName() string {
+	return "shop_sku"
+}
+
+type shopStockLog struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SKUID     string `gorm:"column:sku_id;type:varchar(36);index;not null"`
+	OrderID   string `gorm:"column:order_id;type:varchar(36);index;comment:Order ID causing change"`
+	Quantity  int    `gorm:"column:quantity;comment:Positive for add, Negative for deduct"`
+	Type      string `gorm:"column:type;type:varchar(16)"` // "order", "cancel", "admin", "return"
+	Before    int    `gorm:"column:before;comment:Snapshot before change"`
+	After     int    `gorm:"column:after;comment:Snapshot after change"`
+}
+
+func (shopStockLog) TableName() string {
+	return "shop_stock_log"
+}
+
+type shopCoupon struct {
+	ID          string         `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64          `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64          `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string         `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;comment:Soft delete timestamp"`
+	Title       string         `gorm:"type:varchar(64);not null"`
+	Description string         `gorm:"type:varchar(255)"`
+	Type        string         `gorm:"type:varchar(16);comment:cash, discount"` // cash (fixed), discount (percentage)
+	Value       int64          `gorm:"comment:Amount in cents or Percentage (e.g. 80 for 80%)"`
+	MinSpend    int64          `gorm:"default:0;comment:Minimum order amount in cents"`
+	StartTime   int64
+	EndTime     int64
+	TotalCount  int    `gorm:"default:-1;comment:-1 unlimited"`
+	TakenCount  int    `gorm:"default:0"`
+	Status      string `gorm:"type:varchar(16);default:'enabled'"`
+}
+
+func (shopCoupon) TableName() string {
+	return "shop_coupon"
+}
+
+type shopUserCoupon struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID    string `gorm:"type:varchar(36);index;not null"`
+	CouponID  string `gorm:"type:varchar(36);index;not null"`
+	Status    string `gorm:"type:varchar(16);default:'unused';index"` // unused, used, expired
+	UsedAt    int64
+	OrderID   string `gorm:"type:varchar(36);index"` // If used
+}
+
+func (shopUserCoupon) TableName() string {
+	return "shop_user_coupon"
+}
+
+type shopOrder struct {
+	ID              string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt       int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt       int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID          string `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	OrderNo         string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	UserID          string `gorm:"type:varchar(36);index;not null"`
+	TotalAmount     int64  `gorm:"comment:Sum of item prices"`
+	PayAmount       int64  `gorm:"comment:Final amount to pay"`
+	Discount        int64  `gorm:"comment:Discount applied"`
+	Status          string `gorm:"type:varchar(16);index;default:'pending'"`
+	PayMethod       string `gorm:"type:varchar(16)"`       // wechat, alipay, stripe
+	TransactionID   string `gorm:"type:varchar(64);index"` // 3rd party ID
+	AddressSnapshot string `gorm:"type:text"`
+	Note            string `gorm:"type:varchar(255)"`
+}
+
+func (shopOrder) TableName() string {
+	return "shop_order"
+}
+
+type shopOrderItem struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	OrderID   string `gorm:"type:varchar(36);index;not null"`
+	ProductID string `gorm:"type:varchar(36);index"`
+	SkuID     string `gorm:"type:varchar(36);index"`
+	Title     string `gorm:"type:varchar(128)"`
+	SkuSpec   string `gorm:"type:varchar(255)"` // e.g. "Color: Red, Size: L"
+	Thumb     string `gorm:"type:varchar(255)"`
+	Price     int64  `gorm:"comment:Unit price at moment of purchase"`
+	Quantity  int
+	Amount    int64 `gorm:"comment:Price * Quantity"`
+}
+
+func (shopOrderItem) TableName() string {
+	return "shop_order_item"
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601010005_finance
+
+type finDeal struct {
+	ID          string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	UserID      string `gorm:"type:varchar(36);index;not null"`
+	Type        string `gorm:"type:varchar(32);index"`           // payment, refund, reward, usage
+	Asset       string `gorm:"type:varchar(16);default:'money'"` // money, point
+	Amount      int64  `gorm:"comment:Positive for income, Negative for outcome"`
+	Balance     int64  `gorm:"comment:Snapshot of balance after deal"`
+	RelatedID   string `gorm:"type:varchar(36);index"` // OrderID etc.
+	Description string `gorm:"type:varchar(255)"`
+}
+
+func (finDeal) TableName() string {
+	return "fin_deal"
+}
+
+type finBalance struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID    string `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	UserID    string `gorm:"type:varchar(36);uniqueIndex:idx_user_asset;not null"`
+	Asset     string `gorm:"type:varchar(16);uniqueIndex:idx_user_asset;default:'money'"`
+	Total     int64  `gorm:"default:0"` // Current balance
+	Frozen    int64  `gorm:"default:0"` // Frozen amount
+}
+
+func (finBalance) TableName() string {
+	return "fin_balance"
+}
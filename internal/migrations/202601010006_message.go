@@ -0,0 +1,29 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601010006_message
+
+type messageNotification struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID     string `gorm:"type:varchar(36);index"`
+	SenderID   string `gorm:"type:varchar(36);index;not null"`
+	ReceiverID string `gorm:"type:varchar(36);index;not null"`
+	ReplyID    string `gorm:"type:varchar(36)"`
+	Type       string `gorm:"type:varchar(32);default:'normal'"` // message, notify, suggest
+	Status     string `gorm:"type:varchar(24);default:'sent'"`   // sent, read
+	Content    string `gorm:"type:varchar(512)"`
+	Link       string `gorm:"type:varchar(255)"`
+	LinkParams dbs.Map
+	LinkType   string `gorm:"type:varchar(16)"`
+}
+
+func (messageNotification) TableName() string {
+	return "message_notification"
+}
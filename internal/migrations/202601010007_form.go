@@ -0,0 +1,29 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601010007_form
+
+type formRequest struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID     string `gorm:"type:varchar(36);index"`
+	UserID     string `gorm:"type:varchar(36);index"`
+	ItemID     string `gorm:"type:varchar(36);index"`
+	ItemType   string `gorm:"type:varchar(32);index"`
+	Open       bool   `gorm:"default:false"`
+	Form       dbs.Map
+	Status     string `gorm:"type:varchar(12);default:'pending';index"` // pending, applied, rejected
+	Expire     int64  `gorm:"default:0"`
+	ApplyCall  dbs.Map
+	RejectCall dbs.Map
+}
+
+func (formRequest) TableName() string {
+	return "form_request"
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601010008_relation
+
+type relationCombine struct {
+	ID           string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt    int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt    int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	ItemID       string `gorm:"type:varchar(36);not null;index"`
+	ItemType     string `gorm:"type:varchar(32);not null;index"`
+	RelationID   string `gorm:"type:varchar(36);not null;index"`
+	RelationType string `gorm:"type:varchar(32);not null;index"`
+	Type         string `gorm:"type:varchar(16);index"` // e.g., follow, like, favorite
+	Rate         int    `gorm:"default:0"`
+	Status       string `gorm:"type:varchar(32);default:'enabled'"`
+}
+
+func (relationCombine) TableName() string {
+	return "relation_combine"
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601010009_shieldword
+
+type systemShieldword struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Title     string `gorm:"type:varchar(32);not null;index"`
+	AuthorID  string `gorm:"type:varchar(36);index"`
+	Type      string `gorm:"type:varchar(16)"`
+	Status    string `gorm:"type:varchar(32);default:'enabled'"`
+}
+
+func (systemShieldword) TableName() string {
+	return "system_shieldword"
+}
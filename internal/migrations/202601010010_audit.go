@@ -0,0 +1,24 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601010010_audit
+
+// auditLog has no TableName: like operation.AuditLog its table is named by
+// the naming strategy, so the type name must stay in step with it
+type auditLog struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID    string `gorm:"index;size:32"`
+	TenantID  string `gorm:"index;size:32"`
+	Action    string `gorm:"size:64;index"` // e.g. "login", "create_post"
+	Method    string `gorm:"size:10"`       // HTTP Method
+	Path      string `gorm:"size:255"`      // Request Path
+	IP        string `gorm:"size:45"`
+	UserAgent string `gorm:"size:255"`
+	Status    int    // HTTP Status
+	Detail    string `gorm:"type:text"` // JSON payload or error message
+}
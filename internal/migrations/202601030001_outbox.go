@@ -0,0 +1,27 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601030001_outbox
+
+type sysOutbox struct {
+	ID            string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt     int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt     int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Name          string `gorm:"type:varchar(64);not null;index"`
+	Payload       dbs.Text
+	SaasID        string          `gorm:"type:varchar(36);index;comment:Tenant of the publishing request"`
+	Status        string          `gorm:"type:varchar(16);not null;default:'pending';index:idx_outbox_due,priority:1"`
+	NextAttemptAt int64           `gorm:"not null;default:0;index:idx_outbox_due,priority:2"`
+	Attempts      int             `gorm:"not null;default:0"`
+	Delivered     dbs.StringArray `gorm:"comment:Subscribers that handled the event"`
+	LastError     string          `gorm:"type:varchar(512)"`
+}
+
+func (sysOutbox) TableName() string {
+	return "sys_outbox"
+}
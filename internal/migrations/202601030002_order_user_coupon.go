@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030002_order_user_coupon
+
+type shopOrderUserCoupon struct {
+	UserCouponID string `gorm:"type:varchar(36);index;comment:Coupon redeemed by this order"`
+}
+
+func (shopOrderUserCoupon) TableName() string {
+	return "shop_order"
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030003_cron_run
+
+type sysCronRun struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Name       string `gorm:"type:varchar(64);not null;index;uniqueIndex:idx_cron_tick,priority:1"`
+	Trigger    string `gorm:"type:varchar(16);not null"`
+	Tick       *int64 `gorm:"uniqueIndex:idx_cron_tick,priority:2;comment:Scheduled time of the tick, empty for manual runs"`
+	Instance   string `gorm:"type:varchar(128)"`
+	Status     string `gorm:"type:varchar(16);not null;index"`
+	StartedAt  int64  `gorm:"comment:Milliseconds"`
+	FinishedAt int64  `gorm:"comment:Milliseconds"`
+	Duration   int64  `gorm:"comment:Milliseconds"`
+	Error      string `gorm:"type:varchar(512)"`
+}
+
+func (sysCronRun) TableName() string {
+	return "sys_cron_run"
+}
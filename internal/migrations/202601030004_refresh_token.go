@@ -0,0 +1,25 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030004_refresh_token
+
+type accessRefreshToken struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID     string `gorm:"type:varchar(32);not null;index"`
+	Role       string `gorm:"type:varchar(32)"`
+	Family     string `gorm:"type:varchar(32);not null;index"`
+	Hash       string `gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the token"`
+	ExpiresAt  int64  `gorm:"index"`
+	UsedAt     int64  `gorm:"comment:Rotated at, 0 while current"`
+	RevokedAt  int64  `gorm:"index"`
+	ReplacedBy string `gorm:"type:varchar(32)"`
+}
+
+func (accessRefreshToken) TableName() string {
+	return "access_refresh_token"
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030005_signing_key
+
+type accessSigningKey struct {
+	ID          string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Algorithm   string `gorm:"type:varchar(16);not null"`
+	PrivateKey  string `gorm:"type:text;not null;comment:PKCS#8 PEM"`
+	PublicKey   string `gorm:"type:text;not null;comment:PKIX PEM"`
+	ActivatesAt int64  `gorm:"index;comment:Signs from then until a newer key activates"`
+	ExpiresAt   int64  `gorm:"index;comment:Dropped from the key set, 0 while no successor exists"`
+}
+
+func (accessSigningKey) TableName() string {
+	return "access_signing_key"
+}
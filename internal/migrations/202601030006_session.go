@@ -0,0 +1,23 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030006_session
+
+type userSession struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID     string `gorm:"type:varchar(32);not null;index"`
+	DeviceID   string `gorm:"type:varchar(64);index"`
+	UserAgent  string `gorm:"type:varchar(255)"`
+	IP         string `gorm:"type:varchar(45);comment:Last seen from"`
+	LastSeenAt int64  `gorm:"index"`
+	Current    bool   `gorm:"-"` // The session of the listing request
+}
+
+func (userSession) TableName() string {
+	return "user_session"
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030007_mfa
+
+type userMfa struct {
+	ID          string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID      string `gorm:"type:varchar(32);not null;uniqueIndex"`
+	Secret      string `gorm:"type:varchar(64);not null;comment:Base32 TOTP secret"`
+	ConfirmedAt int64  `gorm:"comment:0 while enrolling"`
+	LastStep    int64  `gorm:"comment:Time step of the last accepted code, against replays"`
+}
+
+func (userMfa) TableName() string {
+	return "user_mfa"
+}
+
+type userMfaRecovery struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID    string `gorm:"type:varchar(32);not null;index"`
+	Hash      string `gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the code"`
+	UsedAt    int64
+}
+
+func (userMfaRecovery) TableName() string {
+	return "user_mfa_recovery"
+}
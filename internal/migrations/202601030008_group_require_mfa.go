@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030008_group_require_mfa
+
+type userGroupRequireMFA struct {
+	RequireMFA bool `gorm:"default:false;comment:Members must sign in with a second factor"`
+}
+
+func (userGroupRequireMFA) TableName() string {
+	return "user_group"
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030009_user_identity
+
+type userIdentity struct {
+	ID          string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt   int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID      string `gorm:"type:varchar(32);default:'';uniqueIndex:idx_identity_subject,priority:1"`
+	Issuer      string `gorm:"size:255;not null;uniqueIndex:idx_identity_subject,priority:2"`
+	Subject     string `gorm:"size:255;not null;uniqueIndex:idx_identity_subject,priority:3"`
+	UserID      string `gorm:"size:32;not null;index"`
+	Provider    string `gorm:"size:64;not null;comment:Provider name in the tenant config"`
+	Email       string `gorm:"size:128;comment:Email at the provider when linked"`
+	LastLoginAt int64
+}
+
+func (userIdentity) TableName() string {
+	return "user_identity"
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030010_refresh_token_client
+
+type accessRefreshTokenClient struct {
+	ClientID string `gorm:"type:varchar(32);index;comment:OAuth client, empty for first-party logins"`
+}
+
+func (accessRefreshTokenClient) TableName() string {
+	return "access_refresh_token"
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030011_refresh_token_scope
+
+type accessRefreshTokenScope struct {
+	Scope string `gorm:"type:varchar(255)"`
+}
+
+func (accessRefreshTokenScope) TableName() string {
+	return "access_refresh_token"
+}
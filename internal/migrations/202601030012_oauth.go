@@ -0,0 +1,40 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601030012_oauth
+
+type oauthClient struct {
+	ID           string          `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt    int64           `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt    int64           `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	Name         string          `gorm:"type:varchar(64);not null"`
+	SecretHash   string          `gorm:"type:varchar(64);comment:SHA-256 of the secret, empty for public clients"`
+	RedirectURIs dbs.StringArray `gorm:"comment:Exact redirect URIs allowed"`
+	Scopes       dbs.StringArray `gorm:"comment:Scopes the client may ask for"`
+	GrantTypes   dbs.StringArray
+	Public       bool   `gorm:"comment:Native or browser app without a secret"`
+	Trusted      bool   `gorm:"comment:First-party app, no consent screen"`
+	Status       string `gorm:"type:varchar(12);default:'enabled'"`
+}
+
+func (oauthClient) TableName() string {
+	return "oauth_client"
+}
+
+type oauthConsent struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	UserID    string `gorm:"type:varchar(32);not null;uniqueIndex:idx_consent_user_client"`
+	ClientID  string `gorm:"type:varchar(32);not null;uniqueIndex:idx_consent_user_client"`
+	Scope     string `gorm:"type:varchar(255)"`
+}
+
+func (oauthConsent) TableName() string {
+	return "oauth_consent"
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/dbs"
+
+// Tables as created by 202601030013_api_key
+
+type accessApiKey struct {
+	ID         string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt  int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt  int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	SaasID     string `gorm:"index;type:varchar(32);default:'';comment:SaaS Tenant ID"`
+	UserID     string `gorm:"type:varchar(32);index;comment:Empty for tenant keys"`
+	Name       string `gorm:"type:varchar(64);not null"`
+	Hint       string `gorm:"type:varchar(16);comment:Start of the key, to recognise it"`
+	Hash       string `gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the key"`
+	Scopes     dbs.StringArray
+	AllowedIPs dbs.StringArray `gorm:"comment:IPs or CIDRs, any when empty"`
+	ExpiresAt  int64           `gorm:"comment:0 never expires"`
+	LastUsedAt int64
+	LastUsedIP string `gorm:"type:varchar(45)"`
+	RevokedAt  int64  `gorm:"index"`
+	CreatedBy  string `gorm:"type:varchar(32)"`
+}
+
+func (accessApiKey) TableName() string {
+	return "access_api_key"
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Tables as created by 202601030014_casbin_rule
+
+type accessCasbinRule struct {
+	ID        string `gorm:"primaryKey;type:varchar(32);comment:Unique ID"`
+	CreatedAt int64  `gorm:"autoCreateTime;comment:Creation timestamp (seconds)"`
+	UpdatedAt int64  `gorm:"autoUpdateTime;comment:Last update timestamp (seconds)"`
+	PType     string `gorm:"column:ptype;type:varchar(8);index:idx_casbin_rule"`
+	V0        string `gorm:"type:varchar(128);index:idx_casbin_rule"`
+	V1        string `gorm:"type:varchar(255)"`
+	V2        string `gorm:"type:varchar(64)"`
+	V3        string `gorm:"type:varchar(64)"`
+	V4        string `gorm:"type:varchar(64)"`
+	V5        string `gorm:"type:varchar(64)"`
+}
+
+func (accessCasbinRule) TableName() string {
+	return "access_casbin_rule"
+}
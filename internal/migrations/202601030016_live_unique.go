@@ -0,0 +1,104 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/pkg/utils/orm"
+)
+
+// Columns and indexes as changed by 202601030016_live_unique
+
+type userAccountLive struct {
+	Username   string  `gorm:"size:64;uniqueIndex:idx_user_account_live_username,priority:1"`
+	Email      *string `gorm:"size:64;uniqueIndex:idx_user_account_live_email,priority:1"`
+	Mobile     *string `gorm:"size:24;uniqueIndex:idx_user_account_live_mobile,priority:1"`
+	DeletedKey string  `gorm:"size:32;not null;default:'';uniqueIndex:idx_user_account_live_username,priority:2;uniqueIndex:idx_user_account_live_email,priority:2;uniqueIndex:idx_user_account_live_mobile,priority:2"`
+}
+
+func (userAccountLive) TableName() string {
+	return "user_account"
+}
+
+type itemPageLive struct {
+	Alias      string `gorm:"type:varchar(32);uniqueIndex:idx_item_page_live_alias,priority:1"`
+	DeletedKey string `gorm:"type:varchar(32);not null;default:'';uniqueIndex:idx_item_page_live_alias,priority:2"`
+}
+
+func (itemPageLive) TableName() string {
+	return "item_page"
+}
+
+// liveUnique moves the unique indexes of user_account and item_page onto
+// their live rows: trashed rows get their ID in deleted_key, which every
+// unique index now ends with.
+func liveUnique(id, description string) orm.Migration {
+	type change struct {
+		table   interface{}
+		before  interface{}
+		indexes map[string]string // new index => the index it replaces
+	}
+	changes := []change{
+		{&userAccountLive{}, &userAccount{}, map[string]string{
+			"idx_user_account_live_username": "idx_user_account_username",
+			"idx_user_account_live_email":    "idx_user_account_email",
+			"idx_user_account_live_mobile":   "idx_user_account_mobile",
+		}},
+		{&itemPageLive{}, &itemPage{}, map[string]string{
+			"idx_item_page_live_alias": "idx_item_page_alias",
+		}},
+	}
+
+	return orm.Migration{
+		ID:          id,
+		Description: description,
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, c := range changes {
+				if !m.HasColumn(c.table, "DeletedKey") {
+					if err := m.AddColumn(c.table, "DeletedKey"); err != nil {
+						return err
+					}
+				}
+				err := tx.Model(c.table).Where("deleted_at IS NOT NULL").
+					Update("deleted_key", gorm.Expr("id")).Error
+				if err != nil {
+					return err
+				}
+				for next, prev := range c.indexes {
+					if m.HasIndex(c.before, prev) {
+						if err := m.DropIndex(c.before, prev); err != nil {
+							return err
+						}
+					}
+					if !m.HasIndex(c.table, next) {
+						if err := m.CreateIndex(c.table, next); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, c := range changes {
+				for next, prev := range c.indexes {
+					if err := m.DropIndex(c.table, next); err != nil {
+						return err
+					}
+					if err := m.CreateIndex(c.before, prev); err != nil {
+						return err
+					}
+				}
+				if err := m.DropColumn(c.table, "DeletedKey"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
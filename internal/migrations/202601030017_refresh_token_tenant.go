@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030017_refresh_token_tenant
+
+type accessRefreshTokenTenant struct {
+	TenantID string `gorm:"type:varchar(36);not null;default:'';comment:Tenant the family acts in"`
+}
+
+func (accessRefreshTokenTenant) TableName() string {
+	return "access_refresh_token"
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+// Columns as added by 202601030019_coupon_lock_fence
+
+type shopCouponLockFence struct {
+	LockFence int64 `gorm:"not null;default:0;comment:Fencing token of the last locked issuance"`
+}
+
+func (shopCouponLockFence) TableName() string {
+	return "shop_coupon"
+}
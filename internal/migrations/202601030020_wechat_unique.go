@@ -0,0 +1,69 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"appsite-go/pkg/utils/orm"
+)
+
+// Columns and indexes as changed by 202601030020_wechat_unique
+
+type userInfoWechatUnique struct {
+	WechatID *string `gorm:"size:64;default:null;uniqueIndex:idx_user_info_wechat_id_unique"`
+}
+
+func (userInfoWechatUnique) TableName() string {
+	return "user_info"
+}
+
+// wechatUnique binds a WeChat account to one user at most. Unbound rows
+// hold NULL instead of "", which unique indexes do not compare, and the
+// index on wechat_id becomes unique. Accounts already bound to several
+// users stop the migration: which user keeps them is for an admin to say.
+func wechatUnique(id, description string) orm.Migration {
+	return orm.Migration{
+		ID:          id,
+		Description: description,
+		Up: func(tx *gorm.DB) error {
+			err := tx.Model(&userInfo{}).Where("wechat_id = ?", "").
+				Update("wechat_id", gorm.Expr("NULL")).Error
+			if err != nil {
+				return err
+			}
+			var shared int64
+			err = tx.Table("(?) AS shared", tx.Model(&userInfo{}).Select("wechat_id").
+				Where("wechat_id IS NOT NULL").Group("wechat_id").Having("COUNT(*) > 1")).
+				Count(&shared).Error
+			if err != nil {
+				return err
+			}
+			if shared > 0 {
+				return fmt.Errorf("%d WeChat accounts are bound to more than one user, unbind the extra users first", shared)
+			}
+
+			m := tx.Migrator()
+			if m.HasIndex(&userInfo{}, "idx_user_info_wechat_id") {
+				if err := m.DropIndex(&userInfo{}, "idx_user_info_wechat_id"); err != nil {
+					return err
+				}
+			}
+			return m.CreateIndex(&userInfoWechatUnique{}, "idx_user_info_wechat_id_unique")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropIndex(&userInfoWechatUnique{}, "idx_user_info_wechat_id_unique"); err != nil {
+				return err
+			}
+			if err := m.CreateIndex(&userInfo{}, "idx_user_info_wechat_id"); err != nil {
+				return err
+			}
+			return tx.Model(&userInfo{}).Where("wechat_id IS NULL").Update("wechat_id", "").Error
+		},
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package migrations holds the versioned schema and data migrations of appsite.
+// New migrations are appended to All with an ID sorting after the existing ones;
+// applied migrations must never be edited.
+//
+// Schema migrations never reference service entities: each migrates its own
+// copy of the tables or columns it creates, kept in a file named after the
+// migration, so changing an entity later cannot change what an old migration
+// does. Entity changes ship as new migrations.
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/pkg/utils/orm"
+)
+
+// All returns every registered migration
+func All() []orm.Migration {
+	var all []orm.Migration
+	all = append(all, schema()...)
+	all = append(all, seeds()...)
+	return all
+}
+
+// NewMigrator creates a migrator over All. Migrations span every tenant,
+// so they run as platform.
+func NewMigrator(db *gorm.DB) (*orm.Migrator, error) {
+	return orm.NewMigrator(db.WithContext(model.AsPlatform(db.Statement.Context)), All())
+}
+
+// Up applies all pending migrations
+func Up(db *gorm.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up()
+	return err
+}
+
+// tables is a migration creating tables for models and dropping them on down
+func tables(id, description string, models ...interface{}) orm.Migration {
+	return orm.Migration{
+		ID:          id,
+		Description: description,
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(models...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(models...)
+		},
+	}
+}
+
+// column is a migration adding one field of model, with its indexes, to an
+// existing table. model is a one-field struct naming the table through TableName.
+func column(id, description string, model interface{}, field string) orm.Migration {
+	return orm.Migration{
+		ID:          id,
+		Description: description,
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(model, field) {
+				if err := m.AddColumn(model, field); err != nil {
+					return err
+				}
+			}
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			for _, idx := range stmt.Schema.ParseIndexes() {
+				if m.HasIndex(model, idx.Name) {
+					continue
+				}
+				if err := m.CreateIndex(model, idx.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(model, field)
+		},
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import "appsite-go/pkg/utils/orm"
+
+// schema creates the tables each service used to AutoMigrate in its constructor
+func schema() []orm.Migration {
+	return []orm.Migration{
+		tables("202601010001_user", "user accounts, info, groups and preferences",
+			&userAccount{}, &userInfo{}, &userGroup{}, &userPreference{}),
+		tables("202601010002_world", "saas tenants",
+			&sysTenant{}),
+		tables("202601010003_contents", "articles, banners, categories, comments, media, pages and tags",
+			&itemArticle{}, &itemBanner{}, &itemCategory{}, &userComment{},
+			&itemMedia{}, &itemPage{}, &itemTag{}),
+		tables("202601010004_commerce", "products, skus, stock logs, coupons and orders",
+			&shopProduct{}, &shopSku{}, &shopStockLog{},
+			&shopCoupon{}, &shopUserCoupon{}, &shopOrder{}, &shopOrderItem{}),
+		tables("202601010005_finance", "deals and balances",
+			&finDeal{}, &finBalance{}),
+		tables("202601010006_message", "notifications",
+			&messageNotification{}),
+		tables("202601010007_form", "form requests",
+			&formRequest{}),
+		tables("202601010008_relation", "relations",
+			&relationCombine{}),
+		tables("202601010009_shieldword", "shield words",
+			&systemShieldword{}),
+		tables("202601010010_audit", "operation audit logs",
+			&auditLog{}),
+		tables("202601030001_outbox", "domain event outbox",
+			&sysOutbox{}),
+		column("202601030002_order_user_coupon", "coupon redeemed by an order",
+			&shopOrderUserCoupon{}, "UserCouponID"),
+		tables("202601030003_cron_run", "scheduler run history",
+			&sysCronRun{}),
+		tables("202601030004_refresh_token", "refresh token families",
+			&accessRefreshToken{}),
+		tables("202601030005_signing_key", "access token signing keys",
+			&accessSigningKey{}),
+		tables("202601030006_session", "user device sessions",
+			&userSession{}),
+		tables("202601030007_mfa", "two-factor secrets and recovery codes",
+			&userMfa{}, &userMfaRecovery{}),
+		column("202601030008_group_require_mfa", "user groups requiring two-factor sign in",
+			&userGroupRequireMFA{}, "RequireMFA"),
+		tables("202601030009_user_identity", "external OpenID Connect identities",
+			&userIdentity{}),
+		column("202601030010_refresh_token_client", "OAuth client of refresh tokens",
+			&accessRefreshTokenClient{}, "ClientID"),
+		column("202601030011_refresh_token_scope", "OAuth scope of refresh tokens",
+			&accessRefreshTokenScope{}, "Scope"),
+		tables("202601030012_oauth", "OAuth clients and consents",
+			&oauthClient{}, &oauthConsent{}),
+		tables("202601030013_api_key", "API keys of users and tenants",
+			&accessApiKey{}),
+		tables("202601030014_casbin_rule", "Access policies of user groups",
+			&accessCasbinRule{}),
+		liveUnique("202601030016_live_unique", "unique login names and page aliases among live rows only"),
+		column("202601030017_refresh_token_tenant", "tenant of refresh token families",
+			&accessRefreshTokenTenant{}, "TenantID"),
+		column("202601030018_sku_lock_fence", "fencing token of locked stock changes",
+			&shopSkuLockFence{}, "LockFence"),
+		column("202601030019_coupon_lock_fence", "fencing token of locked coupon issuance",
+			&shopCouponLockFence{}, "LockFence"),
+		wechatUnique("202601030020_wechat_unique", "one user per WeChat account"),
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"appsite-go/pkg/utils/orm"
+)
+
+// DefaultGroupID is the group new accounts join (entity.User.GroupID default)
+const DefaultGroupID = "100"
+
+// AdminGroupID is the group of administrators, allowed the whole admin API
+const AdminGroupID = "1"
+
+// adminPolicy is the casbin rule opening the admin API to AdminGroupID,
+// stored as the permission adapter stores "p, 1, /admin/v1/*, *"
+var adminPolicy = accessCasbinRule{PType: "p", V0: AdminGroupID, V1: "/admin/v1/*", V2: "*"}
+
+// seeds are data migrations run after the schema.
+// Like the schema they write through the frozen table copies.
+func seeds() []orm.Migration {
+	return []orm.Migration{
+		{
+			ID:          "202601020001_seed_default_group",
+			Description: "default group for registered users",
+			Up: func(tx *gorm.DB) error {
+				var count int64
+				if err := tx.Model(&userGroup{}).Where("id = ?", DefaultGroupID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					return nil
+				}
+				return tx.Create(&userGroup{
+					ID:          DefaultGroupID,
+					Type:        "user",
+					GroupName:   "Member",
+					Description: "Default group for registered users",
+				}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Delete(&userGroup{}, "id = ?", DefaultGroupID).Error
+			},
+		},
+		{
+			// Members get no admin policies, so the admin API refuses them
+			ID:          "202601030015_seed_admin_policies",
+			Description: "administrator group and its access to the admin API",
+			Up: func(tx *gorm.DB) error {
+				var count int64
+				if err := tx.Model(&userGroup{}).Where("id = ?", AdminGroupID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					err := tx.Create(&userGroup{
+						ID:          AdminGroupID,
+						Type:        "admin",
+						GroupName:   "Administrator",
+						Description: "Manages the site through the admin API",
+						Level:       100,
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				rule := adminPolicy
+				rule.ID = strings.ReplaceAll(uuid.New().String(), "-", "")
+				return tx.Create(&rule).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				err := tx.Where(&accessCasbinRule{PType: adminPolicy.PType, V0: adminPolicy.V0, V1: adminPolicy.V1}).
+					Delete(&accessCasbinRule{}).Error
+				if err != nil {
+					return err
+				}
+				return tx.Delete(&userGroup{}, "id = ?", AdminGroupID).Error
+			},
+		},
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package apikey issues API keys to backends calling the API on behalf of
+// a user or a tenant. Only a hash of a key is stored. A key is scoped to
+// route groups and actions, "content:read" or "content:*", and may be
+// limited to client IPs and given an expiry.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/user/entity"
+	world "appsite-go/internal/services/world/entity"
+	"appsite-go/pkg/dbs"
+)
+
+// Prefix starts every key, so keys are told from JWTs and found by secret scanners
+const Prefix = "ask_"
+
+// Actions of scopes
+const (
+	ActionRead  = "read"  // GET and HEAD
+	ActionWrite = "write" // Every other method
+)
+
+// seenInterval throttles last-used updates of a busy key
+const seenInterval = time.Minute
+
+var (
+	ErrKeyInvalid    = errors.New("api key is invalid, expired or revoked")
+	ErrKeyNotFound   = errors.New("api key not found")
+	ErrIPNotAllowed  = errors.New("api key is not allowed from this IP")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidIP     = errors.New("invalid IP or CIDR in the allowlist")
+	ErrInvalidOwner  = errors.New("api key needs an active user or tenant")
+	ErrInvalidExpiry = errors.New("api key expiry is in the past")
+	ErrKeyNotAllowed = errors.New("api key is not allowed here")
+)
+
+// Key is an issued API key. A user key acts as its user; a tenant key has
+// no user and acts within its tenant.
+type Key struct {
+	model.Base
+	model.Tenant                 // SaasID
+	UserID       string          `json:"user_id" gorm:"type:varchar(32);index;comment:Empty for tenant keys"`
+	Name         string          `json:"name" gorm:"type:varchar(64);not null"`
+	Hint         string          `json:"hint" gorm:"type:varchar(16);comment:Start of the key, to recognise it"`
+	Hash         string          `json:"-" gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the key"`
+	Scopes       dbs.StringArray `json:"scopes"`
+	AllowedIPs   dbs.StringArray `json:"allowed_ips" gorm:"comment:IPs or CIDRs, any when empty"`
+	ExpiresAt    int64           `json:"expires_at" gorm:"comment:0 never expires"`
+	LastUsedAt   int64           `json:"last_used_at"`
+	LastUsedIP   string          `json:"last_used_ip" gorm:"type:varchar(45)"`
+	RevokedAt    int64           `json:"revoked_at" gorm:"index"`
+	CreatedBy    string          `json:"created_by" gorm:"type:varchar(32)"`
+}
+
+// TableName returns table name
+func (Key) TableName() string {
+	return "access_api_key"
+}
+
+// Allows reports whether the key may run an action on a route group
+func (k *Key) Allows(group, action string) bool {
+	for _, scope := range k.Scopes {
+		if scope == "*" || (group != "" && (scope == group+":*" || scope == group+":"+action)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Action is the scope action of an HTTP method
+func Action(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return ActionRead
+	}
+	return ActionWrite
+}
+
+// Input describes a new key
+type Input struct {
+	UserID     string   `json:"user_id"`
+	SaasID     string   `json:"saas_id"`
+	Name       string   `json:"name" binding:"required,max=64"`
+	Scopes     []string `json:"scopes" binding:"required,min=1"`
+	AllowedIPs []string `json:"allowed_ips"`
+	ExpiresAt  int64    `json:"expires_at"`
+}
+
+// Filter selects keys; empty fields match all
+type Filter struct {
+	UserID string `form:"user_id"`
+	SaasID string `form:"saas_id"`
+}
+
+// Service manages and checks API keys
+type Service struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewService creates a new API key service. rdb throttles last-used
+// updates and may be nil.
+func NewService(db *gorm.DB, rdb *redis.Client) *Service {
+	return &Service{db: db, rdb: rdb}
+}
+
+// Create issues a key and returns it; it cannot be read again. A user key
+// belongs to the tenant of its user.
+func (s *Service) Create(ctx context.Context, in Input, by string) (*Key, string, error) {
+	if err := validate(in); err != nil {
+		return nil, "", err
+	}
+	saasID := in.SaasID
+	if in.UserID != "" {
+		user, err := s.activeUser(ctx, in.UserID)
+		if err != nil {
+			return nil, "", err
+		}
+		saasID = user.SaasID
+	} else if err := s.activeTenant(ctx, saasID); err != nil {
+		return nil, "", err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	raw := Prefix + base64.RawURLEncoding.EncodeToString(buf)
+	k := &Key{
+		UserID:     in.UserID,
+		Name:       in.Name,
+		Hint:       raw[:len(Prefix)+6],
+		Hash:       hashKey(raw),
+		Scopes:     in.Scopes,
+		AllowedIPs: in.AllowedIPs,
+		ExpiresAt:  in.ExpiresAt,
+		CreatedBy:  by,
+	}
+	k.SaasID = saasID
+	if err := s.db.WithContext(ctx).Create(k).Error; err != nil {
+		return nil, "", err
+	}
+	return k, raw, nil
+}
+
+// List lists keys, newest first
+func (s *Service) List(ctx context.Context, f Filter) ([]Key, error) {
+	q := s.db.WithContext(ctx).Order("created_at desc")
+	if f.UserID != "" {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+	if f.SaasID != "" {
+		q = q.Where("saas_id = ?", f.SaasID)
+	}
+	var list []Key
+	err := q.Find(&list).Error
+	return list, err
+}
+
+// Get loads a key by ID
+func (s *Service) Get(ctx context.Context, id string) (*Key, error) {
+	var k Key
+	err := s.db.WithContext(ctx).Where("id = ?", id).Take(&k).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Revoke stops a key at once. userID limits it to the keys of a user;
+// empty revokes any key.
+func (s *Service) Revoke(ctx context.Context, id, userID string) error {
+	q := s.db.WithContext(ctx).Model(&Key{}).Where("id = ? AND revoked_at = 0", id)
+	if userID != "" {
+		q = q.Where("user_id = ?", userID)
+	}
+	res := q.Update("revoked_at", time.Now().Unix())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate finds the live key of a request. The key of a user stops
+// working while the user is disabled, that of a tenant while the tenant is.
+// Keys of every tenant are looked at: the key decides the tenant.
+func (s *Service) Authenticate(ctx context.Context, raw, ip string) (*Key, error) {
+	if !strings.HasPrefix(raw, Prefix) {
+		return nil, ErrKeyInvalid
+	}
+	ctx = model.AsPlatform(ctx)
+	var k Key
+	err := s.db.WithContext(ctx).Where("hash = ?", hashKey(raw)).Take(&k).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if k.RevokedAt > 0 || (k.ExpiresAt > 0 && k.ExpiresAt <= now) {
+		return nil, ErrKeyInvalid
+	}
+	if !allowedIP(k.AllowedIPs, ip) {
+		return nil, ErrIPNotAllowed
+	}
+	var owner error
+	if k.UserID != "" {
+		_, owner = s.activeUser(ctx, k.UserID)
+	} else {
+		owner = s.activeTenant(ctx, k.SaasID)
+	}
+	if errors.Is(owner, ErrInvalidOwner) {
+		return nil, ErrKeyInvalid
+	}
+	if owner != nil {
+		return nil, owner
+	}
+	return &k, nil
+}
+
+// Seen records the last use of a key, at most once per seenInterval
+func (s *Service) Seen(ctx context.Context, k *Key, ip string) error {
+	if s.rdb != nil {
+		ok, err := s.rdb.SetNX(ctx, "apikey:seen:"+k.ID, 1, seenInterval).Result()
+		if err != nil || !ok {
+			return err
+		}
+	}
+	k.LastUsedAt, k.LastUsedIP = time.Now().Unix(), ip
+	return s.db.WithContext(ctx).Model(&Key{}).Where("id = ?", k.ID).
+		Updates(map[string]interface{}{"last_used_at": k.LastUsedAt, "last_used_ip": ip}).Error
+}
+
+func (s *Service) activeUser(ctx context.Context, userID string) (*entity.User, error) {
+	var user entity.User
+	err := s.db.WithContext(ctx).Where("id = ?", userID).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Status != "enabled") {
+		return nil, fmt.Errorf("%w: user not found or disabled", ErrInvalidOwner)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Service) activeTenant(ctx context.Context, saasID string) error {
+	var t world.Tenant
+	err := s.db.WithContext(ctx).Where("id = ?", saasID).Take(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) ||
+		(err == nil && (t.Status != "enabled" || (t.ExpireAt > 0 && t.ExpireAt < time.Now().Unix()))) {
+		return fmt.Errorf("%w: tenant not found or inactive", ErrInvalidOwner)
+	}
+	return err
+}
+
+func validate(in Input) error {
+	if in.UserID == "" && in.SaasID == "" {
+		return ErrInvalidOwner
+	}
+	for _, scope := range in.Scopes {
+		if scope == "*" {
+			continue
+		}
+		group, action, ok := strings.Cut(scope, ":")
+		if !ok || group == "" || strings.ContainsAny(group, "/* ") ||
+			(action != ActionRead && action != ActionWrite && action != "*") {
+			return fmt.Errorf("%w: %q, want <group>:read, <group>:write, <group>:* or *", ErrInvalidScope, scope)
+		}
+	}
+	for _, entry := range in.AllowedIPs {
+		if _, err := parsePrefix(entry); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidIP, entry)
+		}
+	}
+	if in.ExpiresAt > 0 && in.ExpiresAt <= time.Now().Unix() {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
+// allowedIP reports whether ip is in the allowlist; an empty list allows all
+func allowedIP(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowlist {
+		if p, err := parsePrefix(entry); err == nil && p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix reads an IP or CIDR allowlist entry
+func parsePrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		p, err := netip.ParsePrefix(entry)
+		return p.Masked(), err
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// hashKey is what is stored of a key; keys are random, so unsalted SHA-256 holds
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
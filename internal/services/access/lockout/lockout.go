@@ -0,0 +1,253 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package lockout slows down password guessing. Failed logins are counted
+// per account and per client IP: past a few failures each new attempt must
+// wait a growing delay, and past the limit the account or IP is locked for
+// a while. Failures, locks and unlocks go to the audit log.
+package lockout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/operation"
+)
+
+// Audit actions
+const (
+	ActionFailed   = "login_failed"
+	ActionLocked   = "login_locked"
+	ActionUnlocked = "login_unlocked"
+)
+
+var (
+	ErrLocked = errors.New("too many failed logins, retry later")
+)
+
+// LockedError is returned while an account or IP must wait before the next attempt
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s (in %ds)", ErrLocked.Error(), int(e.RetryAfter.Seconds()+0.999))
+}
+
+// Is matches ErrLocked
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// Options tunes the limits; zero values use the defaults
+type Options struct {
+	MaxFailures   int           // Failures of an account within Window before it is locked
+	IPMaxFailures int           // Failures from an IP within Window before it is locked
+	Window        time.Duration // How long failures are counted
+	Lockout       time.Duration // How long a lock lasts
+	DelayAfter    int           // Failures of an account before delays start
+	BaseDelay     time.Duration // First delay, doubled per further failure
+	MaxDelay      time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxFailures <= 0 {
+		o.MaxFailures = 10
+	}
+	if o.IPMaxFailures <= 0 {
+		o.IPMaxFailures = 50
+	}
+	if o.Window <= 0 {
+		o.Window = 15 * time.Minute
+	}
+	if o.Lockout <= 0 {
+		o.Lockout = 15 * time.Minute
+	}
+	if o.DelayAfter <= 0 {
+		o.DelayAfter = 3
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = time.Second
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 30 * time.Second
+	}
+	return o
+}
+
+// Attempt identifies a login attempt. Subject is the account: its user ID
+// when it exists, else the identifier typed, so unknown and existing
+// accounts are throttled alike.
+type Attempt struct {
+	Subject   string
+	UserID    string // Empty for unknown accounts
+	IP        string
+	UserAgent string
+}
+
+// Service counts failed logins
+type Service struct {
+	rdb   *redis.Client
+	audit *operation.Service
+	opts  Options
+}
+
+// NewService creates a new lockout service; audit may be nil
+func NewService(rdb *redis.Client, audit *operation.Service, opts Options) *Service {
+	return &Service{rdb: rdb, audit: audit, opts: opts.withDefaults()}
+}
+
+// Check returns a *LockedError while the subject or IP is locked, or has
+// to wait after its last failure
+func (s *Service) Check(ctx context.Context, a Attempt) error {
+	keys := []string{lockKey("subject", a.Subject), waitKey(a.Subject)}
+	if a.IP != "" {
+		keys = append(keys, lockKey("ip", a.IP))
+	}
+	pipe := s.rdb.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, k := range keys {
+		ttls[i] = pipe.PTTL(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	var wait time.Duration
+	for _, ttl := range ttls {
+		if d := ttl.Val(); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed attempt, starting the delay or lock it earns
+func (s *Service) Fail(ctx context.Context, a Attempt) error {
+	window := s.opts.Window.Milliseconds()
+	fails, err := failScript.Run(ctx, s.rdb, []string{failKey("subject", a.Subject)}, window).Int()
+	if err != nil {
+		return err
+	}
+	ipFails := 0
+	if a.IP != "" {
+		if ipFails, err = failScript.Run(ctx, s.rdb, []string{failKey("ip", a.IP)}, window).Int(); err != nil {
+			return err
+		}
+	}
+
+	pipe := s.rdb.TxPipeline()
+	if d := s.delay(fails); d > 0 {
+		pipe.Set(ctx, waitKey(a.Subject), 1, d)
+	}
+	var locked []string
+	// Past the limit every failure locks again: a lock shorter than the
+	// window, or failures racing the one reaching the limit, cannot slip by
+	if fails >= s.opts.MaxFailures {
+		pipe.Set(ctx, lockKey("subject", a.Subject), 1, s.opts.Lockout)
+		locked = append(locked, "account")
+	}
+	if a.IP != "" && ipFails >= s.opts.IPMaxFailures {
+		pipe.Set(ctx, lockKey("ip", a.IP), 1, s.opts.Lockout)
+		locked = append(locked, "ip")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	s.record(a, ActionFailed, map[string]interface{}{"subject": a.Subject, "failures": fails, "ip_failures": ipFails})
+	for _, what := range locked {
+		s.record(a, ActionLocked, map[string]interface{}{"subject": a.Subject, "locked": what, "for": s.opts.Lockout.String()})
+	}
+	return nil
+}
+
+// Succeed clears the failures of the subject after a good login. The IP
+// keeps its count, so one valid account cannot launder a spraying run.
+func (s *Service) Succeed(ctx context.Context, a Attempt) error {
+	return s.rdb.Del(ctx, failKey("subject", a.Subject), waitKey(a.Subject), lockKey("subject", a.Subject)).Err()
+}
+
+// Unlock lifts the lock and failures of an account; by is the admin doing it
+func (s *Service) Unlock(ctx context.Context, a Attempt, by string) error {
+	if err := s.Succeed(ctx, a); err != nil {
+		return err
+	}
+	s.record(a, ActionUnlocked, map[string]interface{}{"subject": a.Subject, "by": by})
+	return nil
+}
+
+// UnlockIP lifts the lock and failures of a client IP
+func (s *Service) UnlockIP(ctx context.Context, ip, by string) error {
+	if err := s.rdb.Del(ctx, failKey("ip", ip), lockKey("ip", ip)).Err(); err != nil {
+		return err
+	}
+	s.record(Attempt{IP: ip}, ActionUnlocked, map[string]interface{}{"ip": ip, "by": by})
+	return nil
+}
+
+// delay is the wait earned by the nth failure
+func (s *Service) delay(fails int) time.Duration {
+	if fails < s.opts.DelayAfter {
+		return 0
+	}
+	d := s.opts.BaseDelay
+	for i := s.opts.DelayAfter; i < fails && d < s.opts.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > s.opts.MaxDelay {
+		d = s.opts.MaxDelay
+	}
+	return d
+}
+
+// record writes an audit entry; the audit log must not block logins
+func (s *Service) record(a Attempt, action string, detail map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	raw, _ := json.Marshal(detail)
+	_ = s.audit.Record(&operation.AuditLog{
+		UserID:    a.UserID,
+		Action:    action,
+		IP:        a.IP,
+		UserAgent: truncate(a.UserAgent, 255),
+		Detail:    string(raw),
+	})
+}
+
+func failKey(kind, id string) string {
+	return "login:fail:" + kind + ":" + id
+}
+
+func lockKey(kind, id string) string {
+	return "login:lock:" + kind + ":" + id
+}
+
+func waitKey(subject string) string {
+	return "login:wait:" + subject
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// failScript counts a failure in a window starting at the first one
+var failScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+var (
+	ErrChallengeInvalid = errors.New("two-factor challenge is invalid or expired")
+)
+
+const (
+	// ChallengeTTL is how long the second login step may take
+	ChallengeTTL = 5 * time.Minute
+	// challengeAttempts is how many wrong codes burn a challenge
+	challengeAttempts = 5
+)
+
+// Challenge is the pending second step of a login whose password was right
+type Challenge struct {
+	ID     string
+	UserID string
+	Enroll bool // The user group requires a factor the user has not enrolled yet
+}
+
+// NewChallenge starts the second step of a login
+func (s *Service) NewChallenge(ctx context.Context, userID string, enroll bool) (*Challenge, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	ch := &Challenge{ID: base64.RawURLEncoding.EncodeToString(buf), UserID: userID, Enroll: enroll}
+
+	key := challengeKey(ch.ID)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID, "enroll", enroll, "attempts", 0)
+	pipe.Expire(ctx, key, ChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// GetChallenge returns a pending challenge
+func (s *Service) GetChallenge(ctx context.Context, id string) (*Challenge, error) {
+	vals, err := s.rdb.HMGet(ctx, challengeKey(id), "user_id", "enroll").Result()
+	if err != nil {
+		return nil, err
+	}
+	userID, _ := vals[0].(string)
+	if userID == "" {
+		return nil, ErrChallengeInvalid
+	}
+	enroll, _ := vals[1].(string)
+	return &Challenge{ID: id, UserID: userID, Enroll: enroll == "1"}, nil
+}
+
+// Redeem completes a challenge with a code: a TOTP or recovery code, or the
+// first TOTP code of an enrollment, which it confirms. Recovery codes are
+// returned when the factor was enrolled by this step. Each wrong code
+// counts, and the challenge is burned after a few.
+func (s *Service) Redeem(ctx context.Context, id, code string) (*Challenge, []string, error) {
+	ch, err := s.GetChallenge(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := challengeKey(id)
+	attempts, err := s.rdb.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if attempts > challengeAttempts {
+		s.rdb.Del(ctx, key)
+		return nil, nil, ErrChallengeInvalid
+	}
+
+	var codes []string
+	if ch.Enroll {
+		codes, err = s.Confirm(ctx, ch.UserID, code)
+	} else {
+		err = s.Verify(ctx, ch.UserID, code)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Single use, a concurrent redeem of the same challenge loses
+	n, err := s.rdb.Del(ctx, key).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if n == 0 {
+		return nil, nil, ErrChallengeInvalid
+	}
+	return ch, codes, nil
+}
+
+func challengeKey(id string) string {
+	return "mfa:challenge:" + id
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package mfa adds a second login factor: TOTP authenticator apps with
+// single-use recovery codes, and the challenges bridging the two login steps.
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/user/entity"
+)
+
+var (
+	ErrNotEnrolled     = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrInvalidCode     = errors.New("invalid two-factor code")
+	ErrRequiredByGroup = errors.New("two-factor authentication is required for this user group")
+	ErrLocked          = errors.New("too many wrong two-factor codes, try again later")
+)
+
+const (
+	// recoveryCount is how many recovery codes an enrollment hands out
+	recoveryCount = 10
+	// maxFailures is how many wrong codes, on any path, lock the factor of a user
+	maxFailures = 10
+	// failureWindow is how long wrong codes are counted, and the lock lasts
+	failureWindow = 15 * time.Minute
+)
+
+// Factor is the TOTP secret of a user. It signs in only once confirmed
+// with a first code, so a half-done enrollment cannot lock the user out.
+type Factor struct {
+	model.Base
+	UserID      string `json:"user_id" gorm:"type:varchar(32);not null;uniqueIndex"`
+	Secret      string `json:"-" gorm:"type:varchar(64);not null;comment:Base32 TOTP secret"`
+	ConfirmedAt int64  `json:"confirmed_at" gorm:"comment:0 while enrolling"`
+	LastStep    int64  `json:"-" gorm:"comment:Time step of the last accepted code, against replays"`
+}
+
+// TableName returns table name
+func (Factor) TableName() string {
+	return "user_mfa"
+}
+
+// RecoveryCode replaces a TOTP code once, when the authenticator is lost
+type RecoveryCode struct {
+	model.Base
+	UserID string `json:"user_id" gorm:"type:varchar(32);not null;index"`
+	Hash   string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the code"`
+	UsedAt int64  `json:"used_at"`
+}
+
+// TableName returns table name
+func (RecoveryCode) TableName() string {
+	return "user_mfa_recovery"
+}
+
+// Enrollment is what an authenticator app needs to generate codes
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// provisioning URI, rendered as a QR code
+}
+
+// Service enrolls and verifies second factors
+type Service struct {
+	db     *gorm.DB
+	rdb    *redis.Client
+	issuer string
+}
+
+// NewService creates a new MFA service; issuer names the site in authenticator apps
+func NewService(db *gorm.DB, rdb *redis.Client, issuer string) *Service {
+	return &Service{db: db, rdb: rdb, issuer: issuer}
+}
+
+// Enabled reports whether the user has a confirmed factor
+func (s *Service) Enabled(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&Factor{}).
+		Where("user_id = ? AND confirmed_at > 0", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// Required reports whether the group of user enforces a second factor
+func (s *Service) Required(ctx context.Context, user *entity.User) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&entity.UserGroup{}).
+		Where("id = ? AND require_mfa = ?", user.GroupID, true).Count(&count).Error
+	return count > 0, err
+}
+
+// Enroll starts an enrollment with a new secret, replacing an unconfirmed one.
+// account labels the entry in the app, usually the username or email.
+func (s *Service) Enroll(ctx context.Context, userID, account string) (*Enrollment, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&Factor{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Factor{UserID: userID, Secret: secret}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Enrollment{Secret: secret, URI: ProvisioningURI(s.issuer, account, secret)}, nil
+}
+
+// Confirm enables the enrolled factor with a first code and returns the
+// recovery codes, which are shown once and only stored hashed
+func (s *Service) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	var codes []string
+	err := s.limit(ctx, userID, func() error {
+		var err error
+		codes, err = s.confirm(ctx, userID, code)
+		return err
+	})
+	return codes, err
+}
+
+func (s *Service) confirm(ctx context.Context, userID, code string) ([]string, error) {
+	var f Factor
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Take(&f).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.ConfirmedAt > 0 {
+		return nil, ErrAlreadyEnabled
+	}
+	st := match(f.Secret, code, time.Now())
+	if st == 0 {
+		return nil, ErrInvalidCode
+	}
+
+	var codes []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Factor{}).Where("id = ? AND confirmed_at = 0", f.ID).
+			Updates(map[string]interface{}{"confirmed_at": time.Now().Unix(), "last_step": st})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrAlreadyEnabled
+		}
+		codes, err = s.createRecovery(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify accepts a current TOTP code or an unused recovery code. A TOTP
+// code is accepted once, even within its validity window. Wrong codes
+// count against the user, whichever path checks them, until ErrLocked.
+func (s *Service) Verify(ctx context.Context, userID, code string) error {
+	return s.limit(ctx, userID, func() error {
+		return s.verify(ctx, userID, code)
+	})
+}
+
+func (s *Service) verify(ctx context.Context, userID, code string) error {
+	code = strings.TrimSpace(code)
+	var f Factor
+	err := s.db.WithContext(ctx).Where("user_id = ? AND confirmed_at > 0", userID).Take(&f).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(code) != Digits {
+		return s.useRecovery(ctx, userID, code)
+	}
+	st := match(f.Secret, code, time.Now())
+	if st == 0 {
+		return ErrInvalidCode
+	}
+	res := s.db.WithContext(ctx).Model(&Factor{}).Where("id = ? AND last_step < ?", f.ID, st).
+		UpdateColumn("last_step", st)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// RegenerateRecovery replaces the recovery codes, after checking a code
+func (s *Service) RegenerateRecovery(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.createRecovery(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// Disable removes the factor of a user after checking a code. It is
+// refused while the user group requires a second factor.
+func (s *Service) Disable(ctx context.Context, user *entity.User, code string) error {
+	required, err := s.Required(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequiredByGroup
+	}
+	if err := s.Verify(ctx, user.ID, code); err != nil {
+		return err
+	}
+	return s.Reset(ctx, user.ID)
+}
+
+// Reset removes the factor and recovery codes of a user without a code,
+// for administrators helping a user who lost both
+func (s *Service) Reset(ctx context.Context, userID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&Factor{}).Error
+	})
+}
+
+// limit runs check unless the user is locked, counting ErrInvalidCode
+// results. The failure that reaches maxFailures locks the user for
+// failureWindow; a right code clears the count.
+func (s *Service) limit(ctx context.Context, userID string, check func() error) error {
+	key := failuresKey(userID)
+	fails, err := s.rdb.Get(ctx, key).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if fails >= maxFailures {
+		return ErrLocked
+	}
+
+	err = check()
+	if err == nil {
+		return s.rdb.Del(ctx, key).Err()
+	}
+	if !errors.Is(err, ErrInvalidCode) {
+		return err
+	}
+	fails, ierr := s.rdb.Incr(ctx, key).Result()
+	if ierr != nil {
+		return ierr
+	}
+	if fails == 1 || fails >= maxFailures {
+		s.rdb.Expire(ctx, key, failureWindow)
+	}
+	if fails >= maxFailures {
+		return ErrLocked
+	}
+	return err
+}
+
+func failuresKey(userID string) string {
+	return "mfa:fails:" + userID
+}
+
+func (s *Service) useRecovery(ctx context.Context, userID, code string) error {
+	res := s.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at = 0", userID, hashCode(normalize(code))).
+		UpdateColumn("used_at", time.Now().Unix())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// createRecovery replaces the recovery codes of a user
+func (s *Service) createRecovery(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCount)
+	rows := make([]RecoveryCode, recoveryCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(b32.EncodeToString(buf)) // 8 chars
+		codes[i] = raw[:4] + "-" + raw[4:]
+		rows[i] = RecoveryCode{UserID: userID, Hash: hashCode(raw)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalize accepts recovery codes typed with any case, spaces or dashes
+func normalize(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
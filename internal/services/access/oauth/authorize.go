@@ -0,0 +1,260 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorizeRequest is the query apps send users to the authorize page
+// with; the page passes it on to the authorize API as is
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ClientInfo is what the consent screen shows of a client
+type ClientInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Decision is the answer of the authorize API: the consent screen to
+// show, or where to send the user back to the app, with a code or an error
+type Decision struct {
+	ConsentRequired bool        `json:"consent_required"`
+	Client          *ClientInfo `json:"client,omitempty"`
+	Scopes          []string    `json:"scopes,omitempty"`
+	RedirectURL     string      `json:"redirect_url,omitempty"`
+}
+
+// codeGrant is what an authorization code stands for until its exchange
+type codeGrant struct {
+	ClientID    string `json:"client_id"`
+	UserID      string `json:"user_id"`
+	RedirectURI string `json:"redirect_uri"`
+	Scope       string `json:"scope"`
+	Challenge   string `json:"challenge"`
+	Nonce       string `json:"nonce,omitempty"`
+}
+
+// Authorize handles an authorization request for a signed-in user. A
+// trusted client, or one the user already approved for these scopes, gets
+// a code at once; others need the consent screen, see Consent. An unknown
+// client or redirect URI is an error, as the user cannot be sent back.
+func (s *Service) Authorize(ctx context.Context, userID string, req AuthorizeRequest) (*Decision, error) {
+	c, redirect, scopes, perr, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if perr != nil {
+		return &Decision{RedirectURL: withError(redirect, req.State, perr)}, nil
+	}
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	approved := c.Trusted
+	if !approved {
+		var consent Consent
+		err := s.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, c.ID).Take(&consent).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		approved = err == nil && covers(strings.Fields(consent.Scope), scopes)
+	}
+	if !approved {
+		return &Decision{
+			ConsentRequired: true,
+			Client:          &ClientInfo{ID: c.ID, Name: c.Name},
+			Scopes:          scopes,
+		}, nil
+	}
+	return s.issueCode(ctx, userID, c, redirect, scopes, req)
+}
+
+// Consent records the answer of the user on the consent screen. Approving
+// remembers the scopes for the client and issues a code; denying sends the
+// user back with access_denied.
+func (s *Service) Consent(ctx context.Context, userID string, req AuthorizeRequest, approve bool) (*Decision, error) {
+	c, redirect, scopes, perr, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if perr == nil && !approve {
+		perr = errorf(ErrAccessDenied, "the user denied the request")
+	}
+	if perr != nil {
+		return &Decision{RedirectURL: withError(redirect, req.State, perr)}, nil
+	}
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var consent Consent
+	err = s.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, c.ID).Take(&consent).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	granted := union(strings.Fields(consent.Scope), scopes)
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_at"}),
+	}).Create(&Consent{UserID: userID, ClientID: c.ID, Scope: strings.Join(granted, " ")}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.issueCode(ctx, userID, c, redirect, scopes, req)
+}
+
+// prepare validates an authorization request. err means the user cannot be
+// sent back to the app; perr is a protocol error to send back with.
+func (s *Service) prepare(ctx context.Context, req AuthorizeRequest) (c *Client, redirect string, scopes []string, perr *Error, err error) {
+	c, err = s.Client(ctx, req.ClientID)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	if c.Status != "enabled" {
+		return nil, "", nil, nil, ErrClientNotFound
+	}
+	// Registered URIs match exactly, a client with one may leave it out
+	redirect = req.RedirectURI
+	if redirect == "" && len(c.RedirectURIs) == 1 {
+		redirect = c.RedirectURIs[0]
+	}
+	if redirect == "" || !contains(c.RedirectURIs, redirect) {
+		return nil, "", nil, nil, ErrRedirectURI
+	}
+
+	switch {
+	case req.ResponseType != "code":
+		perr = errorf(ErrUnsupportedResponse, "only the code response type is supported")
+	case !c.Allows(GrantAuthorizationCode):
+		perr = errorf(ErrUnauthorizedClient, "the client may not use authorization codes")
+	case req.CodeChallenge == "" || req.CodeChallengeMethod != "S256":
+		perr = errorf(ErrInvalidRequest, "PKCE with code_challenge_method S256 is required")
+	default:
+		scopes, perr = s.scopes(c, req.Scope, true)
+	}
+	return c, redirect, scopes, perr, nil
+}
+
+// scopes resolves the scopes a client asks for: those it is allowed when
+// it names none, but for the user scopes when no user is signing in. ID
+// tokens need keys clients can verify, so openid is only offered with
+// RS256 or EdDSA.
+func (s *Service) scopes(c *Client, requested string, forUser bool) ([]string, *Error) {
+	list := strings.Fields(requested)
+	if len(list) == 0 {
+		for _, scope := range c.Scopes {
+			if forUser || !userScope(scope) {
+				list = append(list, scope)
+			}
+		}
+	}
+	var out []string
+	for _, scope := range list {
+		if !contains(c.Scopes, scope) {
+			return nil, errorf(ErrInvalidScope, "scope "+scope+" is not allowed for the client")
+		}
+		if userScope(scope) && !forUser {
+			return nil, errorf(ErrInvalidScope, "scope "+scope+" needs a user")
+		}
+		if scope == ScopeOpenID && !s.tokens.Asymmetric() {
+			return nil, errorf(ErrInvalidScope, "openid is not supported")
+		}
+		if !contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
+
+func (s *Service) issueCode(ctx context.Context, userID string, c *Client, redirect string, scopes []string, req AuthorizeRequest) (*Decision, error) {
+	code, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(codeGrant{
+		ClientID:    c.ID,
+		UserID:      userID,
+		RedirectURI: redirect,
+		Scope:       strings.Join(scopes, " "),
+		Challenge:   req.CodeChallenge,
+		Nonce:       req.Nonce,
+	})
+	if err := s.rdb.Set(ctx, codeKey(code), raw, CodeTTL).Err(); err != nil {
+		return nil, err
+	}
+	return &Decision{RedirectURL: withQuery(redirect, url.Values{
+		"code":  {code},
+		"state": {req.State},
+		"iss":   {s.issuer},
+	})}, nil
+}
+
+// withError sends a protocol error back to the app
+func withError(redirect, state string, e *Error) string {
+	q := url.Values{"error": {e.Code}, "state": {state}}
+	if e.Description != "" {
+		q.Set("error_description", e.Description)
+	}
+	return withQuery(redirect, q)
+}
+
+func withQuery(redirect string, q url.Values) string {
+	for k, v := range q {
+		if len(v) == 0 || v[0] == "" {
+			q.Del(k)
+		}
+	}
+	sep := "?"
+	if strings.Contains(redirect, "?") {
+		sep = "&"
+	}
+	return redirect + sep + q.Encode()
+}
+
+// covers reports whether granted includes every scope of wanted
+func covers(granted, wanted []string) bool {
+	for _, scope := range wanted {
+		if !contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func union(a, b []string) []string {
+	out := append([]string{}, a...)
+	for _, scope := range b {
+		if !contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
+
+// userScope reports whether a scope reads the claims of a user
+func userScope(scope string) bool {
+	return scope == ScopeOpenID || scope == ScopeProfile || scope == ScopeEmail
+}
+
+func codeKey(code string) string {
+	return "oauth:code:" + code
+}
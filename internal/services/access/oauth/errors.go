@@ -0,0 +1,43 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+// Error is an OAuth2 error response, see RFC 6749 5.2. Errors match by
+// code, so errors.Is(err, ErrInvalidGrant) holds whatever the description.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// Is matches errors of the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrInvalidRequest       = &Error{Code: "invalid_request"}
+	ErrInvalidClient        = &Error{Code: "invalid_client"}
+	ErrInvalidGrant         = &Error{Code: "invalid_grant"}
+	ErrUnauthorizedClient   = &Error{Code: "unauthorized_client"}
+	ErrUnsupportedGrantType = &Error{Code: "unsupported_grant_type"}
+	ErrUnsupportedResponse  = &Error{Code: "unsupported_response_type"}
+	ErrInvalidScope         = &Error{Code: "invalid_scope"}
+	ErrAccessDenied         = &Error{Code: "access_denied"}
+	ErrInvalidToken         = &Error{Code: "invalid_token"}
+	ErrInsufficientScope    = &Error{Code: "insufficient_scope"}
+)
+
+// errorf returns an error of the code of base with a description
+func errorf(base *Error, description string) *Error {
+	return &Error{Code: base.Code, Description: description}
+}
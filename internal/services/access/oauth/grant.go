@@ -0,0 +1,175 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/token"
+)
+
+// TokenRequest is the form posted to the token endpoint. The client
+// credentials may come in the form or in HTTP Basic authentication.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+// TokenResponse is the answer of the token endpoint
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Token runs a grant of the token endpoint. Errors the client should see
+// are *Error values.
+func (s *Service) Token(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	c, err := s.authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	switch req.GrantType {
+	case GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials:
+	default:
+		return nil, errorf(ErrUnsupportedGrantType, "unsupported grant_type "+req.GrantType)
+	}
+	if !c.Allows(req.GrantType) {
+		return nil, errorf(ErrUnauthorizedClient, "the client may not use "+req.GrantType)
+	}
+
+	switch req.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeCode(ctx, c, req)
+	case GrantRefreshToken:
+		return s.refresh(ctx, c, req)
+	default:
+		return s.clientCredentials(c, req)
+	}
+}
+
+// exchangeCode trades a code for tokens. The code is consumed first, so it
+// works once even when the rest of the request is wrong.
+func (s *Service) exchangeCode(ctx context.Context, c *Client, req TokenRequest) (*TokenResponse, error) {
+	if req.Code == "" {
+		return nil, errorf(ErrInvalidRequest, "code is required")
+	}
+	raw, err := s.rdb.GetDel(ctx, codeKey(req.Code)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errorf(ErrInvalidGrant, "code is invalid or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var g codeGrant
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, errorf(ErrInvalidGrant, "code is invalid or expired")
+	}
+	if g.ClientID != c.ID {
+		return nil, errorf(ErrInvalidGrant, "code was issued to another client")
+	}
+	if req.RedirectURI != "" && req.RedirectURI != g.RedirectURI {
+		return nil, errorf(ErrInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !verifyChallenge(req.CodeVerifier, g.Challenge) {
+		return nil, errorf(ErrInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+	// The code, not the request, names the user and so its tenant
+	user, err := s.activeUser(model.AsPlatform(ctx), g.UserID)
+	if err != nil {
+		return nil, errorf(ErrInvalidGrant, "the user is not active")
+	}
+
+	pair, err := s.tokens.IssueGrant(ctx, user.ID, user.GroupID, token.Grant{SaasID: user.SaasID, ClientID: c.ID, Scope: g.Scope})
+	if err != nil {
+		return nil, err
+	}
+	res := response(pair, c)
+	if contains(strings.Fields(g.Scope), ScopeOpenID) {
+		if res.IDToken, err = s.idToken(user, c, g.Scope, g.Nonce, pair.ExpiresIn); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// refresh rotates a refresh token of the client. The user is looked up
+// again, so a disabled user loses the apps too.
+func (s *Service) refresh(ctx context.Context, c *Client, req TokenRequest) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, errorf(ErrInvalidRequest, "refresh_token is required")
+	}
+	pair, err := s.tokens.RefreshGrant(ctx, req.RefreshToken, c.ID, func(userID string) (string, error) {
+		user, err := s.activeUser(model.AsPlatform(ctx), userID)
+		if err != nil {
+			return "", errorf(ErrInvalidGrant, "the user is not active")
+		}
+		return user.GroupID, nil
+	})
+	if errors.Is(err, token.ErrRefreshInvalid) || errors.Is(err, token.ErrRefreshExpired) ||
+		errors.Is(err, token.ErrRefreshReused) {
+		return nil, errorf(ErrInvalidGrant, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response(pair, c), nil
+}
+
+// clientCredentials issues a token to the client itself, for calls made
+// on its own behalf
+func (s *Service) clientCredentials(c *Client, req TokenRequest) (*TokenResponse, error) {
+	scopes, perr := s.scopes(c, req.Scope, false)
+	if perr != nil {
+		return nil, perr
+	}
+	pair, err := s.tokens.IssueClientToken(c.ID, strings.Join(scopes, " "))
+	if err != nil {
+		return nil, err
+	}
+	return response(pair, c), nil
+}
+
+func response(pair *token.Pair, c *Client) *TokenResponse {
+	res := &TokenResponse{
+		AccessToken: pair.AccessToken,
+		TokenType:   pair.TokenType,
+		ExpiresIn:   pair.ExpiresIn,
+		Scope:       pair.Scope,
+	}
+	// The family exists either way, only clients allowed to refresh get it
+	if c.Allows(GrantRefreshToken) {
+		res.RefreshToken = pair.RefreshToken
+	}
+	return res
+}
+
+// verifyChallenge checks a PKCE verifier against its S256 challenge
+func verifyChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 || challenge == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
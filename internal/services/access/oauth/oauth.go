@@ -0,0 +1,317 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package oauth makes appsite the OAuth2 and OpenID Connect provider of
+// its apps, so they sign users in through appsite instead of sharing the
+// JWT secret. Clients are registered in the database; users approve them
+// on a consent screen, first-party apps marked trusted skip it. The
+// authorization code grant requires PKCE, and the access, refresh and ID
+// tokens are those of token.Service, carrying the client and scope.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/pkg/dbs"
+)
+
+// Grant types
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// Scopes of the user claims; clients may be allowed other scopes of their own
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// CodeTTL is how long an authorization code may wait for its exchange
+const CodeTTL = time.Minute
+
+var (
+	ErrClientNotFound = errors.New("oauth client not found")
+	ErrRedirectURI    = errors.New("redirect_uri is not registered for the client")
+	ErrClientConfig   = errors.New("invalid oauth client")
+	ErrUserInactive   = errors.New("user not found or disabled")
+)
+
+// Client is an app signing users in through appsite
+type Client struct {
+	model.Base
+	Name         string          `json:"name" gorm:"type:varchar(64);not null"`
+	SecretHash   string          `json:"-" gorm:"type:varchar(64);comment:SHA-256 of the secret, empty for public clients"`
+	RedirectURIs dbs.StringArray `json:"redirect_uris" gorm:"comment:Exact redirect URIs allowed"`
+	Scopes       dbs.StringArray `json:"scopes" gorm:"comment:Scopes the client may ask for"`
+	GrantTypes   dbs.StringArray `json:"grant_types"`
+	Public       bool            `json:"public" gorm:"comment:Native or browser app without a secret"`
+	Trusted      bool            `json:"trusted" gorm:"comment:First-party app, no consent screen"`
+	Status       string          `json:"status" gorm:"type:varchar(12);default:'enabled'"`
+}
+
+// TableName returns table name
+func (Client) TableName() string {
+	return "oauth_client"
+}
+
+// Allows reports whether the client may use a grant type
+func (c *Client) Allows(grant string) bool {
+	return contains(c.GrantTypes, grant)
+}
+
+// Consent records the scopes a user approved for a client
+type Consent struct {
+	model.Base
+	UserID   string `json:"user_id" gorm:"type:varchar(32);not null;uniqueIndex:idx_consent_user_client"`
+	ClientID string `json:"client_id" gorm:"type:varchar(32);not null;uniqueIndex:idx_consent_user_client"`
+	Scope    string `json:"scope" gorm:"type:varchar(255)"`
+}
+
+// TableName returns table name
+func (Consent) TableName() string {
+	return "oauth_consent"
+}
+
+// Service runs the authorization server
+type Service struct {
+	db     *gorm.DB
+	rdb    *redis.Client
+	tokens *token.Service
+	issuer string
+	page   string
+}
+
+// NewService creates a new OAuth provider. issuer is the public base URL
+// of the site, which apps discover the endpoints from.
+func NewService(db *gorm.DB, rdb *redis.Client, tokens *token.Service, issuer string) *Service {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return &Service{db: db, rdb: rdb, tokens: tokens, issuer: issuer, page: issuer + "/oauth/authorize"}
+}
+
+// WithAuthorizePage sets the front-end page users are sent to by apps; it
+// signs the user in and calls the authorize API with its query
+func (s *Service) WithAuthorizePage(url string) *Service {
+	if url != "" {
+		s.page = url
+	}
+	return s
+}
+
+// Issuer is the issuer of ID tokens, the base URL of discovery
+func (s *Service) Issuer() string {
+	return s.issuer
+}
+
+// ClientInput is a client as admins create or edit it
+type ClientInput struct {
+	Name         string   `json:"name" binding:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types" binding:"required,min=1,dive,oneof=authorization_code client_credentials refresh_token"`
+	Public       bool     `json:"public"`
+	Trusted      bool     `json:"trusted"`
+	Status       string   `json:"status" binding:"omitempty,oneof=enabled disabled"`
+}
+
+// CreateClient registers a client and returns its secret, which is only
+// stored hashed; public clients get none
+func (s *Service) CreateClient(ctx context.Context, in ClientInput) (*Client, string, error) {
+	c := &Client{}
+	if err := apply(c, in); err != nil {
+		return nil, "", err
+	}
+	secret := ""
+	if !c.Public {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return nil, "", err
+		}
+		c.SecretHash = hashSecret(secret)
+	}
+	if err := s.db.WithContext(ctx).Create(c).Error; err != nil {
+		return nil, "", err
+	}
+	return c, secret, nil
+}
+
+// UpdateClient edits a client. Disabling it revokes its tokens.
+func (s *Service) UpdateClient(ctx context.Context, id string, in ClientInput) (*Client, error) {
+	c, err := s.Client(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if in.Public != c.Public {
+		return nil, fmt.Errorf("%w: a client cannot switch between public and confidential", ErrClientConfig)
+	}
+	if err := apply(c, in); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Save(c).Error; err != nil {
+		return nil, err
+	}
+	if c.Status != "enabled" {
+		return c, s.tokens.RevokeClient(ctx, c.ID, "")
+	}
+	return c, nil
+}
+
+// RotateSecret replaces the secret of a confidential client
+func (s *Service) RotateSecret(ctx context.Context, id string) (string, error) {
+	c, err := s.Client(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if c.Public {
+		return "", fmt.Errorf("%w: public clients have no secret", ErrClientConfig)
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	err = s.db.WithContext(ctx).Model(c).Update("secret_hash", hashSecret(secret)).Error
+	return secret, err
+}
+
+// DeleteClient removes a client with its consents and revokes its tokens.
+// Client credentials tokens have no family and run to their short expiry.
+func (s *Service) DeleteClient(ctx context.Context, id string) error {
+	c, err := s.Client(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", c.ID).Delete(&Consent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(c).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeClient(ctx, c.ID, "")
+}
+
+// Client loads a client by ID
+func (s *Service) Client(ctx context.Context, id string) (*Client, error) {
+	var c Client
+	err := s.db.WithContext(ctx).Where("id = ?", id).Take(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Clients lists the registered clients
+func (s *Service) Clients(ctx context.Context) ([]Client, error) {
+	var list []Client
+	err := s.db.WithContext(ctx).Order("created_at").Find(&list).Error
+	return list, err
+}
+
+// Consents lists the clients a user approved
+func (s *Service) Consents(ctx context.Context, userID string) ([]Consent, error) {
+	var list []Consent
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&list).Error
+	return list, err
+}
+
+// RevokeConsent withdraws the approval of a client by a user and signs
+// the user out of it
+func (s *Service) RevokeConsent(ctx context.Context, userID, clientID string) error {
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, clientID).
+		Delete(&Consent{}).Error; err != nil {
+		return err
+	}
+	return s.tokens.RevokeClient(ctx, clientID, userID)
+}
+
+// authenticate checks the credentials of a client at the token endpoint.
+// Public clients only name themselves, PKCE stands in for their secret.
+func (s *Service) authenticate(ctx context.Context, clientID, secret string) (*Client, error) {
+	if clientID == "" {
+		return nil, errorf(ErrInvalidClient, "client_id is required")
+	}
+	c, err := s.Client(ctx, clientID)
+	if errors.Is(err, ErrClientNotFound) {
+		return nil, errorf(ErrInvalidClient, "unknown client")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != "enabled" {
+		return nil, errorf(ErrInvalidClient, "client is disabled")
+	}
+	if c.Public {
+		return c, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, errorf(ErrInvalidClient, "client authentication failed")
+	}
+	return c, nil
+}
+
+func apply(c *Client, in ClientInput) error {
+	for _, g := range in.GrantTypes {
+		if g == GrantClientCredentials && in.Public {
+			return fmt.Errorf("%w: public clients cannot use client_credentials", ErrClientConfig)
+		}
+	}
+	if contains(in.GrantTypes, GrantAuthorizationCode) && len(in.RedirectURIs) == 0 {
+		return fmt.Errorf("%w: authorization_code needs a redirect URI", ErrClientConfig)
+	}
+	c.Name = in.Name
+	c.RedirectURIs = in.RedirectURIs
+	c.Scopes = in.Scopes
+	c.GrantTypes = in.GrantTypes
+	c.Public = in.Public
+	c.Trusted = in.Trusted
+	c.Status = in.Status
+	if c.Status == "" {
+		c.Status = "enabled"
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret is what is stored of a secret; it is random, so unsalted SHA-256 holds
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/entity"
+)
+
+// Endpoint paths, as routed by apis.RegisterRoutes
+const (
+	TokenPath     = "/api/v1/oauth/token"
+	UserInfoPath  = "/api/v1/oauth/userinfo"
+	JWKSPath      = "/.well-known/jwks.json"
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// Metadata is the discovery document of the provider, see OpenID Connect
+// Discovery 3 and RFC 8414
+type Metadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Discovery returns the discovery document. ID tokens and the openid scope
+// are only offered when tokens are signed with keys from the JWKS.
+func (s *Service) Discovery() *Metadata {
+	m := &Metadata{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.page,
+		TokenEndpoint:                     s.issuer + TokenPath,
+		UserInfoEndpoint:                  s.issuer + UserInfoPath,
+		JWKSURI:                           s.issuer + JWKSPath,
+		ScopesSupported:                   []string{ScopeProfile, ScopeEmail},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantAuthorizationCode, GrantClientCredentials, GrantRefreshToken},
+		SubjectTypesSupported:             []string{"public"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "name", "preferred_username", "picture", "updated_at", "email"},
+	}
+	if s.tokens.Asymmetric() {
+		m.ScopesSupported = append([]string{ScopeOpenID}, m.ScopesSupported...)
+		m.IDTokenSigningAlgValuesSupported = []string{s.tokens.Algorithm()}
+	}
+	return m
+}
+
+// UserInfo returns the claims of the user of an access token, limited to
+// its scope. Tokens of apps need the openid scope; first-party tokens,
+// which carry no client, read every claim.
+func (s *Service) UserInfo(ctx context.Context, claims *token.Claims) (map[string]interface{}, error) {
+	if claims.UserID == "" {
+		return nil, errorf(ErrInvalidToken, "the token has no user")
+	}
+	scopes := []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+	if claims.ClientID != "" {
+		scopes = strings.Fields(claims.Scope)
+		if !contains(scopes, ScopeOpenID) {
+			return nil, errorf(ErrInsufficientScope, "the openid scope is required")
+		}
+	}
+	user, err := s.activeUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, errorf(ErrInvalidToken, "the user is not active")
+	}
+	return userClaims(user, scopes), nil
+}
+
+// idToken signs the ID token of a code exchange, valid as long as the
+// access token issued with it
+func (s *Service) idToken(user *entity.User, c *Client, scope, nonce string, expiresIn int64) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims(userClaims(user, strings.Fields(scope)))
+	claims["iss"] = s.issuer
+	claims["aud"] = c.ID
+	claims["azp"] = c.ID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Unix() + expiresIn
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return s.tokens.SignClaims(claims)
+}
+
+// userClaims are the standard claims of a user a scope grants
+func userClaims(user *entity.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{"sub": user.ID}
+	if contains(scopes, ScopeProfile) {
+		name := user.Nickname
+		if name == "" {
+			name = user.Username
+		}
+		claims["name"] = name
+		claims["preferred_username"] = user.Username
+		if user.Avatar != "" {
+			claims["picture"] = user.Avatar
+		}
+		claims["updated_at"] = user.UpdatedAt
+	}
+	if contains(scopes, ScopeEmail) && user.Email != nil && *user.Email != "" {
+		claims["email"] = *user.Email
+	}
+	return claims
+}
+
+func (s *Service) activeUser(ctx context.Context, userID string) (*entity.User, error) {
+	var user entity.User
+	err := s.db.WithContext(ctx).Where("id = ?", userID).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserInactive
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != "enabled" {
+		return nil, ErrUserInactive
+	}
+	return &user, nil
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"appsite-go/internal/services/access/token"
+)
+
+const (
+	// cacheTTL bounds how stale discovery documents and key sets get
+	cacheTTL = time.Hour
+	// keyRefetch throttles key set reloads for unknown kids
+	keyRefetch = time.Minute
+)
+
+// cached is a document fetched from a provider
+type cached[T any] struct {
+	value     T
+	fetchedAt time.Time
+}
+
+// discovery is the part of /.well-known/openid-configuration in use
+type discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// keySet maps the kid of provider keys to their public key
+type keySet map[string]crypto.PublicKey
+
+// tokenResponse is the answer of the token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// idClaims are the claims read from ID tokens and user info
+type idClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// exchange trades a code for the identity of the user
+func (s *Service) exchange(ctx context.Context, p *ProviderConfig, code string, st *State) (*Identity, error) {
+	tokenURL, userInfoURL := p.TokenURL, p.UserInfoURL
+	basicAuth := false
+	var d *discovery
+	if p.OpenID() {
+		var err error
+		if d, err = s.discover(ctx, p.Issuer); err != nil {
+			return nil, err
+		}
+		if tokenURL == "" {
+			tokenURL = d.TokenEndpoint
+		}
+		if userInfoURL == "" {
+			userInfoURL = d.UserInfoEndpoint
+		}
+		// client_secret_basic is the default of OpenID providers
+		basicAuth = len(d.TokenAuthMethods) == 0 || contains(d.TokenAuthMethods, "client_secret_basic")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"code_verifier": {st.Verifier},
+	}
+	if !basicAuth {
+		form.Set("client_id", p.ClientID)
+		form.Set("client_secret", p.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+	var tok tokenResponse
+	if err := s.do(req, &tok); err != nil && tok.Error == "" {
+		return nil, err
+	}
+	if tok.Error != "" || tok.AccessToken == "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchange, tok.Error, tok.Description)
+	}
+
+	id := &Identity{Provider: p.Name, Issuer: p.issuer()}
+	if p.OpenID() {
+		if tok.IDToken == "" {
+			return nil, fmt.Errorf("%w: missing", ErrIDToken)
+		}
+		claims, err := s.verify(ctx, d, p, tok.IDToken, st.Nonce)
+		if err != nil {
+			return nil, err
+		}
+		id.Subject, id.Email, id.EmailVerified = claims.Subject, claims.Email, claims.EmailVerified
+		id.Name, id.Picture = claims.Name, claims.Picture
+		if (id.Email != "" && id.Name != "") || userInfoURL == "" {
+			return id, nil
+		}
+	}
+
+	// User info fills in what the ID token left out, or is the identity of
+	// plain OAuth2 providers
+	info, err := s.userInfo(ctx, userInfoURL, tok.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	subject := claimString(info, p.SubjectClaim, "sub")
+	if p.OpenID() {
+		if subject != id.Subject {
+			return nil, fmt.Errorf("%w: user info subject mismatch", ErrIDToken)
+		}
+	} else {
+		if subject == "" {
+			return nil, fmt.Errorf("%w: no subject in user info", ErrExchange)
+		}
+		id.Subject = subject
+	}
+	if id.Email == "" {
+		// Unverified unless the provider says so, as GitHub does not
+		id.Email = claimString(info, "email")
+		id.EmailVerified, _ = info["email_verified"].(bool)
+	}
+	if id.Name == "" {
+		id.Name = claimString(info, "name", "login")
+	}
+	if id.Picture == "" {
+		id.Picture = claimString(info, "picture", "avatar_url")
+	}
+	return id, nil
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID token
+func (s *Service) verify(ctx context.Context, d *discovery, p *ProviderConfig, raw, nonce string) (*idClaims, error) {
+	claims := &idClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.key(ctx, d.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDToken, err)
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIDToken)
+	}
+	return claims, nil
+}
+
+// discover fetches the discovery document of an issuer, cached
+func (s *Service) discover(ctx context.Context, issuer string) (*discovery, error) {
+	s.mu.Lock()
+	c := s.discovery[issuer]
+	s.mu.Unlock()
+	if c != nil && time.Since(c.fetchedAt) < cacheTTL {
+		return &c.value, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var d discovery
+	if err := s.do(req, &d); err != nil {
+		return nil, err
+	}
+	// The document must be the issuer's own, see OpenID Connect Discovery 4.3
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("discovery of %s: issuer mismatch %q", issuer, d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery of %s: missing endpoints", issuer)
+	}
+	s.mu.Lock()
+	s.discovery[issuer] = &cached[discovery]{value: d, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return &d, nil
+}
+
+// key finds a provider key by kid, reloading the key set once when it is
+// unknown, so rotations are picked up
+func (s *Service) key(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	c := s.keys[jwksURI]
+	s.mu.Unlock()
+	if c != nil && time.Since(c.fetchedAt) < cacheTTL {
+		if k, ok := c.value[kid]; ok {
+			return k, nil
+		}
+		if time.Since(c.fetchedAt) < keyRefetch {
+			return nil, token.ErrUnknownKey
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set token.JWKSet
+	if err := s.do(req, &set); err != nil {
+		return nil, err
+	}
+	keys := keySet{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if pub, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = pub
+		}
+	}
+	s.mu.Lock()
+	s.keys[jwksURI] = &cached[keySet]{value: keys, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, token.ErrUnknownKey
+}
+
+func (s *Service) userInfo(ctx context.Context, endpoint, accessToken string) (map[string]interface{}, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("%w: no user info endpoint", ErrExchange)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	info := map[string]interface{}{}
+	if err := s.do(req, &info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// do sends a request to an endpoint of a provider, unless it is unsafe, and
+// decodes its JSON answer into out. out is decoded for error statuses too,
+// since OAuth2 errors come as JSON.
+func (s *Service) do(req *http.Request, out interface{}) error {
+	if err := s.checkURL(req.URL); err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	jerr := json.Unmarshal(body, out)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: http %d", req.Method, req.URL.Host+req.URL.Path, resp.StatusCode)
+	}
+	if jerr != nil {
+		return errors.New(req.URL.Host + req.URL.Path + ": invalid JSON")
+	}
+	return nil
+}
+
+// claimString reads the first of names present in claims; numeric IDs,
+// such as GitHub's, are formatted without exponent
+func claimString(claims map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		switch v := claims[name].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrUnsafeEndpoint refuses provider URLs that would have the server call
+// into its own network: tenants configure them, admins of the site do not.
+var ErrUnsafeEndpoint = errors.New("sign in provider endpoints must be public https URLs")
+
+// cgnat is the shared address space of carrier-grade NAT, RFC 6598
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// WithPrivateNetworks lets providers be plain http on private and loopback
+// addresses, for development against a local provider and tests
+func (s *Service) WithPrivateNetworks() *Service {
+	s.private = true
+	s.client = &http.Client{Timeout: 10 * time.Second}
+	return s
+}
+
+// guardedClient calls providers over https on public addresses only. The
+// addresses are checked once resolved, at each connection, so a name
+// pointing inside and redirects are caught too. Proxies are not used: the
+// proxy's own address would be checked instead of the provider's.
+func guardedClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(ap.Addr()) {
+				return ErrUnsafeEndpoint
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return checkEndpoint(req.URL)
+		},
+	}
+}
+
+// checkURL refuses an endpoint the server should not call
+func (s *Service) checkURL(u *url.URL) error {
+	if s.private {
+		return nil
+	}
+	return checkEndpoint(u)
+}
+
+func checkEndpoint(u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return ErrUnsafeEndpoint
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !publicAddr(addr) {
+		return ErrUnsafeEndpoint
+	}
+	return nil
+}
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package oidc signs users in through the OpenID Connect and OAuth2
+// providers a tenant configures, such as Google or a corporate Keycloak.
+// Providers live in the "oidc" list of the tenant config; a redirect runs
+// the authorization code flow with PKCE, bound to the browser starting it,
+// and an OpenID provider's ID token is verified against its published keys
+// and the nonce of the redirect.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	world "appsite-go/internal/services/world/entity"
+)
+
+// ConfigKey is the key of the provider list in the tenant config
+const ConfigKey = "oidc"
+
+// StateTTL is how long the user may take on the provider's page
+const StateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownTenant   = errors.New("unknown or inactive tenant")
+	ErrUnknownProvider = errors.New("unknown sign in provider")
+	ErrStateInvalid    = errors.New("sign in state is invalid or expired")
+	ErrExchange        = errors.New("sign in provider rejected the code")
+	ErrIDToken         = errors.New("invalid ID token")
+)
+
+// ProviderConfig is a provider of a tenant. An OpenID provider only needs
+// its Issuer, the endpoints come from its discovery document. A plain
+// OAuth2 provider, such as GitHub, leaves Issuer empty and sets the
+// endpoints, the identity then comes from its user info.
+type ProviderConfig struct {
+	Name         string   `json:"name"`  // Unique in the tenant, used in URLs
+	Title        string   `json:"title"` // Shown on the sign in button
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"` // The /api/v1/callback/oidc URL registered with the provider
+	Scopes       []string `json:"scopes"`       // openid email profile by default for OpenID providers
+
+	AuthURL      string `json:"auth_url"`
+	TokenURL     string `json:"token_url"`
+	UserInfoURL  string `json:"userinfo_url"`
+	SubjectClaim string `json:"subject_claim"` // User info field of the user ID, "sub" by default
+}
+
+// OpenID reports whether the provider issues ID tokens
+func (p *ProviderConfig) OpenID() bool {
+	return p.Issuer != ""
+}
+
+// issuer identifies the provider in linked identities
+func (p *ProviderConfig) issuer() string {
+	if p.Issuer != "" {
+		return p.Issuer
+	}
+	return p.AuthURL
+}
+
+// Provider is what clients see of a provider
+type Provider struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// Identity is the account of a user at a provider
+type Identity struct {
+	TenantID      string
+	Provider      string
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// State is what a redirect was started for
+type State struct {
+	TenantID string `json:"tenant_id"`
+	Provider string `json:"provider"`
+	UserID   string `json:"user_id,omitempty"` // Set when linking to a signed-in user, empty to sign in
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE code verifier
+	Browser  string `json:"browser"`  // SHA-256 of the nonce kept by the browser
+}
+
+// Service runs sign ins with the providers of tenants
+type Service struct {
+	db      *gorm.DB
+	rdb     *redis.Client
+	client  *http.Client
+	private bool // Providers may be on private networks, see WithPrivateNetworks
+
+	mu        sync.Mutex
+	discovery map[string]*cached[discovery]
+	keys      map[string]*cached[keySet]
+}
+
+// NewService creates a new OIDC client service
+func NewService(db *gorm.DB, rdb *redis.Client) *Service {
+	return &Service{
+		db:        db,
+		rdb:       rdb,
+		client:    guardedClient(),
+		discovery: map[string]*cached[discovery]{},
+		keys:      map[string]*cached[keySet]{},
+	}
+}
+
+// WithHTTPClient replaces the client calling the providers. Endpoints are
+// still required to be https, but keeping off private addresses behind
+// public names is then up to the client.
+func (s *Service) WithHTTPClient(c *http.Client) *Service {
+	s.client = c
+	return s
+}
+
+// Providers lists the providers of a tenant, by ID or code
+func (s *Service) Providers(ctx context.Context, tenant string) ([]Provider, error) {
+	t, err := s.tenant(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := TenantProviders(t)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Provider, 0, len(configs))
+	for _, p := range configs {
+		title := p.Title
+		if title == "" {
+			title = p.Name
+		}
+		list = append(list, Provider{Name: p.Name, Title: title})
+	}
+	return list, nil
+}
+
+// Begin starts a redirect to a provider of a tenant and returns the URL to
+// send the user to, with the nonce the browser keeps, usually in a cookie,
+// to complete it. userID links the identity to a signed-in user; empty
+// signs in.
+func (s *Service) Begin(ctx context.Context, tenant, provider, userID string) (authURL, browserNonce string, err error) {
+	t, err := s.tenant(ctx, tenant)
+	if err != nil {
+		return "", "", err
+	}
+	p, err := findProvider(t, provider)
+	if err != nil {
+		return "", "", err
+	}
+	authURL = p.AuthURL
+	if p.OpenID() && authURL == "" {
+		d, err := s.discover(ctx, p.Issuer)
+		if err != nil {
+			return "", "", err
+		}
+		authURL = d.AuthorizationEndpoint
+	}
+	if authURL == "" {
+		return "", "", ErrUnknownProvider
+	}
+
+	random, err := randomStrings(4)
+	if err != nil {
+		return "", "", err
+	}
+	browserNonce = random[3]
+	st := State{TenantID: t.ID, Provider: p.Name, UserID: userID, Nonce: random[0], Verifier: random[1],
+		Browser: hashNonce(browserNonce)}
+	id := random[2]
+	raw, _ := json.Marshal(st)
+	if err := s.rdb.Set(ctx, stateKey(id), raw, StateTTL).Err(); err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(st.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(scopes(p), " ")},
+		"state":                 {id},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if p.OpenID() {
+		q.Set("nonce", st.Nonce)
+	}
+	sep := "?"
+	if strings.Contains(authURL, "?") {
+		sep = "&"
+	}
+	return authURL + sep + q.Encode(), browserNonce, nil
+}
+
+// Complete ends a redirect: it consumes the state, so a callback works
+// once, checks the nonce of the browser that started it, so a redirect
+// cannot be finished in another browser, trades the code for tokens and
+// returns the identity they prove
+func (s *Service) Complete(ctx context.Context, code, state, browserNonce string) (*Identity, *State, error) {
+	if code == "" || state == "" {
+		return nil, nil, ErrStateInvalid
+	}
+	raw, err := s.rdb.GetDel(ctx, stateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, ErrStateInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var st State
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return nil, nil, ErrStateInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(st.Browser), []byte(hashNonce(browserNonce))) != 1 {
+		return nil, nil, ErrStateInvalid
+	}
+
+	t, err := s.tenant(ctx, st.TenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := findProvider(t, st.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := s.exchange(ctx, p, code, &st)
+	if err != nil {
+		return nil, nil, err
+	}
+	id.TenantID = t.ID
+	return id, &st, nil
+}
+
+// TenantProviders reads the providers in the config of a tenant
+func TenantProviders(t *world.Tenant) ([]ProviderConfig, error) {
+	v, ok := t.Config[ConfigKey]
+	if !ok {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var list []ProviderConfig
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// tenant loads an active tenant by ID or code
+func (s *Service) tenant(ctx context.Context, idOrCode string) (*world.Tenant, error) {
+	if idOrCode == "" {
+		return nil, ErrUnknownTenant
+	}
+	var t world.Tenant
+	err := s.db.WithContext(ctx).Where("id = ? OR code = ?", idOrCode, idOrCode).Take(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTenant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != "enabled" || (t.ExpireAt > 0 && t.ExpireAt < time.Now().Unix()) {
+		return nil, ErrUnknownTenant
+	}
+	return &t, nil
+}
+
+func findProvider(t *world.Tenant, name string) (*ProviderConfig, error) {
+	list, err := TenantProviders(t)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Name == name && name != "" {
+			return &list[i], nil
+		}
+	}
+	return nil, ErrUnknownProvider
+}
+
+func scopes(p *ProviderConfig) []string {
+	if len(p.Scopes) > 0 {
+		return p.Scopes
+	}
+	if p.OpenID() {
+		return []string{"openid", "email", "profile"}
+	}
+	return nil
+}
+
+// randomStrings returns n unguessable URL-safe strings
+func randomStrings(n int) ([]string, error) {
+	list := make([]string, n)
+	buf := make([]byte, 32)
+	for i := range list {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		list[i] = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	return list, nil
+}
+
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+func stateKey(state string) string {
+	return "oidc:state:" + state
+}
@@ -5,6 +5,8 @@
 package operation
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 
 	"gorm.io/gorm"
@@ -31,13 +33,16 @@ type Service struct {
 
 // NewService creates a new audit service
 func NewService(db *gorm.DB) *Service {
-	// Auto migrate
-	if db != nil {
-		_ = db.AutoMigrate(&AuditLog{})
-	}
 	return &Service{db: db}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db: s.db.WithContext(ctx),
+	}
+}
+
 // Record saves an audit log entry
 func (s *Service) Record(log *AuditLog) error {
 	return s.db.Create(log).Error
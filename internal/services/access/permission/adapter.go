@@ -0,0 +1,148 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package permission
+
+import (
+	"strconv"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"gorm.io/gorm"
+
+	coremodel "appsite-go/internal/core/model"
+)
+
+// Rule is a stored policy line, "p, 100, /admin/v1/menu, GET"
+type Rule struct {
+	coremodel.Base
+	PType string `gorm:"column:ptype;type:varchar(8);index:idx_casbin_rule"`
+	V0    string `gorm:"type:varchar(128);index:idx_casbin_rule"`
+	V1    string `gorm:"type:varchar(255)"`
+	V2    string `gorm:"type:varchar(64)"`
+	V3    string `gorm:"type:varchar(64)"`
+	V4    string `gorm:"type:varchar(64)"`
+	V5    string `gorm:"type:varchar(64)"`
+}
+
+// TableName returns table name
+func (Rule) TableName() string {
+	return "access_casbin_rule"
+}
+
+func (r Rule) values() []string {
+	line := []string{r.PType, r.V0, r.V1, r.V2, r.V3, r.V4, r.V5}
+	for len(line) > 0 && line[len(line)-1] == "" {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+func newRule(ptype string, values []string) Rule {
+	r := Rule{PType: ptype}
+	fields := []*string{&r.V0, &r.V1, &r.V2, &r.V3, &r.V4, &r.V5}
+	for i, v := range values {
+		if i < len(fields) {
+			*fields[i] = v
+		}
+	}
+	return r
+}
+
+// Adapter stores the policy in the database, see Rule
+type Adapter struct {
+	db *gorm.DB
+}
+
+var (
+	_ persist.Adapter      = (*Adapter)(nil)
+	_ persist.BatchAdapter = (*Adapter)(nil)
+)
+
+// NewAdapter creates a policy adapter over db
+func NewAdapter(db *gorm.DB) *Adapter {
+	return &Adapter{db: db}
+}
+
+// LoadPolicy loads every stored rule into m
+func (a *Adapter) LoadPolicy(m model.Model) error {
+	var rules []Rule
+	if err := a.db.Order("created_at, id").Find(&rules).Error; err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if err := persist.LoadPolicyArray(r.values(), m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SavePolicy replaces the stored rules with those of m
+func (a *Adapter) SavePolicy(m model.Model) error {
+	var rules []Rule
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range m[sec] {
+			for _, values := range ast.Policy {
+				rules = append(rules, newRule(ptype, values))
+			}
+		}
+	}
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&Rule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+// AddPolicy stores a rule
+func (a *Adapter) AddPolicy(sec, ptype string, values []string) error {
+	r := newRule(ptype, values)
+	return a.db.Create(&r).Error
+}
+
+// AddPolicies stores rules at once
+func (a *Adapter) AddPolicies(sec, ptype string, list [][]string) error {
+	rules := make([]Rule, 0, len(list))
+	for _, values := range list {
+		rules = append(rules, newRule(ptype, values))
+	}
+	return a.db.Create(&rules).Error
+}
+
+// RemovePolicy deletes a rule
+func (a *Adapter) RemovePolicy(sec, ptype string, values []string) error {
+	r := newRule(ptype, values)
+	return a.db.Where(map[string]interface{}{
+		"ptype": r.PType, "v0": r.V0, "v1": r.V1, "v2": r.V2, "v3": r.V3, "v4": r.V4, "v5": r.V5,
+	}).Delete(&Rule{}).Error
+}
+
+// RemovePolicies deletes rules at once
+func (a *Adapter) RemovePolicies(sec, ptype string, list [][]string) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		for _, values := range list {
+			if err := NewAdapter(tx).RemovePolicy(sec, ptype, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveFilteredPolicy deletes the rules whose fields from fieldIndex on
+// match fieldValues; empty values match any
+func (a *Adapter) RemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	q := a.db.Where("ptype = ?", ptype)
+	for i, v := range fieldValues {
+		if v != "" && fieldIndex+i < 6 {
+			q = q.Where("v"+strconv.Itoa(fieldIndex+i)+" = ?", v)
+		}
+	}
+	return q.Delete(&Rule{}).Error
+}
@@ -5,21 +5,58 @@
 package permission
 
 import (
+	"context"
+	_ "embed"
+	"errors"
 	"log"
+	"net/http"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// rbacModel matches roles, paths with keyMatch2 patterns, and HTTP methods
+// or "*"
+//
+//go:embed rbac_model.conf
+var rbacModel string
+
+// AnyMethod allows every HTTP method of a path
+const AnyMethod = "*"
+
+var (
+	ErrInvalidPolicy  = errors.New("invalid policy, want a role, a path from / and an HTTP method or *")
+	ErrPolicyNotFound = errors.New("policy not found")
 )
 
 // Service wraps the Casbin enforcer
 type Service struct {
-	Enforcer *casbin.Enforcer
+	Enforcer *casbin.SyncedEnforcer
+	watcher  *Watcher
 }
 
 // NewService initializes the permission service.
 // modelConf: path to rbac_model.conf or text.
 // adapter: file path (string) or a persist.Adapter implementation.
 func NewService(modelConf string, adapter interface{}) (*Service, error) {
-	e, err := casbin.NewEnforcer(modelConf, adapter)
+	return newService(modelConf, adapter)
+}
+
+// NewDBService initializes the permission service of the app: the RBAC
+// model, with policies stored in db, see Rule
+func NewDBService(db *gorm.DB) (*Service, error) {
+	m, err := model.NewModelFromString(rbacModel)
+	if err != nil {
+		return nil, err
+	}
+	return newService(m, NewAdapter(db))
+}
+
+func newService(modelConf interface{}, adapter interface{}) (*Service, error) {
+	e, err := casbin.NewSyncedEnforcer(modelConf, adapter)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +72,26 @@ func NewService(modelConf string, adapter interface{}) (*Service, error) {
 	return &Service{Enforcer: e}, nil
 }
 
+// WithWatcher reloads the policy when other instances change it, and
+// tells them of changes made here; see Run
+func (s *Service) WithWatcher(rdb *redis.Client) *Service {
+	s.watcher = NewWatcher(rdb)
+	// Neither call can fail with Watcher; the callback replaces the one
+	// SetWatcher installs, which drops reload errors
+	_ = s.Enforcer.SetWatcher(s.watcher)
+	_ = s.watcher.SetUpdateCallback(s.reload)
+	return s
+}
+
+// Run listens for policy changes of other instances until ctx is done,
+// through Redis outages. Without a watcher it returns at once.
+func (s *Service) Run(ctx context.Context) error {
+	if s.watcher == nil {
+		return nil
+	}
+	return s.watcher.Run(ctx)
+}
+
 // Check verifies if the subject has permission
 func (s *Service) Check(sub, obj, act string) (bool, error) {
 	return s.Enforcer.Enforce(sub, obj, act)
@@ -59,3 +116,73 @@ func (s *Service) AddRoleForUser(user, role string) (bool, error) {
 func (s *Service) GetRolesForUser(user string) ([]string, error) {
 	return s.Enforcer.GetRolesForUser(user)
 }
+
+// Policy lets a role, a user group ID, call a path with a method. Paths
+// are keyMatch2 patterns: "/admin/v1/users/:id" or "/admin/v1/*".
+type Policy struct {
+	Role   string `json:"role" binding:"required"`
+	Path   string `json:"path" binding:"required"`
+	Method string `json:"method" binding:"required"`
+}
+
+// Policies lists the policies of a role, or all of them when role is empty
+func (s *Service) Policies(role string) ([]Policy, error) {
+	var rules [][]string
+	var err error
+	if role == "" {
+		rules, err = s.Enforcer.GetPolicy()
+	} else {
+		rules, err = s.Enforcer.GetFilteredPolicy(0, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Policy, 0, len(rules))
+	for _, r := range rules {
+		if len(r) >= 3 {
+			list = append(list, Policy{Role: r[0], Path: r[1], Method: r[2]})
+		}
+	}
+	return list, nil
+}
+
+// Grant adds a policy; granting it twice is no error
+func (s *Service) Grant(p Policy) error {
+	p, err := normalize(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.Enforcer.AddPolicy(p.Role, p.Path, p.Method)
+	return err
+}
+
+// Revoke removes a policy
+func (s *Service) Revoke(p Policy) error {
+	p, err := normalize(p)
+	if err != nil {
+		return err
+	}
+	ok, err := s.Enforcer.RemovePolicy(p.Role, p.Path, p.Method)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPolicyNotFound
+	}
+	return nil
+}
+
+func normalize(p Policy) (Policy, error) {
+	p.Role = strings.TrimSpace(p.Role)
+	p.Path = strings.TrimSpace(p.Path)
+	p.Method = strings.ToUpper(strings.TrimSpace(p.Method))
+	if p.Role == "" || !strings.HasPrefix(p.Path, "/") || strings.ContainsAny(p.Path, " ,") {
+		return p, ErrInvalidPolicy
+	}
+	switch p.Method {
+	case AnyMethod, http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return p, nil
+	}
+	return p, ErrInvalidPolicy
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package permission
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/log"
+)
+
+// Channel carries policy changes between instances
+const Channel = "permission:reload"
+
+// Retry delays of a lost subscription
+const (
+	minRetry = time.Second
+	maxRetry = 30 * time.Second
+)
+
+var _ persist.Watcher = (*Watcher)(nil)
+
+// Watcher tells the other instances to reload the policy after a change,
+// over Redis pub/sub. Changes of the instance itself are skipped.
+type Watcher struct {
+	rdb *redis.Client
+	id  string
+
+	mu       sync.Mutex
+	callback func(string)
+}
+
+// NewWatcher creates a new policy watcher
+func NewWatcher(rdb *redis.Client) *Watcher {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return &Watcher{rdb: rdb, id: hex.EncodeToString(buf)}
+}
+
+// SetUpdateCallback sets what runs on changes of other instances
+func (w *Watcher) SetUpdateCallback(fn func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = fn
+	return nil
+}
+
+// Update announces a change of this instance
+func (w *Watcher) Update() error {
+	return w.rdb.Publish(context.Background(), Channel, w.id).Err()
+}
+
+// Close does nothing; Run stops with its context
+func (w *Watcher) Close() {}
+
+// Run listens for changes until ctx is done. A failed subscription is
+// retried with backoff. Each time it is up the policy is reloaded, as
+// changes may have been missed before.
+func (w *Watcher) Run(ctx context.Context) error {
+	delay := minRetry
+	for {
+		subscribed, err := w.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if subscribed {
+			delay = minRetry
+		}
+		log.Warn(ctx, "Access policy watcher lost Redis, retrying", "err", err, "in", delay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetry)
+	}
+}
+
+// listen runs one subscription until it fails or ctx is done
+func (w *Watcher) listen(ctx context.Context) (bool, error) {
+	sub := w.rdb.Subscribe(ctx, Channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return false, err
+	}
+	w.notify("")
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case msg, ok := <-ch:
+			if !ok {
+				return true, redis.ErrClosed
+			}
+			if msg.Payload != w.id {
+				w.notify(msg.Payload)
+			}
+		}
+	}
+}
+
+func (w *Watcher) notify(from string) {
+	w.mu.Lock()
+	fn := w.callback
+	w.mu.Unlock()
+	if fn != nil {
+		fn(from)
+	}
+}
+
+// reload loads the policy again after a change elsewhere. On failure the
+// last loaded policy stays in force.
+func (s *Service) reload(from string) {
+	if err := s.Enforcer.LoadPolicy(); err != nil {
+		log.Error(context.Background(), "Access policy reload failed", "from", from, "err", err)
+	}
+}
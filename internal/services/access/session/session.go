@@ -0,0 +1,187 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package session tracks where users are logged in. A session is one
+// refresh token family: it starts at login, follows the token rotations and
+// ends at logout, on revocation or when its refresh token expires.
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/entity"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// seenInterval throttles the last-seen updates of a session
+const seenInterval = time.Minute
+
+// Device identifies the client a user logs in from
+type Device struct {
+	DeviceID  string
+	UserAgent string
+	IP        string
+}
+
+// Session is a login of a user on a device. Its ID is the token family.
+type Session struct {
+	model.Base
+	UserID     string `json:"user_id" gorm:"type:varchar(32);not null;index"`
+	DeviceID   string `json:"device_id" gorm:"type:varchar(64);index"`
+	UserAgent  string `json:"user_agent" gorm:"type:varchar(255)"`
+	IP         string `json:"ip" gorm:"type:varchar(45);comment:Last seen from"`
+	LastSeenAt int64  `json:"last_seen_at" gorm:"index"`
+	Current    bool   `json:"current" gorm:"-"` // The session of the listing request
+}
+
+// TableName returns table name
+func (Session) TableName() string {
+	return "user_session"
+}
+
+// Service records and revokes sessions
+type Service struct {
+	db     *gorm.DB
+	rdb    *redis.Client
+	tokens *token.Service
+}
+
+// NewService creates a new session service
+func NewService(db *gorm.DB, rdb *redis.Client, tokens *token.Service) *Service {
+	return &Service{db: db, rdb: rdb, tokens: tokens}
+}
+
+// Start issues the tokens of a new session of a user of tenant saasID and
+// records it. The device ID, when given, becomes the user's current device
+// in UserInfo.DeviceID.
+func (s *Service) Start(ctx context.Context, userID, role, saasID string, device Device) (*token.Pair, error) {
+	pair, err := s.tokens.IssueGrant(ctx, userID, role, token.Grant{SaasID: saasID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	sess := &Session{
+		UserID:     userID,
+		DeviceID:   truncate(device.DeviceID, 64),
+		UserAgent:  truncate(device.UserAgent, 255),
+		IP:         truncate(device.IP, 45),
+		LastSeenAt: now,
+	}
+	sess.ID = pair.SessionID
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sess).Error; err != nil {
+			return err
+		}
+		if sess.DeviceID == "" {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"device_id"}),
+		}).Create(&entity.UserInfo{UserID: userID, DeviceID: sess.DeviceID}).Error
+	})
+	if err != nil {
+		// An unrecorded session must not stay usable
+		if rerr := s.tokens.RevokeFamily(ctx, pair.SessionID); rerr != nil {
+			return nil, errors.Join(err, rerr)
+		}
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Seen records activity on a session, at most once per minute
+func (s *Service) Seen(ctx context.Context, sessionID, ip string) error {
+	ok, err := s.rdb.SetNX(ctx, seenKey(sessionID), 1, seenInterval).Result()
+	if err != nil || !ok {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&Session{}).Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"last_seen_at": time.Now().Unix(), "ip": truncate(ip, 45)}).Error
+}
+
+// List returns the live sessions of a user, most recently seen first,
+// flagging the one with ID current
+func (s *Service) List(ctx context.Context, userID, current string) ([]Session, error) {
+	var list []Session
+	err := s.live(ctx).Where("user_id = ?", userID).Order("last_seen_at desc").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Current = list[i].ID == current
+	}
+	return list, nil
+}
+
+// Revoke ends one session of a user
+func (s *Service) Revoke(ctx context.Context, userID, sessionID string) error {
+	var count int64
+	if err := s.live(ctx).Where("id = ? AND user_id = ?", sessionID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSessionNotFound
+	}
+	return s.tokens.RevokeFamily(ctx, sessionID)
+}
+
+// RevokeOthers ends every session of a user but the current one and
+// returns how many were ended
+func (s *Service) RevokeOthers(ctx context.Context, userID, current string) (int, error) {
+	var ids []string
+	err := s.live(ctx).Where("user_id = ? AND id <> ?", userID, current).Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.tokens.RevokeFamily(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// RevokeAll ends every session of a user, forcing a new login everywhere
+func (s *Service) RevokeAll(ctx context.Context, userID string) error {
+	return s.tokens.RevokeUser(ctx, userID)
+}
+
+// PurgeExpired deletes the sessions whose refresh tokens are all gone
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where("id NOT IN (?)", s.db.Model(&token.RefreshToken{}).Distinct("family")).
+		Delete(&Session{})
+	return res.RowsAffected, res.Error
+}
+
+// live scopes to sessions holding an unexpired, unrevoked refresh token
+func (s *Service) live(ctx context.Context) *gorm.DB {
+	families := s.db.Model(&token.RefreshToken{}).Select("family").
+		Where("revoked_at = 0 AND used_at = 0 AND expires_at > ?", time.Now().Unix())
+	return s.db.WithContext(ctx).Model(&Session{}).Where("id IN (?)", families)
+}
+
+func seenKey(sessionID string) string {
+	return "session:seen:" + sessionID
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package social signs users in with third-party accounts. Each platform is
+// a Provider turning the code of its OAuth redirect into an Identity; the
+// Service tracks the state of each redirect, binding it to its purpose and
+// to the browser that started it.
+package social
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Known providers, one per social column of entity.UserInfo
+const (
+	ProviderWechat = "wechat"
+	ProviderWeibo  = "weibo"
+	ProviderQQ     = "qq"
+	ProviderApple  = "apple"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown social login provider")
+	ErrStateInvalid    = errors.New("social login state is invalid or expired")
+	ErrExchange        = errors.New("social login provider rejected the code")
+)
+
+// StateTTL is how long the user may take on the provider's page
+const StateTTL = 10 * time.Minute
+
+// Identity is the account of a user on a provider
+type Identity struct {
+	Provider string
+	OpenID   string // Per application ID
+	UnionID  string // Per platform ID shared by the applications of a developer, when any
+	Nickname string
+	Avatar   string
+}
+
+// ID is the most stable ID of the identity: the union ID when the
+// provider has one, so the same user is recognized across applications
+func (i *Identity) ID() string {
+	if i.UnionID != "" {
+		return i.UnionID
+	}
+	return i.OpenID
+}
+
+// Provider is a social login platform
+type Provider interface {
+	// Name is one of the Provider constants
+	Name() string
+	// AuthURL is the page where the user grants access, redirecting back with a code and state
+	AuthURL(state string) string
+	// Exchange trades the code of the redirect for the identity of the user
+	Exchange(ctx context.Context, code string) (*Identity, error)
+}
+
+// State is what a redirect was started for
+type State struct {
+	Provider string `json:"provider"`
+	UserID   string `json:"user_id,omitempty"` // Set when binding to a signed-in user, empty to sign in
+	Browser  string `json:"browser"`           // SHA-256 of the nonce kept by the browser
+}
+
+// Service holds the providers and the pending redirects
+type Service struct {
+	rdb       *redis.Client
+	providers map[string]Provider
+}
+
+// NewService creates a new social login service
+func NewService(rdb *redis.Client) *Service {
+	return &Service{rdb: rdb, providers: map[string]Provider{}}
+}
+
+// WithProvider enables a provider
+func (s *Service) WithProvider(p Provider) *Service {
+	s.providers[p.Name()] = p
+	return s
+}
+
+// Providers lists the enabled provider names
+func (s *Service) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Begin starts a redirect to provider and returns the URL to send the user
+// to, with the nonce the browser keeps, usually in a cookie, to complete
+// it. userID binds the identity to a signed-in user; empty signs in.
+func (s *Service) Begin(ctx context.Context, provider, userID string) (authURL, nonce string, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	if nonce, err = randomToken(); err != nil {
+		return "", "", err
+	}
+	raw, _ := json.Marshal(State{Provider: provider, UserID: userID, Browser: hashNonce(nonce)})
+	if err := s.rdb.Set(ctx, stateKey(state), raw, StateTTL).Err(); err != nil {
+		return "", "", err
+	}
+	return p.AuthURL(state), nonce, nil
+}
+
+// Complete ends a redirect of provider: it consumes the state, so a
+// callback works once, checks the nonce of the browser that started it,
+// so a redirect cannot be finished in another browser, and exchanges the
+// code for the identity
+func (s *Service) Complete(ctx context.Context, provider, code, state, nonce string) (*Identity, *State, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, nil, ErrUnknownProvider
+	}
+	if state == "" || code == "" {
+		return nil, nil, ErrStateInvalid
+	}
+	raw, err := s.rdb.GetDel(ctx, stateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, ErrStateInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var st State
+	if err := json.Unmarshal(raw, &st); err != nil || st.Provider != provider {
+		return nil, nil, ErrStateInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(st.Browser), []byte(hashNonce(nonce))) != 1 {
+		return nil, nil, ErrStateInvalid
+	}
+	id, err := p.Exchange(ctx, code)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, &st, nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+func stateKey(state string) string {
+	return "social:state:" + state
+}
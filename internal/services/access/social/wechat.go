@@ -0,0 +1,162 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WeChat scopes: website QR login, or pages opened inside WeChat
+const (
+	WechatScopeLogin    = "snsapi_login"
+	WechatScopeUserInfo = "snsapi_userinfo"
+)
+
+// Wechat signs in with WeChat web authorization
+type Wechat struct {
+	AppID       string
+	Secret      string
+	RedirectURL string // Callback registered with WeChat
+	Scope       string // WechatScopeLogin by default
+
+	// Endpoints, for tests; the WeChat ones when empty
+	OpenURL string // https://open.weixin.qq.com
+	APIURL  string // https://api.weixin.qq.com
+	Client  *http.Client
+}
+
+// Name implements Provider
+func (w *Wechat) Name() string {
+	return ProviderWechat
+}
+
+// AuthURL implements Provider
+func (w *Wechat) AuthURL(state string) string {
+	scope, path := w.Scope, "/connect/qrconnect"
+	if scope == "" {
+		scope = WechatScopeLogin
+	}
+	if scope != WechatScopeLogin {
+		path = "/connect/oauth2/authorize"
+	}
+	q := url.Values{}
+	q.Set("appid", w.AppID)
+	q.Set("redirect_uri", w.RedirectURL)
+	q.Set("response_type", "code")
+	q.Set("scope", scope)
+	q.Set("state", state)
+	// WeChat requires the parameters in this order and the fragment
+	return w.base(w.OpenURL, "https://open.weixin.qq.com") + path + "?" + encodeOrdered(q,
+		"appid", "redirect_uri", "response_type", "scope", "state") + "#wechat_redirect"
+}
+
+// wechatError is the error part of every WeChat API response
+type wechatError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+type wechatToken struct {
+	wechatError
+	AccessToken string `json:"access_token"`
+	OpenID      string `json:"openid"`
+	UnionID     string `json:"unionid"`
+}
+
+type wechatUser struct {
+	wechatError
+	OpenID     string `json:"openid"`
+	UnionID    string `json:"unionid"`
+	Nickname   string `json:"nickname"`
+	HeadImgURL string `json:"headimgurl"`
+}
+
+// Exchange implements Provider: the code buys an access token, which reads
+// the profile of the user
+func (w *Wechat) Exchange(ctx context.Context, code string) (*Identity, error) {
+	var tok wechatToken
+	err := w.get(ctx, "/sns/oauth2/access_token", url.Values{
+		"appid":      {w.AppID},
+		"secret":     {w.Secret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	}, &tok, &tok.wechatError)
+	if err != nil {
+		return nil, err
+	}
+	if tok.OpenID == "" {
+		return nil, ErrExchange
+	}
+
+	var user wechatUser
+	err = w.get(ctx, "/sns/userinfo", url.Values{
+		"access_token": {tok.AccessToken},
+		"openid":       {tok.OpenID},
+	}, &user, &user.wechatError)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identity{
+		Provider: ProviderWechat,
+		OpenID:   tok.OpenID,
+		UnionID:  user.UnionID,
+		Nickname: user.Nickname,
+		Avatar:   user.HeadImgURL,
+	}
+	if id.UnionID == "" {
+		id.UnionID = tok.UnionID
+	}
+	return id, nil
+}
+
+func (w *Wechat) get(ctx context.Context, path string, q url.Values, out interface{}, werr *wechatError) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		w.base(w.APIURL, "https://api.weixin.qq.com")+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wechat %s: http %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return err
+	}
+	if werr.ErrCode != 0 {
+		return fmt.Errorf("%w: wechat %d %s", ErrExchange, werr.ErrCode, werr.ErrMsg)
+	}
+	return nil
+}
+
+func (w *Wechat) base(override, def string) string {
+	if override != "" {
+		return strings.TrimSuffix(override, "/")
+	}
+	return def
+}
+
+// encodeOrdered encodes q with keys in the given order
+func encodeOrdered(q url.Values, keys ...string) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(q.Get(k)))
+	}
+	return strings.Join(parts, "&")
+}
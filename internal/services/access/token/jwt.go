@@ -9,6 +9,9 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
 	"appsite-go/internal/core/setting"
 )
 
@@ -17,20 +20,35 @@ var (
 	ErrTokenNotValidYet = errors.New("token not active yet")
 	ErrTokenMalformed   = errors.New("that's not even a token")
 	ErrTokenInvalid     = errors.New("couldn't handle this token")
+	ErrTokenRevoked     = errors.New("token has been revoked")
 )
 
 // Claims defines the custom claims structure
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
+	Family string `json:"fam,omitempty"`     // Refresh token family the token was issued with
+	SaasID string `json:"saas_id,omitempty"` // Tenant of the user; requests of the token run in it
+
+	// Set on tokens issued to OAuth clients, see Grant
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Service handles JWT operations
+// Service handles JWT operations. Refresh tokens, revocation and the key
+// pairs of RS256/EdDSA need a store, see WithStore.
 type Service struct {
-	secret []byte
-	issuer string
-	expire time.Duration
+	secret        []byte
+	alg           string
+	issuer        string
+	expire        time.Duration
+	refreshExpire time.Duration
+	overlap       time.Duration
+	keys          keyring
+
+	db  *gorm.DB
+	rdb *redis.Client
 }
 
 // NewService creates a new JWT service
@@ -38,42 +56,103 @@ func NewService(cfg setting.AppConfig) *Service {
 	// Default fallbacks
 	expire := cfg.JwtExpire
 	if expire == 0 {
-		expire = 72 * time.Hour
+		expire = 15 * time.Minute
+	}
+	refreshExpire := cfg.JwtRefreshExpire
+	if refreshExpire == 0 {
+		refreshExpire = 30 * 24 * time.Hour
+	}
+	alg := cfg.JwtAlgorithm
+	if alg == "" {
+		alg = AlgHS256
 	}
-	
+	// A retired key must outlive the tokens it signed
+	overlap := cfg.JwtKeyOverlap
+	if overlap == 0 {
+		overlap = 24 * time.Hour
+	}
+	if overlap < expire {
+		overlap = expire
+	}
+
 	return &Service{
-		secret: []byte(cfg.JwtSecret),
-		issuer: cfg.JwtIssuer,
-		expire: expire,
+		secret:        []byte(cfg.JwtSecret),
+		alg:           alg,
+		issuer:        cfg.JwtIssuer,
+		expire:        expire,
+		refreshExpire: refreshExpire,
+		overlap:       overlap,
 	}
 }
 
+// WithStore keeps refresh tokens in db and the revocation list in rdb
+func (s *Service) WithStore(db *gorm.DB, rdb *redis.Client) *Service {
+	s.db, s.rdb = db, rdb
+	return s
+}
+
 // GenerateToken creates a new JWT token
 func (s *Service) GenerateToken(userID, role string) (string, error) {
+	token, _, err := s.sign(userID, role, "", Grant{})
+	return token, err
+}
+
+// sign issues an access token with a unique ID, so it can be revoked alone
+func (s *Service) sign(userID, role, family string, g Grant) (string, *Claims, error) {
 	now := time.Now()
-	claims := Claims{
-		UserID: userID,
-		Role:   role,
+	subject := userID
+	if subject == "" {
+		subject = g.ClientID
+	}
+	claims := &Claims{
+		UserID:   userID,
+		Role:     role,
+		Family:   family,
+		SaasID:   g.SaasID,
+		ClientID: g.ClientID,
+		Scope:    g.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newID(),
+			Subject:   subject,
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.expire)),
 			Issuer:    s.issuer,
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	token, err := s.SignClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// SignClaims signs any claims with the current key, for tokens that are
+// not access tokens, such as OpenID Connect ID tokens. They must carry no
+// "jti": ParseToken refuses tokens without one, so they never pass for
+// access tokens.
+func (s *Service) SignClaims(claims jwt.Claims) (string, error) {
+	if !s.Asymmetric() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+	k, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+	t := jwt.NewWithClaims(k.method, claims)
+	t.Header["kid"] = k.id
+	return t.SignedString(k.private)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+// Algorithm is the JWS algorithm tokens are signed with
+func (s *Service) Algorithm() string {
+	return s.alg
 }
 
-// ParseToken validates and parses the token
+// ParseToken validates and parses the token. With RS256/EdDSA the
+// verification key is the one named by the kid header.
 func (s *Service) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrTokenInvalid
-		}
-		return s.secret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -88,9 +167,32 @@ func (s *Service) ParseToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.ID != "" {
 		return claims, nil
 	}
 
 	return nil, ErrTokenInvalid
 }
+
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if !s.Asymmetric() {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrTokenInvalid
+		}
+		return s.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrTokenInvalid
+	}
+	k, err := s.verifyingKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	// The key decides the algorithm, never the token
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, ErrTokenInvalid
+	}
+	return k.public, nil
+}
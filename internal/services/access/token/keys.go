@@ -0,0 +1,389 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+)
+
+// Signing algorithms
+const (
+	AlgHS256 = "HS256" // Shared secret from config, no key rotation
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedAlg = errors.New("unsupported signing algorithm")
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrNoSigningKey   = errors.New("no active signing key")
+)
+
+// keyReload bounds how stale the in-memory keyring gets. It must stay well
+// below the overlap window, so every instance knows a key before it signs.
+const keyReload = time.Minute
+
+// SigningKey is an asymmetric key pair used to sign access tokens; its ID is
+// the kid of the tokens it signs. A rotation publishes the next key one
+// overlap window before it starts signing, so verifiers caching the JWKS
+// learn it in time, and keeps the previous key for another window after
+// it stops, until the last token it signed has expired.
+type SigningKey struct {
+	model.Base
+	Algorithm   string `json:"algorithm" gorm:"type:varchar(16);not null"`
+	PrivateKey  string `json:"-" gorm:"type:text;not null;comment:PKCS#8 PEM"`
+	PublicKey   string `json:"public_key" gorm:"type:text;not null;comment:PKIX PEM"`
+	ActivatesAt int64  `json:"activates_at" gorm:"index;comment:Signs from then until a newer key activates"`
+	ExpiresAt   int64  `json:"expires_at" gorm:"index;comment:Dropped from the key set, 0 while no successor exists"`
+}
+
+// TableName returns table name
+func (SigningKey) TableName() string {
+	return "access_signing_key"
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey decodes an RSA, EC or Ed25519 public key, for verifying the
+// tokens of other issuers
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedAlg, j.Crv)
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return pub, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedAlg, j.Crv)
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedAlg, j.Kty)
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// key is a loaded SigningKey
+type key struct {
+	id          string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt int64
+}
+
+// keyring caches the signing keys of the store
+type keyring struct {
+	mu       sync.RWMutex
+	keys     []*key // latest activation first
+	loadedAt time.Time
+}
+
+// Asymmetric reports whether tokens are signed with rotating key pairs
+func (s *Service) Asymmetric() bool {
+	return s.alg != AlgHS256
+}
+
+// LoadKeys reads the keyring, creating the first key pair when there is
+// none. It is a no-op for HS256; call it on start to fail fast.
+func (s *Service) LoadKeys(ctx context.Context) error {
+	switch s.alg {
+	case AlgHS256:
+		return nil
+	case AlgRS256, AlgEdDSA:
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedAlg, s.alg)
+	}
+	if s.db == nil {
+		return ErrNoStore
+	}
+
+	db := s.db.WithContext(ctx)
+	var count int64
+	if err := activeKeys(db.Model(&SigningKey{}), time.Now()).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if _, err := s.createKey(db, time.Now()); err != nil {
+			return err
+		}
+	}
+	return s.reloadKeys(ctx)
+}
+
+// RotateKeys publishes the next signing key, which takes over after the
+// overlap window, schedules the current keys to leave the key set one
+// window after that and deletes the keys already gone.
+func (s *Service) RotateKeys(ctx context.Context) error {
+	if !s.Asymmetric() {
+		return fmt.Errorf("%w: %s keys do not rotate", ErrUnsupportedAlg, s.alg)
+	}
+	if s.db == nil {
+		return ErrNoStore
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		next, err := s.createKey(tx, now.Add(s.overlap))
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&SigningKey{}).Where("id <> ? AND expires_at = 0", next.ID).
+			Update("expires_at", next.ActivatesAt+int64(s.overlap/time.Second)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("expires_at > 0 AND expires_at <= ?", now.Unix()).Delete(&SigningKey{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.reloadKeys(ctx)
+}
+
+// JWKS returns the public keys verifying current tokens, including the next
+// key. It is empty for HS256, whose secret must never be published.
+func (s *Service) JWKS(ctx context.Context) (*JWKSet, error) {
+	set := &JWKSet{Keys: []JWK{}}
+	if !s.Asymmetric() {
+		return set, nil
+	}
+	keys, err := s.loadedKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// signingKey returns the latest key already active
+func (s *Service) signingKey() (*key, error) {
+	keys, err := s.loadedKeys(context.Background(), false)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	for _, k := range keys {
+		if k.activatesAt <= now {
+			return k, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// verifyingKey finds a key by kid, reloading once when it is not known yet
+func (s *Service) verifyingKey(kid string) (*key, error) {
+	for _, reload := range []bool{false, true} {
+		keys, err := s.loadedKeys(context.Background(), reload)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			if k.id == kid {
+				return k, nil
+			}
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// loadedKeys returns the cached keys, reloading them when stale. A forced
+// reload is throttled, so tokens with made-up kids cannot hammer the store.
+func (s *Service) loadedKeys(ctx context.Context, force bool) ([]*key, error) {
+	s.keys.mu.RLock()
+	keys, age := s.keys.keys, time.Since(s.keys.loadedAt)
+	s.keys.mu.RUnlock()
+
+	if age < keyReload && (!force || age < keyReload/12) {
+		return keys, nil
+	}
+	if err := s.reloadKeys(ctx); err != nil {
+		return nil, err
+	}
+	s.keys.mu.RLock()
+	defer s.keys.mu.RUnlock()
+	return s.keys.keys, nil
+}
+
+func (s *Service) reloadKeys(ctx context.Context) error {
+	if s.db == nil {
+		return ErrNoStore
+	}
+	var rows []SigningKey
+	err := activeKeys(s.db.WithContext(ctx), time.Now()).Order("activates_at desc").Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*key, 0, len(rows))
+	for i := range rows {
+		k, err := parseKey(&rows[i])
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", rows[i].ID, err)
+		}
+		keys = append(keys, k)
+	}
+	s.keys.mu.Lock()
+	s.keys.keys, s.keys.loadedAt = keys, time.Now()
+	s.keys.mu.Unlock()
+	return nil
+}
+
+// createKey generates a key pair of the configured algorithm
+func (s *Service) createKey(tx *gorm.DB, activatesAt time.Time) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch s.alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("%w %q", ErrUnsupportedAlg, s.alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	pubDer, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	row := &SigningKey{
+		Algorithm:   s.alg,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer})),
+		ActivatesAt: activatesAt.Unix(),
+	}
+	if err := tx.Create(row).Error; err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func parseKey(row *SigningKey) (*key, error) {
+	block, _ := pem.Decode([]byte(row.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlg, parsed)
+	}
+
+	k := &key{id: row.ID, private: private, public: private.Public(), activatesAt: row.ActivatesAt}
+	switch row.Algorithm {
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedAlg, row.Algorithm)
+	}
+	return k, nil
+}
+
+// activeKeys scopes db to the keys still in the key set at now
+func activeKeys(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("expires_at = 0 OR expires_at > ?", now.Unix())
+}
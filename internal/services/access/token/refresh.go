@@ -0,0 +1,254 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+)
+
+var (
+	ErrNoStore        = errors.New("token store is not configured")
+	ErrRefreshInvalid = errors.New("refresh token is invalid")
+	ErrRefreshExpired = errors.New("refresh token is expired")
+	ErrRefreshReused  = errors.New("refresh token was already used, its family is revoked")
+)
+
+// RefreshToken is the server-side record of an issued refresh token. Each
+// refresh consumes the token and issues its successor in the same family;
+// a family lives from a login to its logout or revocation.
+type RefreshToken struct {
+	model.Base
+	UserID     string `json:"user_id" gorm:"type:varchar(32);not null;index"`
+	Role       string `json:"role" gorm:"type:varchar(32)"`
+	Family     string `json:"family" gorm:"type:varchar(32);not null;index"`
+	Hash       string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex;comment:SHA-256 of the token"`
+	ExpiresAt  int64  `json:"expires_at" gorm:"index"`
+	UsedAt     int64  `json:"used_at" gorm:"comment:Rotated at, 0 while current"`
+	RevokedAt  int64  `json:"revoked_at" gorm:"index"`
+	ReplacedBy string `json:"replaced_by" gorm:"type:varchar(32)"`
+	ClientID   string `json:"client_id" gorm:"type:varchar(32);index;comment:OAuth client, empty for first-party logins"`
+	Scope      string `json:"scope" gorm:"type:varchar(255)"`
+	// Not saas_id: refresh tokens are looked up before the tenant is known
+	TenantID string `json:"tenant_id" gorm:"type:varchar(36);not null;default:'';comment:Tenant the family acts in"`
+}
+
+// TableName returns table name
+func (RefreshToken) TableName() string {
+	return "access_refresh_token"
+}
+
+// Pair is an access token with the refresh token renewing it
+type Pair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds until the access token expires
+	SessionID    string `json:"session_id"` // The token family, lasting from login to logout
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Grant is what the tokens of a family carry besides the user: the tenant
+// of the user and, when a user granted an OAuth client, the client ID and
+// scope; only that client may refresh them
+type Grant struct {
+	SaasID   string
+	ClientID string
+	Scope    string
+}
+
+// IssuePair starts a new token family for a user of the default tenant
+func (s *Service) IssuePair(ctx context.Context, userID, role string) (*Pair, error) {
+	return s.IssueGrant(ctx, userID, role, Grant{})
+}
+
+// IssueGrant starts a new token family for a user, typically on login
+func (s *Service) IssueGrant(ctx context.Context, userID, role string, g Grant) (*Pair, error) {
+	if s.db == nil {
+		return nil, ErrNoStore
+	}
+	family := newID()
+	var raw string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		raw, _, err = s.createRefresh(tx, userID, role, family, g)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(userID, role, family, raw, g)
+}
+
+// IssueClientToken issues an access token to an OAuth client acting on its
+// own behalf, with no user, refresh token or family
+func (s *Service) IssueClientToken(clientID, scope string) (*Pair, error) {
+	access, _, err := s.sign("", "", "", Grant{ClientID: clientID, Scope: scope})
+	if err != nil {
+		return nil, err
+	}
+	return &Pair{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.expire / time.Second),
+		Scope:       scope,
+	}, nil
+}
+
+// Refresh consumes a refresh token and returns a new pair in its family.
+// check is called with the owner before rotating and returns the role of
+// the new access token; its error is returned as is and leaves the token
+// usable. Presenting a token that was already consumed revokes the whole
+// family, as either the client or an attacker holds a stolen copy.
+// Tokens of OAuth clients are refused, see RefreshGrant.
+func (s *Service) Refresh(ctx context.Context, refreshToken string, check func(userID string) (string, error)) (*Pair, error) {
+	return s.refresh(ctx, refreshToken, "", check)
+}
+
+// RefreshGrant is Refresh for the tokens of an OAuth client; those of any
+// other client, or of first-party logins, are refused
+func (s *Service) RefreshGrant(ctx context.Context, refreshToken, clientID string, check func(userID string) (string, error)) (*Pair, error) {
+	if clientID == "" {
+		return nil, ErrRefreshInvalid
+	}
+	return s.refresh(ctx, refreshToken, clientID, check)
+}
+
+func (s *Service) refresh(ctx context.Context, refreshToken, clientID string, check func(userID string) (string, error)) (*Pair, error) {
+	if s.db == nil {
+		return nil, ErrNoStore
+	}
+
+	var current RefreshToken
+	err := s.db.WithContext(ctx).Where("hash = ?", hashToken(refreshToken)).Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRefreshInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Checked first, so presenting it elsewhere cannot revoke the family
+	if current.RevokedAt > 0 || current.ClientID != clientID {
+		return nil, ErrRefreshInvalid
+	}
+	if current.UsedAt > 0 {
+		return nil, s.reused(ctx, current.Family)
+	}
+	if current.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrRefreshExpired
+	}
+
+	role := current.Role
+	if check != nil {
+		if role, err = check(current.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	grant := Grant{SaasID: current.TenantID, ClientID: current.ClientID, Scope: current.Scope}
+	var raw string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		next, id, err := s.createRefresh(tx, current.UserID, role, current.Family, grant)
+		if err != nil {
+			return err
+		}
+		// Consuming is conditional, so of two concurrent refreshes one loses
+		res := tx.Model(&RefreshToken{}).
+			Where("id = ? AND used_at = 0 AND revoked_at = 0", current.ID).
+			Updates(map[string]interface{}{"used_at": time.Now().Unix(), "replaced_by": id})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRefreshReused
+		}
+		raw = next
+		return nil
+	})
+	if errors.Is(err, ErrRefreshReused) {
+		return nil, s.reused(ctx, current.Family)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(current.UserID, role, current.Family, raw, grant)
+}
+
+// reused revokes a family whose consumed token came back
+func (s *Service) reused(ctx context.Context, family string) error {
+	if err := s.RevokeFamily(ctx, family); err != nil {
+		return err
+	}
+	return ErrRefreshReused
+}
+
+// PurgeExpired deletes refresh tokens past their expiry, returning the count
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.db == nil {
+		return 0, ErrNoStore
+	}
+	res := s.db.WithContext(ctx).Where("expires_at <= ?", time.Now().Unix()).Delete(&RefreshToken{})
+	return res.RowsAffected, res.Error
+}
+
+// createRefresh stores a new refresh token and returns it with its record ID
+func (s *Service) createRefresh(tx *gorm.DB, userID, role, family string, g Grant) (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	rt := &RefreshToken{
+		UserID:    userID,
+		Role:      role,
+		Family:    family,
+		Hash:      hashToken(raw),
+		ExpiresAt: time.Now().Add(s.refreshExpire).Unix(),
+		ClientID:  g.ClientID,
+		Scope:     g.Scope,
+		TenantID:  g.SaasID,
+	}
+	if err := tx.Create(rt).Error; err != nil {
+		return "", "", err
+	}
+	return raw, rt.ID, nil
+}
+
+func (s *Service) pair(userID, role, family, refreshToken string, g Grant) (*Pair, error) {
+	access, _, err := s.sign(userID, role, family, g)
+	if err != nil {
+		return nil, err
+	}
+	return &Pair{
+		AccessToken:  access,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.expire / time.Second),
+		SessionID:    family,
+		Scope:        g.Scope,
+	}, nil
+}
+
+// hashToken is what is stored of a refresh token, so a leaked table cannot be replayed
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -7,21 +7,145 @@ package verify
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"appsite-go/pkg/extra/mail"
+	"appsite-go/pkg/extra/sms"
+)
+
+// Purposes scope a code to the flow it was sent for, so a login code
+// cannot reset a password
+const (
+	PurposeLogin    = "login"
+	PurposeRegister = "register"
+	PurposeReset    = "reset"
+	PurposeBind     = "bind"
 )
 
+var (
+	ErrOTPInvalid  = errors.New("invalid otp code")
+	ErrOTPLocked   = errors.New("too many failed attempts, request a new code later")
+	ErrOTPCooldown = errors.New("a code was sent recently, retry later")
+	ErrOTPPurpose  = errors.New("unknown otp purpose")
+	ErrNoSender    = errors.New("no sender configured for this target")
+	ErrOTPTarget   = errors.New("target is not a valid email or mobile number")
+)
+
+// OTPOptions tunes codes, attempt limits and send cooldowns; zero values use the defaults
+type OTPOptions struct {
+	Length         int           // Digits of a code
+	TTL            time.Duration // Validity of a code
+	MaxAttempts    int           // Failed checks of a target before it is locked
+	Lockout        time.Duration // Window counting failed checks, the lock lasts until it ends
+	TargetCooldown time.Duration // Minimum delay between two codes sent to a target
+	IPCooldown     time.Duration // Minimum delay between two codes requested by an IP
+	SMSTemplate    string        // SMS template code, with a "code" param; plain text when empty
+}
+
+func (o OTPOptions) withDefaults() OTPOptions {
+	if o.Length <= 0 {
+		o.Length = 6
+	}
+	if o.TTL <= 0 {
+		o.TTL = 5 * time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Lockout <= 0 {
+		o.Lockout = 15 * time.Minute
+	}
+	if o.TargetCooldown <= 0 {
+		o.TargetCooldown = time.Minute
+	}
+	if o.IPCooldown <= 0 {
+		o.IPCooldown = 10 * time.Second
+	}
+	return o
+}
+
 // OTPService handles One-Time Password generation and verification
 type OTPService struct {
-	rdb *redis.Client
+	rdb  *redis.Client
+	opts OTPOptions
+	sms  sms.Sender
+	mail mail.Sender
 }
 
 // NewOTPService creates a new OTP service instance
 func NewOTPService(rdb *redis.Client) *OTPService {
-	return &OTPService{rdb: rdb}
+	return &OTPService{rdb: rdb, opts: OTPOptions{}.withDefaults()}
+}
+
+// WithOptions overrides the default limits
+func (s *OTPService) WithOptions(opts OTPOptions) *OTPService {
+	s.opts = opts.withDefaults()
+	return s
+}
+
+// WithSenders sets how codes are delivered: phones by SMS, addresses
+// containing "@" by email. Either may be nil.
+func (s *OTPService) WithSenders(smsSender sms.Sender, mailSender mail.Sender) *OTPService {
+	s.sms, s.mail = smsSender, mailSender
+	return s
+}
+
+// NormalizeTarget returns the canonical form of an email or mobile, so one
+// address has one code, one cooldown and one lockout however it is typed.
+// Emails are trimmed and lowercased. Mobiles lose spaces, dashes, dots and
+// parentheses; international ones, starting with + or 00, become E.164
+// (+ and 8 to 15 digits), national ones are kept as their digits.
+func NormalizeTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "@") {
+		if strings.ContainsAny(target, " \t\r\n") || strings.Count(target, "@") != 1 ||
+			strings.HasPrefix(target, "@") || strings.HasSuffix(target, "@") {
+			return "", ErrOTPTarget
+		}
+		return strings.ToLower(target), nil
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, target)
+	international := false
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits, international = digits[1:], true
+	case strings.HasPrefix(digits, "00"):
+		digits, international = digits[2:], true
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", ErrOTPTarget
+	}
+	if international {
+		if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+			return "", ErrOTPTarget
+		}
+		return "+" + digits, nil
+	}
+	if len(digits) < 5 || len(digits) > 15 {
+		return "", ErrOTPTarget
+	}
+	return digits, nil
+}
+
+// CanSend reports whether codes can be delivered to target, by SMS or email
+func (s *OTPService) CanSend(target string) bool {
+	if strings.Contains(target, "@") {
+		return s.mail != nil
+	}
+	return s.sms != nil
 }
 
 // Generate creates a numeric OTP code and stores it in Redis
@@ -29,6 +153,105 @@ func NewOTPService(rdb *redis.Client) *OTPService {
 // length: number of digits (e.g. 6)
 // ttl: validity duration
 func (s *OTPService) Generate(ctx context.Context, target string, length int, ttl time.Duration) (string, error) {
+	return s.generate(ctx, "", target, length, ttl)
+}
+
+// Check validates the OTP code. Returns true if valid and deletes the key.
+// Failed checks count towards the lockout of the target.
+func (s *OTPService) Check(ctx context.Context, target, code string) bool {
+	return s.verify(ctx, "", target, code) == nil
+}
+
+// Issue creates a code for a purpose using the configured length and TTL,
+// replacing any previous one. It does not deliver it, see Send.
+func (s *OTPService) Issue(ctx context.Context, purpose, target string) (string, error) {
+	if !validPurpose(purpose) {
+		return "", ErrOTPPurpose
+	}
+	target, err := NormalizeTarget(target)
+	if err != nil {
+		return "", err
+	}
+	return s.generate(ctx, purpose, target, s.opts.Length, s.opts.TTL)
+}
+
+// Send issues a code for a purpose and delivers it to target. It is refused
+// while the target or the requesting IP is cooling down, or while the
+// target is locked out.
+func (s *OTPService) Send(ctx context.Context, purpose, target, ip string) error {
+	if !validPurpose(purpose) {
+		return ErrOTPPurpose
+	}
+	target, err := NormalizeTarget(target)
+	if err != nil {
+		return err
+	}
+	if !s.CanSend(target) {
+		return ErrNoSender
+	}
+	email := strings.Contains(target, "@")
+
+	locked, err := s.locked(ctx, purpose, target)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrOTPLocked
+	}
+
+	release, err := s.Throttle(ctx, target, ip)
+	if err != nil {
+		return err
+	}
+
+	code, err := s.Issue(ctx, purpose, target)
+	if err == nil {
+		err = s.deliver(target, email, code)
+	}
+	if err != nil {
+		// Nothing reached the user, let them retry at once
+		release()
+		return err
+	}
+	return nil
+}
+
+// Throttle starts the send cooldowns of target and of the requesting IP,
+// or returns ErrOTPCooldown while one is running. Other messages sent to
+// users on request, such as reset links, share the cooldowns. release ends
+// them early, when nothing could be sent after all.
+func (s *OTPService) Throttle(ctx context.Context, target, ip string) (release func(), err error) {
+	keys := []string{s.cooldownKey("target:" + target)}
+	args := []interface{}{s.opts.TargetCooldown.Milliseconds()}
+	if ip != "" {
+		keys = append(keys, s.cooldownKey("ip:"+ip))
+		args = append(args, s.opts.IPCooldown.Milliseconds())
+	}
+	cooling, err := cooldownScript.Run(ctx, s.rdb, keys, args...).Int()
+	if err != nil {
+		return nil, err
+	}
+	if cooling == 1 {
+		return nil, ErrOTPCooldown
+	}
+	return func() { s.rdb.Del(context.Background(), keys...) }, nil
+}
+
+// Verify checks a code issued for a purpose and consumes it. Each failure
+// counts towards the lockout of the target; once locked, the pending code
+// is burned and every check fails until the lockout window ends.
+func (s *OTPService) Verify(ctx context.Context, purpose, target, code string) error {
+	if !validPurpose(purpose) {
+		return ErrOTPPurpose
+	}
+	target, err := NormalizeTarget(target)
+	if err != nil {
+		return err
+	}
+	return s.verify(ctx, purpose, target, code)
+}
+
+func (s *OTPService) generate(ctx context.Context, purpose, target string, length int, ttl time.Duration) (string, error) {
 	if length <= 0 {
 		return "", fmt.Errorf("invalid length")
 	}
@@ -42,7 +265,7 @@ func (s *OTPService) Generate(ctx context.Context, target string, length int, tt
 		code += n.String()
 	}
 
-	key := s.key(target)
+	key := s.key(purpose, target)
 	if err := s.rdb.Set(ctx, key, code, ttl).Err(); err != nil {
 		return "", err
 	}
@@ -50,23 +273,107 @@ func (s *OTPService) Generate(ctx context.Context, target string, length int, tt
 	return code, nil
 }
 
-// Check validates the OTP code. Returns true if valid and deletes the key.
-func (s *OTPService) Check(ctx context.Context, target, code string) bool {
-	key := s.key(target)
-	val, err := s.rdb.Get(ctx, key).Result()
+func (s *OTPService) verify(ctx context.Context, purpose, target, code string) error {
+	if code == "" {
+		return ErrOTPInvalid
+	}
+	res, err := verifyScript.Run(ctx, s.rdb,
+		[]string{s.key(purpose, target), s.failKey(purpose, target)},
+		code, s.opts.MaxAttempts, s.opts.Lockout.Milliseconds()).Int()
 	if err != nil {
-		return false // Key doesn't exist or redis error
+		return err
+	}
+	switch res {
+	case 1:
+		return nil
+	case -1:
+		return ErrOTPLocked
+	default:
+		return ErrOTPInvalid
 	}
+}
 
-	if val == code {
-		// Valid, consume it
-		s.rdb.Del(ctx, key)
-		return true
+func (s *OTPService) locked(ctx context.Context, purpose, target string) (bool, error) {
+	fails, err := s.rdb.Get(ctx, s.failKey(purpose, target)).Int()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
 	}
+	return fails >= s.opts.MaxAttempts, err
+}
 
-	return false
+func (s *OTPService) deliver(target string, email bool, code string) error {
+	minutes := int(s.opts.TTL / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	text := fmt.Sprintf("Your verification code is %s, valid for %d minutes.", code, minutes)
+	if email {
+		return s.mail.Send(target, "Your verification code", text)
+	}
+	if s.opts.SMSTemplate != "" {
+		return s.sms.SendTemplate(target, s.opts.SMSTemplate, map[string]string{"code": code})
+	}
+	return s.sms.Send(target, text)
 }
 
-func (s *OTPService) key(target string) string {
-	return fmt.Sprintf("verify:otp:%s", target)
+func (s *OTPService) key(purpose, target string) string {
+	if purpose == "" {
+		return fmt.Sprintf("verify:otp:%s", target)
+	}
+	return fmt.Sprintf("verify:otp:%s:%s", purpose, target)
+}
+
+func (s *OTPService) failKey(purpose, target string) string {
+	return "verify:otp:fail:" + strings.TrimPrefix(s.key(purpose, target), "verify:otp:")
+}
+
+func (s *OTPService) cooldownKey(id string) string {
+	return "verify:otp:cooldown:" + id
+}
+
+func validPurpose(purpose string) bool {
+	switch purpose {
+	case PurposeLogin, PurposeRegister, PurposeReset, PurposeBind:
+		return true
+	}
+	return false
 }
+
+// verifyScript checks a code and counts failures in one step, so parallel
+// guesses cannot slip past the attempt limit.
+// Returns 1 on match, 0 on mismatch, -1 when locked.
+var verifyScript = redis.NewScript(`
+local max = tonumber(ARGV[2])
+local fails = tonumber(redis.call('GET', KEYS[2]) or '0')
+if fails >= max then
+	return -1
+end
+local code = redis.call('GET', KEYS[1])
+if code and code == ARGV[1] then
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return 1
+end
+fails = redis.call('INCR', KEYS[2])
+if fails == 1 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+end
+if fails >= max then
+	redis.call('DEL', KEYS[1])
+	return -1
+end
+return 0
+`)
+
+// cooldownScript starts the cooldowns of every key, or of none when one is
+// still running. Returns 1 when cooling down.
+var cooldownScript = redis.NewScript(`
+for i = 1, #KEYS do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		return 1
+	end
+end
+for i = 1, #KEYS do
+	redis.call('SET', KEYS[i], '1', 'PX', ARGV[i])
+end
+return 0
+`)
@@ -5,12 +5,16 @@
 package coupon
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
+
 	"appsite-go/internal/services/commerce/entity"
+	"appsite-go/pkg/utils/orm"
+	appsite_redis "appsite-go/pkg/utils/redis"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 var (
@@ -22,16 +26,44 @@ var (
 	ErrCouponUsed     = errors.New("coupon already used or invalid")
 )
 
+// lockTimeout bounds a locked issuance, waiting for the lock included
+const lockTimeout = 10 * time.Second
+
 type Service struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *goredis.Client
 }
 
 func NewService(db *gorm.DB) *Service {
 	return &Service{db: db}
 }
 
-func (s *Service) Migrate() error {
-	return s.db.AutoMigrate(&entity.Coupon{}, &entity.UserCoupon{})
+// WithLocker returns the service serializing issuance per coupon with a
+// Redis lock, for replicated setups where a row lock does not cover every writer
+func (s *Service) WithLocker(rdb *goredis.Client) *Service {
+	return &Service{db: s.db, rdb: rdb}
+}
+
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every coupon query is scoped to that tenant
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db:  s.db.WithContext(ctx),
+		rdb: s.rdb,
+	}
+}
+
+// locked runs fn under the coupon lock when a locker is configured, passing
+// the fencing token of the lock, 0 without one
+func (s *Service) locked(couponID string, fn func(db *gorm.DB, token int64) error) error {
+	if s.rdb == nil {
+		return fn(s.db, 0)
+	}
+	ctx, cancel := context.WithTimeout(s.db.Statement.Context, lockTimeout)
+	defer cancel()
+	return appsite_redis.WithLock(ctx, s.rdb, "lock:coupon:"+couponID, func(ctx context.Context, token int64) error {
+		return fn(s.db.WithContext(ctx), token)
+	})
 }
 
 // CreateCoupon creates a new coupon rule
@@ -45,11 +77,21 @@ func (s *Service) Issue(getUserID func() string, couponID string) (*entity.UserC
 	now := time.Now().Unix()
 
 	var uc *entity.UserCoupon
+	err := s.locked(couponID, func(db *gorm.DB, token int64) error {
+		var err error
+		uc, err = s.issue(db, token, userId, couponID, now)
+		return err
+	})
+	return uc, err
+}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+func (s *Service) issue(db *gorm.DB, token int64, userId, couponID string, now int64) (*entity.UserCoupon, error) {
+	var uc *entity.UserCoupon
+
+	err := db.Transaction(func(tx *gorm.DB) error {
 		var c entity.Coupon
 		// Lock the coupon row for atomic counter update
-		if err := tx.Clauses(clauseLocking).First(&c, "id = ?", couponID).Error; err != nil {
+		if err := tx.Scopes(orm.ForUpdate()).First(&c, "id = ?", couponID).Error; err != nil {
 			return err
 		}
 
@@ -73,9 +115,14 @@ func (s *Service) Issue(getUserID func() string, couponID string) (*entity.UserC
 			return ErrAlreadyTaken
 		}
 
-		// Update taken count
-		if err := tx.Model(&c).Update("taken_count", gorm.Expr("taken_count + 1")).Error; err != nil {
-			return err
+		// Update taken count, unless a later lock holder got to the coupon first
+		updates := map[string]interface{}{"taken_count": gorm.Expr("taken_count + 1")}
+		res := tx.Model(&c).Scopes(orm.Fenced(token, updates)).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return orm.ErrFenced
 		}
 
 		// Create UserCoupon
@@ -171,10 +218,72 @@ func (s *Service) Use(userCouponID string, orderID string) error {
 	return nil
 }
 
-// Helper for locking used in Issue
-// We need to define clauseLocking safely. 
-// Standard GORM clause.Locking{Strength: "UPDATE"} works for Postgres/MySQL.
-// For SQLite, it's ignored but harmless, or we can look it up.
-// Let's use a raw query logic or safe generic provided by GORM.
+// Redeem marks the user's coupon used by the order. Called with the
+// transaction creating the order, a coupon is never spent twice: the
+// second order fails with ErrCouponUsed and is rolled back. Coupons
+// outside the validity window of their rule fail with ErrCouponExpired,
+// whether or not ExpireUserCoupons got to them yet.
+func (s *Service) Redeem(userCouponID, userID, orderID string) error {
+	var uc entity.UserCoupon
+	if err := s.db.First(&uc, "id = ? AND user_id = ?", userCouponID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCouponUsed
+		}
+		return err
+	}
+	if uc.Status != "unused" {
+		return ErrCouponUsed
+	}
+	var c entity.Coupon
+	if err := s.db.First(&c, "id = ?", uc.CouponID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCouponNotFound
+		}
+		return err
+	}
+	now := time.Now().Unix()
+	if now < c.StartTime || (c.EndTime > 0 && now > c.EndTime) {
+		return ErrCouponExpired
+	}
+
+	res := s.db.Model(&entity.UserCoupon{}).
+		Where("id = ? AND user_id = ? AND status = ?", userCouponID, userID, "unused").
+		Updates(map[string]interface{}{
+			"status":   "used",
+			"used_at":  time.Now().Unix(),
+			"order_id": orderID,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrCouponUsed
+	}
+	return nil
+}
+
+// Release gives back the coupon redeemed by an order that was closed
+// unpaid. Called with the transaction closing the order, the coupon is
+// unused again exactly when the order is closed.
+func (s *Service) Release(userCouponID, orderID string) error {
+	return s.db.Model(&entity.UserCoupon{}).
+		Where("id = ? AND order_id = ? AND status = ?", userCouponID, orderID, "used").
+		Updates(map[string]interface{}{
+			"status":   "unused",
+			"used_at":  0,
+			"order_id": "",
+		}).Error
+}
+
+// ExpireUserCoupons marks unused coupons whose rule has ended as expired
+// and returns how many were expired
+func (s *Service) ExpireUserCoupons(ctx context.Context) (int64, error) {
+	ended := s.db.WithContext(ctx).Unscoped().Model(&entity.Coupon{}).
+		Select("id").
+		Where("end_time > 0 AND end_time < ?", time.Now().Unix())
 
-var clauseLocking = clause.Locking{Strength: "UPDATE"}
+	res := s.db.WithContext(ctx).Model(&entity.UserCoupon{}).
+		Where("status = ? AND coupon_id IN (?)", "unused", ended).
+		Update("status", "expired")
+	return res.RowsAffected, res.Error
+}
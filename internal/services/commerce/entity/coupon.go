@@ -10,6 +10,7 @@ import "appsite-go/internal/core/model"
 type Coupon struct {
 	model.Base
 	model.Tenant
+	model.SoftDelete
 
 	Title       string `json:"title" gorm:"type:varchar(64);not null"`
 	Description string `json:"description" gorm:"type:varchar(255)"`
@@ -24,6 +25,7 @@ type Coupon struct {
 	
 	TotalCount  int    `json:"total_count" gorm:"default:-1;comment:-1 unlimited"`
 	TakenCount  int    `json:"taken_count" gorm:"default:0"`
+	LockFence   int64  `json:"-" gorm:"not null;default:0;comment:Fencing token of the last locked issuance"`
 	
 	Status      string `json:"status" gorm:"type:varchar(16);default:'enabled'"`
 }
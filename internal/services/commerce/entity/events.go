@@ -0,0 +1,38 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package entity
+
+// OrderCreated is published when an order is placed
+type OrderCreated struct {
+	OrderID      string `json:"order_id"`
+	OrderNo      string `json:"order_no"`
+	UserID       string `json:"user_id"`
+	SaasID       string `json:"saas_id"`
+	PayAmount    int64  `json:"pay_amount"`
+	UserCouponID string `json:"user_coupon_id,omitempty"`
+}
+
+// EventName implements event.Event
+func (OrderCreated) EventName() string { return "order.created" }
+
+// EventTenant implements event.TenantEvent
+func (e OrderCreated) EventTenant() string { return e.SaasID }
+
+// OrderPaid is published when an order moves from pending to paid
+type OrderPaid struct {
+	OrderID       string `json:"order_id"`
+	OrderNo       string `json:"order_no"`
+	UserID        string `json:"user_id"`
+	SaasID        string `json:"saas_id"`
+	PayAmount     int64  `json:"pay_amount"`
+	PayMethod     string `json:"pay_method"`
+	TransactionID string `json:"transaction_id"`
+}
+
+// EventName implements event.Event
+func (OrderPaid) EventName() string { return "order.paid" }
+
+// EventTenant implements event.TenantEvent
+func (e OrderPaid) EventTenant() string { return e.SaasID }
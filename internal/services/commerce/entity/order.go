@@ -16,6 +16,7 @@ type Order struct {
 	TotalAmount int64  `json:"total_amount" gorm:"comment:Sum of item prices"`
 	PayAmount   int64  `json:"pay_amount" gorm:"comment:Final amount to pay"`
 	Discount    int64  `json:"discount" gorm:"comment:Discount applied"`
+	UserCouponID string `json:"user_coupon_id" gorm:"type:varchar(36);index;comment:Coupon redeemed by this order"`
 	
 	Status      string `json:"status" gorm:"type:varchar(16);index;default:'pending'"` 
 	// pending, paid, shipping, done, closed, refunded
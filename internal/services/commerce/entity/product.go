@@ -13,6 +13,8 @@ import (
 type Product struct {
 	model.Base
 	model.Tenant
+	model.Versioned
+	model.SoftDelete
 
 	Title      string  `json:"title" gorm:"type:varchar(128);not null;index"`
 	SubTitle   string  `json:"sub_title" gorm:"type:varchar(255)"`
@@ -20,7 +22,7 @@ type Product struct {
 	BrandID    string  `json:"brand_id" gorm:"type:varchar(36);index"`
 	
 	Cover      string  `json:"cover" gorm:"type:varchar(255)"`
-	Images     dbs.Map `json:"images" gorm:"type:json"` // Array of strings
+	Images     dbs.Map `json:"images"` // Array of strings
 	
 	Price      int64   `json:"price" gorm:"comment:Display Price in cents"`
 	MarketPrice int64  `json:"market_price" gorm:"comment:Original Price"`
@@ -28,7 +30,7 @@ type Product struct {
 	Status     string  `json:"status" gorm:"type:varchar(16);default:'offline';index"` // on_sale, offline, sold_out
 	
 	Content    string  `json:"content" gorm:"type:text"`
-	Specs      dbs.Map `json:"specs" gorm:"type:json;comment:Specification Template"` // e.g. [{"name":"Color", "values":["Red","Blue"]}]
+	Specs      dbs.Map `json:"specs" gorm:"comment:Specification Template"` // e.g. [{"name":"Color", "values":["Red","Blue"]}]
 }
 
 // TableName returns table name
@@ -40,6 +42,7 @@ func (Product) TableName() string {
 type SKU struct {
 	model.Base
 	model.Tenant
+	model.SoftDelete
 
 	ProductID string  `json:"product_id" gorm:"type:varchar(36);index;not null"`
 	Code      string  `json:"code" gorm:"type:varchar(64);index;comment:Unique SKU Code"`
@@ -50,8 +53,9 @@ type SKU struct {
 	Price     int64   `json:"price" gorm:"not null"`
 	CostPrice int64   `json:"cost_price"`
 	Stock     int     `json:"stock" gorm:"default:0"`
+	LockFence int64   `json:"-" gorm:"not null;default:0;comment:Fencing token of the last locked stock change"`
 	
-	Specs     dbs.Map `json:"specs" gorm:"type:json;comment:Specific Spec Values"` // e.g. {"Color":"Red", "Size":"XL"}
+	Specs     dbs.Map `json:"specs" gorm:"comment:Specific Spec Values"` // e.g. {"Color":"Red", "Size":"XL"}
 	
 	Status    string  `json:"status" gorm:"type:varchar(16);default:'enabled'"`
 }
@@ -5,13 +5,16 @@
 package order
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/services/commerce/coupon"
 	"appsite-go/internal/services/commerce/entity"
+	"appsite-go/pkg/utils/orm"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // Status constants
@@ -37,6 +40,14 @@ func NewService(db *gorm.DB) *Service {
 	return &Service{db: db}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every order query is scoped to that tenant
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db: s.db.WithContext(ctx),
+	}
+}
+
 // Create places a new order. 
 // Note: Stock deduction should be done in the same transaction by the caller or we can inject StockService here.
 // For flexibility, this method just creates the order record. Caller orchestrates the transaction.
@@ -62,16 +73,33 @@ func (s *Service) Create(tx *gorm.DB, order *entity.Order, items []entity.OrderI
 				return err
 			}
 		}
-		return nil
+
+		// 3. Redeem the coupon with the order, so it cannot pay for two
+		if order.UserCouponID != "" {
+			if err := coupon.NewService(t).Redeem(order.UserCouponID, order.UserID, order.ID); err != nil {
+				return err
+			}
+		}
+
+		// 4. Announce it
+		return event.Publish(t, entity.OrderCreated{
+			OrderID:      order.ID,
+			OrderNo:      order.OrderNo,
+			UserID:       order.UserID,
+			SaasID:       order.SaasID,
+			PayAmount:    order.PayAmount,
+			UserCouponID: order.UserCouponID,
+		})
 	})
 }
 
-// transition updates status with FSM check
-func (s *Service) transition(orderID string, targetStatus string, check func(current string) bool, updates map[string]interface{}) error {
+// transition updates status with FSM check.
+// after, if set, runs in the same transaction once the order is updated.
+func (s *Service) transition(orderID string, targetStatus string, check func(current string) bool, updates map[string]interface{}, after func(tx *gorm.DB, o *entity.Order) error) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		var o entity.Order
 		// Lock row
-		if err := tx.Clauses(clauseLocking).First(&o, "id = ?", orderID).Error; err != nil {
+		if err := tx.Scopes(orm.ForUpdate()).First(&o, "id = ?", orderID).Error; err != nil {
 			return err
 		}
 
@@ -84,7 +112,13 @@ func (s *Service) transition(orderID string, targetStatus string, check func(cur
 		}
 		updates["status"] = targetStatus
 
-		return tx.Model(&o).Updates(updates).Error
+		if err := tx.Model(&o).Updates(updates).Error; err != nil {
+			return err
+		}
+		if after != nil {
+			return after(tx, &o)
+		}
+		return nil
 	})
 }
 
@@ -95,7 +129,16 @@ func (s *Service) Pay(orderID string, transactionID string) error {
 	}, map[string]interface{}{
 		"pay_method":     "simulated", // dynamic in real world
 		"transaction_id": transactionID,
-		"pay_amount":     0, // In real world update with actual paid
+	}, func(tx *gorm.DB, o *entity.Order) error {
+		return event.Publish(tx, entity.OrderPaid{
+			OrderID:       o.ID,
+			OrderNo:       o.OrderNo,
+			UserID:        o.UserID,
+			SaasID:        o.SaasID,
+			PayAmount:     o.PayAmount,
+			PayMethod:     o.PayMethod,
+			TransactionID: o.TransactionID,
+		})
 	})
 }
 
@@ -103,21 +146,52 @@ func (s *Service) Pay(orderID string, transactionID string) error {
 func (s *Service) Ship(orderID string) error {
 	return s.transition(orderID, StatusShipping, func(curr string) bool {
 		return curr == StatusPaid
-	}, nil)
+	}, nil, nil)
 }
 
 // Confirm marks order as done (received)
 func (s *Service) Confirm(orderID string) error {
 	return s.transition(orderID, StatusDone, func(curr string) bool {
 		return curr == StatusShipping
-	}, nil)
+	}, nil, nil)
 }
 
-// Cancel marks order as closed (only if pending)
+// Cancel marks order as closed (only if pending) and gives its coupon back
 func (s *Service) Cancel(orderID string) error {
 	return s.transition(orderID, StatusClosed, func(curr string) bool {
 		return curr == StatusPending
-	}, nil)
+	}, nil, func(tx *gorm.DB, o *entity.Order) error {
+		if o.UserCouponID == "" {
+			return nil
+		}
+		return coupon.NewService(tx).Release(o.UserCouponID, o.ID)
+	})
 }
 
-var clauseLocking = clause.Locking{Strength: "UPDATE"}
+// CloseUnpaid closes the orders still pending since before cutoff and
+// returns how many were closed. Orders paid meanwhile are left alone.
+func (s *Service) CloseUnpaid(ctx context.Context, cutoff time.Time) (int, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Model(&entity.Order{}).
+		Where("status = ? AND created_at < ?", StatusPending, cutoff.Unix()).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return closed, err
+		}
+		err := s.WithContext(ctx).Cancel(id)
+		if errors.Is(err, ErrInvalidState) {
+			continue
+		}
+		if err != nil {
+			return closed, err
+		}
+		closed++
+	}
+	return closed, nil
+}
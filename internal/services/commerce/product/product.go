@@ -5,6 +5,8 @@
 package product
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 
 	"appsite-go/internal/core/model"
@@ -20,9 +22,6 @@ type Service struct {
 
 // NewService initializes the service
 func NewService(db *gorm.DB) *Service {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Product{}, &entity.SKU{})
-	}
 	return &Service{
 		db:      db,
 		repo:    model.NewCRUD[entity.Product](db),
@@ -30,6 +29,16 @@ func NewService(db *gorm.DB) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every product and SKU query is scoped to that tenant
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db:      s.db.WithContext(ctx),
+		repo:    s.repo.WithContext(ctx),
+		skuRepo: s.skuRepo.WithContext(ctx),
+	}
+}
+
 // CreateProduct adds a new SPU
 func (s *Service) CreateProduct(p *entity.Product) error {
 	res := s.repo.Add(p)
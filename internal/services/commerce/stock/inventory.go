@@ -5,11 +5,16 @@
 package stock
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
 	"appsite-go/internal/services/commerce/entity"
+	"appsite-go/pkg/utils/orm"
+	appsite_redis "appsite-go/pkg/utils/redis"
 )
 
 var (
@@ -17,38 +22,78 @@ var (
 	ErrInvalidQuantity   = errors.New("invalid quantity")
 )
 
+// lockTimeout bounds a locked stock change, waiting for the lock included
+const lockTimeout = 10 * time.Second
+
 // InventoryService handles stock operations
 type InventoryService struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *goredis.Client
 }
 
 // NewInventoryService initializes the service
 func NewInventoryService(db *gorm.DB) *InventoryService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.StockLog{})
-	}
 	return &InventoryService{db: db}
 }
 
+// WithLocker returns the service serializing stock changes per SKU with a
+// Redis lock, for replicated setups where a row lock does not cover every writer
+func (s *InventoryService) WithLocker(rdb *goredis.Client) *InventoryService {
+	return &InventoryService{db: s.db, rdb: rdb}
+}
+
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every SKU query is scoped to that tenant
+func (s *InventoryService) WithContext(ctx context.Context) *InventoryService {
+	return &InventoryService{
+		db:  s.db.WithContext(ctx),
+		rdb: s.rdb,
+	}
+}
+
+// locked runs fn under the SKU lock when a locker is configured, passing
+// the fencing token of the lock, 0 without one
+func (s *InventoryService) locked(skuID string, fn func(db *gorm.DB, token int64) error) error {
+	if s.rdb == nil {
+		return fn(s.db, 0)
+	}
+	ctx, cancel := context.WithTimeout(s.db.Statement.Context, lockTimeout)
+	defer cancel()
+	return appsite_redis.WithLock(ctx, s.rdb, "lock:sku:"+skuID, func(ctx context.Context, token int64) error {
+		return fn(s.db.WithContext(ctx), token)
+	})
+}
+
 // Deduct reduces stock for an SKU. Safe concurrent update.
 func (s *InventoryService) Deduct(skuID string, qty int, orderID string) error {
 	if qty <= 0 {
 		return ErrInvalidQuantity
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	return s.locked(skuID, func(db *gorm.DB, token int64) error {
+		return s.deduct(db, token, skuID, qty, orderID)
+	})
+}
+
+func (s *InventoryService) deduct(db *gorm.DB, token int64, skuID string, qty int, orderID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
 		// 1. Check and Update atomically
 		// GORM Update with expression
 		// UPDATE shop_sku SET stock = stock - ?, updated_at = ? WHERE id = ? AND stock >= ?
 		
+		updates := map[string]interface{}{"stock": gorm.Expr("stock - ?", qty)}
 		res := tx.Model(&entity.SKU{}).
 			Where("id = ? AND stock >= ?", skuID, qty).
-			Update("stock", gorm.Expr("stock - ?", qty))
+			Scopes(orm.Fenced(token, updates)).
+			Updates(updates)
 
 		if res.Error != nil {
 			return res.Error
 		}
 		if res.RowsAffected == 0 {
+			if err := orm.CheckFence(tx, &entity.SKU{}, skuID, token); err != nil {
+				return err
+			}
 			// Either ID not found or partial check failed (stock < qty)
 			// We can check existence separately if we want distinct errors, 
 			// but for high throughput, assume insufficient stock.
@@ -85,15 +130,26 @@ func (s *InventoryService) Restore(skuID string, qty int, orderID string) error
 		return ErrInvalidQuantity
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	return s.locked(skuID, func(db *gorm.DB, token int64) error {
+		return s.restore(db, token, skuID, qty, orderID)
+	})
+}
+
+func (s *InventoryService) restore(db *gorm.DB, token int64, skuID string, qty int, orderID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"stock": gorm.Expr("stock + ?", qty)}
 		res := tx.Model(&entity.SKU{}).
 			Where("id = ?", skuID).
-			Update("stock", gorm.Expr("stock + ?", qty))
+			Scopes(orm.Fenced(token, updates)).
+			Updates(updates)
 		
 		if res.Error != nil {
 			return res.Error
 		}
 		if res.RowsAffected == 0 {
+			if err := orm.CheckFence(tx, &entity.SKU{}, skuID, token); err != nil {
+				return err
+			}
 			return errors.New("sku not found")
 		}
 
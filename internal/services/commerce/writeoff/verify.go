@@ -5,6 +5,8 @@
 package writeoff
 
 import (
+	"context"
+
 	"errors"
 
 	"appsite-go/internal/services/commerce/coupon"
@@ -26,6 +28,13 @@ func NewService(cSvc *coupon.Service) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		couponSvc: s.couponSvc.WithContext(ctx),
+	}
+}
+
 // VerifyCouponCode checks if a coupon code (ID) is valid for write-off.
 // Unlike rule.Verify which checks for 'Apply' (min spend etc), this checks for 'Redemption' (existence, status).
 func (s *Service) VerifyCouponCode(code string) (*entity.UserCoupon, *entity.Coupon, error) {
@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,25 +17,34 @@ type ArticleService struct {
 
 // NewArticleService initializes the service
 func NewArticleService(db *gorm.DB) *ArticleService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Article{})
-	}
 	return &ArticleService{
 		db:   db,
 		repo: model.NewCRUD[entity.Article](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every article query is scoped to that tenant
+func (s *ArticleService) WithContext(ctx context.Context) *ArticleService {
+	return &ArticleService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new article
 func (s *ArticleService) Create(article *entity.Article) error {
 	res := s.repo.Add(article)
 	return res.Error
 }
 
-// Update modifies an existing article
-func (s *ArticleService) Update(id string, updates map[string]interface{}) error {
+// Update modifies an existing article and returns it as written
+func (s *ArticleService) Update(id string, updates map[string]interface{}) (*entity.Article, error) {
 	res := s.repo.Update(id, updates)
-	return res.Error
+	if !res.Success {
+		return nil, res.Error
+	}
+	return res.Data.(*entity.Article), nil
 }
 
 // Delete removes an article
@@ -42,6 +53,23 @@ func (s *ArticleService) Delete(id string) error {
 	return res.Error
 }
 
+// Restore brings back a deleted article
+func (s *ArticleService) Restore(id string) error {
+	res := s.repo.Restore(id)
+	return res.Error
+}
+
+// Purge permanently removes a deleted article
+func (s *ArticleService) Purge(id string) error {
+	res := s.repo.Purge(id)
+	return res.Error
+}
+
+// Trashed returns deleted articles matching the given list params
+func (s *ArticleService) Trashed(params *model.ListParams) (*model.Page[entity.Article], error) {
+	return model.PageOf[entity.Article](s.repo.ListTrashed(params))
+}
+
 // Get retrieves a single article by ID
 func (s *ArticleService) Get(id string) (*entity.Article, error) {
 	res := s.repo.Get(id)
@@ -53,30 +81,24 @@ func (s *ArticleService) Get(id string) (*entity.Article, error) {
 
 // List returns articles with filters
 func (s *ArticleService) List(page, size int, filters map[string]interface{}) ([]entity.Article, int64, error) {
-	// Handle special filters if necessary (e.g. searching by title)
-	// For now, simple equality checks provided by CRUD are likely default, 
-	// but if we need LIKE searches for Title, we might need custom logic or extended ListParams.
-	// Assuming CRUD.List handles standard map filters. If we need "Title LIKE %query%", 
-	// we'd add it to the query manually or use a helper. 
-	// Given the context so far, we rely on the implementation of model.CRUD.
-	// We'll pass standard filters.
-	
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Article)
-	total := data["total"].(int64)
+// Query returns articles matching the given list params
+func (s *ArticleService) Query(params *model.ListParams) (*model.Page[entity.Article], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Article](s.repo.List(params))
 }
 
 // IncrementView increases the view count for an article
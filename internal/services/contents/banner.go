@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,25 +17,34 @@ type BannerService struct {
 
 // NewBannerService initializes the service
 func NewBannerService(db *gorm.DB) *BannerService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Banner{})
-	}
 	return &BannerService{
 		db:   db,
 		repo: model.NewCRUD[entity.Banner](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every banner query is scoped to that tenant
+func (s *BannerService) WithContext(ctx context.Context) *BannerService {
+	return &BannerService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new banner
 func (s *BannerService) Create(banner *entity.Banner) error {
 	res := s.repo.Add(banner)
 	return res.Error
 }
 
-// Update modifies an existing banner
-func (s *BannerService) Update(id string, updates map[string]interface{}) error {
+// Update modifies an existing banner and returns it as written
+func (s *BannerService) Update(id string, updates map[string]interface{}) (*entity.Banner, error) {
 	res := s.repo.Update(id, updates)
-	return res.Error
+	if !res.Success {
+		return nil, res.Error
+	}
+	return res.Data.(*entity.Banner), nil
 }
 
 // Delete removes a banner
@@ -42,6 +53,23 @@ func (s *BannerService) Delete(id string) error {
 	return res.Error
 }
 
+// Restore brings back a deleted banner
+func (s *BannerService) Restore(id string) error {
+	res := s.repo.Restore(id)
+	return res.Error
+}
+
+// Purge permanently removes a deleted banner
+func (s *BannerService) Purge(id string) error {
+	res := s.repo.Purge(id)
+	return res.Error
+}
+
+// Trashed returns deleted banners matching the given list params
+func (s *BannerService) Trashed(params *model.ListParams) (*model.Page[entity.Banner], error) {
+	return model.PageOf[entity.Banner](s.repo.ListTrashed(params))
+}
+
 // Get retrieves a single banner by ID
 func (s *BannerService) Get(id string) (*entity.Banner, error) {
 	res := s.repo.Get(id)
@@ -53,22 +81,24 @@ func (s *BannerService) Get(id string) (*entity.Banner, error) {
 
 // List returns banners with filters
 func (s *BannerService) List(page, size int, filters map[string]interface{}) ([]entity.Banner, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Banner)
-	total := data["total"].(int64)
+// Query returns banners matching the given list params
+func (s *BannerService) Query(params *model.ListParams) (*model.Page[entity.Banner], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Banner](s.repo.List(params))
 }
 
 // IncrementClick increases the click count for a banner
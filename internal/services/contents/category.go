@@ -5,6 +5,8 @@
 package contents
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 	
 	"appsite-go/internal/core/model"
@@ -19,15 +21,21 @@ type CategoryService struct {
 
 // NewCategoryService initializes the service
 func NewCategoryService(db *gorm.DB) *CategoryService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Category{})
-	}
 	return &CategoryService{
 		db:   db,
 		repo: model.NewCRUD[entity.Category](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every category query is scoped to that tenant
+func (s *CategoryService) WithContext(ctx context.Context) *CategoryService {
+	return &CategoryService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new category
 func (s *CategoryService) Create(cat *entity.Category) error {
 	res := s.repo.Add(cat)
@@ -57,19 +65,24 @@ func (s *CategoryService) Get(id string) (*entity.Category, error) {
 
 // List returns categories with filters
 func (s *CategoryService) List(page, size int, filters map[string]interface{}) ([]entity.Category, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-	
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
-	
-	data := res.Data.(map[string]interface{})
-	return data["list"].([]entity.Category), data["total"].(int64), nil
+	return res.List, res.Total, nil
+}
+
+// Query returns categories matching the given list params
+func (s *CategoryService) Query(params *model.ListParams) (*model.Page[entity.Category], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
+
+	return model.PageOf[entity.Category](s.repo.List(params))
 }
 
 // Tree returns a hierarchical structure of categories
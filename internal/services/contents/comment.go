@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,15 +17,20 @@ type CommentService struct {
 
 // NewCommentService initializes the service
 func NewCommentService(db *gorm.DB) *CommentService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Comment{})
-	}
 	return &CommentService{
 		db:   db,
 		repo: model.NewCRUD[entity.Comment](db),
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *CommentService) WithContext(ctx context.Context) *CommentService {
+	return &CommentService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new comment
 func (s *CommentService) Create(comment *entity.Comment) error {
 	res := s.repo.Add(comment)
@@ -53,20 +60,22 @@ func (s *CommentService) Get(id string) (*entity.Comment, error) {
 
 // List returns comments with filters
 func (s *CommentService) List(page, size int, filters map[string]interface{}) ([]entity.Comment, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Comment)
-	total := data["total"].(int64)
+// Query returns comments matching the given list params
+func (s *CommentService) Query(params *model.ListParams) (*model.Page[entity.Comment], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Comment](s.repo.List(params))
 }
@@ -8,6 +8,8 @@ import (
 // Article Article Entity
 type Article struct {
 	model.Base
+	model.Versioned
+	model.SoftDelete
 	CategoryID  string   `json:"category_id" gorm:"type:varchar(36);index"`
 	AuthorID    string   `json:"author_id" gorm:"type:varchar(36);index"`
 	AreaID      string   `json:"area_id" gorm:"type:varchar(36);index"`
@@ -17,13 +19,13 @@ type Article struct {
 	Mode        string   `json:"mode" gorm:"type:varchar(32)"`
 	Title       string   `json:"title" gorm:"type:varchar(255);not null;index"`
 	Cover       string   `json:"cover" gorm:"type:varchar(255)"`
-	Gallery     dbs.Slice       `json:"gallery" gorm:"type:json"`
-	Attachments dbs.Slice       `json:"attachments" gorm:"type:json"`
+	Gallery     dbs.Slice       `json:"gallery"`
+	Attachments dbs.Slice       `json:"attachments"`
 	Video       string          `json:"video" gorm:"type:varchar(255)"`
 	Link        string          `json:"link" gorm:"type:varchar(255)"`
-	Tags        dbs.StringArray `json:"tags" gorm:"type:json"`
+	Tags        dbs.StringArray `json:"tags"`
 	Description string   `json:"description" gorm:"type:varchar(255)"`
-	Introduce   string   `json:"introduce" gorm:"type:longtext"`
+	Introduce   dbs.Text `json:"introduce"`
 	ViewTimes   int      `json:"view_times" gorm:"default:0"`
 	Status      string   `json:"status" gorm:"type:varchar(32);default:'enabled';index"`
 	Featured    bool     `json:"featured" gorm:"default:false;index"`
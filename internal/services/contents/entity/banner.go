@@ -7,6 +7,8 @@ import (
 // Banner Banner Entity
 type Banner struct {
 	model.Base
+	model.Versioned
+	model.SoftDelete
 	SaasID     string `json:"saas_id" gorm:"type:varchar(36);index"`
 	Position   string `json:"position" gorm:"type:varchar(32);index"`
 	Title      string `json:"title" gorm:"type:varchar(255);not null"`
@@ -10,6 +10,7 @@ import "appsite-go/internal/core/model"
 type Category struct {
 	model.Base
 	model.Tenant
+	model.SoftDelete
 
 	Title       string `gorm:"size:64;index;comment:Category Name"`
 	Alias       string `gorm:"size:24;index;comment:Unique Alias/Slug"`
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/model"
+)
+
+// ArticlePublished is published when an article becomes visible,
+// either created enabled or switched to enabled
+type ArticlePublished struct {
+	ArticleID  string `json:"article_id"`
+	SaasID     string `json:"saas_id"`
+	AuthorID   string `json:"author_id"`
+	CategoryID string `json:"category_id"`
+	Title      string `json:"title"`
+}
+
+// EventName implements event.Event
+func (ArticlePublished) EventName() string { return "article.published" }
+
+// EventTenant implements event.TenantEvent
+func (e ArticlePublished) EventTenant() string { return e.SaasID }
+
+func (a *Article) published() event.Event {
+	return ArticlePublished{
+		ArticleID:  a.ID,
+		SaasID:     a.SaasID,
+		AuthorID:   a.AuthorID,
+		CategoryID: a.CategoryID,
+		Title:      a.Title,
+	}
+}
+
+// AfterAdd publishes ArticlePublished for articles created enabled
+func (a *Article) AfterAdd(tx *gorm.DB) error {
+	// An empty status takes the column default, which is enabled
+	if a.Status != "enabled" && a.Status != "" {
+		return nil
+	}
+	return event.Publish(tx, a.published())
+}
+
+// AfterUpdate publishes ArticlePublished when an update enables the article
+func (a *Article) AfterUpdate(tx *gorm.DB) error {
+	old, ok := model.Previous[Article](tx)
+	if !ok || old.Status == "enabled" || a.Status != "enabled" {
+		return nil
+	}
+	return event.Publish(tx, a.published())
+}
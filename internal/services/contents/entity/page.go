@@ -2,17 +2,20 @@ package entity
 
 import (
 	"appsite-go/internal/core/model"
+	"appsite-go/pkg/dbs"
 )
 
 // Page Page Entity
 type Page struct {
 	model.Base
-	Alias     string `json:"alias" gorm:"type:varchar(32);uniqueIndex"`
+	model.SoftDelete
+	Alias     string `json:"alias" gorm:"type:varchar(32);uniqueIndex:idx_item_page_live_alias,priority:1"`
+	DeletedKey string `json:"-" gorm:"type:varchar(32);not null;default:'';uniqueIndex:idx_item_page_live_alias,priority:2" filter:"-"` // model.TombstoneColumn
 	SaasID    string `json:"saas_id" gorm:"type:varchar(36);index"`
 	AuthorID  string `json:"author_id" gorm:"type:varchar(36);index"`
 	Title     string `json:"title" gorm:"type:varchar(64);not null"`
 	Cover     string `json:"cover" gorm:"type:varchar(255)"`
-	Introduce string `json:"introduce" gorm:"type:longtext"`
+	Introduce dbs.Text `json:"introduce"`
 	Status    string `json:"status" gorm:"type:varchar(32);default:'enabled';index"`
 	ViewTimes int    `json:"view_times" gorm:"default:0"`
 	Featured  bool   `json:"featured" gorm:"default:false;index"`
@@ -7,6 +7,7 @@ import (
 // Tag Tag Entity
 type Tag struct {
 	model.Base
+	model.SoftDelete
 	SaasID      string `json:"saas_id" gorm:"type:varchar(36);index"`
 	AuthorID    string `json:"author_id" gorm:"type:varchar(36);index"`
 	Type        string `json:"type" gorm:"type:varchar(32);index"`
@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,15 +17,21 @@ type MediaService struct {
 
 // NewMediaService initializes the service
 func NewMediaService(db *gorm.DB) *MediaService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Media{})
-	}
 	return &MediaService{
 		db:   db,
 		repo: model.NewCRUD[entity.Media](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every media query is scoped to that tenant
+func (s *MediaService) WithContext(ctx context.Context) *MediaService {
+	return &MediaService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new media record
 func (s *MediaService) Create(media *entity.Media) error {
 	res := s.repo.Add(media)
@@ -53,20 +61,22 @@ func (s *MediaService) Get(id string) (*entity.Media, error) {
 
 // List returns media records with filters
 func (s *MediaService) List(page, size int, filters map[string]interface{}) ([]entity.Media, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Media)
-	total := data["total"].(int64)
+// Query returns media records matching the given list params
+func (s *MediaService) Query(params *model.ListParams) (*model.Page[entity.Media], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Media](s.repo.List(params))
 }
@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,15 +17,21 @@ type PageService struct {
 
 // NewPageService initializes the service
 func NewPageService(db *gorm.DB) *PageService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Page{})
-	}
 	return &PageService{
 		db:   db,
 		repo: model.NewCRUD[entity.Page](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every page query is scoped to that tenant
+func (s *PageService) WithContext(ctx context.Context) *PageService {
+	return &PageService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new page
 func (s *PageService) Create(page *entity.Page) error {
 	res := s.repo.Add(page)
@@ -62,22 +70,24 @@ func (s *PageService) GetByAlias(alias string) (*entity.Page, error) {
 
 // List returns pages with filters
 func (s *PageService) List(page, size int, filters map[string]interface{}) ([]entity.Page, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Page)
-	total := data["total"].(int64)
+// Query returns pages matching the given list params
+func (s *PageService) Query(params *model.ListParams) (*model.Page[entity.Page], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Page](s.repo.List(params))
 }
 
 // IncrementView increases the view count for a page
@@ -1,6 +1,8 @@
 package contents
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/contents/entity"
 
@@ -15,15 +17,21 @@ type TagService struct {
 
 // NewTagService initializes the service
 func NewTagService(db *gorm.DB) *TagService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Tag{})
-	}
 	return &TagService{
 		db:   db,
 		repo: model.NewCRUD[entity.Tag](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every tag query is scoped to that tenant
+func (s *TagService) WithContext(ctx context.Context) *TagService {
+	return &TagService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create adds a new tag
 func (s *TagService) Create(tag *entity.Tag) error {
 	res := s.repo.Add(tag)
@@ -53,20 +61,22 @@ func (s *TagService) Get(id string) (*entity.Tag, error) {
 
 // List returns tags with filters
 func (s *TagService) List(page, size int, filters map[string]interface{}) ([]entity.Tag, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "sort desc, created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Tag)
-	total := data["total"].(int64)
+// Query returns tags matching the given list params
+func (s *TagService) Query(params *model.ListParams) (*model.Page[entity.Tag], error) {
+	if params.Sort == "" {
+		params.Sort = "sort desc, created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Tag](s.repo.List(params))
 }
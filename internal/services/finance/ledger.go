@@ -5,9 +5,13 @@
 package finance
 
 import (
+	"context"
+
 	"errors"
 
+	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/finance/entity"
+	"appsite-go/pkg/utils/orm"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -17,15 +21,24 @@ var (
 )
 
 type LedgerService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	deals *model.CRUD[entity.Deal]
 }
 
 func NewLedgerService(db *gorm.DB) *LedgerService {
-	return &LedgerService{db: db}
+	return &LedgerService{
+		db:    db,
+		deals: model.NewCRUD[entity.Deal](db),
+	}
 }
 
-func (s *LedgerService) Migrate() error {
-	return s.db.AutoMigrate(&entity.Deal{}, &entity.Balance{})
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every deal and balance query is scoped to that tenant
+func (s *LedgerService) WithContext(ctx context.Context) *LedgerService {
+	return &LedgerService{
+		db:    s.db.WithContext(ctx),
+		deals: s.deals.WithContext(ctx),
+	}
 }
 
 // EnsureBalance creates a balance record if not exists
@@ -33,7 +46,9 @@ func (s *LedgerService) ensureBalance(tx *gorm.DB, userID string, asset string)
 	var count int64
 	tx.Model(&entity.Balance{}).Where("user_id = ? AND asset = ?", userID, asset).Count(&count)
 	if count == 0 {
-		return tx.Create(&entity.Balance{
+		// A concurrent transaction may create the row first; the insert then
+		// becomes a no-op instead of aborting the transaction (PostgreSQL)
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.Balance{
 			UserID: userID,
 			Asset:  asset,
 			Total:  0,
@@ -45,42 +60,47 @@ func (s *LedgerService) ensureBalance(tx *gorm.DB, userID string, asset string)
 // RecordTransaction updates balance and logs a deal
 func (s *LedgerService) RecordTransaction(userID string, asset string, amount int64, dealType string, relatedID string, desc string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Ensure balance record
-		if err := s.ensureBalance(tx, userID, asset); err != nil {
-			return err
-		}
-
-		// 2. Lock and Update Balance
-		var bal entity.Balance
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("user_id = ? AND asset = ?", userID, asset).
-			First(&bal).Error; err != nil {
-			return err
-		}
-
-		newTotal := bal.Total + amount
-		if newTotal < 0 {
-			return ErrInsufficientFunds
-		}
-
-		if err := tx.Model(&bal).Update("total", newTotal).Error; err != nil {
-			return err
-		}
-
-		// 3. Create Deal record
-		deal := &entity.Deal{
-			UserID:      userID,
-			Asset:       asset,
-			Type:        dealType,
-			Amount:      amount,
-			Balance:     newTotal,
-			RelatedID:   relatedID,
-			Description: desc,
-		}
-		return tx.Create(deal).Error
+		return s.record(tx, userID, asset, amount, dealType, relatedID, desc)
 	})
 }
 
+// record updates balance and logs a deal within tx
+func (s *LedgerService) record(tx *gorm.DB, userID string, asset string, amount int64, dealType string, relatedID string, desc string) error {
+	// 1. Ensure balance record
+	if err := s.ensureBalance(tx, userID, asset); err != nil {
+		return err
+	}
+
+	// 2. Lock and Update Balance
+	var bal entity.Balance
+	if err := tx.Scopes(orm.ForUpdate()).
+		Where("user_id = ? AND asset = ?", userID, asset).
+		First(&bal).Error; err != nil {
+		return err
+	}
+
+	newTotal := bal.Total + amount
+	if newTotal < 0 {
+		return ErrInsufficientFunds
+	}
+
+	if err := tx.Model(&bal).Update("total", newTotal).Error; err != nil {
+		return err
+	}
+
+	// 3. Create Deal record
+	deal := &entity.Deal{
+		UserID:      userID,
+		Asset:       asset,
+		Type:        dealType,
+		Amount:      amount,
+		Balance:     newTotal,
+		RelatedID:   relatedID,
+		Description: desc,
+	}
+	return tx.Create(deal).Error
+}
+
 // GetBalance returns current balance
 func (s *LedgerService) GetBalance(userID string, asset string) (int64, error) {
 	var bal entity.Balance
@@ -93,16 +113,21 @@ func (s *LedgerService) GetBalance(userID string, asset string) (int64, error) {
 
 // ListDeals returns history
 func (s *LedgerService) ListDeals(userID string, asset string, page, size int) ([]entity.Deal, int64, error) {
-	var deals []entity.Deal
-	var total int64
-	
-	db := s.db.Model(&entity.Deal{}).Where("user_id = ?", userID)
-	if asset != "" {
-		db = db.Where("asset = ?", asset)
+	res, err := s.QueryDeals(userID, asset, &model.ListParams{Page: page, PageSize: size})
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	db.Count(&total)
-	
-	err := db.Order("created_at desc").Offset((page - 1) * size).Limit(size).Find(&deals).Error
-	return deals, total, err
+// QueryDeals returns history with offset or cursor pagination (see model.ListParams)
+func (s *LedgerService) QueryDeals(userID string, asset string, params *model.ListParams) (*model.Page[entity.Deal], error) {
+	params.Filters = map[string]interface{}{"user_id": userID}
+	if asset != "" {
+		params.Filters["asset"] = asset
+	}
+	if params.Sort == "" {
+		params.Sort = "created_at desc"
+	}
+	return model.PageOf[entity.Deal](s.deals.List(params))
 }
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package finance
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	commerce "appsite-go/internal/services/commerce/entity"
+	"appsite-go/internal/services/finance/entity"
+)
+
+// OrderRewardRate is the paid amount in cents earning one reward point
+const OrderRewardRate = 100
+
+// Subscribe registers the ledger's reactions to domain events:
+// a paid order credits the buyer with reward points.
+func (s *LedgerService) Subscribe(bus *event.Bus) {
+	event.On(bus, "finance.order_reward", s.rewardOrder)
+}
+
+// rewardOrder credits points for a paid order once, however often it is delivered
+func (s *LedgerService) rewardOrder(ctx context.Context, e commerce.OrderPaid) error {
+	points := e.PayAmount / OrderRewardRate
+	if points <= 0 {
+		return nil
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&entity.Deal{}).
+			Where("user_id = ? AND type = ? AND related_id = ?", e.UserID, "reward", e.OrderID).
+			Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+		return s.record(tx, e.UserID, "point", points, "reward", e.OrderID, "Order "+e.OrderNo)
+	})
+}
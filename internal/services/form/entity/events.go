@@ -0,0 +1,54 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/model"
+	"appsite-go/pkg/dbs"
+)
+
+// SubmissionReviewed is published when a pending request is applied or rejected.
+// Call carries the request's ApplyCall or RejectCall, for subscribers of
+// the request's ItemType to act on.
+type SubmissionReviewed struct {
+	RequestID string  `json:"request_id"`
+	SaasID    string  `json:"saas_id"`
+	UserID    string  `json:"user_id"`
+	ItemID    string  `json:"item_id"`
+	ItemType  string  `json:"item_type"`
+	Approved  bool    `json:"approved"`
+	Call      dbs.Map `json:"call,omitempty"`
+}
+
+// EventName implements event.Event
+func (SubmissionReviewed) EventName() string { return "form.reviewed" }
+
+// EventTenant implements event.TenantEvent
+func (e SubmissionReviewed) EventTenant() string { return e.SaasID }
+
+// AfterUpdate publishes SubmissionReviewed when a pending request is decided
+func (r *Request) AfterUpdate(tx *gorm.DB) error {
+	old, ok := model.Previous[Request](tx)
+	if !ok || old.Status != "pending" || r.Status == "pending" {
+		return nil
+	}
+
+	e := SubmissionReviewed{
+		RequestID: r.ID,
+		SaasID:    r.SaasID,
+		UserID:    r.UserID,
+		ItemID:    r.ItemID,
+		ItemType:  r.ItemType,
+		Approved:  r.Status == "applied",
+		Call:      r.RejectCall,
+	}
+	if e.Approved {
+		e.Call = r.ApplyCall
+	}
+	return event.Publish(tx, e)
+}
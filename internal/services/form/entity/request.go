@@ -13,11 +13,11 @@ type Request struct {
 	ItemID     string  `json:"item_id" gorm:"type:varchar(36);index"`
 	ItemType   string  `json:"item_type" gorm:"type:varchar(32);index"`
 	Open       bool    `json:"open" gorm:"default:false"`
-	Form       dbs.Map `json:"form" gorm:"type:json"`
+	Form       dbs.Map `json:"form"`
 	Status     string  `json:"status" gorm:"type:varchar(12);default:'pending';index"` // pending, applied, rejected
 	Expire     int64   `json:"expire" gorm:"default:0"`
-	ApplyCall  dbs.Map `json:"apply_call" gorm:"type:json"`
-	RejectCall dbs.Map `json:"reject_call" gorm:"type:json"`
+	ApplyCall  dbs.Map `json:"apply_call"`
+	RejectCall dbs.Map `json:"reject_call"`
 }
 
 // TableName table name
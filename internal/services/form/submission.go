@@ -1,6 +1,9 @@
 package form
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 
 	"appsite-go/internal/core/model"
@@ -15,15 +18,21 @@ type SubmissionService struct {
 
 // NewSubmissionService initializes the service
 func NewSubmissionService(db *gorm.DB) *SubmissionService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Request{})
-	}
 	return &SubmissionService{
 		db:   db,
 		repo: model.NewCRUD[entity.Request](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every submission query is scoped to that tenant
+func (s *SubmissionService) WithContext(ctx context.Context) *SubmissionService {
+	return &SubmissionService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Submit creates a new form request
 func (s *SubmissionService) Submit(req *entity.Request) error {
 	req.Status = "pending"
@@ -38,15 +47,11 @@ func (s *SubmissionService) Review(id string, approved bool) error {
 		status = "applied"
 	}
 
+	// Request.AfterUpdate publishes SubmissionReviewed with the ApplyCall/RejectCall
+	// in the same transaction; subscribers of the item type carry them out
 	res := s.repo.Update(id, map[string]interface{}{
 		"status": status,
 	})
-	
-	if res.Success {
-		// In a real system, we would trigger the ApplyCall/RejectCall hooks here
-		// For now, just recording the state change is sufficient for Phase 3
-	}
-
 	return res.Error
 }
 
@@ -61,20 +66,30 @@ func (s *SubmissionService) Get(id string) (*entity.Request, error) {
 
 // List retrieves requests with filters
 func (s *SubmissionService) List(page, size int, filters map[string]interface{}) ([]entity.Request, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "created_at desc",
 	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.List, res.Total, nil
+}
 
-	if !res.Success {
-		return nil, 0, res.Error
+// Query returns requests matching the given list params
+func (s *SubmissionService) Query(params *model.ListParams) (*model.Page[entity.Request], error) {
+	if params.Sort == "" {
+		params.Sort = "created_at desc"
 	}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Request)
-	total := data["total"].(int64)
+	return model.PageOf[entity.Request](s.repo.List(params))
+}
 
-	return list, total, nil
+// PurgeExpired deletes pending requests past their expiry and returns how many were deleted
+func (s *SubmissionService) PurgeExpired(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where("status = ? AND expire > 0 AND expire < ?", "pending", time.Now().Unix()).
+		Delete(&entity.Request{})
+	return res.RowsAffected, res.Error
 }
@@ -16,7 +16,7 @@ type Notification struct {
 	Status     string  `json:"status" gorm:"type:varchar(24);default:'sent'"` // sent, read
 	Content    string  `json:"content" gorm:"type:varchar(512)"`
 	Link       string  `json:"link" gorm:"type:varchar(255)"`
-	LinkParams dbs.Map `json:"link_params" gorm:"type:json"`
+	LinkParams dbs.Map `json:"link_params"`
 	LinkType   string  `json:"link_type" gorm:"type:varchar(16)"`
 }
 
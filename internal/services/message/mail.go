@@ -0,0 +1,45 @@
+package message
+
+import (
+	"context"
+
+	"appsite-go/internal/core/queue"
+	"appsite-go/pkg/extra/mail"
+)
+
+// MailJob is the job type delivering an email
+const MailJob = "mail.send"
+
+// mailPayload is the payload of a MailJob
+type mailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// QueuedMailer is a mail.Sender delivering from a background job, so a
+// slow mail server does not hold up the request and a failed delivery is
+// retried by the queue instead of lost
+type QueuedMailer struct {
+	jobs  *queue.Queue
+	queue string
+}
+
+// NewQueuedMailer registers the MailJob handler sending through mailer and
+// returns the sender enqueueing on the named queue
+func NewQueuedMailer(jobs *queue.Queue, name string, mailer mail.Sender) *QueuedMailer {
+	jobs.Handle(MailJob, func(_ context.Context, job *queue.Job) error {
+		var m mailPayload
+		if err := job.Decode(&m); err != nil {
+			return err
+		}
+		return mailer.Send(m.To, m.Subject, m.Body)
+	})
+	return &QueuedMailer{jobs: jobs, queue: name}
+}
+
+// Send implements mail.Sender; the mail is sent once a worker of the queue runs the job
+func (m *QueuedMailer) Send(to string, subject string, body string) error {
+	_, err := m.jobs.Enqueue(context.Background(), m.queue, MailJob, mailPayload{To: to, Subject: subject, Body: body})
+	return err
+}
@@ -1,29 +1,40 @@
 package message
 
 import (
+	"context"
+
 	"appsite-go/internal/core/model"
 	"appsite-go/internal/services/message/entity"
+	"appsite-go/pkg/extra/mail"
 
 	"gorm.io/gorm"
 )
 
 // NotificationService handles message operations
 type NotificationService struct {
-	db   *gorm.DB
-	repo *model.CRUD[entity.Notification]
+	db     *gorm.DB
+	repo   *model.CRUD[entity.Notification]
+	mailer mail.Sender
 }
 
 // NewNotificationService initializes the service
 func NewNotificationService(db *gorm.DB) *NotificationService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Notification{})
-	}
 	return &NotificationService{
 		db:   db,
 		repo: model.NewCRUD[entity.Notification](db),
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every notification query is scoped to that tenant
+func (s *NotificationService) WithContext(ctx context.Context) *NotificationService {
+	return &NotificationService{
+		db:     s.db.WithContext(ctx),
+		repo:   s.repo.WithContext(ctx),
+		mailer: s.mailer,
+	}
+}
+
 // Send sends a notification
 func (s *NotificationService) Send(senderID, receiverID, content, msgType string) error {
 	notification := &entity.Notification{
@@ -52,22 +63,24 @@ func (s *NotificationService) MarkAllAsRead(receiverID string) error {
 
 // List returns notifications with filters
 func (s *NotificationService) List(page, size int, filters map[string]interface{}) ([]entity.Notification, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "created_at desc",
 	})
-
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return res.List, res.Total, nil
+}
 
-	data := res.Data.(map[string]interface{})
-	list := data["list"].([]entity.Notification)
-	total := data["total"].(int64)
+// Query returns notifications matching the given list params
+func (s *NotificationService) Query(params *model.ListParams) (*model.Page[entity.Notification], error) {
+	if params.Sort == "" {
+		params.Sort = "created_at desc"
+	}
 
-	return list, total, nil
+	return model.PageOf[entity.Notification](s.repo.List(params))
 }
 
 // UnreadCount returns the count of unread notifications
@@ -0,0 +1,108 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"appsite-go/internal/core/event"
+	form "appsite-go/internal/services/form/entity"
+	"appsite-go/internal/services/message/entity"
+	user "appsite-go/internal/services/user/entity"
+	"appsite-go/pkg/extra/mail"
+)
+
+// SystemSender is the sender of notifications raised by the site itself
+const SystemSender = "system"
+
+// WithMailer also delivers security notices by email
+func (s *NotificationService) WithMailer(mailer mail.Sender) *NotificationService {
+	s.mailer = mailer
+	return s
+}
+
+// Subscribe registers the notices sent on domain events:
+// a password change warns the account owner, a reviewed request tells
+// its submitter the outcome.
+func (s *NotificationService) Subscribe(bus *event.Bus) {
+	event.On(bus, "message.password_notice", s.passwordNotice)
+	if s.mailer != nil {
+		event.On(bus, "message.password_mail", s.passwordMail)
+	}
+	event.On(bus, "message.review_notice", s.reviewNotice)
+}
+
+// passwordNotice notifies the owner in-app, once per change
+func (s *NotificationService) passwordNotice(ctx context.Context, e user.PasswordChanged) error {
+	link := fmt.Sprintf("password_changed:%d", e.ChangedAt)
+	var count int64
+	err := s.db.WithContext(ctx).Model(&entity.Notification{}).
+		Where("receiver_id = ? AND type = ? AND link = ?", e.UserID, "notify", link).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	return s.db.WithContext(ctx).Create(&entity.Notification{
+		SaasID:     e.SaasID,
+		SenderID:   SystemSender,
+		ReceiverID: e.UserID,
+		Type:       "notify",
+		Status:     "sent",
+		Content:    passwordText(e),
+		Link:       link,
+		LinkType:   "security",
+	}).Error
+}
+
+// reviewNotice tells the submitter whether their request was approved, once per request
+func (s *NotificationService) reviewNotice(ctx context.Context, e form.SubmissionReviewed) error {
+	if e.UserID == "" {
+		return nil
+	}
+	link := "form_reviewed:" + e.RequestID
+	var count int64
+	err := s.db.WithContext(ctx).Model(&entity.Notification{}).
+		Where("receiver_id = ? AND type = ? AND link = ?", e.UserID, "notify", link).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	outcome := "rejected"
+	if e.Approved {
+		outcome = "approved"
+	}
+	return s.db.WithContext(ctx).Create(&entity.Notification{
+		SaasID:     e.SaasID,
+		SenderID:   SystemSender,
+		ReceiverID: e.UserID,
+		Type:       "notify",
+		Status:     "sent",
+		Content:    fmt.Sprintf("Your %s request was %s.", e.ItemType, outcome),
+		Link:       link,
+		LinkParams: map[string]interface{}{"request_id": e.RequestID, "item_id": e.ItemID, "item_type": e.ItemType},
+		LinkType:   "form",
+	}).Error
+}
+
+// passwordMail warns the owner by email, reaching them even when the
+// account was taken over
+func (s *NotificationService) passwordMail(_ context.Context, e user.PasswordChanged) error {
+	if e.Email == "" {
+		return nil
+	}
+	return s.mailer.Send(e.Email, "Your password was changed", passwordText(e)+
+		"\n\nIf this was not you, reset your password now and review your signed-in devices.")
+}
+
+func passwordText(e user.PasswordChanged) string {
+	at := time.Unix(e.ChangedAt, 0).UTC().Format("2006-01-02 15:04 UTC")
+	switch e.Method {
+	case user.PasswordReset:
+		return "Your password was reset on " + at + ". Every device was signed out."
+	case user.PasswordAdmin:
+		return "Your password was set by an administrator on " + at + "."
+	default:
+		return "Your password was changed on " + at + ". Other devices were signed out."
+	}
+}
@@ -1,6 +1,8 @@
 package relation
 
 import (
+	"context"
+
 	"errors"
 
 	"gorm.io/gorm"
@@ -17,15 +19,20 @@ type Service struct {
 
 // NewService initializes the service
 func NewService(db *gorm.DB) *Service {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Relation{})
-	}
 	return &Service{
 		db:   db,
 		repo: model.NewCRUD[entity.Relation](db),
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Bind creates a relationship
 func (s *Service) Bind(item, itemType, target, targetType, relationType string) error {
 	// Check exists
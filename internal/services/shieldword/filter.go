@@ -23,9 +23,6 @@ type Service struct {
 
 // NewService initializes the service
 func NewService(db *gorm.DB) *Service {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Word{})
-	}
 	return &Service{
 		db:   db,
 		repo: model.NewCRUD[entity.Word](db),
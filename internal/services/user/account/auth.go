@@ -11,6 +11,11 @@ import (
 	"gorm.io/gorm"
 	
 	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/lockout"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/access/oidc"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/social"
 	"appsite-go/internal/services/access/token"
 	"appsite-go/internal/services/access/verify"
 	"appsite-go/internal/services/user/dto"
@@ -23,6 +28,16 @@ var (
 	ErrInvalidPwd    = errors.New("invalid password")
 	ErrUserDisabled  = errors.New("user is disabled")
 	ErrInvalidOTP    = errors.New("invalid otp code")
+	ErrMFARequired   = errors.New("two-factor authentication required")
+	ErrInvalidReset  = errors.New("password reset link is invalid or expired")
+	ErrInvalidCredentials = errors.New("invalid account or password")
+	ErrSocialBound   = errors.New("social account is bound to another user")
+	ErrSocialNotBound = errors.New("social account is not bound")
+	ErrSocialLastLogin = errors.New("set a password, email or mobile before unbinding the last social account")
+	ErrIdentityNotFound = errors.New("linked identity not found")
+	ErrContactTaken  = errors.New("email or mobile is used by another account")
+	ErrBindRequired  = errors.New("verify the new email or mobile with a code")
+	ErrUnverifiable  = errors.New("codes cannot be sent to this email or mobile to verify it")
 )
 
 // AuthService handles authentication
@@ -32,14 +47,16 @@ type AuthService struct {
 	pwd      *PasswordService
 	tokenSvc *token.Service
 	otpSvc   *verify.OTPService
+	sessions *session.Service
+	mfa      *mfa.Service
+	reset    *ResetOptions
+	guard    *lockout.Service
+	social   *social.Service
+	oidc     *oidc.Service
 }
 
 // NewAuthService creates a new auth service
 func NewAuthService(db *gorm.DB, tokenSvc *token.Service, otpSvc *verify.OTPService) *AuthService {
-	// Auto migrate
-	if db != nil {
-		_ = db.AutoMigrate(&entity.User{}, &entity.UserInfo{}, &entity.UserGroup{}, &entity.UserPreference{})
-	}
 	return &AuthService{
 		db:       db,
 		repo:     model.NewCRUD[entity.User](db),
@@ -49,17 +66,48 @@ func NewAuthService(db *gorm.DB, tokenSvc *token.Service, otpSvc *verify.OTPServ
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every user query is scoped to that tenant
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	c := *s
+	c.db = s.db.WithContext(ctx)
+	c.repo = s.repo.WithContext(ctx)
+	return &c
+}
+
+// WithSessions records each login as a device session
+func (s *AuthService) WithSessions(sessions *session.Service) *AuthService {
+	s.sessions = sessions
+	return s
+}
+
 // RegisterInput defines parameters for registration
 type RegisterInput struct {
-	Username string
-	Password string
-	Email    string
-	Mobile   string
-	Nickname string
+	Username   string
+	Password   string
+	Email      string
+	Mobile     string
+	Nickname   string
+	EmailCode  string // Code sent to Email for the register purpose
+	MobileCode string // Code sent to Mobile for the register purpose
 }
 
-// Register creates a new user user
+// Register creates a new user user. The email and mobile are proven with
+// codes sent for the register purpose, whenever codes can be sent to them.
 func (s *AuthService) Register(input RegisterInput) (*entity.User, error) {
+	ctx := s.db.Statement.Context
+	var err error
+	if input.Email != "" {
+		if input.Email, err = s.verifyContact(ctx, verify.PurposeRegister, input.Email, input.EmailCode); err != nil {
+			return nil, err
+		}
+	}
+	if input.Mobile != "" {
+		if input.Mobile, err = s.verifyContact(ctx, verify.PurposeRegister, input.Mobile, input.MobileCode); err != nil {
+			return nil, err
+		}
+	}
+
 	req := dto.UserCreateReq{
 		Username: input.Username,
 		Email:    input.Email,
@@ -74,69 +122,138 @@ func (s *AuthService) Register(input RegisterInput) (*entity.User, error) {
 	return s.Add(req)
 }
 
-// Login verifies credentials and returns a token
-func (s *AuthService) Login(identifier, password string) (string, *entity.User, error) {
+// Login verifies credentials and starts a session for the user on device.
+// When a second factor is on, it returns an *MFARequiredError instead.
+// Unknown accounts and wrong passwords both fail with ErrInvalidCredentials;
+// with a lockout service, repeated failures return a *lockout.LockedError.
+// Login names are unique across tenants, the account found decides the tenant.
+func (s *AuthService) Login(ctx context.Context, identifier, password string, device session.Device) (*token.Pair, *entity.User, error) {
 	user := &entity.User{}
 	
 	// Find (support username/email/mobile login)
-	err := s.db.Where("username = ? OR email = ? OR mobile = ?", identifier, identifier, identifier).First(user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", nil, ErrUserNotFound
+	err := s.db.WithContext(model.AsPlatform(ctx)).Where("username = ? OR email = ? OR mobile = ?", identifier, identifier, identifier).First(user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	found := err == nil
+
+	attempt := loginAttempt(user.ID, identifier, device)
+	if s.guard != nil {
+		if err := s.guard.Check(ctx, attempt); err != nil {
+			return nil, nil, err
 		}
-		return "", nil, err
 	}
 
 	// Verify Password
-	if !s.pwd.Compare(user.Password, password) {
-		return "", nil, ErrInvalidPwd
+	if !found {
+		s.burnCompare(password)
+	}
+	if !found || !s.pwd.Compare(user.Password, password) {
+		if s.guard != nil {
+			if err := s.guard.Fail(ctx, attempt); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+	if s.guard != nil {
+		if err := s.guard.Succeed(ctx, attempt); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Check Status
 	if user.Status != "enabled" {
-		return "", nil, ErrUserDisabled
+		return nil, nil, ErrUserDisabled
 	}
 
-	// Generate Token
-	tokenStr, err := s.tokenSvc.GenerateToken(user.ID, user.GroupID)
+	// Issue Tokens
+	pair, err := s.login(ctx, user, device)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	return tokenStr, user, nil
+	return pair, user, nil
 }
 
 // LoginByOTP logs in using mobile/email + OTP
-func (s *AuthService) LoginByOTP(ctx context.Context, target, code string) (string, *entity.User, error) {
+func (s *AuthService) LoginByOTP(ctx context.Context, target, code string, device session.Device) (*token.Pair, *entity.User, error) {
 	// 1. Verify OTP
-	if !s.otpSvc.Check(ctx, target, code) {
-		return "", nil, ErrInvalidOTP
+	target, err := s.checkOTP(ctx, verify.PurposeLogin, target, code)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// 2. Find User (Mobile or Email)
-	user := &entity.User{}
-	err := s.db.Where("mobile = ? OR email = ?", target, target).First(user).Error
+	user, err := s.contactUser(ctx, target)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Optional: Auto-register logic could be placed here? 
 			// For now, strict login
-			return "", nil, ErrUserNotFound
+			return nil, nil, ErrUserNotFound
 		}
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	// 3. Status Check
 	if user.Status != "enabled" {
-		return "", nil, ErrUserDisabled
+		return nil, nil, ErrUserDisabled
 	}
 
-	// 4. Token
-	tokenStr, err := s.tokenSvc.GenerateToken(user.ID, user.GroupID)
+	// 4. Tokens
+	pair, err := s.login(ctx, user, device)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
+	}
+
+	return pair, user, nil
+}
+
+// startSession issues the tokens of a login, recording the session when
+// enabled; the requests of the tokens run in the tenant of the user
+func (s *AuthService) startSession(ctx context.Context, user *entity.User, device session.Device) (*token.Pair, error) {
+	if s.sessions == nil {
+		return s.tokenSvc.IssueGrant(ctx, user.ID, user.GroupID, token.Grant{SaasID: user.SaasID})
+	}
+	return s.sessions.Start(ctx, user.ID, user.GroupID, user.SaasID, device)
+}
+
+// Refresh exchanges a refresh token for a new pair. The user is looked up
+// again, so a disabled or deleted user is signed out everywhere instead.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*token.Pair, error) {
+	var refused string
+	pair, err := s.tokenSvc.Refresh(ctx, refreshToken, func(userID string) (string, error) {
+		user := &entity.User{}
+		err := s.db.WithContext(model.AsPlatform(ctx)).First(user, "id = ?", userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			refused = userID
+			return "", ErrUserNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+		if user.Status != "enabled" {
+			refused = userID
+			return "", ErrUserDisabled
+		}
+		return user.GroupID, nil
+	})
+	if refused != "" {
+		if rerr := s.tokenSvc.RevokeUser(ctx, refused); rerr != nil {
+			return nil, rerr
+		}
 	}
+	return pair, err
+}
+
+// Logout revokes the access token and the token family it belongs to
+func (s *AuthService) Logout(ctx context.Context, claims *token.Claims) error {
+	return s.tokenSvc.Logout(ctx, claims)
+}
 
-	return tokenStr, user, nil
+// LogoutAll revokes every token family of the user
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	return s.tokenSvc.RevokeUser(ctx, userID)
 }
 
 // RegisterByMobile registers a new user with mobile
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/entity"
+)
+
+// BindContact sets the email or mobile of a user to target, proven with a
+// code sent to it for the bind purpose. Addresses codes cannot be sent to
+// fail with ErrUnverifiable, one used by another account with ErrContactTaken.
+func (s *AuthService) BindContact(ctx context.Context, userID, target, code string) error {
+	target, err := verify.NormalizeTarget(target)
+	if err != nil {
+		return err
+	}
+	if s.otpSvc == nil || !s.otpSvc.CanSend(target) {
+		return ErrUnverifiable
+	}
+	if target, err = s.checkOTP(ctx, verify.PurposeBind, target, code); err != nil {
+		return err
+	}
+
+	column := "mobile"
+	if strings.Contains(target, "@") {
+		column = "email"
+	}
+
+	// Login names are unique across tenants, look in all of them
+	var count int64
+	err = s.db.WithContext(model.AsPlatform(ctx)).Model(&entity.User{}).
+		Where(column+" = ? AND id <> ?", target, userID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrContactTaken
+	}
+
+	res := s.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).Update(column, target)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// verifyContact normalizes an email or mobile and, when codes can be sent
+// to it, checks the code sent for purpose. It returns the normalized target.
+func (s *AuthService) verifyContact(ctx context.Context, purpose, target, code string) (string, error) {
+	target, err := verify.NormalizeTarget(target)
+	if err != nil {
+		return "", err
+	}
+	if s.otpSvc == nil || !s.otpSvc.CanSend(target) {
+		return target, nil
+	}
+	return s.checkOTP(ctx, purpose, target, code)
+}
+
+// checkOTP consumes the code sent to target for purpose and returns the
+// normalized target
+func (s *AuthService) checkOTP(ctx context.Context, purpose, target, code string) (string, error) {
+	target, err := verify.NormalizeTarget(target)
+	if err != nil {
+		return "", err
+	}
+	if err := s.otpSvc.Verify(ctx, purpose, target, code); err != nil {
+		if errors.Is(err, verify.ErrOTPInvalid) {
+			return "", ErrInvalidOTP
+		}
+		return "", err
+	}
+	return target, nil
+}
+
+// contactUser finds the user of a normalized email or mobile in any tenant.
+// Emails were stored as typed, so they are compared without case.
+func (s *AuthService) contactUser(ctx context.Context, target string) (*entity.User, error) {
+	user := &entity.User{}
+	err := s.db.WithContext(model.AsPlatform(ctx)).
+		Where("mobile = ? OR LOWER(email) = ?", target, target).
+		First(user).Error
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
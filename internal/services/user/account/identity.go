@@ -0,0 +1,236 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/oidc"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/dto"
+	"appsite-go/internal/services/user/entity"
+)
+
+// WithOIDC enables signing in with the OpenID Connect and OAuth2 providers of tenants
+func (s *AuthService) WithOIDC(o *oidc.Service) *AuthService {
+	s.oidc = o
+	return s
+}
+
+// OIDCProviders lists the providers a tenant offers on its sign in page
+func (s *AuthService) OIDCProviders(ctx context.Context, tenant string) ([]oidc.Provider, error) {
+	if s.oidc == nil {
+		return nil, oidc.ErrUnknownTenant
+	}
+	return s.oidc.Providers(ctx, tenant)
+}
+
+// OIDCAuthURL starts a sign in redirect to a provider of a tenant,
+// returning the nonce the browser must bring back to the callback
+func (s *AuthService) OIDCAuthURL(ctx context.Context, tenant, provider string) (authURL, nonce string, err error) {
+	if s.oidc == nil {
+		return "", "", oidc.ErrUnknownProvider
+	}
+	return s.oidc.Begin(ctx, tenant, provider, "")
+}
+
+// LinkIdentityURL starts a redirect linking an account at a provider of
+// the tenant of the user to the user, like OIDCAuthURL
+func (s *AuthService) LinkIdentityURL(ctx context.Context, userID, provider string) (authURL, nonce string, err error) {
+	if s.oidc == nil {
+		return "", "", oidc.ErrUnknownProvider
+	}
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	return s.oidc.Begin(ctx, user.SaasID, provider, user.ID)
+}
+
+// CompleteOIDC handles the callback of a provider redirect, in the browser
+// holding the nonce of OIDCAuthURL or LinkIdentityURL. A sign in
+// issues tokens for the user of the identity, registering one in the
+// tenant the first time, and may return an *MFARequiredError like Login. A
+// link adds the identity to the user who started it and returns no tokens.
+func (s *AuthService) CompleteOIDC(ctx context.Context, code, state, nonce string, device session.Device) (*token.Pair, *entity.User, error) {
+	if s.oidc == nil {
+		return nil, nil, oidc.ErrStateInvalid
+	}
+	id, st, err := s.oidc.Complete(ctx, code, state, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The state, not the request, names the tenant
+	ctx = model.WithTenant(ctx, id.TenantID)
+
+	if st.UserID != "" {
+		user, err := s.linkIdentity(ctx, st.UserID, id)
+		return nil, user, err
+	}
+
+	user, err := s.identityUser(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	pair, err := s.login(ctx, user, device)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// Identities lists the external accounts linked to a user
+func (s *AuthService) Identities(ctx context.Context, userID string) ([]entity.UserIdentity, error) {
+	var list []entity.UserIdentity
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&list).Error
+	return list, err
+}
+
+// UnlinkIdentity removes an external account of a user. Like UnbindSocial,
+// it is refused when nothing else would let the user sign in.
+func (s *AuthService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var count int64
+	err = s.db.WithContext(ctx).Model(&entity.UserIdentity{}).
+		Where("id = ? AND user_id = ?", identityID, userID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrIdentityNotFound
+	}
+	others, err := s.otherLogins(ctx, user, "", identityID)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrSocialLastLogin
+	}
+	return s.db.WithContext(ctx).Where("id = ? AND user_id = ?", identityID, userID).
+		Delete(&entity.UserIdentity{}).Error
+}
+
+// identityUser finds the user of an identity, or registers one in its tenant
+func (s *AuthService) identityUser(ctx context.Context, id *oidc.Identity) (*entity.User, error) {
+	var linked entity.UserIdentity
+	err := s.db.WithContext(ctx).
+		Where("saas_id = ? AND issuer = ? AND subject = ?", id.TenantID, id.Issuer, id.Subject).
+		Take(&linked).Error
+	if err == nil {
+		user, err := s.activeUser(ctx, linked.UserID)
+		if err != nil {
+			return nil, err
+		}
+		s.db.WithContext(ctx).Model(&linked).UpdateColumn("last_login_at", time.Now().Unix())
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Only a verified address is trusted, and it is never used to match an
+	// existing account: whoever controls a provider could claim any address
+	email := ""
+	if id.EmailVerified && id.Email != "" {
+		var taken int64
+		// Addresses are unique across tenants
+		if err := s.db.WithContext(model.AsPlatform(ctx)).Model(&entity.User{}).Where("email = ?", id.Email).Count(&taken).Error; err != nil {
+			return nil, err
+		}
+		if taken == 0 {
+			email = id.Email
+		}
+	}
+	suffix := make([]byte, 5)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	user, err := s.WithContext(ctx).Add(dto.UserCreateReq{
+		SaasID:   id.TenantID,
+		Username: id.Provider + "_" + hex.EncodeToString(suffix),
+		Email:    email,
+		Nickname: id.Name,
+		Avatar:   id.Picture,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.createIdentity(ctx, user.ID, id); err != nil {
+		// No way to sign in to it, drop it
+		s.db.WithContext(ctx).Unscoped().Delete(user)
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) linkIdentity(ctx context.Context, userID string, id *oidc.Identity) (*entity.User, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.SaasID != id.TenantID {
+		return nil, oidc.ErrUnknownTenant
+	}
+	var linked entity.UserIdentity
+	err = s.db.WithContext(ctx).
+		Where("saas_id = ? AND issuer = ? AND subject = ?", id.TenantID, id.Issuer, id.Subject).
+		Take(&linked).Error
+	if err == nil {
+		if linked.UserID != userID {
+			return nil, ErrSocialBound
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return user, s.createIdentity(ctx, userID, id)
+}
+
+func (s *AuthService) createIdentity(ctx context.Context, userID string, id *oidc.Identity) error {
+	return s.db.WithContext(ctx).Create(&entity.UserIdentity{
+		SaasID:      id.TenantID,
+		Issuer:      id.Issuer,
+		Subject:     id.Subject,
+		UserID:      userID,
+		Provider:    id.Provider,
+		Email:       id.Email,
+		LastLoginAt: time.Now().Unix(),
+	}).Error
+}
+
+// otherLogins counts the ways a user can sign in besides the social
+// provider or identity about to be removed: a password, email or mobile,
+// other social accounts and other linked identities
+func (s *AuthService) otherLogins(ctx context.Context, user *entity.User, exceptSocial, exceptIdentity string) (int, error) {
+	n := 0
+	if user.Password != "" || valOrEmpty(user.Email) != "" || valOrEmpty(user.Mobile) != "" {
+		n++
+	}
+	bound, err := s.SocialBindings(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	for name, ok := range bound {
+		if ok && name != exceptSocial {
+			n++
+		}
+	}
+	var identities int64
+	err = s.db.WithContext(ctx).Model(&entity.UserIdentity{}).
+		Where("user_id = ? AND id <> ?", user.ID, exceptIdentity).Count(&identities).Error
+	return n + int(identities), err
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"appsite-go/internal/services/access/lockout"
+	"appsite-go/internal/services/access/session"
+)
+
+// WithLockout throttles password logins after failures and locks the
+// accounts and IPs guessing too much
+func (s *AuthService) WithLockout(l *lockout.Service) *AuthService {
+	s.guard = l
+	return s
+}
+
+// UnlockLogin lifts the login lock of a user; by is the admin doing it
+func (s *AuthService) UnlockLogin(ctx context.Context, userID, by string) error {
+	if s.guard == nil {
+		return nil
+	}
+	if _, err := s.activeUser(ctx, userID); err != nil && !errors.Is(err, ErrUserDisabled) {
+		return err
+	}
+	return s.guard.Unlock(ctx, lockout.Attempt{Subject: userSubject(userID), UserID: userID}, by)
+}
+
+// UnlockLoginIP lifts the login lock of a client IP
+func (s *AuthService) UnlockLoginIP(ctx context.Context, ip, by string) error {
+	if s.guard == nil {
+		return nil
+	}
+	return s.guard.UnlockIP(ctx, ip, by)
+}
+
+// loginAttempt names the subject of a login. Known accounts count by ID, so
+// every identifier of an account shares one counter; unknown identifiers
+// count by name, prefixed so they never collide with an ID.
+func loginAttempt(userID, identifier string, device session.Device) lockout.Attempt {
+	subject := "name:" + strings.ToLower(strings.TrimSpace(identifier))
+	if userID != "" {
+		subject = userSubject(userID)
+	}
+	return lockout.Attempt{Subject: subject, UserID: userID, IP: device.IP, UserAgent: device.UserAgent}
+}
+
+func userSubject(userID string) string {
+	return "user:" + userID
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// burnCompare spends the time of a password check for an unknown account,
+// so response times do not tell which accounts exist
+func (s *AuthService) burnCompare(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = s.pwd.Hash("appsite-unknown-account")
+	})
+	s.pwd.Compare(dummyHash, password)
+}
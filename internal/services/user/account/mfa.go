@@ -0,0 +1,194 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/entity"
+)
+
+// MFARequiredError is returned by the logins of users with a second factor,
+// in place of tokens. The client completes the login with the challenge,
+// after enrolling an authenticator first when Enroll is set.
+type MFARequiredError struct {
+	Challenge string
+	Enroll    bool
+	ExpiresIn int64 // Seconds left to complete the login
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+// Is matches ErrMFARequired
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// WithMFA puts logins of users with a second factor, or in a group
+// requiring one, behind a challenge
+func (s *AuthService) WithMFA(m *mfa.Service) *AuthService {
+	s.mfa = m
+	return s
+}
+
+// EnrollMFA starts the enrollment required to complete a login challenge
+func (s *AuthService) EnrollMFA(ctx context.Context, challenge string) (*mfa.Enrollment, error) {
+	if s.mfa == nil {
+		return nil, mfa.ErrNotEnrolled
+	}
+	ch, err := s.mfa.GetChallenge(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.Enroll {
+		return nil, mfa.ErrAlreadyEnabled
+	}
+	// The challenge, not the request, names the user and so its tenant
+	user, err := s.activeUser(model.AsPlatform(ctx), ch.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.mfa.Enroll(ctx, user.ID, accountLabel(user))
+}
+
+// CompleteMFA finishes a login with the challenge and a second factor code
+// and starts the session. Recovery codes are returned when the login
+// enrolled the factor; they are shown to the user once.
+func (s *AuthService) CompleteMFA(ctx context.Context, challenge, code string, device session.Device) (*token.Pair, *entity.User, []string, error) {
+	if s.mfa == nil {
+		return nil, nil, nil, mfa.ErrChallengeInvalid
+	}
+	ch, codes, err := s.mfa.Redeem(ctx, challenge, code)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	user, err := s.activeUser(model.AsPlatform(ctx), ch.UserID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pair, err := s.startSession(ctx, user, device)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return pair, user, codes, nil
+}
+
+// login starts the session of an authenticated user, or the second factor
+// challenge when the user has one or the group requires one
+func (s *AuthService) login(ctx context.Context, user *entity.User, device session.Device) (*token.Pair, error) {
+	if s.mfa == nil {
+		return s.startSession(ctx, user, device)
+	}
+	enabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enroll := false
+	if !enabled {
+		if enroll, err = s.mfa.Required(ctx, user); err != nil {
+			return nil, err
+		}
+		if !enroll {
+			return s.startSession(ctx, user, device)
+		}
+	}
+	ch, err := s.mfa.NewChallenge(ctx, user.ID, enroll)
+	if err != nil {
+		return nil, err
+	}
+	return nil, &MFARequiredError{Challenge: ch.ID, Enroll: enroll, ExpiresIn: int64(mfa.ChallengeTTL.Seconds())}
+}
+
+func (s *AuthService) activeUser(ctx context.Context, userID string) (*entity.User, error) {
+	user := &entity.User{}
+	err := s.db.WithContext(ctx).First(user, "id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != "enabled" {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// accountLabel names the user in authenticator apps
+func accountLabel(user *entity.User) string {
+	if user.Email != nil && *user.Email != "" {
+		return *user.Email
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return user.ID
+}
+
+// MFAStatus reports whether the user has a second factor and whether the
+// user group requires one
+func (s *AuthService) MFAStatus(ctx context.Context, userID string) (enabled, required bool, err error) {
+	if s.mfa == nil {
+		return false, false, nil
+	}
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return false, false, err
+	}
+	if enabled, err = s.mfa.Enabled(ctx, userID); err != nil {
+		return false, false, err
+	}
+	required, err = s.mfa.Required(ctx, user)
+	return enabled, required, err
+}
+
+// SetupMFA starts the enrollment of a signed-in user
+func (s *AuthService) SetupMFA(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	if s.mfa == nil {
+		return nil, mfa.ErrNotEnrolled
+	}
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.mfa.Enroll(ctx, user.ID, accountLabel(user))
+}
+
+// ConfirmMFA enables the enrolled factor and returns the recovery codes
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	if s.mfa == nil {
+		return nil, mfa.ErrNotEnrolled
+	}
+	return s.mfa.Confirm(ctx, userID, code)
+}
+
+// DisableMFA removes the factor of a user, unless the user group requires it
+func (s *AuthService) DisableMFA(ctx context.Context, userID, code string) error {
+	if s.mfa == nil {
+		return mfa.ErrNotEnrolled
+	}
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.mfa.Disable(ctx, user, code)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if s.mfa == nil {
+		return nil, mfa.ErrNotEnrolled
+	}
+	return s.mfa.RegenerateRecovery(ctx, userID, code)
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/entity"
+	"appsite-go/pkg/extra/mail"
+)
+
+// ResetOptions configures password reset links
+type ResetOptions struct {
+	Secret []byte        // Signs the links
+	URL    string        // Page of the client reading the token query parameter
+	TTL    time.Duration // Validity of a link, 30 minutes by default
+	Mailer mail.Sender
+}
+
+// WithPasswordReset enables reset links sent by email
+func (s *AuthService) WithPasswordReset(opts ResetOptions) *AuthService {
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Minute
+	}
+	s.reset = &opts
+	return s
+}
+
+// ChangePassword replaces the password of a signed-in user after checking
+// the old one, and signs out every other session. keepSession is the
+// session of the request, left open.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword, keepSession string) error {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !s.pwd.Compare(user.Password, oldPassword) {
+		return ErrInvalidPwd
+	}
+	if err := s.setPassword(ctx, user, newPassword, entity.PasswordChange); err != nil {
+		return err
+	}
+	if keepSession == "" {
+		return s.tokenSvc.RevokeUser(ctx, userID)
+	}
+	return s.tokenSvc.RevokeUser(ctx, userID, keepSession)
+}
+
+// RequestPasswordReset emails a reset link to the account of email. It
+// succeeds whether or not an account exists, so it cannot probe for them.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email, ip string) error {
+	if s.reset == nil || s.reset.Mailer == nil {
+		return verify.ErrNoSender
+	}
+	release := func() {}
+	if s.otpSvc != nil {
+		var err error
+		if release, err = s.otpSvc.Throttle(ctx, email, ip); err != nil {
+			return err
+		}
+	}
+
+	user := &entity.User{}
+	err := s.db.WithContext(model.AsPlatform(ctx)).Where("email = ?", email).First(user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Status != "enabled") {
+		return nil
+	}
+	if err != nil {
+		release()
+		return err
+	}
+
+	link := s.reset.URL
+	if strings.Contains(link, "?") {
+		link += "&"
+	} else {
+		link += "?"
+	}
+	link += "token=" + url.QueryEscape(s.resetToken(user, time.Now().Add(s.reset.TTL)))
+	body := fmt.Sprintf("Open this link to choose a new password, within %d minutes:\n\n%s\n\n"+
+		"If you did not ask for it, ignore this message.", int(s.reset.TTL/time.Minute), link)
+	if err := s.reset.Mailer.Send(email, "Reset your password", body); err != nil {
+		release()
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset link token and signs the
+// user out everywhere. A link works once: the token is bound to the
+// password it replaces.
+func (s *AuthService) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	if s.reset == nil {
+		return ErrInvalidReset
+	}
+	userID, expires, mac, ok := parseResetToken(resetToken)
+	if !ok || time.Now().Unix() >= expires {
+		return ErrInvalidReset
+	}
+	user, err := s.activeUser(model.AsPlatform(ctx), userID)
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrUserDisabled) {
+		return ErrInvalidReset
+	}
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, s.resetMAC(user, expires)) {
+		return ErrInvalidReset
+	}
+	return s.resetTo(ctx, user, newPassword)
+}
+
+// ResetPasswordByOTP sets a new password with a code sent to the mobile or
+// email of the account for the reset purpose, and signs the user out everywhere
+func (s *AuthService) ResetPasswordByOTP(ctx context.Context, target, code, newPassword string) error {
+	target, err := s.checkOTP(ctx, verify.PurposeReset, target, code)
+	if err != nil {
+		return err
+	}
+	user, err := s.contactUser(ctx, target)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status != "enabled" {
+		return ErrUserDisabled
+	}
+	return s.resetTo(ctx, user, newPassword)
+}
+
+// resetTo runs in the tenant of the account: the reset link or code, not
+// the request, names it
+func (s *AuthService) resetTo(ctx context.Context, user *entity.User, newPassword string) error {
+	ctx = model.WithTenant(ctx, user.SaasID)
+	if err := s.setPassword(ctx, user, newPassword, entity.PasswordReset); err != nil {
+		return err
+	}
+	return s.tokenSvc.RevokeUser(ctx, user.ID)
+}
+
+// setPassword stores a new password and publishes PasswordChanged with it
+func (s *AuthService) setPassword(ctx context.Context, user *entity.User, password, method string) error {
+	hash, err := s.pwd.Hash(password)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.User{}).Where("id = ?", user.ID).Update("password", hash).Error; err != nil {
+			return err
+		}
+		user.Password = hash
+		return event.Publish(tx, passwordChanged(user, method))
+	})
+}
+
+func passwordChanged(user *entity.User, method string) entity.PasswordChanged {
+	return entity.PasswordChanged{
+		UserID:    user.ID,
+		SaasID:    user.SaasID,
+		Email:     valOrEmpty(user.Email),
+		Method:    method,
+		ChangedAt: time.Now().Unix(),
+	}
+}
+
+// resetToken is "<user id>.<expiry>.<mac>", the MAC covering the current
+// password hash so the token dies with the password it resets
+func (s *AuthService) resetToken(user *entity.User, expires time.Time) string {
+	exp := expires.Unix()
+	return user.ID + "." + strconv.FormatInt(exp, 10) + "." +
+		base64.RawURLEncoding.EncodeToString(s.resetMAC(user, exp))
+}
+
+func (s *AuthService) resetMAC(user *entity.User, expires int64) []byte {
+	mac := hmac.New(sha256.New, s.reset.Secret)
+	fmt.Fprintf(mac, "password-reset|%s|%d|%s", user.ID, expires, user.Password)
+	return mac.Sum(nil)
+}
+
+func parseResetToken(raw string) (userID string, expires int64, mac []byte, ok bool) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", 0, nil, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, nil, false
+	}
+	mac, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", 0, nil, false
+	}
+	return parts[0], expires, mac, true
+}
@@ -5,11 +5,14 @@
 package account
 
 import (
+	"context"
 	"errors"
 
 	"gorm.io/gorm"
 
+	"appsite-go/internal/core/event"
 	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/verify"
 	"appsite-go/internal/services/user/dto"
 	"appsite-go/internal/services/user/entity"
 )
@@ -17,8 +20,9 @@ import (
 // Add creates a new user with strict field control (UserAccount.php addFields)
 func (s *AuthService) Add(input dto.UserCreateReq) (*entity.User, error) {
 	// 1. Check uniqueness (Username, Email, Mobile)
+	// Login names are unique across tenants, look in all of them
 	// Build flexible query
-	query := s.db.Model(&entity.User{})
+	query := s.db.WithContext(model.AsPlatform(s.db.Statement.Context)).Model(&entity.User{})
 	conds := []string{}
 	args := []interface{}{}
 
@@ -144,6 +148,7 @@ func (s *AuthService) Update(uid string, input dto.UserUpdateReq) error {
 		return err
 	}
 
+	group := user.GroupID
 	updates := make(map[string]interface{})
 
 	// helper to set if not nil
@@ -203,7 +208,68 @@ func (s *AuthService) Update(uid string, input dto.UserUpdateReq) error {
 		return nil
 	}
 
-	return s.db.Model(&user).Updates(updates).Error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if input.Password == nil {
+			return nil
+		}
+		return event.Publish(tx, passwordChanged(&user, entity.PasswordAdmin))
+	})
+	if err != nil {
+		return err
+	}
+	// A user who can no longer log in loses the sessions already open, and
+	// so does one moved to another group: tokens carry the group as role
+	disabled := input.Status != nil && *input.Status != "enabled"
+	regrouped := input.GroupID != nil && *input.GroupID != group
+	if disabled || regrouped {
+		return s.tokenSvc.RevokeUser(context.Background(), uid)
+	}
+	return nil
+}
+
+// UpdateProfile applies the changes a user makes to their own account. A
+// new email or mobile that can receive codes goes through BindContact.
+func (s *AuthService) UpdateProfile(uid string, input dto.ProfileUpdateReq) error {
+	for _, contact := range []*string{input.Email, input.Mobile} {
+		if contact == nil || *contact == "" {
+			continue
+		}
+		target, err := verify.NormalizeTarget(*contact)
+		if err != nil {
+			return err
+		}
+		// Sending back the address already on the account is not a change
+		var same int64
+		err = s.db.Model(&entity.User{}).
+			Where("id = ? AND (mobile = ? OR LOWER(email) = ?)", uid, target, target).
+			Count(&same).Error
+		if err != nil {
+			return err
+		}
+		if same > 0 {
+			continue
+		}
+		// A new one is only taken with a code, password resets trust it
+		if s.otpSvc == nil || !s.otpSvc.CanSend(target) {
+			return ErrUnverifiable
+		}
+		return ErrBindRequired
+	}
+	return s.Update(uid, dto.UserUpdateReq{
+		Email:       input.Email,
+		Mobile:      input.Mobile,
+		Nickname:    input.Nickname,
+		Avatar:      input.Avatar,
+		Cover:       input.Cover,
+		Description: input.Description,
+		Introduce:   input.Introduce,
+		Birthday:    input.Birthday,
+		Gender:      input.Gender,
+		AreaID:      input.AreaID,
+	})
 }
 
 // GetDetail retrieves full user details
@@ -243,46 +309,142 @@ func valOrEmpty(ptr *string) string {
 	return *ptr
 }
 
-// ListUsers retrieves users based on filter
-func (s *AuthService) ListUsers(filter dto.UserFilterReq, page, pageSize int) ([]dto.UserListResp, int64, error) {
-	var users []entity.User
-	var count int64
-
-	query := s.db.Model(&entity.User{})
+// ListUsers retrieves users based on the legacy filter fields combined with
+// any client supplied filter in params
+func (s *AuthService) ListUsers(filter dto.UserFilterReq, params *model.ListParams) (*model.Page[dto.UserListResp], error) {
+	params, f := copyParams(params)
 
 	if filter.UID != "" {
-		query = query.Where("id = ?", filter.UID)
+		f.Where("id", model.OpEq, filter.UID)
 	}
 	if filter.Username != "" {
-		query = query.Where("username LIKE ?", "%"+filter.Username+"%")
+		f.Where("username", model.OpLike, filter.Username)
 	}
 	if filter.Email != "" {
-		query = query.Where("email = ?", filter.Email)
+		f.Where("email", model.OpEq, filter.Email)
 	}
 	if filter.Mobile != "" {
-		query = query.Where("mobile = ?", filter.Mobile)
+		f.Where("mobile", model.OpEq, filter.Mobile)
 	}
 	if filter.Nickname != "" {
-		query = query.Where("nickname LIKE ?", "%"+filter.Nickname+"%")
+		f.Where("nickname", model.OpLike, filter.Nickname)
 	}
 	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
+		f.Where("status", model.OpEq, filter.Status)
 	}
 	if filter.GroupID != "" {
-		query = query.Where("group_id = ?", filter.GroupID)
+		f.Where("group_id", model.OpEq, filter.GroupID)
 	}
 
-	if err := query.Count(&count).Error; err != nil {
-		return nil, 0, err
+	return userPage(s.repo.List(params))
+}
+
+// publicColumns are the user columns anonymous callers may filter on
+var publicColumns = []string{"id", "username", "nickname", "gender", "group_id", "area_id", "status", "created_at", "updated_at"}
+
+// ListPublicUsers lists users for anonymous callers. Only public
+// columns can be filtered and the response leaves out contact details.
+func (s *AuthService) ListPublicUsers(filter dto.UserPublicFilterReq, params *model.ListParams) (*model.Page[dto.UserPublicListResp], error) {
+	if params.Filter != nil {
+		if err := params.Filter.Only(publicColumns...); err != nil {
+			return nil, err
+		}
 	}
+	params, f := copyParams(params)
 
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
-		return nil, 0, err
+	if filter.UID != "" {
+		f.Where("id", model.OpEq, filter.UID)
+	}
+	if filter.Username != "" {
+		f.Where("username", model.OpLike, filter.Username)
+	}
+	if filter.Nickname != "" {
+		f.Where("nickname", model.OpLike, filter.Nickname)
+	}
+	if filter.Gender != "" {
+		f.Where("gender", model.OpEq, filter.Gender)
+	}
+	if filter.GroupID != "" {
+		f.Where("group_id", model.OpEq, filter.GroupID)
+	}
+	if filter.AreaID != "" {
+		f.Where("area_id", model.OpEq, filter.AreaID)
 	}
 
-	res := make([]dto.UserListResp, len(users))
-	for i, u := range users {
+	page, err := userPage(s.repo.List(params))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]dto.UserPublicListResp, len(page.List))
+	for i, u := range page.List {
+		res[i] = dto.UserPublicListResp{
+			UID:         u.UID,
+			Username:    u.Username,
+			SaasID:      u.SaasID,
+			Nickname:    u.Nickname,
+			Avatar:      u.Avatar,
+			Description: u.Description,
+			GroupID:     u.GroupID,
+			Gender:      u.Gender,
+			AreaID:      u.AreaID,
+			Status:      u.Status,
+			CreateTime:  u.CreateTime,
+			LastTime:    u.LastTime,
+		}
+	}
+	return &model.Page[dto.UserPublicListResp]{List: res, Total: page.Total, Page: page.Page, Size: page.Size, NextCursor: page.NextCursor, CursorMode: page.CursorMode}, nil
+}
+
+// copyParams returns a copy of params with its own filter, so adding
+// conditions never changes the caller's params
+func copyParams(params *model.ListParams) (*model.ListParams, *model.Filter) {
+	p := model.ListParams{}
+	if params != nil {
+		p = *params
+	}
+	if p.Filter == nil {
+		p.Filter = model.NewFilter()
+	} else {
+		p.Filter = p.Filter.Clone()
+	}
+	return &p, p.Filter
+}
+
+// Delete moves a user to the trash
+func (s *AuthService) Delete(uid string) error {
+	res := s.repo.Remove(uid)
+	if !res.Success && errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	return s.tokenSvc.RevokeUser(context.Background(), uid)
+}
+
+// Restore brings back a deleted user
+func (s *AuthService) Restore(uid string) error {
+	res := s.repo.Restore(uid)
+	if !res.Success && errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return res.Error
+}
+
+// TrashedUsers lists deleted users
+func (s *AuthService) TrashedUsers(params *model.ListParams) (*model.Page[dto.UserListResp], error) {
+	return userPage(s.repo.ListTrashed(params))
+}
+
+// userPage converts a user List result into the admin list response
+func userPage(result *model.Result) (*model.Page[dto.UserListResp], error) {
+	users, err := model.PageOf[entity.User](result)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.UserListResp, len(users.List))
+	for i, u := range users.List {
 		res[i] = dto.UserListResp{
 			UID:         u.ID,
 			Username:    u.Username,
@@ -302,5 +464,12 @@ func (s *AuthService) ListUsers(filter dto.UserFilterReq, page, pageSize int) ([
 		}
 	}
 
-	return res, count, nil
+	return &model.Page[dto.UserListResp]{
+		List:       res,
+		Total:      users.Total,
+		Page:       users.Page,
+		Size:       users.Size,
+		NextCursor: users.NextCursor,
+		CursorMode: users.CursorMode,
+	}, nil
 }
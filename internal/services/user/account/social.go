@@ -0,0 +1,203 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/social"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/dto"
+	"appsite-go/internal/services/user/entity"
+)
+
+// socialColumns are the UserInfo columns holding the identity of each provider
+var socialColumns = map[string]string{
+	social.ProviderWechat: "wechat_id",
+	social.ProviderWeibo:  "weibo_id",
+	social.ProviderQQ:     "qq_id",
+	social.ProviderApple:  "apple_uuid",
+}
+
+// WithSocial enables signing in and binding with social accounts
+func (s *AuthService) WithSocial(soc *social.Service) *AuthService {
+	s.social = soc
+	return s
+}
+
+// SocialAuthURL starts a social login redirect, returning the nonce the
+// browser must bring back to the callback. With a userID, the callback
+// binds the social account to that user instead of signing in.
+func (s *AuthService) SocialAuthURL(ctx context.Context, provider, userID string) (authURL, nonce string, err error) {
+	if s.social == nil || socialColumns[provider] == "" {
+		return "", "", social.ErrUnknownProvider
+	}
+	if userID != "" {
+		if _, err := s.activeUser(ctx, userID); err != nil {
+			return "", "", err
+		}
+	}
+	return s.social.Begin(ctx, provider, userID)
+}
+
+// CompleteSocial handles the callback of a social login redirect, in the
+// browser holding the nonce of SocialAuthURL. A login signs in the user of
+// the social account, registering one the first time, and may return an
+// *MFARequiredError like Login. A binding links the social account to the
+// user who started it and returns no tokens. Social accounts are bound
+// across tenants: the user found decides the tenant, and new users join
+// the tenant of the request.
+func (s *AuthService) CompleteSocial(ctx context.Context, provider, code, state, nonce string, device session.Device) (*token.Pair, *entity.User, error) {
+	if s.social == nil || socialColumns[provider] == "" {
+		return nil, nil, social.ErrUnknownProvider
+	}
+	id, st, err := s.social.Complete(ctx, provider, code, state, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if st.UserID != "" {
+		user, err := s.bindSocial(ctx, st.UserID, id)
+		return nil, user, err
+	}
+
+	user, err := s.socialUser(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	pair, err := s.login(ctx, user, device)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// SocialBindings reports which providers are bound to a user
+func (s *AuthService) SocialBindings(ctx context.Context, userID string) (map[string]bool, error) {
+	var info entity.UserInfo
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Take(&info).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return map[string]bool{
+		social.ProviderWechat: info.WechatID != "",
+		social.ProviderWeibo:  info.WeiboID != "",
+		social.ProviderQQ:     info.QQID != "",
+		social.ProviderApple:  info.AppleUUID != "",
+	}, nil
+}
+
+// UnbindSocial unlinks a social account from a user. It is refused when
+// it is the only way left to sign in: no password, email, mobile, other
+// social account or linked identity.
+func (s *AuthService) UnbindSocial(ctx context.Context, userID, provider string) error {
+	if socialColumns[provider] == "" {
+		return social.ErrUnknownProvider
+	}
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	bound, err := s.SocialBindings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !bound[provider] {
+		return ErrSocialNotBound
+	}
+	others, err := s.otherLogins(ctx, user, provider, "")
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrSocialLastLogin
+	}
+	// NULL, not "": unique social columns hold any number of unbound rows
+	return s.db.WithContext(ctx).Model(&entity.UserInfo{}).Where("user_id = ?", userID).
+		Update(socialColumns[provider], gorm.Expr("NULL")).Error
+}
+
+// socialUser finds the user of a social account, or registers one
+func (s *AuthService) socialUser(ctx context.Context, id *social.Identity) (*entity.User, error) {
+	userID, err := s.socialOwner(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" {
+		return s.activeUser(model.AsPlatform(ctx), userID)
+	}
+
+	suffix := make([]byte, 5)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	user, err := s.WithContext(ctx).Add(dto.UserCreateReq{
+		Username: id.Provider + "_" + hex.EncodeToString(suffix),
+		Nickname: id.Nickname,
+		Avatar:   id.Avatar,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setSocial(ctx, user.ID, id); err != nil {
+		// No way to sign in to it, drop it
+		s.db.WithContext(ctx).Unscoped().Delete(user)
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) bindSocial(ctx context.Context, userID string, id *social.Identity) (*entity.User, error) {
+	user, err := s.activeUser(model.AsPlatform(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.socialOwner(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" && owner != userID {
+		return nil, ErrSocialBound
+	}
+	return user, s.setSocial(ctx, userID, id)
+}
+
+// socialOwner returns the ID of the user bound to a social account, or "".
+// Accounts bound before their provider returned a union ID match by open ID.
+func (s *AuthService) socialOwner(ctx context.Context, id *social.Identity) (string, error) {
+	ids := []string{id.OpenID}
+	if id.UnionID != "" {
+		ids = append(ids, id.UnionID)
+	}
+	var info entity.UserInfo
+	err := s.db.WithContext(ctx).Where(socialColumns[id.Provider]+" IN ?", ids).Take(&info).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return info.UserID, err
+}
+
+func (s *AuthService) setSocial(ctx context.Context, userID string, id *social.Identity) error {
+	column := socialColumns[id.Provider]
+	err := s.db.WithContext(ctx).Model(&entity.UserInfo{}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{column}),
+	}).Create(map[string]interface{}{"user_id": userID, column: id.ID()}).Error
+	if err != nil {
+		// A unique column refused it: another user bound it meanwhile
+		if owner, oerr := s.socialOwner(ctx, id); oerr == nil && owner != "" && owner != userID {
+			return ErrSocialBound
+		}
+	}
+	return err
+}
@@ -43,6 +43,22 @@ type UserUpdateReq struct {
 	Status      *string `json:"status,omitempty"`
 }
 
+// ProfileUpdateReq defines fields users may change on their own account.
+// Group, status and password are not among them: groups grant admin
+// access, and passwords change through the password flows.
+type ProfileUpdateReq struct {
+	Email       *string `json:"email,omitempty"`
+	Mobile      *string `json:"mobile,omitempty"`
+	Nickname    *string `json:"nickname,omitempty"`
+	Avatar      *string `json:"avatar,omitempty"`
+	Cover       *string `json:"cover,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Introduce   *string `json:"introduce,omitempty"`
+	Birthday    *int64  `json:"birthday,omitempty"`
+	Gender      *string `json:"gender,omitempty"`
+	AreaID      *string `json:"areaid,omitempty"`
+}
+
 // UserDetailResp defines fields for internal full detail view.
 // Corresponds to PHP 'detailFields'.
 type UserDetailResp struct {
@@ -157,3 +173,14 @@ type UserFilterReq struct {
 	CreateTime int64  `json:"createtime,omitempty" form:"createtime"`
 	LastTime   int64  `json:"lasttime,omitempty" form:"lasttime"`
 }
+
+// UserPublicFilterReq defines the filter fields open to anonymous callers;
+// private contact columns are left out.
+type UserPublicFilterReq struct {
+	UID      string `json:"uid,omitempty" form:"uid"`
+	Username string `json:"username,omitempty" form:"username"`
+	Nickname string `json:"nickname,omitempty" form:"nickname"`
+	Gender   string `json:"gender,omitempty" form:"gender"`
+	GroupID  string `json:"groupid,omitempty" form:"groupid"`
+	AreaID   string `json:"areaid,omitempty" form:"areaid"`
+}
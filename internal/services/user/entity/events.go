@@ -0,0 +1,60 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+)
+
+// UserRegistered is published when an account is created
+type UserRegistered struct {
+	UserID   string `json:"user_id"`
+	SaasID   string `json:"saas_id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	GroupID  string `json:"group_id"`
+}
+
+// EventName implements event.Event
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// EventTenant implements event.TenantEvent
+func (e UserRegistered) EventTenant() string { return e.SaasID }
+
+// AfterAdd publishes UserRegistered with the new account
+func (u *User) AfterAdd(tx *gorm.DB) error {
+	return event.Publish(tx, UserRegistered{
+		UserID:   u.ID,
+		SaasID:   u.SaasID,
+		Username: u.Username,
+		Nickname: u.Nickname,
+		GroupID:  u.GroupID,
+	})
+}
+
+// Password change methods
+const (
+	PasswordChange = "change" // The user changed it, knowing the old one
+	PasswordReset  = "reset"  // The user reset it by code or email link
+	PasswordAdmin  = "admin"  // An administrator set it
+)
+
+// PasswordChanged is published whenever the password of an account changes,
+// so the owner is warned of changes they did not make
+type PasswordChanged struct {
+	UserID    string `json:"user_id"`
+	SaasID    string `json:"saas_id"`
+	Email     string `json:"email"` // Empty when the account has none
+	Method    string `json:"method"`
+	ChangedAt int64  `json:"changed_at"`
+}
+
+// EventName implements event.Event
+func (PasswordChanged) EventName() string { return "user.password_changed" }
+
+// EventTenant implements event.TenantEvent
+func (e PasswordChanged) EventTenant() string { return e.SaasID }
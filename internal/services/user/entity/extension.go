@@ -24,7 +24,7 @@ type UserInfo struct {
 	Company   string `gorm:"size:128"`
 	
 	// Social
-	WechatID  string `gorm:"size:64;index"`
+	WechatID  string `gorm:"size:64;default:null;uniqueIndex:idx_user_info_wechat_id_unique;comment:NULL when unbound"`
 	WeiboID   string `gorm:"size:64;index"`
 	QQID      string `gorm:"size:64;index"`
 	AppleUUID string `gorm:"size:64;index"`
@@ -54,6 +54,7 @@ type UserGroup struct {
 	GroupName   string `gorm:"size:64"`
 	Description string `gorm:"size:255"`
 	MenuAccess  string `gorm:"type:text;comment:JSON of accessible menu IDs"`
+	RequireMFA  bool   `gorm:"default:false;comment:Members must sign in with a second factor"`
 	
 	Status      string `gorm:"size:12;default:'enabled'"`
 	Sort        int    `gorm:"default:0"`
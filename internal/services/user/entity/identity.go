@@ -0,0 +1,29 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"appsite-go/internal/core/model"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect or
+// OAuth2 provider of the tenant. Subjects are unique per issuer, and each
+// tenant keeps its own users, so the same external account may sign in to
+// several tenants as different users.
+type UserIdentity struct {
+	model.Base
+
+	SaasID      string `json:"saas_id" gorm:"type:varchar(32);default:'';uniqueIndex:idx_identity_subject,priority:1"`
+	Issuer      string `json:"issuer" gorm:"size:255;not null;uniqueIndex:idx_identity_subject,priority:2"`
+	Subject     string `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_identity_subject,priority:3"`
+	UserID      string `json:"user_id" gorm:"size:32;not null;index"`
+	Provider    string `json:"provider" gorm:"size:64;not null;comment:Provider name in the tenant config"`
+	Email       string `json:"email" gorm:"size:128;comment:Email at the provider when linked"`
+	LastLoginAt int64  `json:"last_login_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identity"
+}
@@ -12,12 +12,16 @@ import (
 type User struct {
 	model.Base
 	model.Tenant // SaasID
+	model.SoftDelete
 
 	// Core Auth
-	Username string  `gorm:"size:64;uniqueIndex;comment:Login username"`
-	Password string  `gorm:"size:255;comment:Hashed password"`
-	Email    *string `gorm:"size:64;uniqueIndex;comment:Login email"`
-	Mobile   *string `gorm:"size:24;uniqueIndex;comment:Login mobile"`
+	Username string  `gorm:"size:64;uniqueIndex:idx_user_account_live_username,priority:1;comment:Login username"`
+	Password string  `gorm:"size:255;comment:Hashed password" filter:"-"`
+	Email    *string `gorm:"size:64;uniqueIndex:idx_user_account_live_email,priority:1;comment:Login email"`
+	Mobile   *string `gorm:"size:24;uniqueIndex:idx_user_account_live_mobile,priority:1;comment:Login mobile"`
+
+	// DeletedKey frees the login names of deleted accounts (model.TombstoneColumn)
+	DeletedKey string `gorm:"size:32;not null;default:'';uniqueIndex:idx_user_account_live_username,priority:2;uniqueIndex:idx_user_account_live_email,priority:2;uniqueIndex:idx_user_account_live_mobile,priority:2" filter:"-"`
 
 	// Profile Basic
 	Nickname string `gorm:"size:64"`
@@ -5,6 +5,8 @@
 package group
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 	
 	"appsite-go/internal/core/model"
@@ -21,6 +23,13 @@ func NewService(db *gorm.DB) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 func (s *Service) Create(name, desc string, level int) (*entity.UserGroup, error) {
 	g := &entity.UserGroup{
 		GroupName:   name,
@@ -40,3 +49,9 @@ func (s *Service) List() ([]entity.UserGroup, error) {
 	data := res.Data.(map[string]interface{})
 	return data["list"].([]entity.UserGroup), nil
 }
+
+// RequireMFA makes the members of a group sign in with a second factor.
+// Members without one enroll it at their next login.
+func (s *Service) RequireMFA(id string, required bool) error {
+	return s.repo.Update(id, map[string]interface{}{"require_mfa": required}).Error
+}
@@ -5,7 +5,9 @@
 package info
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -27,6 +29,14 @@ func NewService(db *gorm.DB) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // UpdateProfile updates or creates profile info
 func (s *Service) UpdateProfile(userID string, data map[string]interface{}) error {
 	var info entity.UserInfo
@@ -49,3 +59,13 @@ func (s *Service) GetProfile(userID string) (*entity.UserInfo, error) {
 	}
 	return &info, nil
 }
+
+// ExpireVIP drops the VIP level of users whose membership has run out
+// and returns how many were downgraded
+func (s *Service) ExpireVIP(ctx context.Context) (int64, error) {
+	// GORM names the VIP columns v_ip and v_ip_expire
+	res := s.db.WithContext(ctx).Model(&entity.UserInfo{}).
+		Where("v_ip > 0 AND v_ip_expire > 0 AND v_ip_expire < ?", time.Now().Unix()).
+		Update("VIP", 0)
+	return res.RowsAffected, res.Error
+}
@@ -5,6 +5,8 @@
 package pocket
 
 import (
+	"context"
+
 	"appsite-go/internal/services/commerce/coupon"
 	"appsite-go/internal/services/finance"
 	"appsite-go/internal/services/finance/entity"
@@ -29,6 +31,14 @@ func NewService(l *finance.LedgerService, c *coupon.Service) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		ledgerSvc: s.ledgerSvc.WithContext(ctx),
+		couponSvc: s.couponSvc.WithContext(ctx),
+	}
+}
+
 func (s *Service) GetAssets(userID string) (*AssetSummary, error) {
 	points, err := s.ledgerSvc.GetBalance(userID, "point")
 	if err != nil {
@@ -5,6 +5,8 @@
 package preference
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 	
 	"appsite-go/internal/core/model"
@@ -23,6 +25,15 @@ func NewService(db *gorm.DB) *Service {
 	}
 }
 
+// WithContext returns the service bound to ctx; with a tenant in ctx
+// every preference query is scoped to that tenant
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Set uploads or updates a preference key
 func (s *Service) Set(userID, saasID, key, content string) error {
 	var pref entity.UserPreference
@@ -12,6 +12,7 @@ import (
 // Tenant represents a SaaS tenant (or World/Server instance).
 type Tenant struct {
 	model.Base
+	model.Versioned
 
 	Title    string  `json:"title" gorm:"type:varchar(64);not null;comment:Tenant Name"`
 	Domain   string  `json:"domain" gorm:"type:varchar(128);uniqueIndex;comment:Custom Domain"`
@@ -22,7 +23,7 @@ type Tenant struct {
 	
 	OwnerID  string  `json:"owner_id" gorm:"type:varchar(36);index;comment:Admin User ID"`
 	
-	Config   dbs.Map `json:"config" gorm:"type:json;comment:Tenant specific settings"`
+	Config   dbs.Map `json:"config" gorm:"comment:Tenant specific settings"`
 }
 
 // TableName returns table name
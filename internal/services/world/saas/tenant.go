@@ -5,6 +5,7 @@
 package saas
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -22,15 +23,20 @@ type TenantService struct {
 
 // NewTenantService initializes the service
 func NewTenantService(db *gorm.DB) *TenantService {
-	if db != nil {
-		_ = db.AutoMigrate(&entity.Tenant{})
-	}
 	return &TenantService{
 		db:   db,
 		repo: model.NewCRUD[entity.Tenant](db),
 	}
 }
 
+// WithContext returns the service bound to ctx
+func (s *TenantService) WithContext(ctx context.Context) *TenantService {
+	return &TenantService{
+		db:   s.db.WithContext(ctx),
+		repo: s.repo.WithContext(ctx),
+	}
+}
+
 // Create registers a new tenant
 func (s *TenantService) Create(tenant *entity.Tenant) error {
 	// Validate uniqueness of Domain/Code logic if needed beyond DB constraints
@@ -68,19 +74,24 @@ func (s *TenantService) GetByDomain(domain string) (*entity.Tenant, error) {
 
 // List returns tenants
 func (s *TenantService) List(page, size int, filters map[string]interface{}) ([]entity.Tenant, int64, error) {
-	res := s.repo.List(&model.ListParams{
+	res, err := s.Query(&model.ListParams{
 		Page:     page,
 		PageSize: size,
 		Filters:  filters,
-		Sort:     "created_at desc",
 	})
-	
-	if !res.Success {
-		return nil, 0, res.Error
+	if err != nil {
+		return nil, 0, err
 	}
-	
-	data := res.Data.(map[string]interface{})
-	return data["list"].([]entity.Tenant), data["total"].(int64), nil
+	return res.List, res.Total, nil
+}
+
+// Query returns tenants matching the given list params
+func (s *TenantService) Query(params *model.ListParams) (*model.Page[entity.Tenant], error) {
+	if params.Sort == "" {
+		params.Sort = "created_at desc"
+	}
+
+	return model.PageOf[entity.Tenant](s.repo.List(params))
 }
 
 // CheckActive verifies if a tenant is active and not expired
@@ -100,3 +111,15 @@ func (s *TenantService) CheckActive(id string) (bool, error) {
 	
 	return true, nil
 }
+
+// DisableExpired disables enabled tenants whose subscription has expired
+// and returns how many were disabled
+func (s *TenantService) DisableExpired(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Model(&entity.Tenant{}).
+		Where("status = ? AND expire_at > 0 AND expire_at < ?", "enabled", time.Now().Unix()).
+		Updates(map[string]interface{}{
+			"status":  "disabled",
+			"version": gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected, res.Error
+}
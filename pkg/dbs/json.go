@@ -5,11 +5,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
+// jsonDBType picks the native JSON column type of the connected database:
+// jsonb on PostgreSQL, JSON on MySQL and SQLite
+func jsonDBType(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "JSONB"
+	}
+	return "JSON"
+}
+
 // Map represents a JSON object
 type Map map[string]interface{}
 
+// GormDataType implements schema.GormDataTypeInterface
+func (m Map) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType implements migrator.GormDataTypeInterface
+func (m Map) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBType(db)
+}
+
 func (m Map) Value() (driver.Value, error) {
 	if m == nil {
 		return nil, nil
@@ -29,9 +51,9 @@ func (m *Map) Scan(value interface{}) error {
 	case string:
 		bytes = []byte(v)
 	default:
-		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+		return errors.New(fmt.Sprint("Failed to unmarshal JSON value:", value))
 	}
-	
+
 	result := make(map[string]interface{})
 	err := json.Unmarshal(bytes, &result)
 	*m = Map(result)
@@ -41,6 +63,16 @@ func (m *Map) Scan(value interface{}) error {
 // Slice represents a JSON array
 type Slice []interface{}
 
+// GormDataType implements schema.GormDataTypeInterface
+func (s Slice) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType implements migrator.GormDataTypeInterface
+func (s Slice) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBType(db)
+}
+
 func (s Slice) Value() (driver.Value, error) {
 	if s == nil {
 		return nil, nil
@@ -60,7 +92,7 @@ func (s *Slice) Scan(value interface{}) error {
 	case string:
 		bytes = []byte(v)
 	default:
-		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+		return errors.New(fmt.Sprint("Failed to unmarshal JSON value:", value))
 	}
 
 	var result []interface{}
@@ -72,6 +104,16 @@ func (s *Slice) Scan(value interface{}) error {
 // StringArray represents a JSON string array (e.g. tags)
 type StringArray []string
 
+// GormDataType implements schema.GormDataTypeInterface
+func (s StringArray) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType implements migrator.GormDataTypeInterface
+func (s StringArray) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBType(db)
+}
+
 func (s StringArray) Value() (driver.Value, error) {
 	if s == nil {
 		return nil, nil
@@ -91,7 +133,7 @@ func (s *StringArray) Scan(value interface{}) error {
 	case string:
 		bytes = []byte(v)
 	default:
-		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
+		return errors.New(fmt.Sprint("Failed to unmarshal JSON value:", value))
 	}
 
 	var result []string
@@ -99,3 +141,19 @@ func (s *StringArray) Scan(value interface{}) error {
 	*s = StringArray(result)
 	return err
 }
+
+// Text is a long text column: LONGTEXT on MySQL, TEXT elsewhere
+type Text string
+
+// GormDataType implements schema.GormDataTypeInterface
+func (t Text) GormDataType() string {
+	return "text"
+}
+
+// GormDBDataType implements migrator.GormDataTypeInterface
+func (t Text) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "mysql" {
+		return "LONGTEXT"
+	}
+	return "TEXT"
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Sender interface for sending email
+type Sender interface {
+	// Send sends a plain text message
+	Send(to string, subject string, body string) error
+}
+
+// SMTPSender delivers through an SMTP server with PLAIN auth
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(to string, subject string, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid header value")
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	msg := "From: " + s.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(addr, auth, s.From, []string{to}, []byte(msg))
+}
+
+// ConsoleSender logs email to stdout (for local dev)
+type ConsoleSender struct {
+	Prefix string
+}
+
+func (s *ConsoleSender) Send(to string, subject string, body string) error {
+	log.Printf("[%s] Mail to %s | %s: %s", s.Prefix, to, subject, body)
+	return nil
+}
+
+// MockSender for testing
+type MockSender struct {
+	LastTo      string
+	LastSubject string
+	LastBody    string
+}
+
+func (s *MockSender) Send(to string, subject string, body string) error {
+	s.LastTo = to
+	s.LastSubject = subject
+	s.LastBody = body
+	return nil
+}
@@ -6,15 +6,17 @@ package orm
 
 import (
 "fmt"
-"time"
+"net"
+"net/url"
 
 "appsite-go/internal/core/setting"
 
 "gorm.io/driver/mysql"
+"gorm.io/driver/postgres"
 "gorm.io/driver/sqlite"
 "gorm.io/gorm"
-"gorm.io/gorm/logger"
 "gorm.io/gorm/schema"
+"gorm.io/plugin/dbresolver"
 )
 
 // InitDB initializes the database based on configuration
@@ -22,6 +24,8 @@ func InitDB(cfg *setting.DatabaseConfig) (*gorm.DB, error) {
 switch cfg.Type {
 case "mysql":
 return NewMySQLConnection(cfg)
+case "postgres", "postgresql":
+return NewPostgresConnection(cfg)
 case "sqlite", "sqlite3":
 return NewSQLiteConnection(cfg)
 default:
@@ -44,16 +48,30 @@ cfg.Name,
 cfg.Charset,
 )
 
-dialector := mysql.New(mysql.Config{
-DSN:                       dsn,
-DefaultStringSize:         256,
-DisableDatetimePrecision:  true,
-DontSupportRenameIndex:    true,
-DontSupportRenameColumn:   true,
-SkipInitializeWithVersion: false,
-})
+return NewConnection(mysqlDialector(dsn), cfg)
+}
 
-return NewConnection(dialector, cfg)
+// NewPostgresConnection initializes a PostgreSQL connection using GORM.
+func NewPostgresConnection(cfg *setting.DatabaseConfig) (*gorm.DB, error) {
+if cfg == nil {
+return nil, fmt.Errorf("database config is nil")
+}
+
+sslMode := cfg.SSLMode
+if sslMode == "" {
+sslMode = "disable"
+}
+
+// URL form so credentials with spaces or quotes need no escaping rules of their own
+dsn := url.URL{
+Scheme:   "postgres",
+User:     url.UserPassword(cfg.User, cfg.Password),
+Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+Path:     "/" + cfg.Name,
+RawQuery: url.Values{"sslmode": {sslMode}, "TimeZone": {"UTC"}}.Encode(),
+}
+
+return NewConnection(postgresDialector(dsn.String()), cfg)
 }
 
 // NewSQLiteConnection initializes a SQLite connection using GORM.
@@ -72,13 +90,53 @@ dialector := sqlite.Open(dbPath)
 return NewConnection(dialector, cfg)
 }
 
+// mysqlDialector opens a MySQL DSN with the repo's defaults
+func mysqlDialector(dsn string) gorm.Dialector {
+return mysql.New(mysql.Config{
+DSN:                       dsn,
+DefaultStringSize:         256,
+DisableDatetimePrecision:  true,
+DontSupportRenameIndex:    true,
+DontSupportRenameColumn:   true,
+SkipInitializeWithVersion: false,
+})
+}
+
+// postgresDialector opens a PostgreSQL DSN
+func postgresDialector(dsn string) gorm.Dialector {
+return postgres.New(postgres.Config{
+DSN: dsn,
+})
+}
+
+// replicaDialector opens a replica DSN with the same driver as the primary
+func replicaDialector(driver string, dsn string) (gorm.Dialector, error) {
+switch driver {
+case "mysql":
+return mysqlDialector(dsn), nil
+case "postgres":
+return postgresDialector(dsn), nil
+case "sqlite":
+return sqlite.Open(dsn), nil
+default:
+return nil, fmt.Errorf("replicas are not supported for %s", driver)
+}
+}
+
 // NewConnection initializes GORM with a specific dialector.
+// Pool sizes, lifetimes and the SQL log level come from cfg. When cfg lists
+// replicas, plain reads are routed to them and writes, row locks and
+// transactions stay on the primary.
 func NewConnection(dialector gorm.Dialector, cfg *setting.DatabaseConfig) (*gorm.DB, error) {
+if cfg == nil {
+cfg = &setting.DatabaseConfig{}
+}
+
 gormConfig := &gorm.Config{
 NamingStrategy: schema.NamingStrategy{
 SingularTable: true,
 },
-Logger: logger.Default.LogMode(logger.Info),
+Logger: NewLogger(cfg),
 }
 
 db, err := gorm.Open(dialector, gormConfig)
@@ -86,19 +144,49 @@ if err != nil {
 return nil, fmt.Errorf("failed to open database connection: %w", err)
 }
 
+pool := poolConfig(cfg)
+
+if len(cfg.Replicas) > 0 {
+replicas := make([]gorm.Dialector, 0, len(cfg.Replicas))
+for _, dsn := range cfg.Replicas {
+replica, err := replicaDialector(dialector.Name(), dsn)
+if err != nil {
+return nil, err
+}
+replicas = append(replicas, replica)
+}
+
+resolver := dbresolver.Register(dbresolver.Config{
+Replicas: replicas,
+Policy:   dbresolver.RandomPolicy{},
+}).
+SetMaxIdleConns(pool.MaxIdleConns).
+SetMaxOpenConns(pool.MaxOpenConns).
+SetConnMaxLifetime(pool.ConnMaxLifetime).
+SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+if err := db.Use(resolver); err != nil {
+return nil, fmt.Errorf("failed to register read replicas: %w", err)
+}
+return db, nil
+}
+
 sqlDB, err := db.DB()
 if err != nil {
 return nil, err
 }
 
 // SetMaxIdleConns sets the maximum number of connections in the idle connection pool.
-sqlDB.SetMaxIdleConns(10)
+sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
 
 // SetMaxOpenConns sets the maximum number of open connections to the database.
-sqlDB.SetMaxOpenConns(100)
+sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
 
 // SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
-sqlDB.SetConnMaxLifetime(time.Hour)
+sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+// SetConnMaxIdleTime sets the maximum amount of time a connection may be idle.
+sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
 return db, nil
 }
@@ -0,0 +1,208 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateMigration = errors.New("duplicate migration id")
+	ErrUnknownMigration   = errors.New("applied migration is not registered")
+	ErrIrreversible       = errors.New("migration has no down step")
+	ErrInvalidSteps       = errors.New("steps to revert must be at least 1")
+)
+
+// Migration is one versioned schema or data change.
+// IDs sort lexically in apply order, e.g. "202601010001_create_user".
+// Each step runs in a transaction; note that MySQL commits DDL implicitly,
+// so a failing schema step may leave partial changes behind.
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	ID        string `gorm:"primaryKey;size:128"`
+	AppliedAt int64  `gorm:"not null"`
+}
+
+// TableName returns table name
+func (SchemaMigration) TableName() string {
+	return "sys_schema_migration"
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	ID          string
+	Description string
+	Applied     bool
+	AppliedAt   int64
+}
+
+// Migrator applies migrations in ID order and records them in sys_schema_migration
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the given migrations
+func NewMigrator(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	for i := range sorted {
+		if sorted[i].ID == "" || sorted[i].Up == nil {
+			return nil, fmt.Errorf("migration %d: id and up step are required", i)
+		}
+		if i > 0 && sorted[i].ID == sorted[i-1].ID {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateMigration, sorted[i].ID)
+		}
+	}
+
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// applied loads the applied migration records keyed by ID
+func (m *Migrator) applied() (map[string]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var records []SchemaMigration
+	if err := m.db.Order("id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]SchemaMigration, len(records))
+	for _, r := range records {
+		done[r.ID] = r
+	}
+	return done, nil
+}
+
+// Pending returns the migrations not applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := done[mig.ID]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations and returns their IDs
+func (m *Migrator) Up() ([]string, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, mig := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mig.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: mig.ID, AppliedAt: time.Now().Unix()}).Error
+		})
+		if err != nil {
+			return ids, fmt.Errorf("migration %s up: %w", mig.ID, err)
+		}
+		ids = append(ids, mig.ID)
+	}
+	return ids, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns their IDs
+func (m *Migrator) Down(steps int) ([]string, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidSteps, steps)
+	}
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byID[mig.ID] = mig
+	}
+
+	appliedIDs := make([]string, 0, len(done))
+	for id := range done {
+		appliedIDs = append(appliedIDs, id)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(appliedIDs)))
+	if steps < len(appliedIDs) {
+		appliedIDs = appliedIDs[:steps]
+	}
+
+	var ids []string
+	for _, id := range appliedIDs {
+		mig, ok := byID[id]
+		if !ok {
+			return ids, fmt.Errorf("%w: %s", ErrUnknownMigration, id)
+		}
+		if mig.Down == nil {
+			return ids, fmt.Errorf("%w: %s", ErrIrreversible, id)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mig.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{ID: id}).Error
+		})
+		if err != nil {
+			return ids, fmt.Errorf("migration %s down: %w", id, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Status lists every registered migration with its applied state.
+// Applied IDs missing from the registry are reported as well.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]MigrationStatus, 0, len(m.migrations))
+	known := make(map[string]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.ID] = true
+		st := MigrationStatus{ID: mig.ID, Description: mig.Description}
+		if r, ok := done[mig.ID]; ok {
+			st.Applied = true
+			st.AppliedAt = r.AppliedAt
+		}
+		list = append(list, st)
+	}
+	for id, r := range done {
+		if !known[id] {
+			list = append(list, MigrationStatus{ID: id, Description: "(not registered)", Applied: true, AppliedAt: r.AppliedAt})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
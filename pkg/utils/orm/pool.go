@@ -0,0 +1,142 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	stdlog "log"
+	"os"
+	"strings"
+	"time"
+
+	"appsite-go/internal/core/setting"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// PoolConfig holds the effective connection pool settings
+type PoolConfig struct {
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// poolConfig fills unset pool settings with defaults
+func poolConfig(cfg *setting.DatabaseConfig) PoolConfig {
+	pool := PoolConfig{
+		MaxIdleConns:    cfg.MaxIdleConns,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = 10
+	}
+	if pool.MaxOpenConns <= 0 {
+		pool.MaxOpenConns = 100
+	}
+	if pool.ConnMaxLifetime <= 0 {
+		pool.ConnMaxLifetime = time.Hour
+	}
+	if pool.ConnMaxIdleTime <= 0 {
+		pool.ConnMaxIdleTime = 10 * time.Minute
+	}
+	return pool
+}
+
+// ParseLogLevel maps silent, error, warn and info to a GORM log level.
+// Empty or unknown values log warnings (slow queries) and errors only.
+func ParseLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "info", "debug":
+		return logger.Info
+	default:
+		return logger.Warn
+	}
+}
+
+// NewLogger creates the SQL logger configured by cfg
+func NewLogger(cfg *setting.DatabaseConfig) logger.Interface {
+	slow := cfg.SlowThreshold
+	if slow <= 0 {
+		slow = 200 * time.Millisecond
+	}
+	return logger.New(stdlog.New(os.Stdout, "\r\n", stdlog.LstdFlags), logger.Config{
+		SlowThreshold:             slow,
+		LogLevel:                  ParseLogLevel(cfg.LogLevel),
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  true,
+	})
+}
+
+// PoolStat is the connection pool state of one database
+type PoolStat struct {
+	Name              string        `json:"name"` // "primary" or "replica-N"
+	MaxOpen           int           `json:"max_open"`
+	Open              int           `json:"open"`
+	InUse             int           `json:"in_use"`
+	Idle              int           `json:"idle"`
+	WaitCount         int64         `json:"wait_count"`
+	WaitDuration      time.Duration `json:"wait_duration_ns"`
+	MaxIdleClosed     int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64         `json:"max_lifetime_closed"`
+}
+
+func newPoolStat(name string, st sql.DBStats) PoolStat {
+	return PoolStat{
+		Name:              name,
+		MaxOpen:           st.MaxOpenConnections,
+		Open:              st.OpenConnections,
+		InUse:             st.InUse,
+		Idle:              st.Idle,
+		WaitCount:         st.WaitCount,
+		WaitDuration:      st.WaitDuration,
+		MaxIdleClosed:     st.MaxIdleClosed,
+		MaxIdleTimeClosed: st.MaxIdleTimeClosed,
+		MaxLifetimeClosed: st.MaxLifetimeClosed,
+	}
+}
+
+// resolverName is the name dbresolver registers itself under
+const resolverName = "gorm:db_resolver"
+
+// PoolStats reports the pool state of the primary and, when read replicas
+// are configured, of every replica.
+func PoolStats(db *gorm.DB) ([]PoolStat, error) {
+	plugin, ok := db.Config.Plugins[resolverName]
+	resolver, isResolver := plugin.(*dbresolver.DBResolver)
+	if !ok || !isResolver {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		return []PoolStat{newPoolStat("primary", sqlDB.Stats())}, nil
+	}
+
+	// The resolver visits its sources (the primary) before the replicas
+	var stats []PoolStat
+	err := resolver.Call(func(pool gorm.ConnPool) error {
+		sqlDB, ok := pool.(*sql.DB)
+		if !ok {
+			return fmt.Errorf("unexpected connection pool %T", pool)
+		}
+		name := "primary"
+		if len(stats) > 0 {
+			name = fmt.Sprintf("replica-%d", len(stats))
+		}
+		stats = append(stats, newPoolStat(name, sqlDB.Stats()))
+		return nil
+	})
+	return stats, err
+}
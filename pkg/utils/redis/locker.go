@@ -0,0 +1,235 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotAcquired = errors.New("redis: lock not acquired")
+	ErrNotHeld     = errors.New("redis: lock not held")
+	ErrLockLost    = errors.New("redis: lock lost before release")
+)
+
+// LockOptions tunes a Locker; zero values fall back to the defaults
+type LockOptions struct {
+	TTL   time.Duration // key expiry, extended every TTL/3 while held, default 30s
+	Retry time.Duration // wait between attempts of Lock, default 100ms
+}
+
+func (o LockOptions) withDefaults() LockOptions {
+	if o.TTL <= 0 {
+		o.TTL = 30 * time.Second
+	}
+	if o.Retry <= 0 {
+		o.Retry = 100 * time.Millisecond
+	}
+	return o
+}
+
+// acquireScript takes the lock and draws the next fencing token
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// extendScript pushes the expiry back if the caller still owns the lock
+var extendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Locker is a distributed mutex on one key. While held, the key expiry is
+// extended in the background, so the lock outlives long critical sections
+// but still expires if the holder dies. Every acquisition draws a fencing
+// token that increases monotonically per key: pass it to the protected
+// resource and reject writes carrying an older token than the last one
+// seen, which stops a holder that was paused past expiry.
+//
+// The lock lives in the Redis key "{key}" and its tokens in "{key}:fence",
+// which share a hash tag and so a Redis Cluster slot.
+//
+// A Locker is not reentrant and must not be shared by concurrent holders.
+type Locker struct {
+	rdb  *redis.Client
+	key  string
+	opts LockOptions
+
+	mu    sync.Mutex
+	value string
+	token int64
+	stop  chan struct{}
+	lost  chan struct{}
+	done  chan struct{}
+}
+
+// NewLocker creates a locker for key
+func NewLocker(rdb *redis.Client, key string, opts LockOptions) *Locker {
+	return &Locker{rdb: rdb, key: key, opts: opts.withDefaults()}
+}
+
+// TryLock attempts to take the lock once and reports whether it succeeded
+func (l *Locker) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.value != "" {
+		return false, fmt.Errorf("redis: lock %s already held by this locker", l.key)
+	}
+
+	value := strings.ReplaceAll(uuid.New().String(), "-", "")
+	token, err := acquireScript.Run(ctx, l.rdb, []string{lockKey(l.key), fenceKey(l.key)},
+		value, l.opts.TTL.Milliseconds()).Int64()
+	if err != nil || token == 0 {
+		return false, err
+	}
+
+	l.value, l.token = value, token
+	l.stop, l.lost, l.done = make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go l.extend(value, l.stop, l.lost, l.done)
+	return true, nil
+}
+
+// Lock blocks until the lock is taken or ctx is done
+func (l *Locker) Lock(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrNotAcquired, ctx.Err())
+		case <-timer.C:
+		}
+
+		ok, err := l.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		timer.Reset(l.opts.Retry)
+	}
+}
+
+// Unlock releases the lock. It returns ErrLockLost when the lock expired or
+// was taken over while held, meaning the critical section may not have run
+// exclusively.
+func (l *Locker) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.value == "" {
+		return ErrNotHeld
+	}
+
+	close(l.stop)
+	<-l.done
+	value := l.value
+	l.value, l.token = "", 0
+
+	released, err := ReleaseLock(ctx, l.rdb, lockKey(l.key), value)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Token returns the fencing token of the current hold, 0 when not held
+func (l *Locker) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// Lost returns a channel closed when the lock can no longer be extended.
+// It is nil when the lock is not held.
+func (l *Locker) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.value == "" {
+		return nil
+	}
+	return l.lost
+}
+
+// extend keeps the key alive until stop is closed. Transient errors are
+// retried until the last successful extension would have expired.
+func (l *Locker) extend(value string, stop, lost, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(l.opts.TTL / 3)
+	defer ticker.Stop()
+	extended := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.opts.TTL/3)
+		n, err := extendScript.Run(ctx, l.rdb, []string{lockKey(l.key)}, value, l.opts.TTL.Milliseconds()).Int()
+		cancel()
+		if err == nil && n == 1 {
+			extended = time.Now()
+			continue
+		}
+		if err == nil || time.Since(extended) >= l.opts.TTL {
+			close(lost)
+			return
+		}
+	}
+}
+
+// WithLock runs fn while holding the lock on key, waiting for it as long as
+// ctx allows. fn receives the fencing token; its ctx is cancelled if the lock
+// is lost meanwhile, so work bound to it (such as a transaction) is abandoned.
+func WithLock(ctx context.Context, rdb *redis.Client, key string, fn func(ctx context.Context, token int64) error) error {
+	l := NewLocker(rdb, key, LockOptions{})
+	if err := l.Lock(ctx); err != nil {
+		return err
+	}
+
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lost := l.Lost()
+	go func() {
+		select {
+		case <-lost:
+			cancel()
+		case <-lctx.Done():
+		}
+	}()
+
+	err := fn(lctx, l.Token())
+	if uerr := l.Unlock(context.WithoutCancel(ctx)); err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// lockKey wraps key in a hash tag, keeping the lock and its fence together
+func lockKey(key string) string {
+	return "{" + key + "}"
+}
+
+func fenceKey(key string) string {
+	return lockKey(key) + ":fence"
+}
@@ -0,0 +1,299 @@
+package admin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/admin"
+	"appsite-go/internal/apis"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/queue"
+	"appsite-go/internal/core/scheduler"
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/permission"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/contents"
+	centity "appsite-go/internal/services/contents/entity"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/entity"
+)
+
+func setupRouter(t *testing.T) (*gin.Engine, *token.Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	tokenSvc := token.NewService(setting.AppConfig{JwtSecret: "test_secret", JwtExpire: time.Hour}).WithStore(db, rdb)
+	permissionSvc, err := permission.NewDBService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authSvc := account.NewAuthService(db, tokenSvc, verify.NewOTPService(rdb))
+	cron := scheduler.New(db, rdb, scheduler.Options{})
+	if err := cron.Register("purge_trash", "@daily", "Purge expired trash", 0, func(context.Context) error {
+		purged.Store(true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	purged.Store(false)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apis.RegisterRoutes(r, &apis.Container{TokenSvc: tokenSvc, AuthSvc: authSvc})
+	admin.RegisterRoutes(r, &admin.Container{
+		TokenSvc:      tokenSvc,
+		PermissionSvc: permissionSvc,
+		AuthSvc:       authSvc,
+		OAuthSvc:      oauth.NewService(db, rdb, tokenSvc, "http://localhost"),
+		DB:            db,
+		Jobs:          queue.New(rdb),
+		Scheduler:     cron,
+	})
+	return r, tokenSvc, db
+}
+
+// purged records a run of the purge_trash job of setupRouter
+var purged atomic.Bool
+
+type result struct {
+	Code int                    `json:"code"`
+	Data map[string]interface{} `json:"data"`
+}
+
+func do(r *gin.Engine, method, path, bearer string, body interface{}) result {
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var res result
+	json.Unmarshal(w.Body.Bytes(), &res)
+	return res
+}
+
+func call(t *testing.T, r *gin.Engine, tokens *token.Service, role, method, path string, body interface{}) int {
+	t.Helper()
+	bearer := ""
+	if role != "" {
+		pair, err := tokens.IssuePair(t.Context(), "u-"+role, role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bearer = pair.AccessToken
+	}
+	return do(r, method, path, bearer, body).Code
+}
+
+func TestAdminRoutes_NeedPermission(t *testing.T) {
+	r, tokens, _ := setupRouter(t)
+
+	if code := call(t, r, tokens, "", http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Unauthorized) {
+		t.Errorf("anonymous = %d", code)
+	}
+	if code := call(t, r, tokens, migrations.DefaultGroupID, http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Forbidden) {
+		t.Errorf("member = %d", code)
+	}
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Success) {
+		t.Errorf("admin = %d", code)
+	}
+	// Logging in needs no permission
+	if code := call(t, r, tokens, "", http.MethodPost, "/admin/v1/login", nil); code == int(apperr.Unauthorized) || code == int(apperr.Forbidden) {
+		t.Errorf("login = %d", code)
+	}
+}
+
+func TestAdminRoutes_EditPolicies(t *testing.T) {
+	r, tokens, _ := setupRouter(t)
+	member := migrations.DefaultGroupID
+	policy := permission.Policy{Role: member, Path: "/admin/v1/users", Method: "GET"}
+
+	if code := call(t, r, tokens, member, http.MethodPost, "/admin/v1/permissions/policies", policy); code != int(apperr.Forbidden) {
+		t.Errorf("member granting itself = %d", code)
+	}
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodPost, "/admin/v1/permissions/policies", policy); code != int(apperr.Success) {
+		t.Fatalf("grant = %d", code)
+	}
+	if code := call(t, r, tokens, member, http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Success) {
+		t.Errorf("member after grant = %d", code)
+	}
+	if code := call(t, r, tokens, member, http.MethodDelete, "/admin/v1/users/1", nil); code != int(apperr.Forbidden) {
+		t.Errorf("member deleting = %d", code)
+	}
+
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodDelete, "/admin/v1/permissions/policies", policy); code != int(apperr.Success) {
+		t.Fatalf("revoke = %d", code)
+	}
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodDelete, "/admin/v1/permissions/policies", policy); code != int(apperr.NotFound) {
+		t.Errorf("revoke twice = %d", code)
+	}
+	if code := call(t, r, tokens, member, http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Forbidden) {
+		t.Errorf("member after revoke = %d", code)
+	}
+}
+
+func TestAdminRoutes_PlatformOnly(t *testing.T) {
+	r, tokens, _ := setupRouter(t)
+	pair, err := tokens.IssueGrant(t.Context(), "u-tenant-admin", migrations.AdminGroupID, token.Grant{SaasID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenantAdmin := pair.AccessToken
+
+	// The admins of a tenant manage its rows
+	if res := do(r, http.MethodGet, "/admin/v1/users", tenantAdmin, nil); res.Code != int(apperr.Success) {
+		t.Errorf("tenant admin on users = %d", res.Code)
+	}
+
+	// but not what all tenants share
+	seed := permission.Policy{Role: migrations.AdminGroupID, Path: "/admin/v1/*", Method: "*"}
+	open := permission.Policy{Role: "100", Path: "/admin/v1/*", Method: "*"}
+	shared := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, "/admin/v1/permissions/policies", nil},
+		{http.MethodPost, "/admin/v1/permissions/policies", open},
+		{http.MethodDelete, "/admin/v1/permissions/policies", seed},
+		{http.MethodPost, "/admin/v1/login/unlock-ip", map[string]string{"ip": "192.0.2.1"}},
+		{http.MethodPost, "/admin/v1/oauth/clients", map[string]string{"name": "evil"}},
+		{http.MethodPost, "/admin/v1/system/cron/purge_trash/run", nil},
+		{http.MethodGet, "/admin/v1/system/jobs/mail/dead", nil},
+		{http.MethodPost, "/admin/v1/system/jobs/mail/dead/1/retry", nil},
+	}
+	for _, tc := range shared {
+		if res := do(r, tc.method, tc.path, tenantAdmin, tc.body); res.Code != int(apperr.Forbidden) {
+			t.Errorf("tenant admin on %s %s = %d", tc.method, tc.path, res.Code)
+		}
+	}
+	if purged.Load() {
+		t.Error("A tenant admin ran a platform job")
+	}
+
+	// The seeded policy still holds, the platform admin still gets in
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodGet, "/admin/v1/permissions/policies", nil); code != int(apperr.Success) {
+		t.Errorf("platform admin on policies = %d", code)
+	}
+	if code := call(t, r, tokens, migrations.AdminGroupID, http.MethodPost, "/admin/v1/system/cron/purge_trash/run", nil); code != int(apperr.Success) {
+		t.Errorf("platform admin on cron = %d", code)
+	}
+	if code := call(t, r, tokens, "100", http.MethodGet, "/admin/v1/users", nil); code != int(apperr.Forbidden) {
+		t.Errorf("group 100 = %d", code)
+	}
+}
+
+func TestAdminRoutes_ProfileCannotChangeGroup(t *testing.T) {
+	r, tokens, db := setupRouter(t)
+	user := &entity.User{Username: "mallory", Status: "enabled", GroupID: migrations.DefaultGroupID}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tokens.IssuePair(t.Context(), user.ID, user.GroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := do(r, http.MethodPut, "/api/v1/account/profile", pair.AccessToken,
+		map[string]string{"groupid": migrations.AdminGroupID, "status": "enabled", "nickname": "M"})
+	if res.Code != int(apperr.Success) {
+		t.Fatalf("profile = %+v", res)
+	}
+	var stored entity.User
+	db.First(&stored, "id = ?", user.ID)
+	if stored.GroupID != migrations.DefaultGroupID || stored.Nickname != "M" {
+		t.Errorf("user after profile update = %s %q", stored.GroupID, stored.Nickname)
+	}
+
+	// Not even with tokens refreshed from the stored group
+	res = do(r, http.MethodPost, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": pair.RefreshToken})
+	access, _ := res.Data["token"].(string)
+	if access == "" {
+		t.Fatalf("refresh = %+v", res)
+	}
+	if code := do(r, http.MethodGet, "/admin/v1/users", access, nil).Code; code != int(apperr.Forbidden) {
+		t.Errorf("member on admin routes = %d", code)
+	}
+}
+
+func TestAdminRoutes_RegroupingSignsOut(t *testing.T) {
+	r, tokens, db := setupRouter(t)
+	user := &entity.User{Username: "ada", Status: "enabled", GroupID: migrations.AdminGroupID}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tokens.IssuePair(t.Context(), user.ID, user.GroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := call(t, r, tokens, migrations.AdminGroupID, http.MethodPut, "/admin/v1/users/"+user.ID,
+		map[string]string{"groupid": migrations.DefaultGroupID})
+	if admin != int(apperr.Success) {
+		t.Fatalf("demote = %d", admin)
+	}
+	// The demoted admin's tokens still name the old group; they are revoked
+	if code := do(r, http.MethodGet, "/admin/v1/users", pair.AccessToken, nil).Code; code != int(apperr.Unauthorized) {
+		t.Errorf("demoted admin = %d", code)
+	}
+}
+
+func TestAdminRoutes_Trash(t *testing.T) {
+	r, tokens, db := setupRouter(t)
+	pair, err := tokens.IssuePair(t.Context(), "u-admin", migrations.AdminGroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := pair.AccessToken
+
+	tags := contents.NewTagService(db)
+	tag := &centity.Tag{Title: "go"}
+	if err := tags.Create(tag); err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.Delete(tag.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	res := do(r, http.MethodGet, "/admin/v1/trash/tags", admin, nil)
+	if res.Code != int(apperr.Success) || res.Data["total"].(float64) != 1 {
+		t.Fatalf("list = %+v", res)
+	}
+	if res := do(r, http.MethodPost, "/admin/v1/trash/tags/"+tag.ID+"/restore", admin, nil); res.Code != int(apperr.Success) {
+		t.Fatalf("restore = %d", res.Code)
+	}
+	if _, err := tags.Get(tag.ID); err != nil {
+		t.Errorf("restored tag should be live: %v", err)
+	}
+
+	// Live rows cannot be purged, unknown kinds do not exist
+	if res := do(r, http.MethodDelete, "/admin/v1/trash/tags/"+tag.ID+"/purge", admin, nil); res.Code != int(apperr.NotFound) {
+		t.Errorf("purge live = %d", res.Code)
+	}
+	if res := do(r, http.MethodGet, "/admin/v1/trash/secrets", admin, nil); res.Code != int(apperr.NotFound) {
+		t.Errorf("unknown kind = %d", res.Code)
+	}
+}
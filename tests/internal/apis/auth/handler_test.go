@@ -2,6 +2,7 @@ package auth_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -14,11 +15,16 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/apis"
 	"appsite-go/internal/apis/auth"
+	apperr "appsite-go/internal/core/error"
 	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/lockout"
 	"appsite-go/internal/services/access/token"
 	"appsite-go/internal/services/access/verify"
 	"appsite-go/internal/services/user/account"
+	"appsite-go/pkg/extra/sms"
 )
 
 func setupDB(t *testing.T) *gorm.DB {
@@ -26,10 +32,18 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
 func setupAuthService(t *testing.T) *account.AuthService {
+	svc, _ := setupServices(t)
+	return svc
+}
+
+func setupServices(t *testing.T) (*account.AuthService, *token.Service) {
 	db := setupDB(t)
 	s, err := miniredis.Run()
 	if err != nil {
@@ -44,8 +58,8 @@ func setupAuthService(t *testing.T) *account.AuthService {
 		JwtSecret: "test_secret",
 		JwtExpire: time.Hour,
 	}
-	tokenSvc := token.NewService(cfg)
-	return account.NewAuthService(db, tokenSvc, otpSvc)
+	tokenSvc := token.NewService(cfg).WithStore(db, rdb)
+	return account.NewAuthService(db, tokenSvc, otpSvc), tokenSvc
 }
 
 func TestRegister(t *testing.T) {
@@ -107,3 +121,124 @@ func TestLogin(t *testing.T) {
 		t.Errorf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
 	}
 }
+
+type tokenResp struct {
+	Code int `json:"code"`
+	Data struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	} `json:"data"`
+}
+
+func post(r *gin.Engine, path, bearer string, body interface{}) tokenResp {
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp tokenResp
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	svc, tokenSvc := setupServices(t)
+	svc.Register(account.RegisterInput{Username: "session_user", Password: "password123", Email: "session@example.com"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apis.RegisterRoutes(r, &apis.Container{AuthSvc: svc, TokenSvc: tokenSvc})
+
+	login := post(r, "/api/v1/auth/login", "", map[string]string{"identifier": "session_user", "password": "password123"})
+	if login.Code != 200 || login.Data.RefreshToken == "" {
+		t.Fatalf("Login failed: %+v", login)
+	}
+
+	refreshed := post(r, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": login.Data.RefreshToken})
+	if refreshed.Code != 200 || refreshed.Data.Token == "" || refreshed.Data.RefreshToken == login.Data.RefreshToken {
+		t.Fatalf("Refresh failed: %+v", refreshed)
+	}
+
+	// A refresh token works once
+	if resp := post(r, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": login.Data.RefreshToken}); resp.Code != 401 {
+		t.Errorf("Expected 401 on reuse, got %+v", resp)
+	}
+
+	// Reuse revoked the family, so log in again for the logout
+	login = post(r, "/api/v1/auth/login", "", map[string]string{"identifier": "session_user", "password": "password123"})
+	if resp := post(r, "/api/v1/auth/logout", login.Data.Token, nil); resp.Code != 200 {
+		t.Fatalf("Logout failed: %+v", resp)
+	}
+	if resp := post(r, "/api/v1/auth/logout", login.Data.Token, nil); resp.Code != 401 {
+		t.Errorf("Logged out token should be rejected, got %+v", resp)
+	}
+	if resp := post(r, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": login.Data.RefreshToken}); resp.Code != 401 {
+		t.Errorf("Logged out refresh token should be rejected, got %+v", resp)
+	}
+}
+
+func TestOTPSendAndLogin(t *testing.T) {
+	db := setupDB(t)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	sender := &sms.MockSender{}
+	otpSvc := verify.NewOTPService(rdb).WithSenders(sender, nil).
+		WithOptions(verify.OTPOptions{SMSTemplate: "SMS_001"})
+	tokenSvc := token.NewService(setting.AppConfig{JwtSecret: "test_secret", JwtExpire: time.Hour}).WithStore(db, rdb)
+	svc := account.NewAuthService(db, tokenSvc, otpSvc)
+	// The SMS sender makes registering prove the mobile
+	reg, _ := otpSvc.Issue(context.Background(), verify.PurposeRegister, "13600000000")
+	if _, err := svc.Register(account.RegisterInput{Username: "otp_user", Password: "password123", Mobile: "13600000000", MobileCode: reg}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apis.RegisterRoutes(r, &apis.Container{AuthSvc: svc, TokenSvc: tokenSvc, OTPSvc: otpSvc})
+
+	send := map[string]string{"target": "13600000000", "purpose": "login"}
+	if resp := post(r, "/api/v1/auth/otp", "", send); resp.Code != 200 {
+		t.Fatalf("Send failed: %+v", resp)
+	}
+	code := sender.LastParams["code"]
+	if sender.LastTemplate != "SMS_001" || len(code) != 6 {
+		t.Fatalf("Unexpected SMS %+v", sender)
+	}
+	if resp := post(r, "/api/v1/auth/otp", "", send); resp.Code != 429 {
+		t.Errorf("Expected a cooldown, got %+v", resp)
+	}
+
+	wrong := map[string]string{"target": "13600000000", "code": "x"}
+	if resp := post(r, "/api/v1/auth/login/otp", "", wrong); resp.Code != 401 {
+		t.Errorf("Expected 401 for a wrong code, got %+v", resp)
+	}
+	login := post(r, "/api/v1/auth/login/otp", "", map[string]string{"target": "13600000000", "code": code})
+	if login.Code != 200 || login.Data.Token == "" || login.Data.RefreshToken == "" {
+		t.Fatalf("OTP login failed: %+v", login)
+	}
+}
+
+func TestLoginFailuresAreUniform(t *testing.T) {
+	svc := setupAuthService(t)
+	s := miniredis.RunT(t)
+	svc.WithLockout(lockout.NewService(redis.NewClient(&redis.Options{Addr: s.Addr()}), nil, lockout.Options{MaxFailures: 2, DelayAfter: 100}))
+	_, _ = svc.Register(account.RegisterInput{Username: "dave", Password: "password123", Email: "dave@example.com"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", auth.NewHandler(svc).Login)
+
+	wrong := post(r, "/login", "", map[string]string{"identifier": "dave", "password": "nope"})
+	unknown := post(r, "/login", "", map[string]string{"identifier": "erin", "password": "nope"})
+	if wrong.Code != int(apperr.Unauthorized) || unknown.Code != int(apperr.Unauthorized) {
+		t.Fatalf("expected 401 for both, got %d and %d", wrong.Code, unknown.Code)
+	}
+
+	post(r, "/login", "", map[string]string{"identifier": "dave", "password": "nope"})
+	if resp := post(r, "/login", "", map[string]string{"identifier": "dave", "password": "password123"}); resp.Code != int(apperr.TooManyRequests) {
+		t.Errorf("expected 429 once locked, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/apis"
+	"appsite-go/internal/apis/auth"
+	"appsite-go/internal/services/access/social"
+)
+
+// fakeWechat signs every code in as the same WeChat user
+func fakeWechat(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "at", "openid": "o_1"})
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"openid": "o_1", "nickname": "wx"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSocialCallback_NeedsTheStartingBrowser(t *testing.T) {
+	svc, tokenSvc := setupServices(t)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	svc.WithSocial(social.NewService(rdb).WithProvider(&social.Wechat{AppID: "wx", APIURL: fakeWechat(t).URL}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apis.RegisterRoutes(r, &apis.Container{AuthSvc: svc, TokenSvc: tokenSvc})
+
+	// begin starts a redirect, returning its state and the cookie set
+	begin := func() (string, *http.Cookie) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/social/wechat", nil))
+		var resp struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		u, err := url.Parse(resp.Data.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range w.Result().Cookies() {
+			if c.Name == auth.StateCookie {
+				if !c.HttpOnly || c.SameSite != http.SameSiteLaxMode {
+					t.Errorf("Unexpected cookie %+v", c)
+				}
+				return u.Query().Get("state"), c
+			}
+		}
+		t.Fatalf("No state cookie in %v", w.Result().Cookies())
+		return "", nil
+	}
+	callback := func(state string, cookie *http.Cookie) tokenResp {
+		req := httptest.NewRequest("GET", "/api/v1/callback/wechat?code=c&state="+url.QueryEscape(state), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp tokenResp
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// A state sent to another browser, as in login CSRF, is refused
+	state, _ := begin()
+	if resp := callback(state, nil); resp.Code != 401 {
+		t.Errorf("Expected 401 without the cookie, got %+v", resp)
+	}
+	state, _ = begin()
+	_, other := begin()
+	if resp := callback(state, other); resp.Code != 401 {
+		t.Errorf("Expected 401 with the cookie of another redirect, got %+v", resp)
+	}
+
+	state, cookie := begin()
+	if resp := callback(state, cookie); resp.Code != 200 || resp.Data.Token == "" {
+		t.Errorf("Callback failed: %+v", resp)
+	}
+}
@@ -0,0 +1,107 @@
+package content_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/apis/content"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/contents"
+	"appsite-go/internal/services/contents/entity"
+)
+
+func setupRouter(t *testing.T) (*gin.Engine, *contents.ArticleService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	articleSvc := contents.NewArticleService(db)
+	h := content.NewHandler(articleSvc, contents.NewBannerService(db))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/articles/:id", h.GetArticle)
+	r.PUT("/articles/:id", h.UpdateArticle)
+	return r, articleSvc
+}
+
+func put(r *gin.Engine, id, ifMatch string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPut, "/articles/"+id, bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestUpdateArticle_IfMatch(t *testing.T) {
+	r, svc := setupRouter(t)
+	article := &entity.Article{Title: "Draft"}
+	if err := svc.Create(article); err != nil {
+		t.Fatal(err)
+	}
+
+	// GET exposes the version as ETag
+	req, _ := http.NewRequest(http.MethodGet, "/articles/"+article.ID, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("Expected ETag \"1\", got %q", etag)
+	}
+
+	// First writer succeeds and receives the new ETag
+	w, resp := put(r, article.ID, etag, map[string]interface{}{"title": "First"})
+	if resp["code"].(float64) != float64(apperr.Success) {
+		t.Fatalf("Expected success, got %v", resp)
+	}
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("Expected new ETag \"2\", got %q", got)
+	}
+
+	// Second writer with the stale ETag gets a conflict
+	_, resp = put(r, article.ID, etag, map[string]interface{}{"title": "Second"})
+	if resp["code"].(float64) != float64(apperr.Conflict) {
+		t.Errorf("Expected conflict, got %v", resp)
+	}
+
+	// Weak tags never match, not even the current one
+	_, resp = put(r, article.ID, `W/"2"`, map[string]interface{}{"title": "Weak"})
+	if resp["code"].(float64) != float64(apperr.InvalidParams) {
+		t.Errorf("Expected invalid params for a weak ETag, got %v", resp)
+	}
+
+	// Malformed header
+	_, resp = put(r, article.ID, "abc", map[string]interface{}{"title": "Third"})
+	if resp["code"].(float64) != float64(apperr.InvalidParams) {
+		t.Errorf("Expected invalid params, got %v", resp)
+	}
+
+	// Malformed version in the body
+	_, resp = put(r, article.ID, "", map[string]interface{}{"title": "Fourth", "version": "abc"})
+	if resp["code"].(float64) != float64(apperr.InvalidParams) {
+		t.Errorf("Expected invalid params for body version, got %v", resp)
+	}
+
+	current, _ := svc.Get(article.ID)
+	if current.Title != "First" || current.Version != 2 {
+		t.Errorf("Expected First at version 2, got %s at %d", current.Title, current.Version)
+	}
+}
@@ -0,0 +1,176 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/apis"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/route"
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/apikey"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/contents"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/entity"
+)
+
+func setupRouter(t *testing.T) (*gin.Engine, *apikey.Service, *token.Service, *entity.User) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(model.NewTenantPlugin()); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	tokenSvc := token.NewService(setting.AppConfig{JwtSecret: "test_secret", JwtExpire: time.Hour}).WithStore(db, rdb)
+	keys := apikey.NewService(db, rdb)
+
+	user := &entity.User{Username: "ada", Status: "enabled", GroupID: "100"}
+	if err := db.WithContext(model.WithTenant(t.Context(), "")).Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(route.SaasMiddleware())
+	apis.RegisterRoutes(r, &apis.Container{
+		TokenSvc:   tokenSvc,
+		AuthSvc:    account.NewAuthService(db, tokenSvc, verify.NewOTPService(rdb)),
+		APIKeySvc:  keys,
+		ArticleSvc: contents.NewArticleService(db),
+		BannerSvc:  contents.NewBannerService(db),
+	})
+	return r, keys, tokenSvc, user
+}
+
+func call(r *gin.Engine, method, path string, header http.Header, body interface{}) map[string]interface{} {
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func code(resp map[string]interface{}) int {
+	c, _ := resp["code"].(float64)
+	return int(c)
+}
+
+func TestAPIKey_ScopesOnContent(t *testing.T) {
+	r, keys, _, user := setupRouter(t)
+	_, reader, err := keys.Create(model.WithTenant(t.Context(), ""), apikey.Input{UserID: user.ID, Name: "r", Scopes: []string{"content:read"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, writer, _ := keys.Create(model.WithTenant(t.Context(), ""), apikey.Input{UserID: user.ID, Name: "w", Scopes: []string{"content:*"}}, "")
+	article := map[string]string{"title": "From the partner"}
+
+	resp := call(r, http.MethodPost, "/api/v1/content/articles", http.Header{"X-Api-Key": {reader}}, article)
+	if code(resp) != int(apperr.Forbidden) {
+		t.Errorf("write with a read key = %v", resp)
+	}
+	resp = call(r, http.MethodPost, "/api/v1/content/articles", http.Header{"X-Api-Key": {writer}}, article)
+	if code(resp) != int(apperr.Success) {
+		t.Errorf("write with a write key = %v", resp)
+	}
+	// Keys also come as Bearer tokens
+	resp = call(r, http.MethodPost, "/api/v1/content/articles", http.Header{"Authorization": {"Bearer " + writer}}, article)
+	if code(resp) != int(apperr.Success) {
+		t.Errorf("write with a Bearer key = %v", resp)
+	}
+	// No scope on the users group
+	resp = call(r, http.MethodGet, "/api/v1/users", http.Header{"X-Api-Key": {writer}}, nil)
+	if code(resp) != int(apperr.Forbidden) {
+		t.Errorf("users with a content key = %v", resp)
+	}
+	resp = call(r, http.MethodPost, "/api/v1/content/articles", http.Header{"X-Api-Key": {writer + "x"}}, article)
+	if code(resp) != int(apperr.Unauthorized) {
+		t.Errorf("unknown key = %v", resp)
+	}
+}
+
+func TestAPIKey_ManagedByTheUserOnly(t *testing.T) {
+	r, _, tokens, user := setupRouter(t)
+	pair, err := tokens.IssuePair(t.Context(), user.ID, user.GroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bearer := http.Header{"Authorization": {"Bearer " + pair.AccessToken}}
+
+	resp := call(r, http.MethodPost, "/api/v1/account/api-keys", bearer,
+		map[string]interface{}{"name": "CI", "scopes": []string{"*"}})
+	data, _ := resp["data"].(map[string]interface{})
+	raw, _ := data["api_key"].(string)
+	if code(resp) != int(apperr.Success) || raw == "" {
+		t.Fatalf("create = %v", resp)
+	}
+
+	// Even a key with every scope cannot manage keys or the account
+	resp = call(r, http.MethodGet, "/api/v1/account/api-keys", http.Header{"X-Api-Key": {raw}}, nil)
+	if code(resp) != int(apperr.Unauthorized) {
+		t.Errorf("list with a key = %v", resp)
+	}
+
+	resp = call(r, http.MethodPost, "/api/v1/account/api-keys", bearer,
+		map[string]interface{}{"name": "CI", "scopes": []string{"content:delete"}})
+	if code(resp) != int(apperr.InvalidParams) {
+		t.Errorf("bad scope = %v", resp)
+	}
+}
+
+func TestAuth_TenantComesFromTheCredential(t *testing.T) {
+	r, keys, tokens, user := setupRouter(t)
+	pair, err := tokens.IssuePair(t.Context(), user.ID, user.GroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := keys.Create(model.WithTenant(t.Context(), ""), apikey.Input{UserID: user.ID, Name: "w", Scopes: []string{"content:*"}}, "")
+
+	for name, req := range map[string]struct {
+		method, path string
+		header       http.Header
+		body         interface{}
+	}{
+		"token": {http.MethodGet, "/api/v1/users", http.Header{"Authorization": {"Bearer " + pair.AccessToken}}, nil},
+		"key":   {http.MethodPost, "/api/v1/content/articles", http.Header{"X-Api-Key": {key}}, map[string]string{"title": "t"}},
+	} {
+		resp := call(r, req.method, req.path, req.header, req.body)
+		if code(resp) != int(apperr.Success) {
+			t.Errorf("%s in its own tenant = %v", name, resp)
+		}
+		req.header.Set("X-Tenant-ID", "other")
+		resp = call(r, req.method, req.path, req.header, req.body)
+		if code(resp) != int(apperr.Forbidden) {
+			t.Errorf("%s asking for another tenant = %v", name, resp)
+		}
+		req.header.Del("X-Tenant-ID")
+		resp = call(r, req.method, req.path+"?tenant_id=other", req.header, req.body)
+		if code(resp) != int(apperr.Forbidden) {
+			t.Errorf("%s asking for another tenant by query = %v", name, resp)
+		}
+	}
+}
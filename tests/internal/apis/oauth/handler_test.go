@@ -0,0 +1,132 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/apis"
+	apperr "appsite-go/internal/core/error"
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/account"
+)
+
+func setupRouter(t *testing.T) (*gin.Engine, *oauth.Service, *token.Service) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	tokenSvc := token.NewService(setting.AppConfig{JwtSecret: "test_secret", JwtExpire: time.Hour}).WithStore(db, rdb)
+	oauthSvc := oauth.NewService(db, rdb, tokenSvc, "https://id.example.com")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apis.RegisterRoutes(r, &apis.Container{
+		TokenSvc: tokenSvc,
+		AuthSvc:  account.NewAuthService(db, tokenSvc, verify.NewOTPService(rdb)),
+		OAuthSvc: oauthSvc,
+	})
+	return r, oauthSvc, tokenSvc
+}
+
+func TestToken_ClientCredentialsOverBasicAuth(t *testing.T) {
+	r, svc, _ := setupRouter(t)
+	c, secret, err := svc.CreateClient(t.Context(), oauth.ClientInput{
+		Name: "Reports", Scopes: []string{"reports:read"}, GrantTypes: []string{oauth.GrantClientCredentials},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(secret string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(url.QueryEscape(c.ID), url.QueryEscape(secret))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Answers follow RFC 6749, not the API envelope
+	w := post(secret)
+	var res oauth.TokenResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusOK || res.AccessToken == "" || res.TokenType != "Bearer" || res.Scope != "reports:read" {
+		t.Fatalf("token = %d %s", w.Code, w.Body)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Error("token answer is cacheable")
+	}
+
+	w = post("wrong")
+	var oerr oauth.Error
+	json.Unmarshal(w.Body.Bytes(), &oerr)
+	if w.Code != http.StatusUnauthorized || oerr.Code != "invalid_client" || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("wrong secret = %d %s", w.Code, w.Body)
+	}
+}
+
+func TestAppTokensStayOnAppRoutes(t *testing.T) {
+	r, _, tokens := setupRouter(t)
+	pair, err := tokens.IssueGrant(t.Context(), "u1", "100", token.Grant{ClientID: "app", Scope: "profile"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First-party routes refuse the tokens of apps
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/account/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var body struct {
+		Code int `json:"code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Code != int(apperr.Forbidden) {
+		t.Errorf("app token on /account = %s", w.Body)
+	}
+
+	// User info takes them, checking their scope
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/oauth/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Header().Get("WWW-Authenticate"), "insufficient_scope") {
+		t.Errorf("userinfo without openid = %d %s", w.Code, w.Body)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	r, _, _ := setupRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+	var m oauth.Metadata
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Issuer != "https://id.example.com" || m.TokenEndpoint != "https://id.example.com/api/v1/oauth/token" ||
+		m.AuthorizationEndpoint != "https://id.example.com/oauth/authorize" || m.CodeChallengeMethodsSupported[0] != "S256" {
+		t.Errorf("discovery = %+v", m)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/model"
+)
+
+type Pinged struct {
+	Seq int `json:"seq"`
+}
+
+func (Pinged) EventName() string { return "test.pinged" }
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&event.Outbox{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func publish(t *testing.T, db *gorm.DB, e event.Event) {
+	if err := db.Transaction(func(tx *gorm.DB) error { return event.Publish(tx, e) }); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+}
+
+func outbox(t *testing.T, db *gorm.DB) event.Outbox {
+	var row event.Outbox
+	if err := db.First(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestPublish_Transactional(t *testing.T) {
+	db := setupDB(t)
+
+	if err := event.Publish(db, Pinged{}); !errors.Is(err, event.ErrNoTransaction) {
+		t.Errorf("Publish outside a transaction should fail, got %v", err)
+	}
+
+	// A rolled back change takes its events with it
+	db.Transaction(func(tx *gorm.DB) error {
+		event.Publish(tx, Pinged{Seq: 1})
+		return errors.New("rollback")
+	})
+	var count int64
+	db.Model(&event.Outbox{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no events after rollback, got %d", count)
+	}
+
+	ctx := model.WithTenant(context.Background(), "t1")
+	db.WithContext(ctx).Transaction(func(tx *gorm.DB) error { return event.Publish(tx, Pinged{Seq: 2}) })
+	if row := outbox(t, db); row.Name != "test.pinged" || row.SaasID != "t1" || row.Status != event.StatusPending {
+		t.Errorf("Unexpected outbox row %+v", row)
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	db := setupDB(t)
+	bus := event.NewBus()
+
+	var got []int
+	var tenant string
+	event.On(bus, "recorder", func(ctx context.Context, e Pinged) error {
+		got = append(got, e.Seq)
+		tenant, _ = model.TenantFrom(ctx)
+		return nil
+	})
+
+	db.WithContext(model.WithTenant(context.Background(), "t9")).Transaction(func(tx *gorm.DB) error {
+		return event.Publish(tx, Pinged{Seq: 7})
+	})
+
+	d := event.NewDispatcher(db, bus, event.Options{})
+	n, err := d.DispatchOnce(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("DispatchOnce = %d, %v", n, err)
+	}
+	if len(got) != 1 || got[0] != 7 || tenant != "t9" {
+		t.Errorf("Handler got %v in tenant %q", got, tenant)
+	}
+	if row := outbox(t, db); row.Status != event.StatusDone {
+		t.Errorf("Expected done, got %s", row.Status)
+	}
+
+	// Nothing is delivered twice
+	if n, _ := d.DispatchOnce(context.Background()); n != 0 || len(got) != 1 {
+		t.Errorf("Done events must not be redelivered")
+	}
+}
+
+func TestDispatcher_RetryAndDeadLetter(t *testing.T) {
+	db := setupDB(t)
+	bus := event.NewBus()
+
+	okCalls, failCalls := 0, 0
+	bus.Subscribe("test.pinged", "ok", func(ctx context.Context, payload []byte) error {
+		okCalls++
+		return nil
+	})
+	bus.Subscribe("test.pinged", "flaky", func(ctx context.Context, payload []byte) error {
+		failCalls++
+		if failCalls == 1 {
+			panic("boom")
+		}
+		return errors.New("downstream unavailable")
+	})
+
+	publish(t, db, Pinged{Seq: 1})
+	d := event.NewDispatcher(db, bus, event.Options{MaxAttempts: 3, Backoff: time.Hour})
+	ctx := context.Background()
+
+	d.DispatchOnce(ctx)
+	row := outbox(t, db)
+	if row.Status != event.StatusPending || row.Attempts != 1 || row.NextAttemptAt <= time.Now().Unix() {
+		t.Fatalf("Expected a scheduled retry, got %+v", row)
+	}
+	if len(row.Delivered) != 1 || row.Delivered[0] != "ok" || row.LastError == "" {
+		t.Errorf("Expected ok delivered and the panic recorded, got %+v", row)
+	}
+
+	// Not due yet
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Error("Retry should wait for the backoff")
+	}
+
+	// Make it due twice more: the third failure dead-letters it
+	for i := 0; i < 2; i++ {
+		db.Model(&event.Outbox{}).Where("id = ?", row.ID).UpdateColumn("next_attempt_at", 0)
+		d.DispatchOnce(ctx)
+	}
+	row = outbox(t, db)
+	if row.Status != event.StatusDead || row.Attempts != 3 {
+		t.Fatalf("Expected dead letter after 3 attempts, got %+v", row)
+	}
+	if okCalls != 1 || failCalls != 3 {
+		t.Errorf("Succeeded subscribers must not be retried: ok=%d flaky=%d", okCalls, failCalls)
+	}
+
+	page, err := d.DeadLetters(context.Background(), &model.ListParams{})
+	if err != nil || page.Total != 1 {
+		t.Fatalf("DeadLetters = %v, %v", page, err)
+	}
+
+	// Requeue gives it a fresh budget
+	if err := d.Requeue(context.Background(), row.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Requeue(context.Background(), row.ID); !errors.Is(err, event.ErrNotDead) {
+		t.Errorf("Requeue of a pending event should fail, got %v", err)
+	}
+	d.DispatchOnce(ctx)
+	if row = outbox(t, db); row.Attempts != 1 || failCalls != 4 {
+		t.Errorf("Requeued event should be delivered again, got %+v", row)
+	}
+}
+
+func TestDispatcher_PurgeDelivered(t *testing.T) {
+	db := setupDB(t)
+	bus := event.NewBus()
+	event.On(bus, "ok", func(context.Context, Pinged) error { return nil })
+
+	publish(t, db, Pinged{Seq: 1})
+	d := event.NewDispatcher(db, bus, event.Options{})
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	publish(t, db, Pinged{Seq: 2})
+	db.Create(&event.Outbox{Name: "test.pinged", Status: event.StatusDead})
+
+	// Only delivered events older than the cutoff go
+	if n, err := d.PurgeDelivered(context.Background(), time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PurgeDelivered before delivery = %d, %v", n, err)
+	}
+	n, err := d.PurgeDelivered(context.Background(), time.Now().Add(time.Hour))
+	if err != nil || n != 1 {
+		t.Errorf("PurgeDelivered = %d, %v", n, err)
+	}
+	var left []event.Outbox
+	db.Order("status").Find(&left)
+	if len(left) != 2 || left[0].Status != event.StatusDead || left[1].Status != event.StatusPending {
+		t.Errorf("Expected the dead and pending events kept, got %+v", left)
+	}
+}
+
+func TestDispatcher_Lease(t *testing.T) {
+	db := setupDB(t)
+	publish(t, db, Pinged{Seq: 1})
+
+	// A dispatcher crashing mid-delivery leaves the event leased
+	blocked := event.NewBus()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocked.Subscribe("test.pinged", "slow", func(ctx context.Context, payload []byte) error {
+		close(started)
+		<-release
+		return nil
+	})
+	finished := make(chan struct{})
+	go func() {
+		event.NewDispatcher(db, blocked, event.Options{}).DispatchOnce(context.Background())
+		close(finished)
+	}()
+	<-started
+
+	calls := 0
+	other := event.NewBus()
+	other.Subscribe("test.pinged", "slow", func(ctx context.Context, payload []byte) error {
+		calls++
+		return nil
+	})
+	if n, _ := event.NewDispatcher(db, other, event.Options{}).DispatchOnce(context.Background()); n != 0 || calls != 0 {
+		t.Error("A leased event must not be claimed by another dispatcher")
+	}
+	close(release)
+	<-finished
+	if row := outbox(t, db); row.Status != event.StatusDone {
+		t.Errorf("Expected the first dispatcher to finish the event, got %s", row.Status)
+	}
+}
+
+func TestBus_DuplicateSubscriber(t *testing.T) {
+	bus := event.NewBus()
+	bus.Subscribe("x", "a", func(context.Context, []byte) error { return nil })
+	defer func() {
+		if recover() == nil {
+			t.Error("Duplicate subscriber should panic")
+		}
+	}()
+	bus.Subscribe("x", "a", func(context.Context, []byte) error { return nil })
+}
@@ -77,7 +77,7 @@ func TestGlobalLogger(t *testing.T) {
 
 func TestZapLogger(t *testing.T) {
 	// Initialize ZapLogger
-	logger, err := log.NewZapLogger("debug")
+	logger, err := log.NewZapLogger("debug", "json")
 	if err != nil {
 		t.Fatalf("NewZapLogger failed: %v", err)
 	}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"appsite-go/internal/core/model"
+)
+
+type FeedItem struct {
+	model.Base
+	Sort  int
+	Title string
+}
+
+func seedFeed(t *testing.T) *model.CRUD[FeedItem] {
+	db := setupDB(t)
+	db.AutoMigrate(&FeedItem{})
+
+	// 25 rows with many duplicated sort/created_at values to exercise the id tie-breaker
+	for i := 0; i < 25; i++ {
+		db.Create(&FeedItem{
+			Base:  model.Base{ID: fmt.Sprintf("feed_%02d", i), CreatedAt: int64(100 + i%5)},
+			Sort:  i % 3,
+			Title: "T",
+		})
+	}
+	return model.NewCRUD[FeedItem](db)
+}
+
+func walkFeed(t *testing.T, crud *model.CRUD[FeedItem], sort string, size int) []FeedItem {
+	var all []FeedItem
+	cursor := ""
+	for round := 0; round < 20; round++ {
+		page, err := model.PageOf[FeedItem](crud.List(&model.ListParams{
+			PageSize:   size,
+			Sort:       sort,
+			CursorMode: true,
+			Cursor:     cursor,
+		}))
+		if err != nil {
+			t.Fatalf("cursor list failed: %v", err)
+		}
+		if !page.CursorMode {
+			t.Fatal("page should be in cursor mode")
+		}
+		all = append(all, page.List...)
+		if page.NextCursor == "" {
+			return all
+		}
+		cursor = page.NextCursor
+	}
+	t.Fatal("cursor walk did not terminate")
+	return nil
+}
+
+func TestCursor_WalkAll(t *testing.T) {
+	crud := seedFeed(t)
+
+	for _, sort := range []string{"sort desc, created_at desc", "created_at asc", "sort asc, created_at desc", ""} {
+		items := walkFeed(t, crud, sort, 7)
+		if len(items) != 25 {
+			t.Errorf("%q: expected 25 rows, got %d", sort, len(items))
+		}
+		seen := map[string]bool{}
+		for _, it := range items {
+			if seen[it.ID] {
+				t.Errorf("%q: duplicated row %s", sort, it.ID)
+			}
+			seen[it.ID] = true
+		}
+	}
+
+	// Ordering check for descending sort
+	items := walkFeed(t, crud, "sort desc, created_at desc", 4)
+	for i := 1; i < len(items); i++ {
+		prev, cur := items[i-1], items[i]
+		if prev.Sort < cur.Sort || (prev.Sort == cur.Sort && prev.CreatedAt < cur.CreatedAt) {
+			t.Fatalf("rows out of order at %d: %+v then %+v", i, prev, cur)
+		}
+	}
+}
+
+func TestCursor_WithFilterAndErrors(t *testing.T) {
+	crud := seedFeed(t)
+
+	page, err := model.PageOf[FeedItem](crud.List(&model.ListParams{
+		PageSize:   50,
+		CursorMode: true,
+		Filter:     model.NewFilter().Where("sort", model.OpEq, "1"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.List) != 8 || page.NextCursor != "" {
+		t.Errorf("expected 8 rows and no next cursor, got %d / %q", len(page.List), page.NextCursor)
+	}
+
+	for _, params := range []*model.ListParams{
+		{CursorMode: true, Cursor: "not-base64!"},
+		{CursorMode: true, Cursor: "WzFd"}, // [1] : wrong arity
+		{CursorMode: true, Sort: "unknown desc"},
+		{CursorMode: true, Sort: "sort sideways"},
+	} {
+		res := crud.List(params)
+		if res.Success || !errors.Is(res.Error, model.ErrInvalidCursor) {
+			t.Errorf("%+v: expected ErrInvalidCursor, got %v", params, res.Error)
+		}
+	}
+}
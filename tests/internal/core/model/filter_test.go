@@ -0,0 +1,214 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"appsite-go/internal/core/model"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type FilterItem struct {
+	model.Base
+	Title  string
+	Status string
+	Score  int
+	Cover  *string
+	Secret string `filter:"-"`
+}
+
+func seedFilterItems(t *testing.T) *model.CRUD[FilterItem] {
+	db := setupDB(t)
+	db.AutoMigrate(&FilterItem{})
+
+	cover := "c.png"
+	items := []FilterItem{
+		{Base: model.Base{ID: "f1", CreatedAt: 100}, Title: "Go in Action", Status: "enabled", Score: 10, Cover: &cover},
+		{Base: model.Base{ID: "f2", CreatedAt: 200}, Title: "Rust Book", Status: "enabled", Score: 20},
+		{Base: model.Base{ID: "f3", CreatedAt: 300}, Title: "Learning Go", Status: "draft", Score: 30},
+		{Base: model.Base{ID: "f4", CreatedAt: 400}, Title: "PHP Basics", Status: "disabled", Score: 40, Secret: "x"},
+	}
+	for i := range items {
+		db.Create(&items[i])
+	}
+	return model.NewCRUD[FilterItem](db)
+}
+
+func listWithQuery(t *testing.T, crud *model.CRUD[FilterItem], query string) *model.Result {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := model.ParseFilter(values)
+	if err != nil {
+		t.Fatalf("ParseFilter(%q) failed: %v", query, err)
+	}
+	return crud.List(&model.ListParams{Page: 1, PageSize: 10, Filter: f})
+}
+
+func TestFilter_Operators(t *testing.T) {
+	crud := seedFilterItems(t)
+
+	cases := []struct {
+		query string
+		want  int64
+	}{
+		{"filter[status]=enabled", 2},
+		{"filter[status][ne]=enabled", 2},
+		{"filter[title][like]=Go", 2},
+		{"filter[score][gt]=20", 2},
+		{"filter[score][gte]=20", 3},
+		{"filter[score][lt]=20", 1},
+		{"filter[score][lte]=20", 2},
+		{"filter[score][between]=15,35", 2},
+		{"filter[status][in]=draft,disabled", 2},
+		{"filter[status][in]=draft&filter[status][in]=enabled", 3},
+		{"filter[status][nin]=draft,disabled", 2},
+		{"filter[cover][null]=true", 3},
+		{"filter[cover][null]=false", 1},
+		{"filter[created_at][gte]=200&filter[status]=enabled", 1},
+		{"filter[or][g][status]=draft&filter[or][g][score][gte]=40", 2},
+		{"filter[or][g][status]=draft&filter[or][g][status]=disabled&filter[title][like]=Go", 1},
+	}
+
+	for _, tc := range cases {
+		res := listWithQuery(t, crud, tc.query)
+		if !res.Success {
+			t.Errorf("%s: list failed: %v", tc.query, res.Error)
+			continue
+		}
+		total := res.Data.(map[string]interface{})["total"].(int64)
+		if total != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.query, tc.want, total)
+		}
+	}
+}
+
+func TestFilter_Rejects(t *testing.T) {
+	crud := seedFilterItems(t)
+
+	// Parse-time errors
+	for _, q := range []string{
+		"filter[title][regex]=x",
+		"filter[cover][null]=maybe",
+		"filter[]=x",
+		"filter[a][b][c]=x",
+		"filter[or][g]=x",
+	} {
+		values, _ := url.ParseQuery(q)
+		if _, err := model.ParseFilter(values); !errors.Is(err, model.ErrInvalidFilter) {
+			t.Errorf("%s: expected ErrInvalidFilter, got %v", q, err)
+		}
+	}
+
+	// Schema-time errors
+	for _, q := range []string{
+		"filter[unknown]=x",
+		"filter[secret]=x",
+		"filter[score]=abc",
+		"filter[score][like]=1",
+		"filter[score][between]=1",
+		"filter[title%20OR%20id]=x",
+	} {
+		res := listWithQuery(t, crud, q)
+		if res.Success || !errors.Is(res.Error, model.ErrInvalidFilter) {
+			t.Errorf("%s: expected ErrInvalidFilter, got %v", q, res.Error)
+		}
+	}
+}
+
+func TestFilter_Builder(t *testing.T) {
+	crud := seedFilterItems(t)
+
+	f := model.NewFilter().
+		Where("Status", model.OpEq, "enabled").
+		OrGroup(
+			model.Condition{Field: "score", Op: model.OpLt, Value: 15},
+			model.Condition{Field: "title", Op: model.OpLike, Value: "Rust"},
+		)
+	res := crud.List(&model.ListParams{Filter: f})
+	if !res.Success {
+		t.Fatalf("List failed: %v", res.Error)
+	}
+	if total := res.Data.(map[string]interface{})["total"].(int64); total != 2 {
+		t.Errorf("expected 2, got %d", total)
+	}
+
+	if !model.NewFilter().IsEmpty() {
+		t.Error("new filter should be empty")
+	}
+}
+
+func TestFilter_PostgresLike(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=x dbname=x sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope, err := model.NewFilter().Where("title", model.OpLike, "go").Scope(db, &FilterItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := db.Scopes(scope).Find(&[]FilterItem{}).Statement
+	if sql := stmt.SQL.String(); !strings.Contains(sql, `"title" ILIKE $1`) {
+		t.Errorf("postgres like should be case-insensitive, got %s", sql)
+	}
+	if got := stmt.Vars[0]; got != "%go%" {
+		t.Errorf("unexpected pattern %v", got)
+	}
+}
+
+func TestFilter_LikeEscapesWildcards(t *testing.T) {
+	crud := seedFilterItems(t)
+	crud.Add(&FilterItem{Base: model.Base{ID: "f5"}, Title: "100% Go", Status: "draft"})
+	crud.Add(&FilterItem{Base: model.Base{ID: "f6"}, Title: "snake_case", Status: "draft"})
+
+	cases := []struct {
+		query string
+		want  int64
+	}{
+		{"filter[title][like]=%25", 1},
+		{"filter[title][like]=_", 1},
+		{"filter[title][like]=!", 0},
+	}
+	for _, tc := range cases {
+		res := listWithQuery(t, crud, tc.query)
+		if !res.Success {
+			t.Errorf("%s: list failed: %v", tc.query, res.Error)
+			continue
+		}
+		if total := res.Data.(map[string]interface{})["total"].(int64); total != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.query, tc.want, total)
+		}
+	}
+}
+
+func TestFilter_OnlyAndClone(t *testing.T) {
+	f := model.NewFilter().
+		Where("title", model.OpEq, "x").
+		OrGroup(model.Condition{Field: "secret", Op: model.OpEq, Value: "y"})
+
+	if err := f.Only("title", "secret"); err != nil {
+		t.Errorf("allowed columns rejected: %v", err)
+	}
+	if err := f.Only("title"); !errors.Is(err, model.ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter for secret, got %v", err)
+	}
+
+	c := f.Clone()
+	c.Where("status", model.OpEq, "enabled")
+	if len(f.And) != 1 {
+		t.Errorf("clone changed the original filter: %d conditions", len(f.And))
+	}
+}
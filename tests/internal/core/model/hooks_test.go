@@ -0,0 +1,145 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"appsite-go/internal/core/model"
+
+	"gorm.io/gorm"
+)
+
+// Journal records the lifecycle seen by its hooks
+type Journal struct {
+	model.Base
+	Title string
+}
+
+var journalEvents []string
+
+func (j *Journal) AfterAdd(tx *gorm.DB) error {
+	if j.Title == "RollbackAdd" {
+		return errors.New("after add failed")
+	}
+	journalEvents = append(journalEvents, "add:"+j.Title)
+	return nil
+}
+
+func (j *Journal) BeforeUpdate(tx *gorm.DB) error {
+	old, ok := model.Previous[Journal](tx)
+	if !ok {
+		return errors.New("previous state missing")
+	}
+	journalEvents = append(journalEvents, "before_update:"+old.Title+"->"+j.Title)
+	return nil
+}
+
+func (j *Journal) AfterUpdate(tx *gorm.DB) error {
+	if j.Title == "RollbackUpdate" {
+		return errors.New("after update failed")
+	}
+	journalEvents = append(journalEvents, "after_update:"+j.Title)
+	return nil
+}
+
+func (j *Journal) BeforeDelete(tx *gorm.DB) error {
+	journalEvents = append(journalEvents, "before_delete:"+j.Title)
+	return nil
+}
+
+func (j *Journal) AfterDelete(tx *gorm.DB) error {
+	if j.Title == "RollbackDelete" {
+		return errors.New("after delete failed")
+	}
+	journalEvents = append(journalEvents, "after_delete:"+j.Title)
+	return nil
+}
+
+func TestCRUD_HookLifecycle(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Journal{})
+	crud := model.NewCRUD[Journal](db)
+	journalEvents = nil
+
+	j := &Journal{Title: "First"}
+	if res := crud.Add(j); !res.Success {
+		t.Fatalf("Add failed: %v", res.Error)
+	}
+
+	res := crud.Update(j.ID, map[string]interface{}{"title": "Second"})
+	if !res.Success {
+		t.Fatalf("Update failed: %v", res.Error)
+	}
+	if res.Data.(*Journal).Title != "Second" {
+		t.Errorf("Update should return new state, got %s", res.Data.(*Journal).Title)
+	}
+
+	if res := crud.Remove(j.ID); !res.Success {
+		t.Fatalf("Remove failed: %v", res.Error)
+	}
+
+	want := []string{
+		"add:First",
+		"before_update:First->Second",
+		"after_update:Second",
+		"before_delete:Second",
+		"after_delete:Second",
+	}
+	if len(journalEvents) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, journalEvents)
+	}
+	for i := range want {
+		if journalEvents[i] != want[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, want[i], journalEvents[i])
+		}
+	}
+}
+
+func TestCRUD_AfterHooksRollback(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Journal{})
+	crud := model.NewCRUD[Journal](db)
+
+	// AfterAdd failure removes the insert
+	res := crud.Add(&Journal{Base: model.Base{ID: "j1"}, Title: "RollbackAdd"})
+	if res.Success || res.Message != "after add failed" {
+		t.Errorf("Expected add rollback, got %+v", res)
+	}
+	var count int64
+	db.Model(&Journal{}).Where("id = ?", "j1").Count(&count)
+	if count != 0 {
+		t.Error("AfterAdd failure should roll back the insert")
+	}
+
+	// AfterUpdate failure restores the old values
+	db.Create(&Journal{Base: model.Base{ID: "j2"}, Title: "Keep"})
+	res = crud.Update("j2", map[string]interface{}{"title": "RollbackUpdate"})
+	if res.Success {
+		t.Error("Expected update rollback")
+	}
+	var j Journal
+	db.First(&j, "id = ?", "j2")
+	if j.Title != "Keep" {
+		t.Errorf("AfterUpdate failure should roll back, got %s", j.Title)
+	}
+
+	// AfterDelete failure keeps the row
+	db.Create(&Journal{Base: model.Base{ID: "j3"}, Title: "RollbackDelete"})
+	res = crud.Remove("j3")
+	if res.Success {
+		t.Error("Expected delete rollback")
+	}
+	db.Model(&Journal{}).Where("id = ?", "j3").Count(&count)
+	if count != 1 {
+		t.Error("AfterDelete failure should roll back the delete")
+	}
+
+	// Previous outside of a hook
+	if _, ok := model.Previous[Journal](db); ok {
+		t.Error("Previous should be empty outside hooks")
+	}
+}
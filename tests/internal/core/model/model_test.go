@@ -154,17 +154,21 @@ func TestCRUD_Update(t *testing.T) {
 	// 3. Hook Fail
 	db.AutoMigrate(&UserWithHooks{})
 	crudHooks := model.NewCRUD[UserWithHooks](db)
-	// Note: We bypass CRUD.Add to avoid "Hooked:" prefix logic for simplicity or just allow it
-	// But we manually create to set the Name exactly to "FailUpdate" which triggers the block.
-	db.Create(&UserWithHooks{Base: model.Base{ID: "hook1"}, Name: "FailUpdate"})
+	// BeforeUpdate sees the new values, so updating the Name to "FailUpdate" triggers the block.
+	db.Create(&UserWithHooks{Base: model.Base{ID: "hook1"}, Name: "Valid"})
 	
-	res = crudHooks.Update("hook1", map[string]interface{}{"Name": "Valid"})
+	res = crudHooks.Update("hook1", map[string]interface{}{"Name": "FailUpdate"})
 	if res.Success {
 		t.Error("Update should have been blocked by hook")
 	}
 	if res.Message != "update blocked" {
 		t.Errorf("Expected 'update blocked', got %s", res.Message)
 	}
+	var hu UserWithHooks
+	db.First(&hu, "id = ?", "hook1")
+	if hu.Name != "Valid" {
+		t.Errorf("Blocked update should not be written, got %s", hu.Name)
+	}
 }
 
 func TestCRUD_MultiRow(t *testing.T) {
@@ -0,0 +1,174 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"appsite-go/internal/core/model"
+)
+
+type TenantPost struct {
+	model.Base
+	model.Tenant
+	model.SoftDelete
+	Title string
+}
+
+func setupTenantCRUD(t *testing.T) *model.CRUD[TenantPost] {
+	db := setupDB(t)
+	if err := db.Use(model.NewTenantPlugin()); err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&TenantPost{}, &User{})
+
+	crud := model.NewCRUD[TenantPost](db)
+	for _, p := range []struct{ id, tenant string }{{"a1", "A"}, {"a2", "A"}, {"b1", "B"}} {
+		res := crud.WithContext(model.WithTenant(context.Background(), p.tenant)).
+			Add(&TenantPost{Base: model.Base{ID: p.id}, Title: p.id})
+		if !res.Success {
+			t.Fatalf("seed %s failed: %v", p.id, res.Error)
+		}
+	}
+	return crud
+}
+
+func TestTenant_StampsAndScopesReads(t *testing.T) {
+	crud := setupTenantCRUD(t)
+	ctxA := model.WithTenant(context.Background(), "A")
+	ctxB := model.WithTenant(context.Background(), "B")
+
+	// Stamped on create
+	var stamped TenantPost
+	crud.DB.WithContext(model.AsPlatform(context.Background())).First(&stamped, "id = ?", "b1")
+	if stamped.SaasID != "B" {
+		t.Errorf("Expected saas_id B, got %q", stamped.SaasID)
+	}
+
+	// List sees only the tenant's rows
+	page, err := model.PageOf[TenantPost](crud.WithContext(ctxA).List(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || len(page.List) != 2 {
+		t.Errorf("Tenant A should see 2 posts, got total %d / %d rows", page.Total, len(page.List))
+	}
+	for _, p := range page.List {
+		if p.SaasID != "A" {
+			t.Errorf("Leaked post %s of tenant %s", p.ID, p.SaasID)
+		}
+	}
+
+	// Get across tenants is not found
+	if res := crud.WithContext(ctxB).Get("a1"); res.Success {
+		t.Error("Tenant B must not read tenant A's post")
+	}
+	if res := crud.WithContext(ctxA).Get("a1"); !res.Success {
+		t.Error("Tenant A should read its own post")
+	}
+
+	// Cursor mode is scoped too
+	cursor, err := model.PageOf[TenantPost](crud.WithContext(ctxB).List(&model.ListParams{CursorMode: true}))
+	if err != nil || len(cursor.List) != 1 {
+		t.Errorf("Tenant B cursor list should have 1 row, got %v / %v", cursor, err)
+	}
+
+	// Platform sees everything, until a tenant is set again
+	platform := model.AsPlatform(ctxA)
+	page, err = model.PageOf[TenantPost](crud.WithContext(platform).List(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil || page.Total != 3 {
+		t.Errorf("Platform should see 3 posts, got %v / %v", page, err)
+	}
+	page, err = model.PageOf[TenantPost](crud.WithContext(model.WithTenant(platform, "B")).List(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil || page.Total != 1 {
+		t.Errorf("WithTenant should end platform scope, got %v / %v", page, err)
+	}
+}
+
+func TestTenant_FailsClosedWithoutTenant(t *testing.T) {
+	crud := setupTenantCRUD(t)
+
+	// Forgetting the tenant reads and writes nothing
+	if _, err := model.PageOf[TenantPost](crud.List(&model.ListParams{Page: 1, PageSize: 10})); !errors.Is(err, model.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on list, got %v", err)
+	}
+	if res := crud.Get("a1"); !errors.Is(res.Error, model.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on get, got %v", res.Error)
+	}
+	if res := crud.Update("a1", map[string]interface{}{"title": "x"}); !errors.Is(res.Error, model.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on update, got %v", res.Error)
+	}
+	if res := crud.Add(&TenantPost{Base: model.Base{ID: "x1"}}); !errors.Is(res.Error, model.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on create, got %v", res.Error)
+	}
+
+	// The default tenant is a tenant like any other
+	def := crud.WithContext(model.WithTenant(context.Background(), ""))
+	if res := def.Add(&TenantPost{Base: model.Base{ID: "d1"}, Title: "d1"}); !res.Success {
+		t.Fatalf("Default tenant create failed: %v", res.Error)
+	}
+	page, err := model.PageOf[TenantPost](def.List(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil || page.Total != 1 || page.List[0].ID != "d1" {
+		t.Errorf("Default tenant should see only its post, got %v / %v", page, err)
+	}
+}
+
+func TestTenant_BlocksCrossTenantWrites(t *testing.T) {
+	crud := setupTenantCRUD(t)
+	ctxA := model.WithTenant(context.Background(), "A")
+	repoB := crud.WithContext(model.WithTenant(context.Background(), "B"))
+
+	// Update
+	if res := repoB.Update("a1", map[string]interface{}{"title": "hijacked"}); res.Success {
+		t.Error("Tenant B must not update tenant A's post")
+	}
+	// Moving a row to another tenant
+	if res := crud.WithContext(ctxA).Update("a1", map[string]interface{}{"saas_id": "B"}); !errors.Is(res.Error, model.ErrCrossTenant) {
+		t.Errorf("Expected ErrCrossTenant, got %v", res.Error)
+	}
+	// Delete, restore and purge
+	if res := repoB.Remove("a2"); res.Success {
+		t.Error("Tenant B must not delete tenant A's post")
+	}
+	crud.WithContext(ctxA).Remove("a2")
+	if res := repoB.Restore("a2"); res.Success {
+		t.Error("Tenant B must not restore tenant A's post")
+	}
+	if res := repoB.Purge("a2"); res.Success {
+		t.Error("Tenant B must not purge tenant A's post")
+	}
+	// Creating a row for another tenant
+	if res := repoB.Add(&TenantPost{Base: model.Base{ID: "x1"}, Tenant: model.Tenant{SaasID: "A"}}); !errors.Is(res.Error, model.ErrCrossTenant) {
+		t.Errorf("Expected ErrCrossTenant on create, got %v", res.Error)
+	}
+
+	platform := crud.DB.WithContext(model.AsPlatform(context.Background()))
+	var post TenantPost
+	platform.Unscoped().First(&post, "id = ?", "a1")
+	if post.Title != "a1" || post.SaasID != "A" {
+		t.Errorf("Tenant A's post was modified: %+v", post)
+	}
+	var count int64
+	platform.Unscoped().Model(&TenantPost{}).Where("id = ?", "a2").Count(&count)
+	if count != 1 {
+		t.Error("Tenant A's trashed post should still exist")
+	}
+}
+
+func TestTenant_IgnoresUnscopedTables(t *testing.T) {
+	db := setupDB(t)
+	db.Use(model.NewTenantPlugin())
+	db.AutoMigrate(&User{})
+	users := model.NewCRUD[User](db)
+	users.Add(&User{Name: "global"})
+
+	page, err := model.PageOf[User](users.WithContext(model.WithTenant(context.Background(), "A")).
+		List(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil || page.Total != 1 {
+		t.Errorf("Tables without saas_id are not scoped, got %v / %v", page, err)
+	}
+}
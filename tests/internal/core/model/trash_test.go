@@ -0,0 +1,155 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"appsite-go/internal/core/model"
+
+	"gorm.io/gorm"
+)
+
+type Note struct {
+	model.Base
+	model.SoftDelete
+	Title string
+}
+
+func TestCRUD_Trash(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Note{})
+	crud := model.NewCRUD[Note](db)
+
+	for _, id := range []string{"n1", "n2", "n3"} {
+		crud.Add(&Note{Base: model.Base{ID: id}, Title: id})
+	}
+
+	// Remove is a soft delete
+	if res := crud.Remove("n1"); !res.Success {
+		t.Fatalf("Remove failed: %v", res.Error)
+	}
+	crud.Remove("n2")
+	if res := crud.Get("n1"); res.Success {
+		t.Error("Trashed entity should be hidden from Get")
+	}
+	var raw int64
+	db.Unscoped().Model(&Note{}).Count(&raw)
+	if raw != 3 {
+		t.Errorf("Rows should still exist, got %d", raw)
+	}
+
+	page, err := model.PageOf[Note](crud.ListTrashed(&model.ListParams{Page: 1, PageSize: 10}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Errorf("Expected 2 trashed, got %d", page.Total)
+	}
+
+	// Restore
+	if res := crud.Restore("n1"); !res.Success {
+		t.Fatalf("Restore failed: %v", res.Error)
+	}
+	if res := crud.Get("n1"); !res.Success {
+		t.Error("Restored entity should be visible")
+	}
+	if res := crud.Restore("n3"); res.Success || !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		t.Error("Restoring a live entity should report not found")
+	}
+
+	// Purge only applies to trashed rows
+	if res := crud.Purge("n3"); res.Success {
+		t.Error("Purging a live entity should fail")
+	}
+	if res := crud.Purge("n2"); !res.Success {
+		t.Fatalf("Purge failed: %v", res.Error)
+	}
+	db.Unscoped().Model(&Note{}).Count(&raw)
+	if raw != 2 {
+		t.Errorf("Expected 2 rows after purge, got %d", raw)
+	}
+
+	// Entities without SoftDelete
+	users := model.NewCRUD[User](db)
+	if res := users.Restore("x"); !errors.Is(res.Error, model.ErrNotSoftDeletable) {
+		t.Errorf("Expected ErrNotSoftDeletable, got %v", res.Error)
+	}
+}
+
+func TestCRUD_PurgeRetention(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Note{})
+	crud := model.NewCRUD[Note](db)
+
+	old := time.Now().Add(-48 * time.Hour)
+	db.Create(&Note{Base: model.Base{ID: "old"}, SoftDelete: model.SoftDelete{DeletedAt: gorm.DeletedAt{Time: old, Valid: true}}})
+	db.Create(&Note{Base: model.Base{ID: "recent"}, SoftDelete: model.SoftDelete{DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}})
+	db.Create(&Note{Base: model.Base{ID: "live"}})
+
+	res := crud.PurgeBefore(time.Now().Add(-24 * time.Hour))
+	if !res.Success || res.Data.(int64) != 1 {
+		t.Fatalf("Expected 1 purged row, got %+v", res)
+	}
+
+	// PurgeTrash with a retention shorter than the age of "recent"
+	n, err := model.PurgeTrash(context.Background(), time.Nanosecond, crud)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 purged row, got %d (%v)", n, err)
+	}
+
+	var ids []string
+	db.Unscoped().Model(&Note{}).Pluck("id", &ids)
+	if len(ids) != 1 || ids[0] != "live" {
+		t.Errorf("Only the live row should remain, got %v", ids)
+	}
+}
+
+type Handle struct {
+	model.Base
+	model.SoftDelete
+	Name       string `gorm:"uniqueIndex:idx_handle_live_name,priority:1"`
+	DeletedKey string `gorm:"not null;default:'';uniqueIndex:idx_handle_live_name,priority:2"`
+}
+
+func TestCRUD_TombstoneFreesUniqueValues(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Handle{})
+	crud := model.NewCRUD[Handle](db)
+
+	crud.Add(&Handle{Base: model.Base{ID: "h1"}, Name: "alice"})
+	if res := crud.Add(&Handle{Base: model.Base{ID: "h2"}, Name: "alice"}); res.Success {
+		t.Fatal("Live rows must stay unique")
+	}
+
+	// Trashing h1 frees the name for a new row
+	if res := crud.Remove("h1"); !res.Success {
+		t.Fatalf("Remove failed: %v", res.Error)
+	}
+	var key string
+	db.Unscoped().Model(&Handle{}).Where("id = ?", "h1").Pluck("deleted_key", &key)
+	if key != "h1" {
+		t.Errorf("Expected deleted_key h1, got %q", key)
+	}
+	if res := crud.Add(&Handle{Base: model.Base{ID: "h2"}, Name: "alice"}); !res.Success {
+		t.Fatalf("Name of a trashed row should be free: %v", res.Error)
+	}
+
+	// h1 cannot come back while h2 holds its name
+	if res := crud.Restore("h1"); res.Success {
+		t.Error("Restore should fail while the name is taken")
+	}
+	crud.Remove("h2")
+	if res := crud.Restore("h1"); !res.Success {
+		t.Fatalf("Restore failed: %v", res.Error)
+	}
+	db.Unscoped().Model(&Handle{}).Where("id = ?", "h1").Pluck("deleted_key", &key)
+	if key != "" {
+		t.Errorf("Restored row should be live, got deleted_key %q", key)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"appsite-go/internal/core/model"
+)
+
+type Doc struct {
+	model.Base
+	model.Versioned
+	Title string
+}
+
+func TestCRUD_VersionedUpdate(t *testing.T) {
+	db := setupDB(t)
+	db.AutoMigrate(&Doc{})
+	crud := model.NewCRUD[Doc](db)
+
+	doc := &Doc{Title: "v1"}
+	if res := crud.Add(doc); !res.Success {
+		t.Fatalf("Add failed: %v", res.Error)
+	}
+	var stored Doc
+	db.First(&stored, "id = ?", doc.ID)
+	if stored.Version != 1 {
+		t.Fatalf("Expected initial version 1, got %d", stored.Version)
+	}
+
+	// Unchecked update still increments
+	res := crud.Update(doc.ID, map[string]interface{}{"title": "v2"})
+	if !res.Success {
+		t.Fatalf("Update failed: %v", res.Error)
+	}
+	if v := res.Data.(*Doc).Version; v != 2 {
+		t.Errorf("Expected version 2, got %d", v)
+	}
+
+	// Two editors read version 2; the first wins, the second conflicts
+	updates := map[string]interface{}{"title": "editor A", "version": float64(2)}
+	if res := crud.Update(doc.ID, updates); !res.Success {
+		t.Fatalf("First editor should succeed: %v", res.Error)
+	}
+	if _, ok := updates["version"]; !ok {
+		t.Error("Update should not modify the caller's map")
+	}
+
+	res = crud.Update(doc.ID, map[string]interface{}{"title": "editor B", "version": "2"})
+	if res.Success || !errors.Is(res.Error, model.ErrConflict) {
+		t.Fatalf("Expected ErrConflict, got %+v", res)
+	}
+	if res.Message != "Version Conflict" {
+		t.Errorf("Expected 'Version Conflict', got %s", res.Message)
+	}
+
+	db.First(&stored, "id = ?", doc.ID)
+	if stored.Title != "editor A" || stored.Version != 3 {
+		t.Errorf("Expected editor A at version 3, got %s at %d", stored.Title, stored.Version)
+	}
+
+	// Garbage versions are rejected as invalid, not reported as conflicts
+	for _, bad := range []interface{}{"abc", -1, 2.5} {
+		res = crud.Update(doc.ID, map[string]interface{}{"version": bad})
+		if !errors.Is(res.Error, model.ErrInvalidVersion) {
+			t.Errorf("Expected ErrInvalidVersion for %v, got %v", bad, res.Error)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/queue"
+)
+
+type sms struct {
+	Phone string `json:"phone"`
+}
+
+func setupQueue(t *testing.T) (*queue.Queue, *goredis.Client) {
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return queue.New(rdb), rdb
+}
+
+func stats(t *testing.T, q *queue.Queue, name string) *queue.Stats {
+	s, err := q.Stats(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// eventually polls cond until it holds, failing the test after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting until %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue_Process(t *testing.T) {
+	q, rdb := setupQueue(t)
+	ctx := context.Background()
+
+	var got sms
+	var tenant string
+	q.Handle("sms.send", func(ctx context.Context, job *queue.Job) error {
+		tenant, _ = model.TenantFrom(ctx)
+		return job.Decode(&got)
+	})
+
+	job, err := q.Enqueue(model.WithTenant(ctx, "t1"), "default", "sms.send", sms{Phone: "13800000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "", "sms.send", nil); !errors.Is(err, queue.ErrNoQueue) {
+		t.Errorf("Expected ErrNoQueue, got %v", err)
+	}
+
+	// Every key of the queue is in one cluster slot, scripts touch them together
+	all, _ := rdb.Keys(ctx, "*").Result()
+	for _, key := range all {
+		if !strings.HasPrefix(key, "queue:{default}:") {
+			t.Errorf("Key %q is outside the {default} hash tag", key)
+		}
+	}
+
+	w := queue.NewWorker(q, "default", queue.Options{})
+	ok, err := w.ProcessOne(ctx)
+	if !ok || err != nil {
+		t.Fatalf("ProcessOne = %v, %v", ok, err)
+	}
+	if got.Phone != "13800000000" || tenant != "t1" {
+		t.Errorf("Handler got %+v in tenant %q", got, tenant)
+	}
+	if n, _ := rdb.Exists(ctx, "queue:{default}:job:"+job.ID).Result(); n != 0 {
+		t.Error("A finished job should be deleted")
+	}
+	if s := stats(t, q, "default"); *s != (queue.Stats{}) {
+		t.Errorf("Expected an empty queue, got %+v", s)
+	}
+
+	// Queues are independent
+	q.Enqueue(ctx, "other", "sms.send", sms{})
+	if ok, _ := w.ProcessOne(ctx); ok {
+		t.Error("A worker must only take jobs of its own queue")
+	}
+}
+
+func TestQueue_Delayed(t *testing.T) {
+	q, _ := setupQueue(t)
+	ctx := context.Background()
+
+	runs := 0
+	q.Handle("order.expire", func(context.Context, *queue.Job) error {
+		runs++
+		return nil
+	})
+	due := time.Now().Add(50 * time.Millisecond)
+	q.Schedule(ctx, "default", "order.expire", nil, due)
+
+	w := queue.NewWorker(q, "default", queue.Options{})
+	if ok, _ := w.ProcessOne(ctx); ok || stats(t, q, "default").Delayed != 1 {
+		t.Fatal("A delayed job must wait until it is due")
+	}
+	eventually(t, "the delayed job runs", func() bool {
+		ok, _ := w.ProcessOne(ctx)
+		return ok
+	})
+	if runs != 1 || time.Now().Before(due) {
+		t.Errorf("Expected the delayed job to run once due, runs=%d", runs)
+	}
+}
+
+func TestQueue_RetryAndDeadLetter(t *testing.T) {
+	q, _ := setupQueue(t)
+	ctx := context.Background()
+
+	calls := 0
+	q.Handle("webhook.send", func(context.Context, *queue.Job) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return errors.New("endpoint unavailable")
+	})
+	job, _ := q.Enqueue(ctx, "default", "webhook.send", map[string]string{"url": "https://example.com"})
+	q.Enqueue(ctx, "default", "unknown.type", nil)
+
+	w := queue.NewWorker(q, "default", queue.Options{MaxAttempts: 2, Backoff: 50 * time.Millisecond})
+	w.ProcessOne(ctx)
+	w.ProcessOne(ctx)
+	if s := stats(t, q, "default"); s.Delayed != 2 || s.Active != 0 {
+		t.Fatalf("Expected both jobs scheduled for retry, got %+v", s)
+	}
+	if ok, _ := w.ProcessOne(ctx); ok {
+		t.Error("Retry should wait for the backoff")
+	}
+
+	eventually(t, "both jobs are dead after 2 attempts", func() bool {
+		w.ProcessOne(ctx)
+		return stats(t, q, "default").Dead == 2
+	})
+	if s := stats(t, q, "default"); s.Delayed != 0 || s.Active != 0 {
+		t.Fatalf("Expected only dead jobs, got %+v", s)
+	}
+
+	page, err := q.Dead(ctx, "default", &model.ListParams{Page: 1, PageSize: 10})
+	if err != nil || page.Total != 2 || len(page.List) != 2 {
+		t.Fatalf("Dead = %+v, %v", page, err)
+	}
+	for _, j := range page.List {
+		if j.Attempts != 2 || j.LastError == "" {
+			t.Errorf("Dead job should keep its attempts and error, got %+v", j)
+		}
+	}
+
+	// Retry gives a fresh budget
+	if err := q.Retry(ctx, "default", job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Retry(ctx, "default", job.ID); !errors.Is(err, queue.ErrNotDead) {
+		t.Errorf("Retry of a live job should fail, got %v", err)
+	}
+	w.ProcessOne(ctx)
+	if calls != 3 || stats(t, q, "default").Delayed != 1 {
+		t.Errorf("Retried job should run again, calls=%d", calls)
+	}
+}
+
+func TestQueue_LeaseExpiry(t *testing.T) {
+	q, rdb := setupQueue(t)
+	ctx := context.Background()
+
+	runs := 0
+	q.Handle("thumbnail", func(context.Context, *queue.Job) error {
+		runs++
+		return nil
+	})
+	job, _ := q.Enqueue(ctx, "media", "thumbnail", nil)
+
+	// A worker that died mid-run leaves the job active with a lapsed lease
+	rdb.LRem(ctx, "queue:{media}:ready", 1, job.ID)
+	rdb.ZAdd(ctx, "queue:{media}:active", goredis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: job.ID})
+
+	if ok, _ := queue.NewWorker(q, "media", queue.Options{}).ProcessOne(ctx); !ok || runs != 1 {
+		t.Errorf("Job with an expired lease should run again, runs=%d", runs)
+	}
+}
+
+func TestWorker_Drain(t *testing.T) {
+	q, _ := setupQueue(t)
+	ctx := context.Background()
+
+	var finished atomic.Int32
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	q.Handle("slow", func(ctx context.Context, job *queue.Job) error {
+		started <- struct{}{}
+		<-release
+		finished.Add(1)
+		return nil
+	})
+	q.Enqueue(ctx, "default", "slow", nil)
+
+	w := queue.NewWorker(q, "default", queue.Options{Concurrency: 2, PollInterval: 10 * time.Millisecond})
+	w.Start()
+	<-started
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- queue.Drain(shutdownCtx, w) }()
+	select {
+	case err := <-drained:
+		t.Fatalf("Shutdown returned before the running job finished: %v", err)
+	default:
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+	if finished.Load() != 1 {
+		t.Error("Shutdown should wait for the running job")
+	}
+
+	// Nothing is taken after shutdown: every worker goroutine has returned
+	q.Enqueue(ctx, "default", "slow", nil)
+	if stats(t, q, "default").Ready != 1 {
+		t.Error("A stopped worker must not take new jobs")
+	}
+}
+
+func TestWorker_DrainTimeout(t *testing.T) {
+	q, _ := setupQueue(t)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	q.Handle("stuck", func(ctx context.Context, job *queue.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q.Enqueue(ctx, "default", "stuck", nil)
+
+	w := queue.NewWorker(q, "default", queue.Options{PollInterval: 10 * time.Millisecond})
+	w.Start()
+	<-started
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := w.Shutdown(shutdownCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the drain to time out, got %v", err)
+	}
+
+	// The interrupted job goes back without using an attempt
+	eventually(t, "the interrupted job is released", func() bool {
+		return stats(t, q, "default").Delayed == 1
+	})
+	page, _ := q.Dead(ctx, "default", &model.ListParams{})
+	if page.Total != 0 {
+		t.Error("An interrupted job must not be dead-lettered")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/scheduler"
+)
+
+func setup(t *testing.T) (*gorm.DB, *goredis.Client) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&scheduler.Run{}); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return db, rdb
+}
+
+func waitRun(t *testing.T, s *scheduler.Scheduler, name string) scheduler.Run {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		page, err := s.History(name, &model.ListParams{Page: 1, PageSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.List) == 1 && page.List[0].Status != scheduler.StatusRunning {
+			return page.List[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Run of %s did not finish", name)
+	return scheduler.Run{}
+}
+
+func TestScheduler_SingleRunPerTick(t *testing.T) {
+	db, rdb := setup(t)
+
+	// Two instances share the schedule, the database and Redis
+	var runs atomic.Int32
+	task := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+	a := scheduler.New(db, rdb, scheduler.Options{})
+	b := scheduler.New(db, rdb, scheduler.Options{})
+	for _, s := range []*scheduler.Scheduler{a, b} {
+		if err := s.Register("tick", "@every 1s", "", 0, task); err != nil {
+			t.Fatal(err)
+		}
+		s.Start()
+	}
+
+	time.Sleep(2300 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	a.Stop(ctx)
+	b.Stop(ctx)
+
+	var history []scheduler.Run
+	db.Order("tick").Find(&history)
+	if len(history) < 2 || int(runs.Load()) != len(history) {
+		t.Fatalf("Expected one run per tick, got %d runs for %d records", runs.Load(), len(history))
+	}
+	for i, run := range history {
+		if run.Trigger != scheduler.TriggerSchedule || run.Status != scheduler.StatusSuccess || run.Tick == nil {
+			t.Errorf("Unexpected run %+v", run)
+		}
+		if i > 0 && *run.Tick == *history[i-1].Tick {
+			t.Errorf("Tick %d ran twice", *run.Tick)
+		}
+	}
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	db, rdb := setup(t)
+	s := scheduler.New(db, rdb, scheduler.Options{})
+
+	release := make(chan struct{})
+	s.Register("slow", "@daily", "Waits for the test", 0, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	s.Register("broken", "@daily", "", 0, func(ctx context.Context) error {
+		return errors.New("disk full")
+	})
+	s.Register("panicky", "@daily", "", 0, func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	run, err := s.Trigger("slow")
+	if err != nil || run.Status != scheduler.StatusRunning || run.Trigger != scheduler.TriggerManual {
+		t.Fatalf("Trigger = %+v, %v", run, err)
+	}
+	if _, err := s.Trigger("slow"); !errors.Is(err, scheduler.ErrBusy) {
+		t.Errorf("A running job must not start twice, got %v", err)
+	}
+	close(release)
+	if done := waitRun(t, s, "slow"); done.Status != scheduler.StatusSuccess || done.FinishedAt < done.StartedAt {
+		t.Errorf("Unexpected run %+v", done)
+	}
+
+	s.Trigger("broken")
+	if done := waitRun(t, s, "broken"); done.Status != scheduler.StatusFailed || done.Error != "disk full" {
+		t.Errorf("Failure should be recorded, got %+v", done)
+	}
+	s.Trigger("panicky")
+	if done := waitRun(t, s, "panicky"); done.Status != scheduler.StatusFailed || done.Error != "panic: boom" {
+		t.Errorf("Panic should be recorded, got %+v", done)
+	}
+
+	if _, err := s.Trigger("missing"); !errors.Is(err, scheduler.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestScheduler_Register(t *testing.T) {
+	db, rdb := setup(t)
+	s := scheduler.New(db, rdb, scheduler.Options{Specs: map[string]string{
+		"nightly": "0 3 * * *",
+		"off":     "-",
+	}})
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Register("bad", "every now and then", "", 0, noop); err == nil {
+		t.Error("Invalid spec should be rejected")
+	}
+	s.Register("nightly", "@hourly", "", 0, noop)
+	s.Register("off", "@hourly", "", 0, noop)
+	if err := s.Register("off", "@hourly", "", 0, noop); err == nil {
+		t.Error("Duplicate job should be rejected")
+	}
+	s.Start()
+	defer s.Stop(context.Background())
+	time.Sleep(10 * time.Millisecond)
+
+	jobs, err := s.Jobs()
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("Jobs = %+v, %v", jobs, err)
+	}
+	nightly, off := jobs[0], jobs[1]
+	if nightly.Spec != "0 3 * * *" || nightly.NextRun == 0 || time.Unix(nightly.NextRun, 0).Hour() != 3 {
+		t.Errorf("Spec override not applied: %+v", nightly)
+	}
+	if !off.Disabled || off.NextRun != 0 {
+		t.Errorf("Job should be disabled: %+v", off)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations_test
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/core/scheduler"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/apikey"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/operation"
+	"appsite-go/internal/services/access/permission"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	commerce "appsite-go/internal/services/commerce/entity"
+	contents "appsite-go/internal/services/contents/entity"
+	finance "appsite-go/internal/services/finance/entity"
+	form "appsite-go/internal/services/form/entity"
+	message "appsite-go/internal/services/message/entity"
+	relation "appsite-go/internal/services/relation/entity"
+	shieldword "appsite-go/internal/services/shieldword/entity"
+	user "appsite-go/internal/services/user/entity"
+	world "appsite-go/internal/services/world/entity"
+)
+
+// entities are the models the services read and write
+var entities = []interface{}{
+	&user.User{}, &user.UserInfo{}, &user.UserGroup{}, &user.UserPreference{}, &user.UserIdentity{},
+	&world.Tenant{},
+	&contents.Article{}, &contents.Banner{}, &contents.Category{}, &contents.Comment{},
+	&contents.Media{}, &contents.Page{}, &contents.Tag{},
+	&commerce.Product{}, &commerce.SKU{}, &commerce.StockLog{},
+	&commerce.Coupon{}, &commerce.UserCoupon{}, &commerce.Order{}, &commerce.OrderItem{},
+	&finance.Deal{}, &finance.Balance{},
+	&message.Notification{}, &form.Request{}, &relation.Relation{}, &shieldword.Word{},
+	&operation.AuditLog{}, &event.Outbox{}, &scheduler.Run{},
+	&token.RefreshToken{}, &token.SigningKey{}, &session.Session{},
+	&mfa.Factor{}, &mfa.RecoveryCode{}, &oauth.Client{}, &oauth.Consent{},
+	&apikey.Key{}, &permission.Rule{},
+}
+
+// Migrations keep frozen copies of the tables, so an entity change without
+// a migration of its own shows up here as a missing column or index
+func TestMigrations_MatchEntities(t *testing.T) {
+	for _, naming := range []schema.Namer{schema.NamingStrategy{}, schema.NamingStrategy{SingularTable: true}} {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{NamingStrategy: naming})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := migrations.Up(db); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, e := range entities {
+			stmt := &gorm.Statement{DB: db}
+			if err := stmt.Parse(e); err != nil {
+				t.Fatal(err)
+			}
+			sch := stmt.Schema
+			if !db.Migrator().HasTable(e) {
+				t.Errorf("%s: no migration creates the table", sch.Table)
+				continue
+			}
+			for _, f := range sch.Fields {
+				if f.DBName != "" && !db.Migrator().HasColumn(e, f.DBName) {
+					t.Errorf("%s.%s: no migration adds the column", sch.Table, f.DBName)
+				}
+			}
+			for _, idx := range sch.ParseIndexes() {
+				if !db.Migrator().HasIndex(e, idx.Name) {
+					t.Errorf("%s: no migration adds index %s", sch.Table, idx.Name)
+				}
+			}
+		}
+	}
+}
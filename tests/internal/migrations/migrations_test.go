@@ -0,0 +1,96 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations_test
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/user/entity"
+)
+
+func TestMigrations_RoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(migrations.All()) {
+		t.Errorf("Expected %d applied, got %d", len(migrations.All()), len(ids))
+	}
+
+	for _, table := range []string{"user_account", "item_article", "shop_product", "sys_tenant"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("Missing table %s", table)
+		}
+	}
+
+	var group entity.UserGroup
+	if err := db.First(&group, "id = ?", migrations.DefaultGroupID).Error; err != nil {
+		t.Errorf("Default group should be seeded: %v", err)
+	}
+
+	// Everything can be reverted and re-applied
+	if _, err := m.Down(len(ids)); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("user_account") {
+		t.Error("user_account should be dropped")
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrations_WechatUnique(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows from before: unbound ones hold "", and one account is bound twice
+	for id, wechat := range map[string]string{"u1": "", "u2": "", "u3": "w1", "u4": "w1"} {
+		if err := db.Table("user_info").Create(map[string]interface{}{"user_id": id, "wechat_id": wechat}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Up(); err == nil {
+		t.Fatal("Up should refuse an account bound to two users")
+	}
+
+	db.Table("user_info").Where("user_id = ?", "u4").Update("wechat_id", "")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	var unbound int64
+	db.Table("user_info").Where("wechat_id IS NULL").Count(&unbound)
+	if unbound != 3 {
+		t.Errorf("Expected the unbound rows to hold NULL, got %d", unbound)
+	}
+	if err := db.Table("user_info").Where("user_id = ?", "u1").Update("wechat_id", "w1").Error; err == nil {
+		t.Error("A WeChat account should be bound to one user only")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package migrations_test
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/contents"
+	"appsite-go/internal/services/contents/entity"
+	"appsite-go/internal/services/finance"
+	"appsite-go/pkg/dbs"
+	"appsite-go/pkg/utils/orm"
+)
+
+// setupPostgres connects to a disposable local Postgres, e.g.
+//
+//	docker run -e POSTGRES_USER=testuser -e POSTGRES_PASSWORD=testpass -e POSTGRES_DB=testdb -p 5432:5432 postgres:16
+//
+// Host and port can be overridden with APPSITE_TEST_PG_HOST / APPSITE_TEST_PG_PORT.
+// The public schema is wiped, so never point this at real data.
+func setupPostgres(t *testing.T) *gorm.DB {
+	cfg := &setting.DatabaseConfig{
+		Type:     "postgres",
+		Host:     envOr("APPSITE_TEST_PG_HOST", "127.0.0.1"),
+		Port:     envOr("APPSITE_TEST_PG_PORT", "5432"),
+		User:     "testuser",
+		Password: "testpass",
+		Name:     "testdb",
+	}
+
+	db, err := orm.InitDB(cfg)
+	if err != nil {
+		t.Skipf("Skipping Postgres tests: %v", err)
+	}
+	if err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public").Error; err != nil {
+		t.Fatalf("reset schema: %v", err)
+	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate postgres: %v", err)
+	}
+	return db
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func TestPostgres_Integration(t *testing.T) {
+	db := setupPostgres(t)
+
+	var typ string
+	db.Raw("SELECT data_type FROM information_schema.columns WHERE table_name = 'item_article' AND column_name = 'tags'").Scan(&typ)
+	if typ != "jsonb" {
+		t.Errorf("tags should be jsonb, got %q", typ)
+	}
+
+	// JSON round trip, case-insensitive filter, versioned update, trash
+	svc := contents.NewArticleService(db)
+	a := &entity.Article{Title: "Hello Postgres", Tags: dbs.StringArray{"go", "pg"}, Introduce: "body"}
+	a.ID = "pg1"
+	if err := svc.Create(a); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := svc.Query(&model.ListParams{Filter: model.NewFilter().Where("title", model.OpLike, "hello")})
+	if err != nil || page.Total != 1 || page.List[0].Tags[1] != "pg" {
+		t.Fatalf("ILIKE query: total=%v err=%v", page, err)
+	}
+
+	if _, err := svc.Update("pg1", map[string]interface{}{"title": "v2", "version": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Update("pg1", map[string]interface{}{"title": "v3", "version": 1}); err != model.ErrConflict {
+		t.Errorf("stale version should conflict, got %v", err)
+	}
+
+	if err := svc.Delete("pg1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Restore("pg1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Row locking and balance upsert
+	ledger := finance.NewLedgerService(db)
+	for i := 0; i < 2; i++ {
+		if err := ledger.RecordTransaction("u1", "money", 100, "recharge", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bal, _ := ledger.GetBalance("u1", "money"); bal != 200 {
+		t.Errorf("balance = %d, want 200", bal)
+	}
+}
@@ -0,0 +1,189 @@
+package apikey_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/apikey"
+	"appsite-go/internal/services/user/entity"
+	world "appsite-go/internal/services/world/entity"
+)
+
+func setup(t *testing.T) (*apikey.Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	return apikey.NewService(db, rdb), db
+}
+
+func newUser(t *testing.T, db *gorm.DB, saasID string) *entity.User {
+	user := &entity.User{Username: "ada", Status: "enabled", GroupID: "100"}
+	user.SaasID = saasID
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestAPIKey_CreateAndAuthenticate(t *testing.T) {
+	svc, db := setup(t)
+	user := newUser(t, db, "acme")
+
+	k, raw, err := svc.Create(t.Context(), apikey.Input{
+		UserID: user.ID, Name: "CI", Scopes: []string{"content:read"},
+	}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.SaasID != "acme" || k.Hash == raw || raw[:len(apikey.Prefix)] != apikey.Prefix {
+		t.Fatalf("key = %+v", k)
+	}
+
+	got, err := svc.Authenticate(t.Context(), raw, "203.0.113.9")
+	if err != nil || got.ID != k.ID || got.UserID != user.ID {
+		t.Fatalf("Authenticate = %v, %v", got, err)
+	}
+	if !got.Allows("content", apikey.ActionRead) || got.Allows("content", apikey.ActionWrite) || got.Allows("users", apikey.ActionRead) {
+		t.Error("scopes are not enforced")
+	}
+
+	// Use is recorded, at most once a minute
+	if err := svc.Seen(t.Context(), got, "203.0.113.9"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Seen(t.Context(), got, "203.0.113.10"); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := svc.Get(t.Context(), k.ID)
+	if stored.LastUsedAt == 0 || stored.LastUsedIP != "203.0.113.9" {
+		t.Errorf("last use = %d %s", stored.LastUsedAt, stored.LastUsedIP)
+	}
+
+	if _, err := svc.Authenticate(t.Context(), raw+"x", ""); !errors.Is(err, apikey.ErrKeyInvalid) {
+		t.Errorf("wrong key = %v", err)
+	}
+}
+
+func TestAPIKey_RevokedExpiredAndDisabled(t *testing.T) {
+	svc, db := setup(t)
+	user := newUser(t, db, "")
+
+	k, raw, err := svc.Create(t.Context(), apikey.Input{UserID: user.ID, Name: "a", Scopes: []string{"*"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoke(t.Context(), k.ID, "someone-else"); !errors.Is(err, apikey.ErrKeyNotFound) {
+		t.Errorf("revoke by another user = %v", err)
+	}
+	if err := svc.Revoke(t.Context(), k.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate(t.Context(), raw, ""); !errors.Is(err, apikey.ErrKeyInvalid) {
+		t.Errorf("revoked key = %v", err)
+	}
+
+	k, raw, _ = svc.Create(t.Context(), apikey.Input{
+		UserID: user.ID, Name: "b", Scopes: []string{"*"}, ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, "")
+	db.Model(&apikey.Key{}).Where("id = ?", k.ID).Update("expires_at", time.Now().Add(-time.Second).Unix())
+	if _, err := svc.Authenticate(t.Context(), raw, ""); !errors.Is(err, apikey.ErrKeyInvalid) {
+		t.Errorf("expired key = %v", err)
+	}
+
+	_, raw, _ = svc.Create(t.Context(), apikey.Input{UserID: user.ID, Name: "c", Scopes: []string{"*"}}, "")
+	db.Model(&entity.User{}).Where("id = ?", user.ID).Update("status", "disabled")
+	if _, err := svc.Authenticate(t.Context(), raw, ""); !errors.Is(err, apikey.ErrKeyInvalid) {
+		t.Errorf("key of a disabled user = %v", err)
+	}
+}
+
+func TestAPIKey_IPAllowlist(t *testing.T) {
+	svc, db := setup(t)
+	user := newUser(t, db, "")
+
+	_, raw, err := svc.Create(t.Context(), apikey.Input{
+		UserID: user.ID, Name: "a", Scopes: []string{"*"}, AllowedIPs: []string{"10.0.0.0/8", "2001:db8::1"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, ok := range map[string]bool{
+		"10.1.2.3": true, "::ffff:10.1.2.3": true, "2001:db8::1": true, "192.168.0.1": false, "": false,
+	} {
+		_, err := svc.Authenticate(t.Context(), raw, ip)
+		if ok != (err == nil) || (!ok && !errors.Is(err, apikey.ErrIPNotAllowed)) {
+			t.Errorf("Authenticate from %q = %v", ip, err)
+		}
+	}
+
+	if _, _, err := svc.Create(t.Context(), apikey.Input{
+		UserID: user.ID, Name: "b", Scopes: []string{"*"}, AllowedIPs: []string{"10.0.0.0/33"},
+	}, ""); !errors.Is(err, apikey.ErrInvalidIP) {
+		t.Errorf("bad CIDR = %v", err)
+	}
+}
+
+func TestAPIKey_Validation(t *testing.T) {
+	svc, db := setup(t)
+	user := newUser(t, db, "")
+
+	for _, scope := range []string{"content", "content:delete", ":read", "a/b:read"} {
+		_, _, err := svc.Create(t.Context(), apikey.Input{UserID: user.ID, Name: "a", Scopes: []string{scope}}, "")
+		if !errors.Is(err, apikey.ErrInvalidScope) {
+			t.Errorf("scope %q = %v", scope, err)
+		}
+	}
+	if _, _, err := svc.Create(t.Context(), apikey.Input{Name: "a", Scopes: []string{"*"}}, ""); !errors.Is(err, apikey.ErrInvalidOwner) {
+		t.Errorf("no owner = %v", err)
+	}
+	if _, _, err := svc.Create(t.Context(), apikey.Input{
+		UserID: user.ID, Name: "a", Scopes: []string{"*"}, ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+	}, ""); !errors.Is(err, apikey.ErrInvalidExpiry) {
+		t.Errorf("past expiry = %v", err)
+	}
+}
+
+func TestAPIKey_TenantKey(t *testing.T) {
+	svc, db := setup(t)
+	tenant := &world.Tenant{Title: "Acme", Code: "acme", Status: "enabled"}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	k, raw, err := svc.Create(t.Context(), apikey.Input{SaasID: tenant.ID, Name: "sync", Scopes: []string{"content:*"}}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.Authenticate(t.Context(), raw, "")
+	if err != nil || got.UserID != "" || got.SaasID != tenant.ID || !got.Allows("content", apikey.ActionWrite) {
+		t.Fatalf("Authenticate = %+v, %v", got, err)
+	}
+
+	list, _ := svc.List(t.Context(), apikey.Filter{SaasID: tenant.ID})
+	if len(list) != 1 || list[0].ID != k.ID || list[0].CreatedBy != "admin" {
+		t.Errorf("List = %+v", list)
+	}
+
+	// Keys of a suspended tenant stop working
+	db.Model(&world.Tenant{}).Where("id = ?", tenant.ID).Update("status", "disabled")
+	if _, err := svc.Authenticate(t.Context(), raw, ""); !errors.Is(err, apikey.ErrKeyInvalid) {
+		t.Errorf("key of a disabled tenant = %v", err)
+	}
+	if _, _, err := svc.Create(t.Context(), apikey.Input{SaasID: tenant.ID, Name: "x", Scopes: []string{"*"}}, ""); !errors.Is(err, apikey.ErrInvalidOwner) {
+		t.Errorf("key for a disabled tenant = %v", err)
+	}
+}
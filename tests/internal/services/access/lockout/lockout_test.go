@@ -0,0 +1,158 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package lockout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/lockout"
+	"appsite-go/internal/services/access/operation"
+)
+
+func setup(t *testing.T, opts lockout.Options) (*lockout.Service, *miniredis.Miniredis, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return lockout.NewService(rdb, operation.NewService(db), opts), mr, db
+}
+
+func countActions(t *testing.T, db *gorm.DB, action string) int64 {
+	var n int64
+	if err := db.Model(&operation.AuditLog{}).Where("action = ?", action).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestLockout_ProgressiveDelay(t *testing.T) {
+	svc, mr, db := setup(t, lockout.Options{DelayAfter: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second})
+	ctx := context.Background()
+	a := lockout.Attempt{Subject: "user:u1", UserID: "u1", IP: "10.0.0.1"}
+
+	if err := svc.Fail(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Check(ctx, a); err != nil {
+		t.Fatalf("first failure should not delay: %v", err)
+	}
+
+	// Delays double from the DelayAfter-th failure up to MaxDelay
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if err := svc.Fail(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		var locked *lockout.LockedError
+		if err := svc.Check(ctx, a); !errors.As(err, &locked) || !errors.Is(err, lockout.ErrLocked) {
+			t.Fatalf("failure %d: expected LockedError, got %v", i+2, err)
+		}
+		if locked.RetryAfter != want {
+			t.Errorf("failure %d: expected wait %v, got %v", i+2, want, locked.RetryAfter)
+		}
+		mr.FastForward(want)
+	}
+	if err := svc.Check(ctx, a); err != nil {
+		t.Errorf("expected no wait once the delay passed, got %v", err)
+	}
+
+	if n := countActions(t, db, lockout.ActionFailed); n != 5 {
+		t.Errorf("expected 5 failures audited, got %d", n)
+	}
+}
+
+func TestLockout_LockAndUnlock(t *testing.T) {
+	svc, mr, db := setup(t, lockout.Options{MaxFailures: 3, DelayAfter: 100, Lockout: time.Minute})
+	ctx := context.Background()
+	a := lockout.Attempt{Subject: "user:u1", UserID: "u1", IP: "10.0.0.1"}
+
+	for i := 0; i < 3; i++ {
+		if err := svc.Fail(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.Check(ctx, a); !errors.Is(err, lockout.ErrLocked) {
+		t.Fatalf("expected account locked, got %v", err)
+	}
+	if n := countActions(t, db, lockout.ActionLocked); n != 1 {
+		t.Errorf("expected 1 lock audited, got %d", n)
+	}
+
+	// Other accounts from the same IP are not affected
+	if err := svc.Check(ctx, lockout.Attempt{Subject: "user:u2", IP: "10.0.0.1"}); err != nil {
+		t.Errorf("other account should not be locked: %v", err)
+	}
+
+	if err := svc.Unlock(ctx, a, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Check(ctx, a); err != nil {
+		t.Errorf("expected unlocked, got %v", err)
+	}
+	if n := countActions(t, db, lockout.ActionUnlocked); n != 1 {
+		t.Errorf("expected 1 unlock audited, got %d", n)
+	}
+
+	// The lock also ends by itself
+	for i := 0; i < 3; i++ {
+		svc.Fail(ctx, a)
+	}
+	mr.FastForward(time.Minute)
+	if err := svc.Check(ctx, a); err != nil {
+		t.Errorf("expected lock expired, got %v", err)
+	}
+
+	// Failures still in the window lock again at once
+	if err := svc.Fail(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Check(ctx, a); !errors.Is(err, lockout.ErrLocked) {
+		t.Errorf("expected a failure past the limit to lock again, got %v", err)
+	}
+}
+
+func TestLockout_IP(t *testing.T) {
+	svc, _, _ := setup(t, lockout.Options{MaxFailures: 100, IPMaxFailures: 3, DelayAfter: 100})
+	ctx := context.Background()
+
+	// A spraying run: one failure per account from a single IP
+	for _, subject := range []string{"name:a", "name:b", "name:c"} {
+		if err := svc.Fail(ctx, lockout.Attempt{Subject: subject, IP: "10.0.0.9"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.Check(ctx, lockout.Attempt{Subject: "name:d", IP: "10.0.0.9"}); !errors.Is(err, lockout.ErrLocked) {
+		t.Fatalf("expected IP locked, got %v", err)
+	}
+	if err := svc.Check(ctx, lockout.Attempt{Subject: "name:d", IP: "10.0.0.10"}); err != nil {
+		t.Errorf("other IP should not be locked: %v", err)
+	}
+
+	// A good login does not clear the IP count
+	svc.Succeed(ctx, lockout.Attempt{Subject: "name:d", IP: "10.0.0.9"})
+	if err := svc.Check(ctx, lockout.Attempt{Subject: "name:d", IP: "10.0.0.9"}); !errors.Is(err, lockout.ErrLocked) {
+		t.Errorf("expected IP still locked, got %v", err)
+	}
+
+	if err := svc.UnlockIP(ctx, "10.0.0.9", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Check(ctx, lockout.Attempt{Subject: "name:d", IP: "10.0.0.9"}); err != nil {
+		t.Errorf("expected IP unlocked, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mfa_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/user/entity"
+)
+
+func setup(t *testing.T) (*mfa.Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	return mfa.NewService(db, rdb, "Appsite"), db
+}
+
+// enable enrolls and confirms a factor, returning its secret and recovery codes
+func enable(t *testing.T, svc *mfa.Service, userID string) (string, []string) {
+	ctx := context.Background()
+	enrollment, err := svc.Enroll(ctx, userID, "alice@example.com")
+	if err != nil {
+		t.Fatalf("Enroll failed: %v", err)
+	}
+	code, _ := mfa.Code(enrollment.Secret, time.Now().Add(-mfa.Period))
+	codes, err := svc.Confirm(ctx, userID, code)
+	if err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func TestTOTP_RFC6238(t *testing.T) {
+	// Appendix B vector for SHA-1, "12345678901234567890" in base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for at, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924"} {
+		if got, _ := mfa.Code(secret, time.Unix(at, 0)); got != want {
+			t.Errorf("Code at %d = %s, want %s", at, got, want)
+		}
+	}
+
+	uri := mfa.ProvisioningURI("Appsite", "alice@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Appsite:alice@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Unexpected provisioning URI %s", uri)
+	}
+}
+
+func TestMFA_EnrollAndVerify(t *testing.T) {
+	svc, _ := setup(t)
+	ctx := context.Background()
+
+	enrollment, _ := svc.Enroll(ctx, "u1", "alice@example.com")
+	if enabled, _ := svc.Enabled(ctx, "u1"); enabled {
+		t.Error("An unconfirmed factor must not be enabled")
+	}
+	if _, err := svc.Confirm(ctx, "u1", "000000"); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Expected ErrInvalidCode, got %v", err)
+	}
+	first, _ := mfa.Code(enrollment.Secret, time.Now())
+	codes, err := svc.Confirm(ctx, "u1", first)
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("Confirm = %v, %v", codes, err)
+	}
+	if _, err := svc.Enroll(ctx, "u1", "alice@example.com"); !errors.Is(err, mfa.ErrAlreadyEnabled) {
+		t.Errorf("Expected ErrAlreadyEnabled, got %v", err)
+	}
+
+	// The confirming code cannot be replayed, the next step's code works once
+	if err := svc.Verify(ctx, "u1", first); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Replayed code should be refused, got %v", err)
+	}
+	next, _ := mfa.Code(enrollment.Secret, time.Now().Add(mfa.Period))
+	if err := svc.Verify(ctx, "u1", next); err != nil {
+		t.Errorf("Next code should verify, got %v", err)
+	}
+	if err := svc.Verify(ctx, "u1", next); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Code should verify once, got %v", err)
+	}
+
+	// Recovery codes are single use and forgiving on format
+	if err := svc.Verify(ctx, "u1", strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))); err != nil {
+		t.Errorf("Recovery code should verify, got %v", err)
+	}
+	if err := svc.Verify(ctx, "u1", codes[0]); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Recovery code should verify once, got %v", err)
+	}
+	if err := svc.Verify(ctx, "u2", codes[1]); !errors.Is(err, mfa.ErrNotEnrolled) {
+		t.Errorf("Expected ErrNotEnrolled, got %v", err)
+	}
+}
+
+func TestMFA_DisableAndGroup(t *testing.T) {
+	svc, db := setup(t)
+	ctx := context.Background()
+	_, codes := enable(t, svc, "u1")
+
+	group := &entity.UserGroup{GroupName: "admins", RequireMFA: true}
+	db.Create(group)
+	user := &entity.User{GroupID: group.ID}
+	user.ID = "u1"
+	if required, _ := svc.Required(ctx, user); !required {
+		t.Fatal("Group should require a second factor")
+	}
+	if err := svc.Disable(ctx, user, codes[0]); !errors.Is(err, mfa.ErrRequiredByGroup) {
+		t.Errorf("Expected ErrRequiredByGroup, got %v", err)
+	}
+
+	user.GroupID = "100"
+	if err := svc.Disable(ctx, user, codes[0]); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if enabled, _ := svc.Enabled(ctx, "u1"); enabled {
+		t.Error("Factor should be removed")
+	}
+}
+
+func TestMFA_Challenge(t *testing.T) {
+	svc, _ := setup(t)
+	ctx := context.Background()
+	secret, _ := enable(t, svc, "u1")
+
+	ch, err := svc.NewChallenge(ctx, "u1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Redeem(ctx, ch.ID, "000000"); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Expected ErrInvalidCode, got %v", err)
+	}
+	code, _ := mfa.Code(secret, time.Now())
+	got, _, err := svc.Redeem(ctx, ch.ID, code)
+	if err != nil || got.UserID != "u1" {
+		t.Fatalf("Redeem = %+v, %v", got, err)
+	}
+	if _, _, err := svc.Redeem(ctx, ch.ID, code); !errors.Is(err, mfa.ErrChallengeInvalid) {
+		t.Errorf("Challenge should be single use, got %v", err)
+	}
+
+	// Wrong codes burn the challenge
+	ch, _ = svc.NewChallenge(ctx, "u1", false)
+	for i := 0; i < 5; i++ {
+		svc.Redeem(ctx, ch.ID, "000000")
+	}
+	code, _ = mfa.Code(secret, time.Now().Add(mfa.Period))
+	if _, _, err := svc.Redeem(ctx, ch.ID, code); !errors.Is(err, mfa.ErrChallengeInvalid) {
+		t.Errorf("Expected a burned challenge, got %v", err)
+	}
+
+	// An enrollment challenge confirms the factor and hands out recovery codes
+	ch, _ = svc.NewChallenge(ctx, "u2", true)
+	enrollment, _ := svc.Enroll(ctx, "u2", "bob")
+	code, _ = mfa.Code(enrollment.Secret, time.Now())
+	_, codes, err := svc.Redeem(ctx, ch.ID, code)
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("Enrollment redeem = %v, %v", codes, err)
+	}
+	if enabled, _ := svc.Enabled(ctx, "u2"); !enabled {
+		t.Error("Factor should be enabled")
+	}
+}
+
+func TestMFA_Lockout(t *testing.T) {
+	_, db := setup(t)
+	mr := miniredis.RunT(t)
+	svc := mfa.NewService(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "Appsite")
+	ctx := context.Background()
+	secret, codes := enable(t, svc, "u1")
+
+	// Wrong codes count on every path checking one
+	user := &entity.User{}
+	user.ID = "u1"
+	for i := 0; i < 9; i++ {
+		var err error
+		switch i % 3 {
+		case 0:
+			err = svc.Verify(ctx, "u1", "000000")
+		case 1:
+			_, err = svc.RegenerateRecovery(ctx, "u1", "000000")
+		case 2:
+			err = svc.Disable(ctx, user, "000000")
+		}
+		if !errors.Is(err, mfa.ErrInvalidCode) {
+			t.Fatalf("Attempt %d: expected ErrInvalidCode, got %v", i, err)
+		}
+	}
+	if err := svc.Disable(ctx, user, "000000"); !errors.Is(err, mfa.ErrLocked) {
+		t.Fatalf("Expected ErrLocked on the last attempt, got %v", err)
+	}
+	if err := svc.Disable(ctx, user, codes[0]); !errors.Is(err, mfa.ErrLocked) {
+		t.Errorf("Right codes should wait for the lock to end, got %v", err)
+	}
+	svc.Enroll(ctx, "u2", "bob")
+	if _, err := svc.Confirm(ctx, "u2", "000000"); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Errorf("Other users are counted apart, got %v", err)
+	}
+
+	mr.FastForward(16 * time.Minute)
+	code, _ := mfa.Code(secret, time.Now().Add(mfa.Period))
+	if err := svc.Verify(ctx, "u1", code); err != nil {
+		t.Errorf("Lock should end with the window, got %v", err)
+	}
+}
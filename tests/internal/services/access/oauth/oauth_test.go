@@ -0,0 +1,395 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oauth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/oauth"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/entity"
+)
+
+const (
+	issuer   = "https://id.example.com"
+	redirect = "https://app.example.com/callback"
+	verifier = "dBjftJeZ4CVP-mJ92K9TwOSXkzkH9tE0V3DOcW9rO2Nh-2pkvwXgAq"
+)
+
+type fixture struct {
+	svc    *oauth.Service
+	tokens *token.Service
+	db     *gorm.DB
+	user   *entity.User
+}
+
+func setup(t *testing.T, alg string) *fixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	tokens := token.NewService(setting.AppConfig{JwtSecret: "k", JwtAlgorithm: alg, JwtExpire: time.Minute}).
+		WithStore(db, rdb)
+	if err := tokens.LoadKeys(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	email := "ada@example.com"
+	user := &entity.User{Username: "ada", Nickname: "Ada", Email: &email, Status: "enabled", GroupID: "100"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &fixture{svc: oauth.NewService(db, rdb, tokens, issuer+"/"), tokens: tokens, db: db, user: user}
+}
+
+func (f *fixture) client(t *testing.T, in oauth.ClientInput) (*oauth.Client, string) {
+	c, secret, err := f.svc.CreateClient(context.Background(), in)
+	if err != nil {
+		t.Fatalf("CreateClient failed: %v", err)
+	}
+	return c, secret
+}
+
+func challenge() string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func authRequest(clientID, scope string) oauth.AuthorizeRequest {
+	return oauth.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         redirect,
+		Scope:               scope,
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       challenge(),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// query parses the redirect URL of a decision
+func query(t *testing.T, d *oauth.Decision) url.Values {
+	if d.RedirectURL == "" || !strings.HasPrefix(d.RedirectURL, redirect+"?") {
+		t.Fatalf("decision = %+v, want a redirect", d)
+	}
+	u, err := url.Parse(d.RedirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestOAuth_AuthorizationCodeWithConsent(t *testing.T) {
+	f := setup(t, token.AlgRS256)
+	ctx := context.Background()
+	c, secret := f.client(t, oauth.ClientInput{
+		Name:         "Notes",
+		RedirectURIs: []string{redirect},
+		Scopes:       []string{"openid", "profile", "email"},
+		GrantTypes:   []string{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken},
+	})
+	if secret == "" {
+		t.Fatal("confidential client got no secret")
+	}
+
+	// The first request needs the consent screen
+	d, err := f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, "openid profile"))
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	if !d.ConsentRequired || d.Client.Name != "Notes" || strings.Join(d.Scopes, " ") != "openid profile" {
+		t.Fatalf("decision = %+v, want the consent screen", d)
+	}
+
+	d, err = f.svc.Consent(ctx, f.user.ID, authRequest(c.ID, "openid profile"), true)
+	if err != nil {
+		t.Fatalf("Consent failed: %v", err)
+	}
+	q := query(t, d)
+	if q.Get("state") != "xyz" || q.Get("iss") != issuer || q.Get("code") == "" {
+		t.Fatalf("redirect query = %v", q)
+	}
+
+	// The secret is required
+	req := oauth.TokenRequest{
+		GrantType: oauth.GrantAuthorizationCode, ClientID: c.ID, ClientSecret: "wrong",
+		Code: q.Get("code"), RedirectURI: redirect, CodeVerifier: verifier,
+	}
+	if _, err := f.svc.Token(ctx, req); !errors.Is(err, oauth.ErrInvalidClient) {
+		t.Fatalf("wrong secret err = %v, want invalid_client", err)
+	}
+	req.ClientSecret = secret
+	res, err := f.svc.Token(ctx, req)
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if res.RefreshToken == "" || res.IDToken == "" || res.Scope != "openid profile" {
+		t.Fatalf("token response = %+v", res)
+	}
+
+	// Access tokens are those of the token service, carrying the grant
+	claims, err := f.tokens.ParseToken(res.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if claims.UserID != f.user.ID || claims.ClientID != c.ID || claims.Role != "100" {
+		t.Errorf("access claims = %+v", claims)
+	}
+
+	// The ID token verifies against the published keys
+	set, err := f.tokens.JWKS(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(res.IDToken, id, func(tok *jwt.Token) (interface{}, error) {
+		for _, k := range set.Keys {
+			if k.Kid == tok.Header["kid"] {
+				return k.PublicKey()
+			}
+		}
+		return nil, token.ErrUnknownKey
+	}, jwt.WithIssuer(issuer), jwt.WithAudience(c.ID), jwt.WithExpirationRequired())
+	if err != nil {
+		t.Fatalf("ID token invalid: %v", err)
+	}
+	if id["sub"] != f.user.ID || id["nonce"] != "n-0S6" || id["name"] != "Ada" || id["email"] != nil {
+		t.Errorf("ID token claims = %v", id)
+	}
+	if _, err := f.tokens.ParseToken(res.IDToken); err == nil {
+		t.Error("ID token accepted as an access token")
+	}
+
+	// User info follows the scope
+	info, err := f.svc.UserInfo(ctx, claims)
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if info["sub"] != f.user.ID || info["preferred_username"] != "ada" || info["email"] != nil {
+		t.Errorf("user info = %v", info)
+	}
+
+	// A code works once
+	if _, err := f.svc.Token(ctx, req); !errors.Is(err, oauth.ErrInvalidGrant) {
+		t.Errorf("reused code err = %v, want invalid_grant", err)
+	}
+
+	// Refresh keeps the grant
+	next, err := f.svc.Token(ctx, oauth.TokenRequest{
+		GrantType: oauth.GrantRefreshToken, ClientID: c.ID, ClientSecret: secret, RefreshToken: res.RefreshToken,
+	})
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if next.Scope != "openid profile" || next.RefreshToken == res.RefreshToken {
+		t.Errorf("refreshed = %+v", next)
+	}
+
+	// The consent is remembered, a wider scope asks again
+	d, err = f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, "openid"))
+	if err != nil || d.ConsentRequired {
+		t.Fatalf("approved scope decision = %+v, err %v", d, err)
+	}
+	d, err = f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, "openid email"))
+	if err != nil || !d.ConsentRequired {
+		t.Fatalf("wider scope decision = %+v, err %v", d, err)
+	}
+
+	// Revoking the consent signs the user out of the app
+	if err := f.svc.RevokeConsent(ctx, f.user.ID, c.ID); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+	if _, err := f.svc.Token(ctx, oauth.TokenRequest{
+		GrantType: oauth.GrantRefreshToken, ClientID: c.ID, ClientSecret: secret, RefreshToken: next.RefreshToken,
+	}); !errors.Is(err, oauth.ErrInvalidGrant) {
+		t.Errorf("refresh after revoke err = %v, want invalid_grant", err)
+	}
+}
+
+func TestOAuth_PublicTrustedClient(t *testing.T) {
+	f := setup(t, token.AlgHS256)
+	ctx := context.Background()
+	c, secret := f.client(t, oauth.ClientInput{
+		Name:         "Mobile",
+		RedirectURIs: []string{redirect},
+		Scopes:       []string{"profile", "email"},
+		GrantTypes:   []string{oauth.GrantAuthorizationCode},
+		Public:       true,
+		Trusted:      true,
+	})
+	if secret != "" {
+		t.Fatal("public client got a secret")
+	}
+
+	// Trusted apps skip the consent screen
+	d, err := f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, ""))
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	code := query(t, d).Get("code")
+
+	// PKCE stands in for the secret
+	req := oauth.TokenRequest{GrantType: oauth.GrantAuthorizationCode, ClientID: c.ID, Code: code, CodeVerifier: strings.Repeat("x", 43)}
+	if _, err := f.svc.Token(ctx, req); !errors.Is(err, oauth.ErrInvalidGrant) {
+		t.Fatalf("wrong verifier err = %v, want invalid_grant", err)
+	}
+	// The failed attempt consumed the code
+	req.CodeVerifier = verifier
+	if _, err := f.svc.Token(ctx, req); !errors.Is(err, oauth.ErrInvalidGrant) {
+		t.Fatalf("consumed code err = %v, want invalid_grant", err)
+	}
+
+	d, _ = f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, ""))
+	req.Code = query(t, d).Get("code")
+	res, err := f.svc.Token(ctx, req)
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	// No refresh grant, no refresh token; no openid with HS256, no ID token
+	if res.RefreshToken != "" || res.IDToken != "" || res.Scope != "profile email" {
+		t.Errorf("token response = %+v", res)
+	}
+	claims, _ := f.tokens.ParseToken(res.AccessToken)
+	if _, err := f.svc.UserInfo(ctx, claims); !errors.Is(err, oauth.ErrInsufficientScope) {
+		t.Errorf("UserInfo without openid err = %v, want insufficient_scope", err)
+	}
+	if f.svc.Discovery().IDTokenSigningAlgValuesSupported != nil {
+		t.Error("HS256 provider advertises ID tokens")
+	}
+}
+
+func TestOAuth_AuthorizeErrors(t *testing.T) {
+	f := setup(t, token.AlgHS256)
+	ctx := context.Background()
+	c, _ := f.client(t, oauth.ClientInput{
+		Name:         "Notes",
+		RedirectURIs: []string{redirect},
+		Scopes:       []string{"openid", "profile"},
+		GrantTypes:   []string{oauth.GrantAuthorizationCode},
+	})
+
+	// Never sent back to an unregistered redirect URI
+	req := authRequest(c.ID, "profile")
+	req.RedirectURI = "https://evil.example.com/callback"
+	if _, err := f.svc.Authorize(ctx, f.user.ID, req); !errors.Is(err, oauth.ErrRedirectURI) {
+		t.Errorf("foreign redirect err = %v, want ErrRedirectURI", err)
+	}
+	if _, err := f.svc.Authorize(ctx, f.user.ID, authRequest("nope", "")); !errors.Is(err, oauth.ErrClientNotFound) {
+		t.Errorf("unknown client err = %v, want ErrClientNotFound", err)
+	}
+
+	// Protocol errors go back to the app
+	cases := map[string]func(r *oauth.AuthorizeRequest){
+		"invalid_request":           func(r *oauth.AuthorizeRequest) { r.CodeChallengeMethod = "plain" },
+		"invalid_scope":             func(r *oauth.AuthorizeRequest) { r.Scope = "admin" },
+		"unsupported_response_type": func(r *oauth.AuthorizeRequest) { r.ResponseType = "token" },
+	}
+	for code, mutate := range cases {
+		req := authRequest(c.ID, "profile")
+		mutate(&req)
+		d, err := f.svc.Authorize(ctx, f.user.ID, req)
+		if err != nil {
+			t.Fatalf("%s: Authorize failed: %v", code, err)
+		}
+		if q := query(t, d); q.Get("error") != code || q.Get("state") != "xyz" || q.Get("code") != "" {
+			t.Errorf("%s: redirect query = %v", code, q)
+		}
+	}
+	// openid needs keys apps can verify
+	d, _ := f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, "openid"))
+	if q := query(t, d); q.Get("error") != "invalid_scope" {
+		t.Errorf("openid with HS256 query = %v", q)
+	}
+
+	// Denying the consent
+	d, err := f.svc.Consent(ctx, f.user.ID, authRequest(c.ID, "profile"), false)
+	if err != nil {
+		t.Fatalf("Consent failed: %v", err)
+	}
+	if q := query(t, d); q.Get("error") != "access_denied" {
+		t.Errorf("denied query = %v", q)
+	}
+
+	// Disabled users cannot authorize apps
+	f.db.Model(f.user).Update("status", "disabled")
+	if _, err := f.svc.Authorize(ctx, f.user.ID, authRequest(c.ID, "profile")); !errors.Is(err, oauth.ErrUserInactive) {
+		t.Errorf("disabled user err = %v, want ErrUserInactive", err)
+	}
+}
+
+func TestOAuth_ClientCredentials(t *testing.T) {
+	f := setup(t, token.AlgRS256)
+	ctx := context.Background()
+	c, secret := f.client(t, oauth.ClientInput{
+		Name:       "Reports",
+		Scopes:     []string{"profile", "reports:read"},
+		GrantTypes: []string{oauth.GrantClientCredentials},
+	})
+
+	res, err := f.svc.Token(ctx, oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: c.ID, ClientSecret: secret})
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	// User scopes are left out of the default
+	if res.Scope != "reports:read" || res.RefreshToken != "" {
+		t.Errorf("token response = %+v", res)
+	}
+	claims, err := f.tokens.ParseToken(res.AccessToken)
+	if err != nil || claims.UserID != "" || claims.ClientID != c.ID {
+		t.Errorf("claims = %+v, err %v", claims, err)
+	}
+
+	_, err = f.svc.Token(ctx, oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: c.ID, ClientSecret: secret, Scope: "profile"})
+	if !errors.Is(err, oauth.ErrInvalidScope) {
+		t.Errorf("user scope err = %v, want invalid_scope", err)
+	}
+	_, err = f.svc.Token(ctx, oauth.TokenRequest{GrantType: oauth.GrantAuthorizationCode, ClientID: c.ID, ClientSecret: secret, Code: "x"})
+	if !errors.Is(err, oauth.ErrUnauthorizedClient) {
+		t.Errorf("ungranted type err = %v, want unauthorized_client", err)
+	}
+	_, err = f.svc.Token(ctx, oauth.TokenRequest{GrantType: "password", ClientID: c.ID, ClientSecret: secret})
+	if !errors.Is(err, oauth.ErrUnsupportedGrantType) {
+		t.Errorf("password grant err = %v, want unsupported_grant_type", err)
+	}
+
+	// Public clients cannot hold credentials of their own
+	_, _, err = f.svc.CreateClient(ctx, oauth.ClientInput{Name: "SPA", Public: true, GrantTypes: []string{oauth.GrantClientCredentials}})
+	if !errors.Is(err, oauth.ErrClientConfig) {
+		t.Errorf("public client_credentials err = %v, want ErrClientConfig", err)
+	}
+
+	// A rotated secret replaces the old one
+	rotated, err := f.svc.RotateSecret(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("RotateSecret failed: %v", err)
+	}
+	if _, err := f.svc.Token(ctx, oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: c.ID, ClientSecret: secret}); !errors.Is(err, oauth.ErrInvalidClient) {
+		t.Errorf("old secret err = %v, want invalid_client", err)
+	}
+	if _, err := f.svc.Token(ctx, oauth.TokenRequest{GrantType: oauth.GrantClientCredentials, ClientID: c.ID, ClientSecret: rotated}); err != nil {
+		t.Errorf("rotated secret failed: %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/oidc"
+	world "appsite-go/internal/services/world/entity"
+	"appsite-go/pkg/dbs"
+)
+
+// grant is what the fake provider remembers of an authorization
+type grant struct {
+	nonce     string
+	challenge string
+}
+
+// fakeProvider is an OpenID provider signing ID tokens with an RSA key
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	grants map[string]grant
+	nonce  string // Overrides the nonce of ID tokens when set
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key, grants: map[string]grant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"userinfo_endpoint":      f.URL + "/userinfo",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": b64.EncodeToString(key.N.Bytes()),
+			"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		r.ParseForm()
+		f.mu.Lock()
+		g, ok := f.grants[r.PostForm.Get("code")]
+		delete(f.grants, r.PostForm.Get("code"))
+		f.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		nonce := g.nonce
+		if f.nonce != "" {
+			nonce = f.nonce
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss": f.URL, "aud": "client", "sub": "sub-1", "nonce": nonce,
+			"exp": time.Now().Add(time.Minute).Unix(), "iat": time.Now().Unix(),
+			"email": "ann@example.com", "email_verified": true, "name": "Ann",
+		})
+		tok.Header["kid"] = "k1"
+		signed, _ := tok.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": signed, "token_type": "Bearer"})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// authorize plays the user approving the request of authURL and returns
+// the code and state of the redirect back
+func (f *fakeProvider) authorize(t *testing.T, authURL string) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" || q.Get("scope") != "openid email profile" {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+	code = "code-" + q.Get("state")[:8]
+	f.mu.Lock()
+	f.grants[code] = grant{nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	f.mu.Unlock()
+	return code, q.Get("state")
+}
+
+// setup serves the providers to tenant acme; they run on this machine
+func setup(t *testing.T, providers ...oidc.ProviderConfig) (*oidc.Service, *world.Tenant) {
+	svc, tenant := setupGuarded(t, providers...)
+	return svc.WithPrivateNetworks(), tenant
+}
+
+// setupGuarded serves the providers to tenant acme from public https URLs only
+func setupGuarded(t *testing.T, providers ...oidc.ProviderConfig) (*oidc.Service, *world.Tenant) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	tenant := &world.Tenant{Title: "Acme", Code: "acme", Status: "enabled", Config: dbs.Map{oidc.ConfigKey: providers}}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	return oidc.NewService(db, rdb), tenant
+}
+
+func TestOIDC_SignIn(t *testing.T) {
+	f := newFakeProvider(t)
+	svc, tenant := setup(t, oidc.ProviderConfig{
+		Name: "corp", Title: "Corporate", Issuer: f.URL, ClientID: "client", ClientSecret: "secret",
+		RedirectURL: "https://app.example.com/api/v1/callback/oidc",
+	})
+	ctx := context.Background()
+
+	list, err := svc.Providers(ctx, "acme")
+	if err != nil || len(list) != 1 || list[0].Title != "Corporate" {
+		t.Fatalf("unexpected providers: %v %v", list, err)
+	}
+
+	authURL, nonce, err := svc.Begin(ctx, "acme", "corp", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := f.authorize(t, authURL)
+	id, st, err := svc.Complete(ctx, code, state, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "sub-1" || id.Issuer != f.URL || id.Email != "ann@example.com" || !id.EmailVerified || id.Name != "Ann" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+	if id.TenantID != tenant.ID || st.Provider != "corp" || st.UserID != "" {
+		t.Errorf("unexpected state: %+v %+v", id, st)
+	}
+
+	// A state works once
+	if _, _, err := svc.Complete(ctx, code, state, nonce); !errors.Is(err, oidc.ErrStateInvalid) {
+		t.Errorf("expected ErrStateInvalid on replay, got %v", err)
+	}
+
+	// and only in the browser that started it
+	authURL, _, _ = svc.Begin(ctx, "acme", "corp", "")
+	_, other, _ := svc.Begin(ctx, "acme", "corp", "")
+	code, state = f.authorize(t, authURL)
+	if _, _, err := svc.Complete(ctx, code, state, other); !errors.Is(err, oidc.ErrStateInvalid) {
+		t.Errorf("expected ErrStateInvalid in another browser, got %v", err)
+	}
+}
+
+func TestOIDC_NonceMismatch(t *testing.T) {
+	f := newFakeProvider(t)
+	f.nonce = "replayed"
+	svc, _ := setup(t, oidc.ProviderConfig{Name: "corp", Issuer: f.URL, ClientID: "client", ClientSecret: "secret"})
+	ctx := context.Background()
+
+	authURL, nonce, err := svc.Begin(ctx, "acme", "corp", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := f.authorize(t, authURL)
+	if _, _, err := svc.Complete(ctx, code, state, nonce); !errors.Is(err, oidc.ErrIDToken) {
+		t.Errorf("expected ErrIDToken, got %v", err)
+	}
+}
+
+func TestOIDC_RejectedCode(t *testing.T) {
+	f := newFakeProvider(t)
+	svc, _ := setup(t, oidc.ProviderConfig{Name: "corp", Issuer: f.URL, ClientID: "client", ClientSecret: "secret"})
+	ctx := context.Background()
+
+	authURL, nonce, _ := svc.Begin(ctx, "acme", "corp", "")
+	_, state := f.authorize(t, authURL)
+	if _, _, err := svc.Complete(ctx, "forged", state, nonce); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("expected ErrExchange, got %v", err)
+	}
+}
+
+func TestOIDC_PlainOAuth2(t *testing.T) {
+	// A GitHub-like provider: no ID token, numeric IDs in user info
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("client_secret") != "secret" || r.PostForm.Get("code") != "good" || r.PostForm.Get("code_verifier") == "" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gh", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 583231, "login": "octocat", "email": "octo@example.com"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	svc, _ := setup(t, oidc.ProviderConfig{
+		Name: "github", ClientID: "client", ClientSecret: "secret", SubjectClaim: "id",
+		AuthURL: srv.URL + "/authorize", TokenURL: srv.URL + "/token", UserInfoURL: srv.URL + "/user",
+	})
+	ctx := context.Background()
+
+	authURL, nonce, err := svc.Begin(ctx, "acme", "github", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("nonce") != "" {
+		t.Error("plain OAuth2 requests carry no nonce")
+	}
+	id, st, err := svc.Complete(ctx, "good", u.Query().Get("state"), nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "583231" || id.Name != "octocat" || id.Email != "octo@example.com" || id.EmailVerified {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+	if st.UserID != "user-1" {
+		t.Errorf("expected the linking user in the state, got %+v", st)
+	}
+}
+
+func TestOIDC_UnknownTenantOrProvider(t *testing.T) {
+	svc, _ := setup(t)
+	ctx := context.Background()
+	if _, _, err := svc.Begin(ctx, "nope", "corp", ""); !errors.Is(err, oidc.ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant, got %v", err)
+	}
+	if _, _, err := svc.Begin(ctx, "acme", "corp", ""); !errors.Is(err, oidc.ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestOIDC_UnsafeEndpoints(t *testing.T) {
+	f := newFakeProvider(t)
+	tls := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(tls.Close)
+	_, port, _ := net.SplitHostPort(tls.Listener.Addr().String())
+	ctx := context.Background()
+
+	// Tenants cannot point the server at itself or its network
+	for _, issuer := range []string{
+		f.URL,                                      // plain http
+		"https://127.0.0.1:" + port,                // loopback address
+		"https://[::ffff:10.0.0.1]",                // private address, mapped
+		"https://localhost:" + port,                // public name of a loopback address
+		"https://169.254.169.254/latest/meta-data", // cloud metadata
+	} {
+		svc, _ := setupGuarded(t, oidc.ProviderConfig{Name: "corp", Issuer: issuer, ClientID: "client", ClientSecret: "secret"})
+		if _, _, err := svc.Begin(ctx, "acme", "corp", ""); !errors.Is(err, oidc.ErrUnsafeEndpoint) {
+			t.Errorf("%s: expected ErrUnsafeEndpoint, got %v", issuer, err)
+		}
+	}
+
+	// Plain OAuth2 endpoints are only called on the callback
+	svc, _ := setupGuarded(t, oidc.ProviderConfig{
+		Name: "github", ClientID: "client", ClientSecret: "secret",
+		AuthURL: "https://github.example/authorize", TokenURL: "https://192.168.1.1/token", UserInfoURL: "https://192.168.1.1/user",
+	})
+	authURL, nonce, err := svc.Begin(ctx, "acme", "github", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if _, _, err := svc.Complete(ctx, "good", u.Query().Get("state"), nonce); !errors.Is(err, oidc.ErrUnsafeEndpoint) {
+		t.Errorf("expected ErrUnsafeEndpoint for the token URL, got %v", err)
+	}
+}
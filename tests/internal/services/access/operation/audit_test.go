@@ -7,6 +7,7 @@ package operation_test
 import (
 	"testing"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/access/operation"
 
 	"gorm.io/driver/sqlite"
@@ -18,6 +19,9 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
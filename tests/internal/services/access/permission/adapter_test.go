@@ -0,0 +1,180 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package permission_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/permission"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDBService_SeededPolicies(t *testing.T) {
+	svc, err := permission.NewDBService(setupDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Role, Path, Method string
+		Expected           bool
+	}{
+		{migrations.AdminGroupID, "/admin/v1/users", "GET", true},
+		{migrations.AdminGroupID, "/admin/v1/users/42", "DELETE", true},
+		{migrations.DefaultGroupID, "/admin/v1/users", "GET", false},
+		{"", "/admin/v1/users", "GET", false},
+	}
+	for _, tt := range tests {
+		ok, err := svc.Check(tt.Role, tt.Path, tt.Method)
+		if err != nil || ok != tt.Expected {
+			t.Errorf("%q %s %s = %v, %v", tt.Role, tt.Method, tt.Path, ok, err)
+		}
+	}
+}
+
+func TestDBService_GrantAndRevoke(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := permission.NewDBService(db)
+	member := migrations.DefaultGroupID
+
+	if err := svc.Grant(permission.Policy{Role: member, Path: "/admin/v1/contents/articles/:id", Method: "get"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Grant(permission.Policy{Role: member, Path: "/admin/v1/contents/articles/:id", Method: "GET"}); err != nil {
+		t.Errorf("granting twice = %v", err)
+	}
+	if err := svc.Grant(permission.Policy{Role: member, Path: "admin", Method: "GET"}); !errors.Is(err, permission.ErrInvalidPolicy) {
+		t.Errorf("bad path = %v", err)
+	}
+	if err := svc.Grant(permission.Policy{Role: member, Path: "/admin", Method: "FETCH"}); !errors.Is(err, permission.ErrInvalidPolicy) {
+		t.Errorf("bad method = %v", err)
+	}
+
+	// Policies live in the database
+	fresh, err := permission.NewDBService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := fresh.Check(member, "/admin/v1/contents/articles/7", "GET"); !ok {
+		t.Error("granted policy was not stored")
+	}
+	if ok, _ := fresh.Check(member, "/admin/v1/contents/articles/7", "PUT"); ok {
+		t.Error("policy allows another method")
+	}
+	list, _ := fresh.Policies(member)
+	if len(list) != 1 || list[0].Method != "GET" {
+		t.Errorf("Policies = %+v", list)
+	}
+
+	if err := fresh.Revoke(list[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.Revoke(list[0]); !errors.Is(err, permission.ErrPolicyNotFound) {
+		t.Errorf("revoking twice = %v", err)
+	}
+	again, _ := permission.NewDBService(db)
+	if ok, _ := again.Check(member, "/admin/v1/contents/articles/7", "GET"); ok {
+		t.Error("revoked policy is still stored")
+	}
+}
+
+func TestDBService_ReloadAcrossInstances(t *testing.T) {
+	db := setupDB(t)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	a, _ := permission.NewDBService(db)
+	a.WithWatcher(rdb)
+	b, _ := permission.NewDBService(db)
+	b.WithWatcher(rdb)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go b.Run(ctx)
+	waitFor(t, func() bool {
+		subs, _ := rdb.PubSubNumSub(ctx, permission.Channel).Result()
+		return subs[permission.Channel] > 0
+	})
+
+	policy := permission.Policy{Role: migrations.DefaultGroupID, Path: "/admin/v1/menu", Method: "GET"}
+	if err := a.Grant(policy); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		ok, _ := b.Check(policy.Role, policy.Path, policy.Method)
+		return ok
+	})
+
+	if err := a.Revoke(policy); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		ok, _ := b.Check(policy.Role, policy.Path, policy.Method)
+		return !ok
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	waitForWithin(t, 2*time.Second, cond)
+}
+
+func waitForWithin(t *testing.T, d time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDBService_ReloadAfterRedisOutage(t *testing.T) {
+	db := setupDB(t)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svc, _ := permission.NewDBService(db)
+	svc.WithWatcher(rdb)
+
+	mr.Close()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go svc.Run(ctx)
+
+	// Changed while the instance cannot hear of it
+	other, _ := permission.NewDBService(db)
+	policy := permission.Policy{Role: migrations.DefaultGroupID, Path: "/admin/v1/menu", Method: "GET"}
+	if err := other.Grant(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitForWithin(t, 5*time.Second, func() bool {
+		ok, _ := svc.Check(policy.Role, policy.Path, policy.Method)
+		return ok
+	})
+}
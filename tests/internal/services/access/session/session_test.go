@@ -0,0 +1,198 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/user/entity"
+)
+
+type fixture struct {
+	db       *gorm.DB
+	mr       *miniredis.Miniredis
+	tokens   *token.Service
+	sessions *session.Service
+}
+
+func setup(t *testing.T) *fixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	tokens := token.NewService(setting.AppConfig{JwtSecret: "k", JwtExpire: time.Minute}).WithStore(db, rdb)
+	return &fixture{db: db, mr: mr, tokens: tokens, sessions: session.NewService(db, rdb, tokens)}
+}
+
+func (f *fixture) start(t *testing.T, userID, deviceID string) *token.Pair {
+	pair, err := f.sessions.Start(context.Background(), userID, "100", "",
+		session.Device{DeviceID: deviceID, UserAgent: "test-agent", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	return pair
+}
+
+func (f *fixture) revoked(t *testing.T, pair *token.Pair) bool {
+	claims, err := f.tokens.ParseToken(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errors.Is(f.tokens.Revoked(context.Background(), claims), token.ErrTokenRevoked)
+}
+
+func TestSession_StartAndList(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	phone := f.start(t, "u1", "phone-1")
+	laptop := f.start(t, "u1", "laptop-1")
+	f.start(t, "u2", "phone-2")
+
+	var info entity.UserInfo
+	f.db.First(&info, "user_id = ?", "u1")
+	if info.DeviceID != "laptop-1" {
+		t.Errorf("Expected the latest device in UserInfo, got %q", info.DeviceID)
+	}
+
+	list, err := f.sessions.List(ctx, "u1", phone.SessionID)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	for _, s := range list {
+		if s.Current != (s.ID == phone.SessionID) || s.UserAgent != "test-agent" || s.IP != "10.0.0.1" {
+			t.Errorf("Unexpected session %+v", s)
+		}
+	}
+
+	// A session follows its token family through refreshes
+	if _, err := f.tokens.Refresh(ctx, laptop.RefreshToken, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := f.sessions.List(ctx, "u1", ""); len(list) != 2 {
+		t.Errorf("Refresh should keep the session, got %d", len(list))
+	}
+}
+
+func TestSession_Revoke(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	a := f.start(t, "u1", "a")
+	b := f.start(t, "u1", "b")
+	c := f.start(t, "u1", "c")
+	other := f.start(t, "u2", "x")
+
+	if err := f.sessions.Revoke(ctx, "u1", other.SessionID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Users cannot revoke sessions of others, got %v", err)
+	}
+	if err := f.sessions.Revoke(ctx, "u1", b.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if !f.revoked(t, b) {
+		t.Error("Revoked session should be refused at once")
+	}
+	if err := f.sessions.Revoke(ctx, "u1", b.SessionID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Revoked session should be gone, got %v", err)
+	}
+
+	n, err := f.sessions.RevokeOthers(ctx, "u1", a.SessionID)
+	if err != nil || n != 1 {
+		t.Fatalf("RevokeOthers = %d, %v", n, err)
+	}
+	if f.revoked(t, a) || !f.revoked(t, c) {
+		t.Error("Only the current session should survive")
+	}
+
+	// Admin force logout
+	if err := f.sessions.RevokeAll(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := f.sessions.List(ctx, "u1", ""); len(list) != 0 || !f.revoked(t, a) {
+		t.Errorf("Expected no session left, got %+v", list)
+	}
+	if f.revoked(t, other) {
+		t.Error("Other users are untouched")
+	}
+}
+
+func TestSession_Seen(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	pair := f.start(t, "u1", "a")
+
+	lastSeen := func() session.Session {
+		var s session.Session
+		f.db.First(&s, "id = ?", pair.SessionID)
+		return s
+	}
+	reset := func() {
+		f.db.Model(&session.Session{}).Where("id = ?", pair.SessionID).UpdateColumn("last_seen_at", 0)
+	}
+
+	reset()
+	f.sessions.Seen(ctx, pair.SessionID, "10.0.0.2")
+	if s := lastSeen(); s.LastSeenAt == 0 || s.IP != "10.0.0.2" {
+		t.Fatalf("Expected activity recorded, got %+v", s)
+	}
+
+	// Throttled within the minute
+	reset()
+	f.sessions.Seen(ctx, pair.SessionID, "10.0.0.3")
+	if s := lastSeen(); s.LastSeenAt != 0 {
+		t.Errorf("Expected a throttled update, got %+v", s)
+	}
+	f.mr.FastForward(2 * time.Minute)
+	f.sessions.Seen(ctx, pair.SessionID, "10.0.0.3")
+	if s := lastSeen(); s.LastSeenAt == 0 || s.IP != "10.0.0.3" {
+		t.Errorf("Expected activity recorded after the interval, got %+v", s)
+	}
+}
+
+func TestSession_Purge(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.start(t, "u1", "a")
+	keep := f.start(t, "u1", "b")
+
+	f.db.Model(&token.RefreshToken{}).Where("family <> ?", keep.SessionID).
+		UpdateColumn("expires_at", time.Now().Unix()-1)
+	f.tokens.PurgeExpired(ctx)
+
+	n, err := f.sessions.PurgeExpired(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeExpired = %d, %v", n, err)
+	}
+	var count int64
+	f.db.Model(&session.Session{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the live session kept, got %d", count)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package social_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/social"
+)
+
+// fakeWechat stands in for api.weixin.qq.com, accepting the code "good"
+func fakeWechat(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("appid") != "wx123" || q.Get("secret") != "s3cret" || q.Get("grant_type") != "authorization_code" {
+			t.Errorf("unexpected token query: %s", r.URL.RawQuery)
+		}
+		if q.Get("code") != "good" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 40029, "errmsg": "invalid code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "at", "expires_in": 7200, "openid": "o_1", "scope": "snsapi_login",
+		})
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "at" || r.URL.Query().Get("openid") != "o_1" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 40001, "errmsg": "invalid credential"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"openid": "o_1", "unionid": "u_1", "nickname": "Wei", "headimgurl": "http://img/1",
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func setup(t *testing.T) *social.Service {
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	srv := fakeWechat(t)
+	return social.NewService(rdb).WithProvider(&social.Wechat{
+		AppID:       "wx123",
+		Secret:      "s3cret",
+		RedirectURL: "https://example.com/api/v1/callback/wechat",
+		APIURL:      srv.URL,
+	})
+}
+
+func stateOf(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("state")
+}
+
+func TestWechat_AuthURL(t *testing.T) {
+	svc := setup(t)
+	authURL, nonce, err := svc.Begin(context.Background(), social.ProviderWechat, "")
+	if err != nil || nonce == "" {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, "https://open.weixin.qq.com/connect/qrconnect?appid=wx123&redirect_uri=") ||
+		!strings.HasSuffix(authURL, "#wechat_redirect") {
+		t.Errorf("unexpected auth URL: %s", authURL)
+	}
+	if !strings.Contains(authURL, "scope=snsapi_login") || stateOf(t, authURL) == "" {
+		t.Errorf("missing scope or state: %s", authURL)
+	}
+
+	if _, _, err := svc.Begin(context.Background(), social.ProviderQQ, ""); !errors.Is(err, social.ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestWechat_Complete(t *testing.T) {
+	svc := setup(t)
+	ctx := context.Background()
+
+	authURL, nonce, _ := svc.Begin(ctx, social.ProviderWechat, "user_1")
+	state := stateOf(t, authURL)
+
+	id, st, err := svc.Complete(ctx, social.ProviderWechat, "good", state, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.OpenID != "o_1" || id.UnionID != "u_1" || id.ID() != "u_1" || id.Nickname != "Wei" || id.Avatar != "http://img/1" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+	if st.UserID != "user_1" {
+		t.Errorf("expected the binding user in the state, got %+v", st)
+	}
+
+	// A state works once
+	if _, _, err := svc.Complete(ctx, social.ProviderWechat, "good", state, nonce); !errors.Is(err, social.ErrStateInvalid) {
+		t.Errorf("expected ErrStateInvalid on replay, got %v", err)
+	}
+	if _, _, err := svc.Complete(ctx, social.ProviderWechat, "good", "forged", nonce); !errors.Is(err, social.ErrStateInvalid) {
+		t.Errorf("expected ErrStateInvalid, got %v", err)
+	}
+
+	// A state started in another browser is refused, and burned
+	authURL, _, _ = svc.Begin(ctx, social.ProviderWechat, "")
+	state = stateOf(t, authURL)
+	_, other, _ := svc.Begin(ctx, social.ProviderWechat, "")
+	for _, n := range []string{"", other} {
+		if _, _, err := svc.Complete(ctx, social.ProviderWechat, "good", state, n); !errors.Is(err, social.ErrStateInvalid) {
+			t.Errorf("expected ErrStateInvalid for nonce %q, got %v", n, err)
+		}
+	}
+
+	authURL, nonce, _ = svc.Begin(ctx, social.ProviderWechat, "")
+	if _, _, err := svc.Complete(ctx, social.ProviderWechat, "bad", stateOf(t, authURL), nonce); !errors.Is(err, social.ErrExchange) {
+		t.Errorf("expected ErrExchange for a rejected code, got %v", err)
+	}
+}
@@ -5,9 +5,12 @@
 package token_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+
 	"appsite-go/internal/core/setting"
 	"appsite-go/internal/services/access/token"
 )
@@ -98,7 +101,7 @@ func TestJWT_Malformed(t *testing.T) {
 }
 
 func TestJWT_Defaults(t *testing.T) {
-	// Empty config should default to 72h
+	// Empty config should default to 15m
 	svc := token.NewService(setting.AppConfig{JwtSecret: "k"})
 	
 	// Reflection or just test Generate expiration?
@@ -112,3 +115,16 @@ func TestJWT_Defaults(t *testing.T) {
 		t.Error("Default config failed")
 	}
 }
+
+func TestJWT_SignClaimsAreNotAccessTokens(t *testing.T) {
+	svc := token.NewService(setting.AppConfig{JwtSecret: "k"})
+
+	// ID tokens and the like carry no jti, so they never pass for access tokens
+	raw, err := svc.SignClaims(jwt.MapClaims{"sub": "u1", "user_id": "u1", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("SignClaims failed: %v", err)
+	}
+	if _, err := svc.ParseToken(raw); !errors.Is(err, token.ErrTokenInvalid) {
+		t.Errorf("ParseToken err = %v, want ErrTokenInvalid", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package token_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/services/access/token"
+)
+
+func setupKeys(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&token.SigningKey{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// instance is one server process sharing the key store
+func instance(t *testing.T, db *gorm.DB, alg string) *token.Service {
+	svc := token.NewService(setting.AppConfig{JwtAlgorithm: alg, JwtExpire: time.Minute}).WithStore(db, nil)
+	if err := svc.LoadKeys(context.Background()); err != nil {
+		t.Fatalf("LoadKeys failed: %v", err)
+	}
+	return svc
+}
+
+func kid(t *testing.T, tok string) string {
+	parsed, _, err := jwt.NewParser().ParseUnverified(tok, &token.Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := parsed.Header["kid"].(string)
+	return id
+}
+
+func TestKeys_RS256AndJWKS(t *testing.T) {
+	db := setupKeys(t)
+	svc := instance(t, db, token.AlgRS256)
+
+	tok, err := svc.GenerateToken("u1", "100")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if claims, err := svc.ParseToken(tok); err != nil || claims.UserID != "u1" {
+		t.Fatalf("ParseToken = %+v, %v", claims, err)
+	}
+
+	// Anyone holding the JWKS can verify, without the store
+	set, err := svc.JWKS(context.Background())
+	if err != nil || len(set.Keys) != 1 {
+		t.Fatalf("JWKS = %+v, %v", set, err)
+	}
+	jwk := set.Keys[0]
+	if jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.Kid != kid(t, tok) {
+		t.Fatalf("Unexpected JWK %+v", jwk)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	_, err = jwt.ParseWithClaims(tok, &token.Claims{}, func(*jwt.Token) (interface{}, error) { return pub, nil },
+		jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil {
+		t.Errorf("Token should verify with the published key: %v", err)
+	}
+
+	// The algorithm comes from the key: an HMAC token under a known kid is refused
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &token.Claims{UserID: "admin"})
+	forged.Header["kid"] = jwk.Kid
+	forgedStr, _ := forged.SignedString([]byte(jwk.N))
+	if _, err := svc.ParseToken(forgedStr); err == nil {
+		t.Error("Algorithm confusion must be rejected")
+	}
+}
+
+func TestKeys_Rotation(t *testing.T) {
+	db := setupKeys(t)
+	a := instance(t, db, token.AlgEdDSA)
+	ctx := context.Background()
+
+	old, _ := a.GenerateToken("u1", "100")
+	if err := a.RotateKeys(ctx); err != nil {
+		t.Fatalf("RotateKeys failed: %v", err)
+	}
+
+	// The next key is published but does not sign yet
+	set, _ := a.JWKS(ctx)
+	if len(set.Keys) != 2 || set.Keys[0].Kty != "OKP" || set.Keys[0].Crv != "Ed25519" {
+		t.Fatalf("Expected both keys published, got %+v", set.Keys)
+	}
+	if tok, _ := a.GenerateToken("u1", "100"); kid(t, tok) != kid(t, old) {
+		t.Error("Current key should sign during the overlap window")
+	}
+
+	// Once the overlap has passed, another instance signs with the next key
+	// and this one verifies it from the key set it already holds
+	var next token.SigningKey
+	db.Where("expires_at = 0").First(&next)
+	db.Model(&token.SigningKey{}).Where("id <> ?", next.ID).UpdateColumn("activates_at", time.Now().Unix()-10)
+	db.Model(&next).UpdateColumn("activates_at", time.Now().Unix()-1)
+	b := instance(t, db, token.AlgEdDSA)
+	fresh, _ := b.GenerateToken("u2", "100")
+	if kid(t, fresh) != next.ID {
+		t.Fatalf("Expected the next key to sign, got kid %s", kid(t, fresh))
+	}
+	if _, err := a.ParseToken(fresh); err != nil {
+		t.Errorf("Next key should verify everywhere: %v", err)
+	}
+	if _, err := b.ParseToken(old); err != nil {
+		t.Errorf("Retired key should still verify its tokens: %v", err)
+	}
+
+	// After the second window the retired key is gone
+	db.Model(&token.SigningKey{}).Where("id <> ?", next.ID).UpdateColumn("expires_at", time.Now().Unix()-1)
+	c := instance(t, db, token.AlgEdDSA)
+	if _, err := c.ParseToken(old); !errors.Is(err, token.ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	if set, _ := c.JWKS(ctx); len(set.Keys) != 1 {
+		t.Errorf("Expected the retired key unpublished, got %+v", set.Keys)
+	}
+}
+
+func TestKeys_HS256(t *testing.T) {
+	svc := token.NewService(setting.AppConfig{JwtSecret: "k"})
+	if svc.Asymmetric() {
+		t.Error("HS256 should be the default")
+	}
+	set, err := svc.JWKS(context.Background())
+	if err != nil || len(set.Keys) != 0 {
+		t.Errorf("The secret must not be published, got %+v, %v", set, err)
+	}
+	if err := svc.RotateKeys(context.Background()); !errors.Is(err, token.ErrUnsupportedAlg) {
+		t.Errorf("Expected ErrUnsupportedAlg, got %v", err)
+	}
+
+	bad := token.NewService(setting.AppConfig{JwtAlgorithm: "none"}).WithStore(setupKeys(t), nil)
+	if err := bad.LoadKeys(context.Background()); !errors.Is(err, token.ErrUnsupportedAlg) {
+		t.Errorf("Expected ErrUnsupportedAlg, got %v", err)
+	}
+}
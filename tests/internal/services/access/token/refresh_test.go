@@ -0,0 +1,263 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package token_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/internal/services/access/token"
+)
+
+func setupStore(t *testing.T) (*token.Service, *gorm.DB, *miniredis.Miniredis) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&token.RefreshToken{}); err != nil {
+		t.Fatal(err)
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	svc := token.NewService(setting.AppConfig{JwtSecret: "k", JwtExpire: time.Minute}).WithStore(db, rdb)
+	return svc, db, mr
+}
+
+func access(t *testing.T, svc *token.Service, pair *token.Pair) *token.Claims {
+	claims, err := svc.ParseToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	return claims
+}
+
+func TestRefresh_Rotation(t *testing.T) {
+	svc, _, _ := setupStore(t)
+	ctx := context.Background()
+
+	first, err := svc.IssuePair(ctx, "u1", "100")
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+	claims := access(t, svc, first)
+	if claims.ID == "" || claims.Family == "" || first.ExpiresIn != 60 {
+		t.Errorf("Unexpected access token %+v, expires in %d", claims, first.ExpiresIn)
+	}
+
+	// The check decides the role of the new access token
+	second, err := svc.Refresh(ctx, first.RefreshToken, func(userID string) (string, error) {
+		if userID != "u1" {
+			t.Errorf("check got user %s", userID)
+		}
+		return "200", nil
+	})
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Refresh must rotate the refresh token")
+	}
+	next := access(t, svc, second)
+	if next.Role != "200" || next.Family != claims.Family || next.ID == claims.ID {
+		t.Errorf("Unexpected rotated claims %+v", next)
+	}
+
+	// A refused check leaves the token usable
+	refused := errors.New("refused")
+	if _, err := svc.Refresh(ctx, second.RefreshToken, func(string) (string, error) { return "", refused }); !errors.Is(err, refused) {
+		t.Errorf("Expected the check error, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, second.RefreshToken, nil); err != nil {
+		t.Errorf("Token should survive a refused check: %v", err)
+	}
+
+	if _, err := svc.Refresh(ctx, "bogus", nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("Expected ErrRefreshInvalid, got %v", err)
+	}
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	svc, _, _ := setupStore(t)
+	ctx := context.Background()
+
+	first, _ := svc.IssuePair(ctx, "u1", "100")
+	second, err := svc.Refresh(ctx, first.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := svc.IssuePair(ctx, "u1", "100")
+
+	// Replaying the consumed token kills everything issued in its family
+	if _, err := svc.Refresh(ctx, first.RefreshToken, nil); !errors.Is(err, token.ErrRefreshReused) {
+		t.Fatalf("Expected ErrRefreshReused, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, second.RefreshToken, nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("Successor should be revoked, got %v", err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, second)); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Access tokens of the family should be revoked, got %v", err)
+	}
+
+	// Other families are untouched
+	if err := svc.Revoked(ctx, access(t, svc, other)); err != nil {
+		t.Errorf("Other session should stay valid, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, other.RefreshToken, nil); err != nil {
+		t.Errorf("Other session should refresh, got %v", err)
+	}
+}
+
+func TestRefresh_Expired(t *testing.T) {
+	svc, db, _ := setupStore(t)
+	ctx := context.Background()
+
+	pair, _ := svc.IssuePair(ctx, "u1", "100")
+	db.Model(&token.RefreshToken{}).Where("1 = 1").UpdateColumn("expires_at", time.Now().Unix()-1)
+	if _, err := svc.Refresh(ctx, pair.RefreshToken, nil); !errors.Is(err, token.ErrRefreshExpired) {
+		t.Errorf("Expected ErrRefreshExpired, got %v", err)
+	}
+
+	n, err := svc.PurgeExpired(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("PurgeExpired = %d, %v", n, err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	svc, _, mr := setupStore(t)
+	ctx := context.Background()
+
+	a, _ := svc.IssuePair(ctx, "u1", "100")
+	b, _ := svc.IssuePair(ctx, "u1", "100")
+	c, _ := svc.IssuePair(ctx, "u2", "100")
+
+	// Logout ends one session
+	if err := svc.Logout(ctx, access(t, svc, a)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, a)); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Logged out token should be revoked, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, a.RefreshToken, nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("Logged out refresh token should be invalid, got %v", err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, b)); err != nil {
+		t.Errorf("Other session should stay valid, got %v", err)
+	}
+
+	// RevokeUser ends all of them
+	if err := svc.RevokeUser(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, b)); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Expected every session of u1 revoked, got %v", err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, c)); err != nil {
+		t.Errorf("Other users are untouched, got %v", err)
+	}
+
+	// Entries live as long as the access tokens they cover
+	mr.FastForward(2 * time.Minute)
+	if len(mr.Keys()) != 0 {
+		t.Errorf("Expected the revocation list to expire, got %v", mr.Keys())
+	}
+}
+
+func TestRevoke_NoStore(t *testing.T) {
+	svc := token.NewService(setting.AppConfig{JwtSecret: "k"})
+	if _, err := svc.IssuePair(context.Background(), "u1", "100"); !errors.Is(err, token.ErrNoStore) {
+		t.Errorf("Expected ErrNoStore, got %v", err)
+	}
+	tok, _ := svc.GenerateToken("u1", "100")
+	claims, _ := svc.ParseToken(tok)
+	if err := svc.Revoked(context.Background(), claims); err != nil {
+		t.Errorf("Nothing is revoked without a store, got %v", err)
+	}
+}
+
+func TestRefresh_GrantFamilies(t *testing.T) {
+	svc, _, _ := setupStore(t)
+	ctx := context.Background()
+
+	pair, err := svc.IssueGrant(ctx, "u1", "100", token.Grant{ClientID: "app", Scope: "openid profile"})
+	if err != nil {
+		t.Fatalf("IssueGrant failed: %v", err)
+	}
+	claims := access(t, svc, pair)
+	if claims.ClientID != "app" || claims.Scope != "openid profile" || claims.Subject != "u1" {
+		t.Errorf("grant claims = %+v", claims)
+	}
+
+	// Neither first-party refresh nor another client may use it, and trying does not burn it
+	if _, err := svc.Refresh(ctx, pair.RefreshToken, nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("first-party refresh err = %v, want ErrRefreshInvalid", err)
+	}
+	if _, err := svc.RefreshGrant(ctx, pair.RefreshToken, "other", nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("other client refresh err = %v, want ErrRefreshInvalid", err)
+	}
+	next, err := svc.RefreshGrant(ctx, pair.RefreshToken, "app", nil)
+	if err != nil {
+		t.Fatalf("RefreshGrant failed: %v", err)
+	}
+	if c := access(t, svc, next); c.ClientID != "app" || c.Scope != "openid profile" || next.Scope != "openid profile" {
+		t.Errorf("refreshed claims = %+v, scope %q", c, next.Scope)
+	}
+
+	// Revoking the client shuts out its family
+	if err := svc.RevokeClient(ctx, "app", "u1"); err != nil {
+		t.Fatalf("RevokeClient failed: %v", err)
+	}
+	if err := svc.Revoked(ctx, access(t, svc, next)); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Revoked = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := svc.RefreshGrant(ctx, next.RefreshToken, "app", nil); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("refresh after revoke err = %v, want ErrRefreshInvalid", err)
+	}
+
+	// Client tokens have no user and no family
+	ct, err := svc.IssueClientToken("app", "reports")
+	if err != nil {
+		t.Fatalf("IssueClientToken failed: %v", err)
+	}
+	if c := access(t, svc, ct); c.UserID != "" || c.Family != "" || c.Subject != "app" || ct.RefreshToken != "" {
+		t.Errorf("client token claims = %+v", c)
+	}
+}
+
+func TestRefresh_KeepsTenant(t *testing.T) {
+	svc, _, _ := setupStore(t)
+	ctx := context.Background()
+
+	pair, err := svc.IssueGrant(ctx, "u1", "100", token.Grant{SaasID: "A"})
+	if err != nil {
+		t.Fatalf("IssueGrant failed: %v", err)
+	}
+	if c := access(t, svc, pair); c.SaasID != "A" {
+		t.Errorf("SaasID = %q, want A", c.SaasID)
+	}
+	next, err := svc.Refresh(ctx, pair.RefreshToken, nil)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if c := access(t, svc, next); c.SaasID != "A" {
+		t.Errorf("refreshed SaasID = %q, want A", c.SaasID)
+	}
+}
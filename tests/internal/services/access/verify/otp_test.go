@@ -6,10 +6,14 @@ package verify_test
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"appsite-go/internal/services/access/verify"
+	"appsite-go/pkg/extra/mail"
+	"appsite-go/pkg/extra/sms"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -86,3 +90,131 @@ func TestOTP_InvalidArgs(t *testing.T) {
 		t.Error("Should fail on lengths <= 0")
 	}
 }
+
+func TestOTP_Lockout(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	svc := verify.NewOTPService(rdb).WithOptions(verify.OTPOptions{MaxAttempts: 3, Lockout: time.Minute})
+	ctx := context.Background()
+	target := "13800000000"
+
+	code, _ := svc.Issue(ctx, verify.PurposeLogin, target)
+	for i := 0; i < 2; i++ {
+		if err := svc.Verify(ctx, verify.PurposeLogin, target, "x"); !errors.Is(err, verify.ErrOTPInvalid) {
+			t.Fatalf("Expected ErrOTPInvalid, got %v", err)
+		}
+	}
+	if err := svc.Verify(ctx, verify.PurposeLogin, target, "x"); !errors.Is(err, verify.ErrOTPLocked) {
+		t.Fatalf("Expected ErrOTPLocked on the last attempt, got %v", err)
+	}
+	// The right code is burned, and a new one cannot be checked until the window ends
+	if err := svc.Verify(ctx, verify.PurposeLogin, target, code); !errors.Is(err, verify.ErrOTPLocked) {
+		t.Errorf("Expected ErrOTPLocked, got %v", err)
+	}
+	code, _ = svc.Issue(ctx, verify.PurposeLogin, target)
+	if err := svc.Verify(ctx, verify.PurposeLogin, target, code); !errors.Is(err, verify.ErrOTPLocked) {
+		t.Errorf("Expected ErrOTPLocked, got %v", err)
+	}
+
+	// Other purposes are counted apart
+	reset, _ := svc.Issue(ctx, verify.PurposeReset, target)
+	if err := svc.Verify(ctx, verify.PurposeReset, target, reset); err != nil {
+		t.Errorf("Reset code should verify, got %v", err)
+	}
+
+	s.FastForward(2 * time.Minute)
+	code, _ = svc.Issue(ctx, verify.PurposeLogin, target)
+	if err := svc.Verify(ctx, verify.PurposeLogin, target, code); err != nil {
+		t.Errorf("Lock should end with the window, got %v", err)
+	}
+	if err := svc.Verify(ctx, "unknown", target, code); !errors.Is(err, verify.ErrOTPPurpose) {
+		t.Errorf("Expected ErrOTPPurpose, got %v", err)
+	}
+}
+
+func TestOTP_Send(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	smsSender, mailSender := &sms.MockSender{}, &mail.MockSender{}
+	svc := verify.NewOTPService(rdb).WithSenders(smsSender, mailSender).
+		WithOptions(verify.OTPOptions{TargetCooldown: time.Minute, IPCooldown: 10 * time.Second})
+	ctx := context.Background()
+
+	if err := svc.Send(ctx, verify.PurposeLogin, "13800000000", "10.0.0.1"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if smsSender.LastPhone != "13800000000" || !strings.Contains(smsSender.LastMessage, "verification code") {
+		t.Fatalf("Unexpected SMS %+v", smsSender)
+	}
+	code := strings.Fields(strings.TrimPrefix(smsSender.LastMessage, "Your verification code is "))[0]
+	code = strings.TrimSuffix(code, ",")
+
+	// Per-IP cooldown
+	if err := svc.Send(ctx, verify.PurposeLogin, "user@example.com", "10.0.0.1"); !errors.Is(err, verify.ErrOTPCooldown) {
+		t.Errorf("Expected the IP cooling down, got %v", err)
+	}
+	s.FastForward(15 * time.Second)
+	if err := svc.Send(ctx, verify.PurposeBind, "user@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("Send email failed: %v", err)
+	}
+	if mailSender.LastTo != "user@example.com" || mailSender.LastBody == "" {
+		t.Errorf("Unexpected mail %+v", mailSender)
+	}
+
+	// Per-target cooldown, whatever the IP
+	if err := svc.Send(ctx, verify.PurposeLogin, "13800000000", "10.0.0.2"); !errors.Is(err, verify.ErrOTPCooldown) {
+		t.Errorf("Expected the target cooling down, got %v", err)
+	}
+	if err := svc.Verify(ctx, verify.PurposeLogin, "13800000000", code); err != nil {
+		t.Errorf("Sent code should verify, got %v", err)
+	}
+
+	if err := svc.Send(ctx, "unknown", "13900000000", ""); !errors.Is(err, verify.ErrOTPPurpose) {
+		t.Errorf("Expected ErrOTPPurpose, got %v", err)
+	}
+	noMail := verify.NewOTPService(rdb).WithSenders(smsSender, nil)
+	if err := noMail.Send(ctx, verify.PurposeLogin, "other@example.com", ""); !errors.Is(err, verify.ErrNoSender) {
+		t.Errorf("Expected ErrNoSender, got %v", err)
+	}
+}
+
+func TestNormalizeTarget(t *testing.T) {
+	cases := map[string]string{
+		" User@Example.COM ":   "user@example.com",
+		"138 0000-0000":        "13800000000",
+		"+86 138 0000 0000":    "+8613800000000",
+		"0086 (138) 0000.0000": "+8613800000000",
+	}
+	for in, want := range cases {
+		got, err := verify.NormalizeTarget(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeTarget(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "@example.com", "a b@example.com", "+0123456789", "+12", "138x0000"} {
+		if _, err := verify.NormalizeTarget(in); !errors.Is(err, verify.ErrOTPTarget) {
+			t.Errorf("NormalizeTarget(%q) should fail, got %v", in, err)
+		}
+	}
+
+	// Codes follow the normalized target, however it is typed
+	svc := verify.NewOTPService(setupRedis(t))
+	ctx := context.Background()
+	code, err := svc.Issue(ctx, verify.PurposeLogin, "User@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Verify(ctx, verify.PurposeLogin, " user@example.com", code); err != nil {
+		t.Errorf("Code should verify for the same address, got %v", err)
+	}
+}
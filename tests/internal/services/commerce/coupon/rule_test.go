@@ -1,14 +1,23 @@
 package coupon_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/commerce/coupon"
 	"appsite-go/internal/services/commerce/entity"
+	"appsite-go/internal/services/commerce/order"
+	"appsite-go/pkg/utils/orm"
 )
 
 func setupDB(t *testing.T) *gorm.DB {
@@ -20,16 +29,15 @@ func setupDB(t *testing.T) *gorm.DB {
 	sqlDB, _ := db.DB()
 	sqlDB.SetMaxOpenConns(1)
 
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
 func TestCouponService(t *testing.T) {
 	db := setupDB(t)
 	svc := coupon.NewService(db)
-	if err := svc.Migrate(); err != nil {
-		t.Fatal(err)
-	}
-
 	// 1. Create Coupon
 	now := time.Now().Unix()
 	c1 := &entity.Coupon{
@@ -164,3 +172,211 @@ func TestCouponService(t *testing.T) {
 		t.Errorf("Expected ErrCouponExpired, got %v", err)
 	}
 }
+
+func TestCoupon_ExpireUserCoupons(t *testing.T) {
+	db := setupDB(t)
+	svc := coupon.NewService(db)
+	now := time.Now().Unix()
+
+	ending := &entity.Coupon{Title: "Ending", TotalCount: 10, Status: "enabled", StartTime: now - 60, EndTime: now + 60}
+	lasting := &entity.Coupon{Title: "Lasting", TotalCount: 10, Status: "enabled", StartTime: now - 60, EndTime: now + 3600}
+	svc.CreateCoupon(ending)
+	svc.CreateCoupon(lasting)
+	ucEnding, _ := svc.Issue(func() string { return "user-exp" }, ending.ID)
+	ucLasting, _ := svc.Issue(func() string { return "user-exp" }, lasting.ID)
+
+	// The rule ends after the coupon was taken
+	db.Model(&entity.Coupon{}).Where("id = ?", ending.ID).Update("end_time", now-1)
+
+	n, err := svc.ExpireUserCoupons(context.Background())
+	if err != nil || n < 1 {
+		t.Fatalf("ExpireUserCoupons = %d, %v", n, err)
+	}
+	if got, _ := svc.GetUserCoupon(ucEnding.ID); got.Status != "expired" {
+		t.Errorf("Expected expired, got %s", got.Status)
+	}
+	if got, _ := svc.GetUserCoupon(ucLasting.ID); got.Status != "unused" {
+		t.Errorf("Live coupon must stay unused, got %s", got.Status)
+	}
+}
+
+func TestCoupon_IssueWithLocker(t *testing.T) {
+	db := setupDB(t)
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	svc := coupon.NewService(db).WithLocker(rdb)
+
+	now := time.Now().Unix()
+	c := &entity.Coupon{Title: "Locked", TotalCount: 3, Status: "enabled", StartTime: now - 60, EndTime: now + 60}
+	svc.CreateCoupon(c)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	issued := 0
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := svc.Issue(func() string { return fmt.Sprintf("locked-user-%d", i) }, c.ID); err == nil {
+				mu.Lock()
+				issued++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if issued != 3 {
+		t.Errorf("Expected 3 coupons issued, got %d", issued)
+	}
+	got, _ := svc.GetCoupon(c.ID)
+	if got.TakenCount != 3 {
+		t.Errorf("Expected taken count 3, got %d", got.TakenCount)
+	}
+
+	// A holder whose token is older than the last write is refused
+	open := &entity.Coupon{Title: "Fenced", TotalCount: -1, Status: "enabled", StartTime: now - 60, EndTime: now + 60}
+	svc.CreateCoupon(open)
+	db.Model(&entity.Coupon{}).Where("id = ?", open.ID).Update("lock_fence", 1000)
+	if _, err := svc.Issue(func() string { return "locked-user-late" }, open.ID); !errors.Is(err, orm.ErrFenced) {
+		t.Errorf("Expected ErrFenced, got %v", err)
+	}
+}
+
+func TestCoupon_RedeemedWithOrder(t *testing.T) {
+	db := setupDB(t)
+	svc := coupon.NewService(db)
+	orders := order.NewService(db)
+
+	now := time.Now().Unix()
+	c := &entity.Coupon{Title: "Order 10", Type: "cash", Value: 1000, StartTime: now - 60, EndTime: now + 3600, Status: "enabled"}
+	if err := svc.CreateCoupon(c); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := svc.Issue(func() string { return "buyer-1" }, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone else's coupon pays for nothing
+	stolen := &entity.Order{UserID: "buyer-2", TotalAmount: 5000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, stolen, nil); !errors.Is(err, coupon.ErrCouponUsed) {
+		t.Fatalf("Expected ErrCouponUsed for another user, got %v", err)
+	}
+
+	first := &entity.Order{UserID: "buyer-1", TotalAmount: 5000, Discount: 1000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, first, nil); err != nil {
+		t.Fatal(err)
+	}
+	used, _ := svc.GetUserCoupon(uc.ID)
+	if used.Status != "used" || used.OrderID != first.ID {
+		t.Fatalf("Expected coupon used by %s, got %+v", first.ID, used)
+	}
+
+	// A second order with the same coupon is rolled back
+	second := &entity.Order{UserID: "buyer-1", TotalAmount: 5000, Discount: 1000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, second, nil); !errors.Is(err, coupon.ErrCouponUsed) {
+		t.Fatalf("Expected ErrCouponUsed, got %v", err)
+	}
+	var count int64
+	db.Model(&entity.Order{}).Where("user_coupon_id = ?", uc.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected only the first order, got %d", count)
+	}
+}
+
+func TestCoupon_RedeemOutsideWindow(t *testing.T) {
+	db := setupDB(t)
+	svc := coupon.NewService(db)
+	orders := order.NewService(db)
+	now := time.Now().Unix()
+
+	c := &entity.Coupon{Title: "Window", Type: "cash", Value: 1000, StartTime: now - 60, EndTime: now + 3600, Status: "enabled"}
+	if err := svc.CreateCoupon(c); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := svc.Issue(func() string { return "buyer-w" }, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rule ended, ExpireUserCoupons has not run yet
+	db.Model(&entity.Coupon{}).Where("id = ?", c.ID).Update("end_time", now-1)
+	late := &entity.Order{UserID: "buyer-w", TotalAmount: 5000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, late, nil); !errors.Is(err, coupon.ErrCouponExpired) {
+		t.Errorf("Expected ErrCouponExpired after the end, got %v", err)
+	}
+
+	// Nor before the rule starts
+	db.Model(&entity.Coupon{}).Where("id = ?", c.ID).Updates(map[string]interface{}{"start_time": now + 60, "end_time": now + 3600})
+	early := &entity.Order{UserID: "buyer-w", TotalAmount: 5000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, early, nil); !errors.Is(err, coupon.ErrCouponExpired) {
+		t.Errorf("Expected ErrCouponExpired before the start, got %v", err)
+	}
+
+	if got, _ := svc.GetUserCoupon(uc.ID); got.Status != "unused" {
+		t.Errorf("Refused coupon must stay unused, got %s", got.Status)
+	}
+}
+
+func TestCoupon_ReleasedWithClosedOrder(t *testing.T) {
+	db := setupDB(t)
+	svc := coupon.NewService(db)
+	orders := order.NewService(db)
+	now := time.Now().Unix()
+
+	c := &entity.Coupon{Title: "Release", Type: "cash", Value: 1000, StartTime: now - 60, EndTime: now + 3600, Status: "enabled"}
+	if err := svc.CreateCoupon(c); err != nil {
+		t.Fatal(err)
+	}
+	place := func(user string) (*entity.UserCoupon, *entity.Order) {
+		uc, err := svc.Issue(func() string { return user }, c.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := &entity.Order{UserID: user, TotalAmount: 5000, Discount: 1000, PayAmount: 4000, UserCouponID: uc.ID}
+		if err := orders.Create(nil, o, nil); err != nil {
+			t.Fatal(err)
+		}
+		return uc, o
+	}
+	released := func(uc *entity.UserCoupon) {
+		t.Helper()
+		got, _ := svc.GetUserCoupon(uc.ID)
+		if got.Status != "unused" || got.OrderID != "" || got.UsedAt != 0 {
+			t.Errorf("Expected the coupon back, got %+v", got)
+		}
+	}
+
+	// Cancelled by the buyer
+	uc, o := place("buyer-c")
+	if err := orders.Cancel(o.ID); err != nil {
+		t.Fatal(err)
+	}
+	released(uc)
+	again := &entity.Order{UserID: "buyer-c", TotalAmount: 5000, PayAmount: 4000, UserCouponID: uc.ID}
+	if err := orders.Create(nil, again, nil); err != nil {
+		t.Errorf("Released coupon should pay for another order: %v", err)
+	}
+
+	// Closed unpaid
+	uc, o = place("buyer-u")
+	db.Model(&entity.Order{}).Where("id = ?", o.ID).UpdateColumn("created_at", now-3600)
+	if n, err := orders.CloseUnpaid(context.Background(), time.Now().Add(-30*time.Minute)); err != nil || n != 1 {
+		t.Fatalf("CloseUnpaid = %d, %v", n, err)
+	}
+	released(uc)
+
+	// Paid orders keep theirs
+	uc, o = place("buyer-p")
+	if err := orders.Pay(o.ID, "txn-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Cancel(o.ID); !errors.Is(err, order.ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+	if got, _ := svc.GetUserCoupon(uc.ID); got.Status != "used" || got.OrderID != o.ID {
+		t.Errorf("Paid order lost its coupon: %+v", got)
+	}
+}
@@ -1,11 +1,15 @@
 package order_test
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/core/event"
 	"appsite-go/internal/services/commerce/entity"
 	"appsite-go/internal/services/commerce/order"
 )
@@ -15,7 +19,7 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
-	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{})
+	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{}, &event.Outbox{})
 	return db
 }
 
@@ -58,6 +62,16 @@ func TestOrderFSM(t *testing.T) {
 		t.Errorf("Expected paid, got %s", check.Status)
 	}
 
+	// Creation and payment were announced in their transactions
+	var events []event.Outbox
+	db.Where("payload LIKE ?", "%"+o.ID+"%").Order("name").Find(&events)
+	if len(events) != 2 || events[0].Name != "order.created" || events[1].Name != "order.paid" {
+		t.Fatalf("Expected order.created and order.paid, got %+v", events)
+	}
+	if !strings.Contains(string(events[1].Payload), `"pay_method":"simulated"`) || check.PayAmount != 1000 {
+		t.Errorf("Paid event or amount wrong: %s, pay_amount=%d", events[1].Payload, check.PayAmount)
+	}
+
 	// 3. Double Pay (Should fail or be handled, here strict transition fails)
 	if err := svc.Pay(o.ID, "txn-456"); err == nil {
 		t.Error("Expected error on double pay, got nil")
@@ -83,3 +97,34 @@ func TestOrderFSM(t *testing.T) {
 		t.Errorf("Expected done, got %s", check.Status)
 	}
 }
+
+func TestOrder_CloseUnpaid(t *testing.T) {
+	db := setupDB(t)
+	svc := order.NewService(db)
+
+	stale := &entity.Order{UserID: "u2", TotalAmount: 100, PayAmount: 100}
+	paid := &entity.Order{UserID: "u2", TotalAmount: 100, PayAmount: 100}
+	fresh := &entity.Order{UserID: "u2", TotalAmount: 100, PayAmount: 100}
+	for _, o := range []*entity.Order{stale, paid, fresh} {
+		if err := svc.Create(nil, o, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc.Pay(paid.ID, "txn-789")
+	old := time.Now().Add(-time.Hour).Unix()
+	db.Model(&entity.Order{}).Where("id IN ?", []string{stale.ID, paid.ID}).UpdateColumn("created_at", old)
+
+	n, err := svc.CloseUnpaid(context.Background(), time.Now().Add(-30*time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("CloseUnpaid = %d, %v", n, err)
+	}
+
+	want := map[string]string{stale.ID: order.StatusClosed, paid.ID: order.StatusPaid, fresh.ID: order.StatusPending}
+	for id, status := range want {
+		var check entity.Order
+		db.First(&check, "id = ?", id)
+		if check.Status != status {
+			t.Errorf("Order %s: expected %s, got %s", id, status, check.Status)
+		}
+	}
+}
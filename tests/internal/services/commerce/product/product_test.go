@@ -6,6 +6,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/commerce/entity"
 	"appsite-go/internal/services/commerce/product"
 )
@@ -15,6 +16,9 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
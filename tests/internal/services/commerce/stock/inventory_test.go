@@ -1,14 +1,18 @@
 package stock_test
 
 import (
+	"errors"
 	"sync"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"appsite-go/internal/services/commerce/entity"
 	"appsite-go/internal/services/commerce/stock"
+	"appsite-go/pkg/utils/orm"
 )
 
 func setupDB(t *testing.T) *gorm.DB {
@@ -130,3 +134,70 @@ func TestInventory_Concurrency(t *testing.T) {
 		t.Errorf("Expected remaining stock 4, got %d", current)
 	}
 }
+
+func TestInventory_WithLocker(t *testing.T) {
+	db := setupDB(t)
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	svc := stock.NewInventoryService(db).WithLocker(rdb)
+
+	sku := &entity.SKU{Stock: 30}
+	db.Create(sku)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.Deduct(sku.ID, 5, "locked_order")
+		}()
+	}
+	wg.Wait()
+
+	if current, _ := svc.GetStock(sku.ID); current != 0 {
+		t.Errorf("Expected stock 0, got %d", current)
+	}
+	if err := svc.Restore(sku.ID, 5, "locked_order"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("{lock:sku:" + sku.ID + "}") {
+		t.Error("SKU lock should be released")
+	}
+}
+
+func TestInventory_FencesStaleHolders(t *testing.T) {
+	db := setupDB(t)
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	svc := stock.NewInventoryService(db).WithLocker(rdb)
+
+	sku := &entity.SKU{Stock: 10}
+	db.Create(sku)
+	if err := svc.Deduct(sku.ID, 1, "o1"); err != nil {
+		t.Fatal(err)
+	}
+	var fenced entity.SKU
+	db.First(&fenced, "id = ?", sku.ID)
+	if fenced.LockFence == 0 {
+		t.Fatal("A locked change should record its fencing token")
+	}
+
+	// A later holder wrote the row: an older token is refused
+	db.Model(&entity.SKU{}).Where("id = ?", sku.ID).Update("lock_fence", fenced.LockFence+10)
+	if err := svc.Deduct(sku.ID, 1, "o2"); !errors.Is(err, orm.ErrFenced) {
+		t.Errorf("Expected ErrFenced, got %v", err)
+	}
+	if err := svc.Restore(sku.ID, 1, "o1"); !errors.Is(err, orm.ErrFenced) {
+		t.Errorf("Expected ErrFenced on restore, got %v", err)
+	}
+	if current, _ := svc.GetStock(sku.ID); current != 9 {
+		t.Errorf("Expected stock 9, got %d", current)
+	}
+
+	// Without a locker no token is checked
+	if err := stock.NewInventoryService(db).Deduct(sku.ID, 1, "o3"); err != nil {
+		t.Errorf("Unlocked deduct failed: %v", err)
+	}
+}
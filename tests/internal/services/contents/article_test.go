@@ -6,6 +6,8 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/contents"
 	"appsite-go/internal/services/contents/entity"
 )
@@ -15,6 +17,9 @@ func setupArticleDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
@@ -55,7 +60,7 @@ func TestArticle_CRUD(t *testing.T) {
 	updates := map[string]interface{}{
 		"title": "Hello Golang",
 	}
-	if err := svc.Update(article.ID, updates); err != nil {
+	if _, err := svc.Update(article.ID, updates); err != nil {
 		t.Fatalf("Failed to update article: %v", err)
 	}
 
@@ -100,3 +105,42 @@ func TestArticle_CRUD(t *testing.T) {
 		t.Error("Expected error getting deleted article, got nil")
 	}
 }
+
+func TestArticle_Trash(t *testing.T) {
+	db := setupArticleDB(t)
+	svc := contents.NewArticleService(db)
+
+	article := &entity.Article{Title: "Trash me"}
+	if err := svc.Create(article); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(article.ID); err == nil {
+		t.Error("Deleted article should not be found")
+	}
+
+	trashed, err := svc.Trashed(&model.ListParams{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed.List) != 1 || trashed.List[0].ID != article.ID {
+		t.Fatalf("Expected the article in trash, got %+v", trashed.List)
+	}
+
+	if err := svc.Restore(article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(article.ID); err != nil {
+		t.Error("Restored article should be found")
+	}
+
+	svc.Delete(article.ID)
+	if err := svc.Purge(article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Restore(article.ID); err == nil {
+		t.Error("Purged article cannot be restored")
+	}
+}
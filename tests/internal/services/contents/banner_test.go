@@ -6,6 +6,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/contents"
 	"appsite-go/internal/services/contents/entity"
 )
@@ -15,6 +16,9 @@ func setupBannerDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
@@ -50,7 +54,7 @@ func TestBanner_CRUD(t *testing.T) {
 	updates := map[string]interface{}{
 		"title": "Home Banner Updated",
 	}
-	if err := svc.Update(banner.ID, updates); err != nil {
+	if _, err := svc.Update(banner.ID, updates); err != nil {
 		t.Fatalf("Failed to update banner: %v", err)
 	}
 
@@ -10,6 +10,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/contents"
 	"appsite-go/internal/services/contents/entity"
 )
@@ -19,6 +20,9 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
@@ -7,6 +7,8 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/finance"
 	"appsite-go/internal/services/finance/entity"
 )
@@ -20,16 +22,15 @@ func setupDB(t *testing.T) *gorm.DB {
 	sqlDB, _ := db.DB()
 	sqlDB.SetMaxOpenConns(1)
 
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
 func TestLedger(t *testing.T) {
 	db := setupDB(t)
 	svc := finance.NewLedgerService(db)
-	if err := svc.Migrate(); err != nil {
-		t.Fatal(err)
-	}
-
 	uid := "u-100"
 	asset := "point"
 
@@ -85,4 +86,20 @@ func TestLedger(t *testing.T) {
 	if deals[0].Amount != -30 { // Desc order
 		t.Errorf("Expected latest डील -30, got %d", deals[0].Amount)
 	}
+
+	// 6. Cursor History
+	page, err := svc.QueryDeals(uid, asset, &model.ListParams{PageSize: 1, CursorMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.List) != 1 || page.List[0].Amount != -30 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first cursor page: %+v", page)
+	}
+	page, err = svc.QueryDeals(uid, asset, &model.ListParams{PageSize: 1, CursorMode: true, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.List) != 1 || page.List[0].Amount != 100 || page.NextCursor != "" {
+		t.Errorf("Unexpected last cursor page: %+v", page)
+	}
 }
@@ -0,0 +1,46 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package finance_test
+
+import (
+	"context"
+	"testing"
+
+	"appsite-go/internal/core/event"
+	commerce "appsite-go/internal/services/commerce/entity"
+	"appsite-go/internal/services/commerce/order"
+	"appsite-go/internal/services/finance"
+)
+
+func TestLedger_OrderReward(t *testing.T) {
+	db := setupDB(t)
+	svc := finance.NewLedgerService(db)
+	bus := event.NewBus()
+	svc.Subscribe(bus)
+	dispatcher := event.NewDispatcher(db, bus, event.Options{})
+
+	orders := order.NewService(db)
+	o := &commerce.Order{UserID: "u-reward", TotalAmount: 12345, PayAmount: 12345}
+	if err := orders.Create(nil, o, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.Pay(o.ID, "txn-r1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivered twice: points are credited once
+	for i := 0; i < 2; i++ {
+		db.Model(&event.Outbox{}).Where("name = ?", "order.paid").
+			Updates(map[string]interface{}{"status": event.StatusPending, "delivered": nil, "next_attempt_at": 0})
+		if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bal, _ := svc.GetBalance("u-reward", "point")
+	if bal != 12345/finance.OrderRewardRate {
+		t.Errorf("Expected %d points, got %d", 12345/finance.OrderRewardRate, bal)
+	}
+}
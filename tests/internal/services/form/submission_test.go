@@ -1,11 +1,14 @@
 package form_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/form"
 	"appsite-go/internal/services/form/entity"
 	"appsite-go/pkg/dbs"
@@ -16,6 +19,9 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
@@ -78,3 +84,33 @@ func TestSubmission(t *testing.T) {
 		t.Errorf("Expected list length 1, got %d", len(list))
 	}
 }
+
+func TestSubmission_PurgeExpired(t *testing.T) {
+	db := setupDB(t)
+	svc := form.NewSubmissionService(db)
+	past := time.Now().Add(-time.Hour).Unix()
+
+	expired := &entity.Request{UserID: "u1", Expire: past}
+	open := &entity.Request{UserID: "u1", Expire: time.Now().Add(time.Hour).Unix()}
+	reviewed := &entity.Request{UserID: "u1", Expire: past}
+	for _, req := range []*entity.Request{expired, open, reviewed} {
+		if err := svc.Submit(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc.Review(reviewed.ID, false)
+
+	n, err := svc.PurgeExpired(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeExpired = %d, %v", n, err)
+	}
+	if _, err := svc.Get(expired.ID); err == nil {
+		t.Error("Expired request should be deleted")
+	}
+	if _, err := svc.Get(open.ID); err != nil {
+		t.Error("Open request must be kept")
+	}
+	if _, err := svc.Get(reviewed.ID); err != nil {
+		t.Error("Reviewed request must be kept")
+	}
+}
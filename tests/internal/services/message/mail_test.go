@@ -0,0 +1,37 @@
+package message_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/core/queue"
+	"appsite-go/internal/services/message"
+	"appsite-go/pkg/extra/mail"
+)
+
+func TestQueuedMailer(t *testing.T) {
+	rdb := goredis.NewClient(&goredis.Options{Addr: miniredis.RunT(t).Addr()})
+	defer rdb.Close()
+	jobs := queue.New(rdb)
+
+	smtp := &mail.MockSender{}
+	mailer := message.NewQueuedMailer(jobs, "mail", smtp)
+	if err := mailer.Send("u1@example.com", "Hello", "Body"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing goes out until a worker runs the job
+	if smtp.LastTo != "" {
+		t.Fatal("Mail should wait for a worker")
+	}
+	ok, err := queue.NewWorker(jobs, "mail", queue.Options{}).ProcessOne(context.Background())
+	if !ok || err != nil {
+		t.Fatalf("ProcessOne = %v, %v", ok, err)
+	}
+	if smtp.LastTo != "u1@example.com" || smtp.LastSubject != "Hello" || smtp.LastBody != "Body" {
+		t.Errorf("Unexpected mail %+v", smtp)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package message_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/services/form"
+	fentity "appsite-go/internal/services/form/entity"
+	"appsite-go/internal/services/message"
+	"appsite-go/internal/services/message/entity"
+	user "appsite-go/internal/services/user/entity"
+	"appsite-go/pkg/extra/mail"
+)
+
+func TestSubscriber_PasswordChanged(t *testing.T) {
+	db := setupDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	mailer := &mail.MockSender{}
+	bus := event.NewBus()
+	message.NewNotificationService(db).WithMailer(mailer).Subscribe(bus)
+	dispatcher := event.NewDispatcher(db, bus, event.Options{})
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return event.Publish(tx, user.PasswordChanged{
+			UserID: "u1", Email: "u1@example.com", Method: user.PasswordReset, ChangedAt: time.Now().Unix(),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivered twice: the owner is notified once
+	for i := 0; i < 2; i++ {
+		db.Model(&event.Outbox{}).Where("name = ?", "user.password_changed").
+			Updates(map[string]interface{}{"status": event.StatusPending, "delivered": nil, "next_attempt_at": 0})
+		if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var list []entity.Notification
+	db.Where("receiver_id = ?", "u1").Find(&list)
+	if len(list) != 1 || list[0].SenderID != message.SystemSender || !strings.Contains(list[0].Content, "reset") {
+		t.Errorf("Expected one security notice, got %+v", list)
+	}
+	if mailer.LastTo != "u1@example.com" || !strings.Contains(mailer.LastBody, "reset") {
+		t.Errorf("Expected a mail to the owner, got %+v", mailer)
+	}
+}
+
+func TestSubscriber_SubmissionReviewed(t *testing.T) {
+	db := setupDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	bus := event.NewBus()
+	message.NewNotificationService(db).Subscribe(bus)
+	dispatcher := event.NewDispatcher(db, bus, event.Options{})
+
+	forms := form.NewSubmissionService(db)
+	req := &fentity.Request{UserID: "u1", ItemID: "shop-1", ItemType: "shop"}
+	if err := forms.Submit(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := forms.Review(req.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivered twice: the submitter is notified once
+	for i := 0; i < 2; i++ {
+		db.Model(&event.Outbox{}).Where("name = ?", "form.reviewed").
+			Updates(map[string]interface{}{"status": event.StatusPending, "delivered": nil, "next_attempt_at": 0})
+		if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var list []entity.Notification
+	db.Where("receiver_id = ?", "u1").Find(&list)
+	if len(list) != 1 || list[0].Link != "form_reviewed:"+req.ID || !strings.Contains(list[0].Content, "shop request was approved") {
+		t.Errorf("Expected one review notice, got %+v", list)
+	}
+}
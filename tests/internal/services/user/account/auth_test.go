@@ -6,6 +6,7 @@ package account_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -15,9 +16,12 @@ import (
 	"gorm.io/gorm"
 	
 	"appsite-go/internal/core/setting"
+	"appsite-go/internal/migrations"
+	"appsite-go/internal/services/access/session"
 	"appsite-go/internal/services/access/token"
 	"appsite-go/internal/services/access/verify"
 	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/dto"
 )
 
 
@@ -26,10 +30,18 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
 func setupAuthComponents(t *testing.T, db *gorm.DB) (*account.AuthService, *verify.OTPService) {
+	svc, otpSvc, _ := setupAuthTokens(t, db)
+	return svc, otpSvc
+}
+
+func setupAuthTokens(t *testing.T, db *gorm.DB) (*account.AuthService, *verify.OTPService, *token.Service) {
 	// Redis setup
 	s, err := miniredis.Run()
 	if err != nil {
@@ -43,8 +55,8 @@ func setupAuthComponents(t *testing.T, db *gorm.DB) (*account.AuthService, *veri
 		JwtSecret: "test_secret",
 		JwtExpire: time.Hour,
 	}
-	tokenSvc := token.NewService(cfg)
-	return account.NewAuthService(db, tokenSvc, otpSvc), otpSvc
+	tokenSvc := token.NewService(cfg).WithStore(db, rdb)
+	return account.NewAuthService(db, tokenSvc, otpSvc), otpSvc, tokenSvc
 }
 
 func TestRegisterAndLogin(t *testing.T) {
@@ -78,11 +90,11 @@ func TestRegisterAndLogin(t *testing.T) {
 	}
 
 	// 3. Login
-	tokenStr, loginUser, err := svc.Login("alice", "password123")
+	pair, loginUser, err := svc.Login(context.Background(), "alice", "password123", session.Device{})
 	if err != nil {
 		t.Fatalf("Login failed: %v", err)
 	}
-	if tokenStr == "" {
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
 		t.Error("Token empty")
 	}
 	if loginUser.ID != user.ID {
@@ -90,21 +102,22 @@ func TestRegisterAndLogin(t *testing.T) {
 	}
 
 	// 4. Login Fail
-	_, _, err = svc.Login("alice", "wrongpass")
-	if err != account.ErrInvalidPwd {
-		t.Error("Expected ErrInvalidPwd")
+	_, _, err = svc.Login(context.Background(), "alice", "wrongpass", session.Device{})
+	if err != account.ErrInvalidCredentials {
+		t.Error("Expected ErrInvalidCredentials")
 	}
 
-	_, _, err = svc.Login("bob", "password123")
-	if err != account.ErrUserNotFound {
-		t.Error("Expected ErrUserNotFound")
+	// Unknown accounts fail alike
+	_, _, err = svc.Login(context.Background(), "bob", "password123", session.Device{})
+	if err != account.ErrInvalidCredentials {
+		t.Error("Expected ErrInvalidCredentials")
 	}
 
 	// 5. Disabled User
 	user.Status = "disabled"
 	db.Save(user)
 	
-	_, _, err = svc.Login("alice", "password123")
+	_, _, err = svc.Login(context.Background(), "alice", "password123", session.Device{})
 	if err != account.ErrUserDisabled {
 		t.Error("Expected ErrUserDisabled")
 	}
@@ -121,17 +134,23 @@ func TestOTPLogin(t *testing.T) {
 		Password: "password",
 	})
 	
-	// Generate OTP
-	code, _ := otp.Generate(ctx, "13800000000", 6, time.Minute)
+	// Issue OTP
+	code, _ := otp.Issue(ctx, verify.PurposeLogin, "13800000000")
+	other, _ := otp.Issue(ctx, verify.PurposeReset, "13800000000")
+
+	// A code of another purpose is refused
+	if _, _, err := svc.LoginByOTP(ctx, "13800000000", other, session.Device{}); err != account.ErrInvalidOTP && other != code {
+		t.Errorf("Expected a reset code refused, got %v", err)
+	}
 	
 	// Valid Access
-	_, _, err := svc.LoginByOTP(ctx, "13800000000", code)
+	_, _, err := svc.LoginByOTP(ctx, "13800000000", code, session.Device{})
 	if err != nil {
 		t.Errorf("OTP Login failed: %v", err)
 	}
 	
 	// Invalid Code
-	_, _, err = svc.LoginByOTP(ctx, "13800000000", "000000")
+	_, _, err = svc.LoginByOTP(ctx, "13800000000", "000000", session.Device{})
 	if err != account.ErrInvalidOTP {
 		t.Errorf("Expected invalid OTP, got %v", err)
 	}
@@ -149,3 +168,111 @@ func TestRegisterMobile(t *testing.T) {
 		t.Error("Username generation failed")
 	}
 }
+
+func TestRefresh_DisabledUser(t *testing.T) {
+	db := setupDB(t)
+	svc, _, tokens := setupAuthTokens(t, db)
+	ctx := context.Background()
+
+	user, _ := svc.Register(account.RegisterInput{Username: "carol", Password: "password123", Email: "carol@example.com"})
+	first, _, err := svc.Login(context.Background(), "carol", "password123", session.Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// Disabling the user revokes the open session at once
+	disabled := "disabled"
+	if err := svc.Update(user.ID, dto.UserUpdateReq{Status: &disabled}); err != nil {
+		t.Fatal(err)
+	}
+	claims, _ := tokens.ParseToken(second.AccessToken)
+	if err := tokens.Revoked(ctx, claims); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Expected the access token revoked, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, second.RefreshToken); !errors.Is(err, token.ErrRefreshInvalid) {
+		t.Errorf("Expected ErrRefreshInvalid, got %v", err)
+	}
+}
+
+func TestRefresh_DisabledOutsideService(t *testing.T) {
+	db := setupDB(t)
+	svc, _, tokens := setupAuthTokens(t, db)
+	ctx := context.Background()
+
+	user, _ := svc.Register(account.RegisterInput{Username: "dave", Password: "password123", Email: "dave@example.com"})
+	pair, _, _ := svc.Login(context.Background(), "dave", "password123", session.Device{})
+
+	// A status changed behind the service's back is caught at refresh
+	db.Model(user).Update("status", "disabled")
+	if _, err := svc.Refresh(ctx, pair.RefreshToken); !errors.Is(err, account.ErrUserDisabled) {
+		t.Fatalf("Expected ErrUserDisabled, got %v", err)
+	}
+	claims, _ := tokens.ParseToken(pair.AccessToken)
+	if err := tokens.Revoked(ctx, claims); !errors.Is(err, token.ErrTokenRevoked) {
+		t.Errorf("Refused refresh should sign the user out, got %v", err)
+	}
+}
+
+func TestLogin_RecordsSession(t *testing.T) {
+	db := setupDB(t)
+	svc, otp, tokens := setupAuthTokens(t, db)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	sessions := session.NewService(db, rdb, tokens)
+	svc.WithSessions(sessions)
+	ctx := context.Background()
+
+	svc.Register(account.RegisterInput{Username: "erin", Password: "password123", Mobile: "13700000000"})
+	pair, user, err := svc.Login(context.Background(), "erin", "password123", session.Device{DeviceID: "ios-1", UserAgent: "App/1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := otp.Issue(ctx, verify.PurposeLogin, "13700000000")
+	if _, _, err := svc.LoginByOTP(ctx, "13700000000", code, session.Device{DeviceID: "web-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := sessions.List(ctx, user.ID, pair.SessionID)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Expected both logins recorded, got %+v, %v", list, err)
+	}
+	for _, s := range list {
+		if s.Current && (s.DeviceID != "ios-1" || s.UserAgent != "App/1.0") {
+			t.Errorf("Unexpected current session %+v", s)
+		}
+	}
+}
+
+func TestRegister_AfterDelete(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+
+	input := account.RegisterInput{Username: "alice", Password: "password123", Email: "alice@example.com"}
+	user, err := svc.Register(input)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Delete(user.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// The login names of a deleted account are free again
+	again, err := svc.Register(input)
+	if err != nil {
+		t.Fatalf("Register after delete failed: %v", err)
+	}
+
+	// The old account cannot come back over the new one
+	if err := svc.Restore(user.ID); err == nil {
+		t.Error("Restore should fail while the username is taken")
+	}
+	if err := svc.Delete(again.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Restore(user.ID); err != nil {
+		t.Errorf("Restore failed: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/verify"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/dto"
+	"appsite-go/pkg/extra/mail"
+)
+
+func TestRegister_VerifiesContact(t *testing.T) {
+	db := setupDB(t)
+	svc, otp := setupAuthComponents(t, db)
+	otp.WithSenders(nil, &mail.MockSender{})
+	ctx := context.Background()
+
+	input := account.RegisterInput{Username: "frank", Password: "password123", Email: "Frank@Example.com", Mobile: "137 0000 0001"}
+	if _, err := svc.Register(input); !errors.Is(err, account.ErrInvalidOTP) {
+		t.Fatalf("Expected the email code required, got %v", err)
+	}
+	// Only a code sent for registering counts
+	input.EmailCode, _ = otp.Issue(ctx, verify.PurposeLogin, "frank@example.com")
+	if _, err := svc.Register(input); !errors.Is(err, account.ErrInvalidOTP) {
+		t.Fatalf("Expected a login code refused, got %v", err)
+	}
+
+	// No SMS sender: the mobile cannot be proven and needs no code
+	input.EmailCode, _ = otp.Issue(ctx, verify.PurposeRegister, "frank@example.com")
+	user, err := svc.Register(input)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if *user.Email != "frank@example.com" || *user.Mobile != "13700000001" {
+		t.Errorf("Contacts should be stored normalized, got %q %q", *user.Email, *user.Mobile)
+	}
+
+	// Codes are sent to whatever form the address is typed in
+	code, _ := otp.Issue(ctx, verify.PurposeLogin, " FRANK@example.com")
+	if _, _, err := svc.LoginByOTP(ctx, "frank@EXAMPLE.com", code, session.Device{}); err != nil {
+		t.Errorf("OTP login failed: %v", err)
+	}
+}
+
+func TestBindContact(t *testing.T) {
+	db := setupDB(t)
+	svc, otp := setupAuthComponents(t, db)
+	ctx := context.Background()
+
+	grace, _ := svc.Register(account.RegisterInput{Username: "grace", Password: "password123", Email: "grace@example.com"})
+	svc.Register(account.RegisterInput{Username: "heidi", Password: "password123", Email: "heidi@example.com"})
+
+	// Without a sender the new address cannot be proven, it is not taken
+	unproven := "mallory@example.com"
+	if err := svc.UpdateProfile(grace.ID, dto.ProfileUpdateReq{Email: &unproven}); !errors.Is(err, account.ErrUnverifiable) {
+		t.Fatalf("Expected ErrUnverifiable from the profile, got %v", err)
+	}
+	if err := svc.BindContact(ctx, grace.ID, unproven, ""); !errors.Is(err, account.ErrUnverifiable) {
+		t.Fatalf("Expected ErrUnverifiable from binding, got %v", err)
+	}
+	if detail, _ := svc.GetDetail(grace.ID); detail.Email != "grace@example.com" {
+		t.Fatalf("Unverified email was saved: %q", detail.Email)
+	}
+	otp.WithSenders(nil, &mail.MockSender{})
+
+	// A profile update cannot skip the code
+	email := "grace@new.example.com"
+	if err := svc.UpdateProfile(grace.ID, dto.ProfileUpdateReq{Email: &email}); !errors.Is(err, account.ErrBindRequired) {
+		t.Fatalf("Expected ErrBindRequired, got %v", err)
+	}
+	same := "Grace@example.com"
+	if err := svc.UpdateProfile(grace.ID, dto.ProfileUpdateReq{Email: &same}); err != nil {
+		t.Errorf("Keeping the address should need no code, got %v", err)
+	}
+	mobile := "137 0000 0002"
+	if err := svc.UpdateProfile(grace.ID, dto.ProfileUpdateReq{Mobile: &mobile}); !errors.Is(err, account.ErrUnverifiable) {
+		t.Errorf("Expected ErrUnverifiable for a mobile without an SMS sender, got %v", err)
+	}
+
+	if err := svc.BindContact(ctx, grace.ID, email, "000000"); !errors.Is(err, account.ErrInvalidOTP) {
+		t.Fatalf("Expected ErrInvalidOTP, got %v", err)
+	}
+	code, _ := otp.Issue(ctx, verify.PurposeBind, email)
+	if err := svc.BindContact(ctx, grace.ID, "Grace@New.example.com", code); err != nil {
+		t.Fatalf("BindContact failed: %v", err)
+	}
+	detail, _ := svc.GetDetail(grace.ID)
+	if detail.Email != email {
+		t.Errorf("Expected the new email bound, got %q", detail.Email)
+	}
+
+	code, _ = otp.Issue(ctx, verify.PurposeBind, "heidi@example.com")
+	if err := svc.BindContact(ctx, grace.ID, "heidi@example.com", code); !errors.Is(err, account.ErrContactTaken) {
+		t.Errorf("Expected ErrContactTaken, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/oidc"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/dto"
+	"appsite-go/internal/services/user/entity"
+	world "appsite-go/internal/services/world/entity"
+	"appsite-go/pkg/dbs"
+)
+
+// fakeOAuth2 is a plain OAuth2 provider: the code "<x>" signs in as user
+// <x> with the verified address <x>@corp.example
+func fakeOAuth2(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at_" + r.PostForm.Get("code")})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		who := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer at_")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sub": who, "name": strings.ToUpper(who), "email": who + "@corp.example", "email_verified": true,
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func oidcCallback(t *testing.T, svc *account.AuthService, authURL, nonce, code string) (*entity.User, bool, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, user, err := svc.CompleteOIDC(context.Background(), code, u.Query().Get("state"), nonce, session.Device{})
+	return user, pair != nil, err
+}
+
+func TestOIDC_SignInAndLink(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+	srv := fakeOAuth2(t)
+	tenant := &world.Tenant{Title: "Corp", Code: "corp", Status: "enabled", Config: dbs.Map{
+		oidc.ConfigKey: []oidc.ProviderConfig{{
+			Name: "sso", ClientID: "c", ClientSecret: "s",
+			AuthURL: srv.URL + "/authorize", TokenURL: srv.URL + "/token", UserInfoURL: srv.URL + "/user",
+		}},
+	}}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	svc.WithOIDC(oidc.NewService(db, rdb).WithPrivateNetworks())
+	ctx := context.Background()
+
+	// First sign in registers a user of the tenant
+	authURL, nonce, err := svc.OIDCAuthURL(ctx, "corp", "sso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann, signedIn, err := oidcCallback(t, svc, authURL, nonce, "ann")
+	if err != nil || !signedIn {
+		t.Fatalf("sign in failed: %v", err)
+	}
+	if ann.SaasID != tenant.ID || ann.Nickname != "ANN" || ann.Email == nil || *ann.Email != "ann@corp.example" {
+		t.Errorf("unexpected registered user: %+v", ann)
+	}
+
+	authURL, nonce, _ = svc.OIDCAuthURL(ctx, "corp", "sso")
+	again, _, err := oidcCallback(t, svc, authURL, nonce, "ann")
+	if err != nil || again.ID != ann.ID {
+		t.Fatalf("expected the same user, got %v %v", again, err)
+	}
+
+	// A taken address is not reused, nor matched to its owner
+	bob, err := svc.Add(dto.UserCreateReq{Username: "bob", Password: "password123", Email: "bob@corp.example", SaasID: tenant.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, nonce, _ = svc.OIDCAuthURL(ctx, "corp", "sso")
+	other, _, err := oidcCallback(t, svc, authURL, nonce, "bob")
+	if err != nil || other.ID == bob.ID || other.Email != nil {
+		t.Fatalf("expected a new user without email, got %+v %v", other, err)
+	}
+
+	// Linking to an existing user of the tenant
+	authURL, nonce, err = svc.LinkIdentityURL(ctx, bob.ID, "sso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := oidcCallback(t, svc, authURL, nonce, "ann"); !errors.Is(err, account.ErrSocialBound) {
+		t.Errorf("expected ErrSocialBound, got %v", err)
+	}
+	authURL, nonce, _ = svc.LinkIdentityURL(ctx, bob.ID, "sso")
+	linked, signedIn, err := oidcCallback(t, svc, authURL, nonce, "bobby")
+	if err != nil || signedIn || linked.ID != bob.ID {
+		t.Fatalf("expected a link without tokens, got %v %v", signedIn, err)
+	}
+	list, err := svc.Identities(ctx, bob.ID)
+	if err != nil || len(list) != 1 || list[0].Subject != "bobby" || list[0].Provider != "sso" {
+		t.Fatalf("unexpected identities: %+v %v", list, err)
+	}
+
+	// Unlinking keeps a way to sign in
+	if err := svc.UnlinkIdentity(ctx, bob.ID, list[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	annIDs, _ := svc.Identities(ctx, other.ID)
+	if err := svc.UnlinkIdentity(ctx, other.ID, annIDs[0].ID); !errors.Is(err, account.ErrSocialLastLogin) {
+		t.Errorf("expected ErrSocialLastLogin, got %v", err)
+	}
+	if err := svc.UnlinkIdentity(ctx, bob.ID, "missing"); !errors.Is(err, account.ErrIdentityNotFound) {
+		t.Errorf("expected ErrIdentityNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"errors"
+	"testing"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/dto"
+)
+
+func TestListPublicUsers(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+
+	for _, in := range []account.RegisterInput{
+		{Username: "alice", Password: "password123", Email: "alice@example.com"},
+		{Username: "bob", Password: "password123", Email: "bob@example.com"},
+	} {
+		if _, err := svc.Register(in); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	// Private columns cannot be probed through the filter DSL
+	private := model.NewFilter().Where("email", model.OpEq, "alice@example.com")
+	if _, err := svc.ListPublicUsers(dto.UserPublicFilterReq{}, &model.ListParams{Filter: private}); !errors.Is(err, model.ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter, got %v", err)
+	}
+
+	params := &model.ListParams{Page: 1, PageSize: 10, Filter: model.NewFilter().Where("status", model.OpEq, "enabled")}
+	page, err := svc.ListPublicUsers(dto.UserPublicFilterReq{Username: "ali"}, params)
+	if err != nil {
+		t.Fatalf("ListPublicUsers failed: %v", err)
+	}
+	if len(page.List) != 1 || page.List[0].Username != "alice" {
+		t.Errorf("expected alice only, got %+v", page.List)
+	}
+	// The caller's params keep their own filter
+	if len(params.Filter.And) != 1 {
+		t.Errorf("params mutated: %d conditions", len(params.Filter.And))
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/lockout"
+	"appsite-go/internal/services/access/operation"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/user/account"
+)
+
+func TestLogin_Lockout(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	svc.WithLockout(lockout.NewService(rdb, operation.NewService(db), lockout.Options{MaxFailures: 3, DelayAfter: 100}))
+	ctx := context.Background()
+
+	user, err := svc.Register(account.RegisterInput{Username: "carol", Password: "password123", Email: "carol@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := session.Device{IP: "10.0.0.1"}
+
+	// Every identifier of the account shares one counter
+	for _, id := range []string{"carol", "carol@example.com", "carol"} {
+		if _, _, err := svc.Login(context.Background(), id, "wrong", device); !errors.Is(err, account.ErrInvalidCredentials) {
+			t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+		}
+	}
+	if _, _, err := svc.Login(context.Background(), "carol", "password123", device); !errors.Is(err, lockout.ErrLocked) {
+		t.Fatalf("expected locked account, got %v", err)
+	}
+
+	// Unknown accounts lock the same way
+	for i := 0; i < 3; i++ {
+		svc.Login(context.Background(), "nobody", "wrong", device)
+	}
+	if _, _, err := svc.Login(context.Background(), "nobody", "wrong", device); !errors.Is(err, lockout.ErrLocked) {
+		t.Fatalf("expected unknown account locked too, got %v", err)
+	}
+
+	if err := svc.UnlockLogin(ctx, user.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Login(context.Background(), "carol", "password123", device); err != nil {
+		t.Fatalf("expected login after unlock, got %v", err)
+	}
+
+	var logs []operation.AuditLog
+	if err := db.Where("user_id = ?", user.ID).Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, l := range logs {
+		counts[l.Action]++
+	}
+	if counts[lockout.ActionFailed] != 3 || counts[lockout.ActionLocked] != 1 || counts[lockout.ActionUnlocked] != 1 {
+		t.Errorf("unexpected audit trail: %v", counts)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/mfa"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/entity"
+	"appsite-go/internal/services/user/group"
+)
+
+func TestLogin_MFAChallenge(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+	m := mfa.NewService(db, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), "Appsite")
+	svc.WithMFA(m)
+	ctx := context.Background()
+
+	user, _ := svc.Register(account.RegisterInput{Username: "frank", Password: "password123", Email: "frank@example.com"})
+	if _, _, err := svc.Login(context.Background(), "frank", "password123", session.Device{}); err != nil {
+		t.Fatalf("Login without a factor should not be challenged: %v", err)
+	}
+
+	enrollment, _ := svc.SetupMFA(ctx, user.ID)
+	code, _ := mfa.Code(enrollment.Secret, time.Now().Add(-mfa.Period))
+	if _, err := svc.ConfirmMFA(ctx, user.ID, code); err != nil {
+		t.Fatal(err)
+	}
+
+	pair, _, err := svc.Login(context.Background(), "frank", "password123", session.Device{})
+	var challenge *account.MFARequiredError
+	if pair != nil || !errors.As(err, &challenge) || challenge.Enroll || !errors.Is(err, account.ErrMFARequired) {
+		t.Fatalf("Expected a challenge instead of tokens, got %v, %v", pair, err)
+	}
+	code, _ = mfa.Code(enrollment.Secret, time.Now())
+	pair, got, codes, err := svc.CompleteMFA(ctx, challenge.Challenge, code, session.Device{})
+	if err != nil || pair.AccessToken == "" || got.ID != user.ID || codes != nil {
+		t.Fatalf("CompleteMFA = %v, %v, %v", pair, codes, err)
+	}
+}
+
+func TestLogin_GroupRequiresMFA(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+	m := mfa.NewService(db, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), "Appsite")
+	svc.WithMFA(m)
+	ctx := context.Background()
+
+	groups := group.NewService(db)
+	admins, _ := groups.Create("Admins", "", 90)
+	if err := groups.RequireMFA(admins.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := svc.Register(account.RegisterInput{Username: "grace", Password: "password123", Email: "grace@example.com"})
+	db.Model(&entity.User{}).Where("id = ?", user.ID).Update("group_id", admins.ID)
+
+	_, _, err := svc.Login(context.Background(), "grace", "password123", session.Device{})
+	var challenge *account.MFARequiredError
+	if !errors.As(err, &challenge) || !challenge.Enroll {
+		t.Fatalf("Expected an enrollment challenge, got %v", err)
+	}
+	enrollment, err := svc.EnrollMFA(ctx, challenge.Challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := mfa.Code(enrollment.Secret, time.Now())
+	pair, _, codes, err := svc.CompleteMFA(ctx, challenge.Challenge, code, session.Device{})
+	if err != nil || pair == nil || len(codes) != 10 {
+		t.Fatalf("CompleteMFA = %v, %v, %v", pair, codes, err)
+	}
+
+	// Required factors cannot be turned off by the user
+	if err := svc.DisableMFA(ctx, user.ID, codes[0]); !errors.Is(err, mfa.ErrRequiredByGroup) {
+		t.Errorf("Expected ErrRequiredByGroup, got %v", err)
+	}
+}
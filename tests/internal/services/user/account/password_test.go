@@ -5,31 +5,125 @@
 package account_test
 
 import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"appsite-go/internal/core/event"
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/token"
+	"appsite-go/internal/services/access/verify"
 	"appsite-go/internal/services/user/account"
+	"appsite-go/pkg/extra/mail"
 )
 
-func TestPassword(t *testing.T) {
-	svc := account.NewPasswordService()
-	pwd := "mySecret123"
-
-	// Hash
-	hash, err := svc.Hash(pwd)
+func revoked(t *testing.T, tokens *token.Service, pair *token.Pair) bool {
+	claims, err := tokens.ParseToken(pair.AccessToken)
 	if err != nil {
-		t.Fatalf("Hash failed: %v", err)
+		t.Fatal(err)
+	}
+	return errors.Is(tokens.Revoked(context.Background(), claims), token.ErrTokenRevoked)
+}
+
+func TestChangePassword(t *testing.T) {
+	db := setupDB(t)
+	svc, _, tokens := setupAuthTokens(t, db)
+	ctx := context.Background()
+
+	user, _ := svc.Register(account.RegisterInput{Username: "henry", Password: "password123", Email: "henry@example.com"})
+	current, _, _ := svc.Login(context.Background(), "henry", "password123", session.Device{})
+	other, _, _ := svc.Login(context.Background(), "henry", "password123", session.Device{})
+
+	if err := svc.ChangePassword(ctx, user.ID, "wrong", "newpass456", current.SessionID); !errors.Is(err, account.ErrInvalidPwd) {
+		t.Fatalf("Expected ErrInvalidPwd, got %v", err)
+	}
+	if err := svc.ChangePassword(ctx, user.ID, "password123", "newpass456", current.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if revoked(t, tokens, current) || !revoked(t, tokens, other) {
+		t.Error("Only the current session should stay signed in")
+	}
+	if _, _, err := svc.Login(context.Background(), "henry", "newpass456", session.Device{}); err != nil {
+		t.Errorf("New password should log in: %v", err)
+	}
+
+	var events []event.Outbox
+	db.Where("name = ?", "user.password_changed").Find(&events)
+	if len(events) != 1 || !strings.Contains(string(events[0].Payload), `"method":"change"`) {
+		t.Errorf("Expected one password change event, got %+v", events)
+	}
+}
+
+func TestResetPassword_Link(t *testing.T) {
+	db := setupDB(t)
+	svc, _, tokens := setupAuthTokens(t, db)
+	mailer := &mail.MockSender{}
+	svc.WithPasswordReset(account.ResetOptions{Secret: []byte("k"), URL: "https://app.example.com/reset", Mailer: mailer})
+	ctx := context.Background()
+
+	svc.Register(account.RegisterInput{Username: "ivy", Password: "password123", Email: "ivy@example.com"})
+	pair, _, _ := svc.Login(context.Background(), "ivy", "password123", session.Device{})
+
+	// Unknown addresses look the same and get nothing
+	if err := svc.RequestPasswordReset(ctx, "nobody@example.com", ""); err != nil || mailer.LastTo != "" {
+		t.Fatalf("Unknown address = %v, mail to %q", err, mailer.LastTo)
+	}
+	if err := svc.RequestPasswordReset(ctx, "ivy@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RequestPasswordReset(ctx, "ivy@example.com", "10.0.0.2"); !errors.Is(err, verify.ErrOTPCooldown) {
+		t.Errorf("Expected a cooldown, got %v", err)
+	}
+
+	start := strings.Index(mailer.LastBody, "https://app.example.com/reset?token=")
+	if mailer.LastTo != "ivy@example.com" || start < 0 {
+		t.Fatalf("Unexpected mail %+v", mailer)
+	}
+	link, _ := url.Parse(strings.Fields(mailer.LastBody[start:])[0])
+	resetToken := link.Query().Get("token")
+
+	if err := svc.ResetPassword(ctx, resetToken[:len(resetToken)-2]+"xx", "newpass456"); !errors.Is(err, account.ErrInvalidReset) {
+		t.Errorf("Tampered token should fail, got %v", err)
+	}
+	if err := svc.ResetPassword(ctx, resetToken, "newpass456"); err != nil {
+		t.Fatal(err)
 	}
-	if hash == pwd {
-		t.Error("Hash should not match plaintext")
+	if !revoked(t, tokens, pair) {
+		t.Error("A reset should sign the user out everywhere")
+	}
+	if err := svc.ResetPassword(ctx, resetToken, "again789"); !errors.Is(err, account.ErrInvalidReset) {
+		t.Errorf("Link should work once, got %v", err)
 	}
 
-	// Compare Success
-	if !svc.Compare(hash, pwd) {
-		t.Error("Compare failed for correct password")
+	// Expired links are refused
+	expired := account.NewAuthService(db, tokens, nil).
+		WithPasswordReset(account.ResetOptions{Secret: []byte("k"), TTL: time.Nanosecond, Mailer: mailer})
+	expired.RequestPasswordReset(ctx, "ivy@example.com", "")
+	stale := strings.Fields(mailer.LastBody[strings.Index(mailer.LastBody, "token="):])[0][len("token="):]
+	stale, _ = url.QueryUnescape(stale)
+	if err := expired.ResetPassword(ctx, stale, "again789"); !errors.Is(err, account.ErrInvalidReset) {
+		t.Errorf("Expired link should fail, got %v", err)
 	}
+}
 
-	// Compare Fail
-	if svc.Compare(hash, "wrong") {
-		t.Error("Compare passed for wrong password")
+func TestResetPassword_OTP(t *testing.T) {
+	db := setupDB(t)
+	svc, otp := setupAuthComponents(t, db)
+	ctx := context.Background()
+
+	svc.Register(account.RegisterInput{Username: "jack", Password: "password123", Mobile: "13500000000"})
+	login, _ := otp.Issue(ctx, verify.PurposeLogin, "13500000000")
+	if err := svc.ResetPasswordByOTP(ctx, "13500000000", login, "newpass456"); !errors.Is(err, account.ErrInvalidOTP) {
+		t.Errorf("A login code must not reset, got %v", err)
+	}
+	code, _ := otp.Issue(ctx, verify.PurposeReset, "13500000000")
+	if err := svc.ResetPasswordByOTP(ctx, "13500000000", code, "newpass456"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Login(context.Background(), "jack", "newpass456", session.Device{}); err != nil {
+		t.Errorf("New password should log in: %v", err)
 	}
 }
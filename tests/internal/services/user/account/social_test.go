@@ -0,0 +1,126 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"appsite-go/internal/services/access/session"
+	"appsite-go/internal/services/access/social"
+	"appsite-go/internal/services/user/account"
+	"appsite-go/internal/services/user/entity"
+)
+
+// fakeWechat stands in for the WeChat API: the code "<x>" signs in as the
+// WeChat user o_<x> with union ID u_<x>
+func fakeWechat(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Query().Get("code")
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "at_" + code, "openid": "o_" + code})
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		code := strings.TrimPrefix(r.URL.Query().Get("access_token"), "at_")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"openid": "o_" + code, "unionid": "u_" + code, "nickname": "wx " + code,
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func socialCallback(t *testing.T, svc *account.AuthService, code, userID string) (*entity.User, bool, error) {
+	ctx := context.Background()
+	authURL, nonce, err := svc.SocialAuthURL(ctx, social.ProviderWechat, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	pair, user, err := svc.CompleteSocial(ctx, social.ProviderWechat, code, u.Query().Get("state"), nonce, session.Device{})
+	return user, pair != nil, err
+}
+
+func TestSocial_LoginBindUnbind(t *testing.T) {
+	db := setupDB(t)
+	svc, _ := setupAuthComponents(t, db)
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	svc.WithSocial(social.NewService(rdb).WithProvider(&social.Wechat{AppID: "wx", APIURL: fakeWechat(t).URL}))
+	ctx := context.Background()
+
+	// First login registers the user
+	first, signedIn, err := socialCallback(t, svc, "a", "")
+	if err != nil || !signedIn {
+		t.Fatalf("social login failed: %v", err)
+	}
+	if first.Nickname != "wx a" || !strings.HasPrefix(first.Username, "wechat_") {
+		t.Errorf("unexpected registered user: %+v", first)
+	}
+	var info entity.UserInfo
+	db.First(&info, "user_id = ?", first.ID)
+	if info.WechatID != "u_a" {
+		t.Errorf("expected union ID stored, got %q", info.WechatID)
+	}
+
+	// Next logins find it
+	again, _, err := socialCallback(t, svc, "a", "")
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("expected the same user, got %v %v", again, err)
+	}
+
+	// Binding to an existing account
+	alice, err := svc.Register(account.RegisterInput{Username: "alice", Password: "password123", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, signedIn, err := socialCallback(t, svc, "b", alice.ID)
+	if err != nil || signedIn || bound.ID != alice.ID {
+		t.Fatalf("expected a binding without tokens, got %v %v", signedIn, err)
+	}
+	if b, _ := svc.SocialBindings(ctx, alice.ID); !b[social.ProviderWechat] {
+		t.Error("expected wechat bound")
+	}
+	byWechat, _, _ := socialCallback(t, svc, "b", "")
+	if byWechat.ID != alice.ID {
+		t.Error("expected login with the bound account")
+	}
+
+	// A social account belongs to one user
+	if _, _, err := socialCallback(t, svc, "a", alice.ID); !errors.Is(err, account.ErrSocialBound) {
+		t.Errorf("expected ErrSocialBound, got %v", err)
+	}
+
+	// Unbinding
+	if err := svc.UnbindSocial(ctx, alice.ID, social.ProviderWechat); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.UnbindSocial(ctx, alice.ID, social.ProviderWechat); !errors.Is(err, account.ErrSocialNotBound) {
+		t.Errorf("expected ErrSocialNotBound, got %v", err)
+	}
+	if err := svc.UnbindSocial(ctx, first.ID, social.ProviderWechat); !errors.Is(err, account.ErrSocialLastLogin) {
+		t.Errorf("expected ErrSocialLastLogin for a social-only account, got %v", err)
+	}
+
+	// Unbound rows leave the column empty, so the unique index only holds
+	// bound accounts: the account alice dropped goes to bob, and only him
+	bob, _ := svc.Register(account.RegisterInput{Username: "bob", Password: "password123", Email: "bob@example.com"})
+	if user, _, err := socialCallback(t, svc, "b", bob.ID); err != nil || user.ID != bob.ID {
+		t.Fatalf("expected the account bound to bob, got %v", err)
+	}
+	err = db.Model(&entity.UserInfo{}).Where("user_id = ?", alice.ID).Update("wechat_id", "u_b").Error
+	if err == nil {
+		t.Error("expected the database to refuse a WeChat account bound twice")
+	}
+}
@@ -1,7 +1,9 @@
 package user_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -67,3 +69,24 @@ func TestProfile(t *testing.T) {
 		t.Errorf("Expected John Doe to remain, got %s", p2.RealName)
 	}
 }
+
+func TestProfile_ExpireVIP(t *testing.T) {
+	db := setupInfoDB(t)
+	svc := info.NewService(db)
+	now := time.Now().Unix()
+
+	svc.UpdateProfile("vip_lapsed", map[string]interface{}{"VIP": 2, "VIPExpire": now - 60})
+	svc.UpdateProfile("vip_active", map[string]interface{}{"VIP": 1, "VIPExpire": now + 3600})
+	svc.UpdateProfile("vip_forever", map[string]interface{}{"VIP": 3, "VIPExpire": 0})
+
+	n, err := svc.ExpireVIP(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("ExpireVIP = %d, %v", n, err)
+	}
+	for id, level := range map[string]int{"vip_lapsed": 0, "vip_active": 1, "vip_forever": 3} {
+		p, _ := svc.GetProfile(id)
+		if p.VIP != level {
+			t.Errorf("%s: expected VIP %d, got %d", id, level, p.VIP)
+		}
+	}
+}
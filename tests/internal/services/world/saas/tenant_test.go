@@ -1,12 +1,14 @@
 package saas_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"appsite-go/internal/migrations"
 	"appsite-go/internal/services/world/entity"
 	"appsite-go/internal/services/world/saas"
 )
@@ -16,6 +18,9 @@ func setupDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
 	return db
 }
 
@@ -121,3 +126,31 @@ func TestTenant_Validation(t *testing.T) {
 		t.Error("Expected error for missing code")
 	}
 }
+
+func TestTenant_DisableExpired(t *testing.T) {
+	db := setupDB(t)
+	svc := saas.NewTenantService(db)
+
+	lapsed := &entity.Tenant{Title: "Lapsed", Code: "lapsed", Domain: "lapsed.com", Status: "enabled", ExpireAt: time.Now().Add(-time.Hour).Unix()}
+	paying := &entity.Tenant{Title: "Paying", Code: "paying", Domain: "paying.com", Status: "enabled", ExpireAt: time.Now().Add(time.Hour).Unix()}
+	forever := &entity.Tenant{Title: "Forever", Code: "forever", Domain: "forever.com", Status: "enabled"}
+	for _, tenant := range []*entity.Tenant{lapsed, paying, forever} {
+		if err := svc.Create(tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := svc.DisableExpired(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("DisableExpired = %d, %v", n, err)
+	}
+	got, _ := svc.Get(lapsed.ID)
+	if got.Status != "disabled" || got.Version != lapsed.Version+1 {
+		t.Errorf("Expected disabled with a new version, got %s v%d", got.Status, got.Version)
+	}
+	for _, id := range []string{paying.ID, forever.ID} {
+		if active, _ := svc.CheckActive(id); !active {
+			t.Errorf("Tenant %s should stay active", id)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package dbs_test
+
+import (
+	"strings"
+	"testing"
+
+	"appsite-go/pkg/dbs"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type Doc struct {
+	ID    string `gorm:"primaryKey"`
+	Meta  dbs.Map
+	Items dbs.Slice
+	Tags  dbs.StringArray
+	Body  dbs.Text
+}
+
+func columnTypes(t *testing.T, db *gorm.DB) map[string]string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&Doc{}); err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]string{}
+	for _, name := range []string{"meta", "items", "tags", "body"} {
+		types[name] = strings.ToUpper(db.Migrator().FullDataTypeOf(stmt.Schema.LookUpField(name)).SQL)
+	}
+	return types
+}
+
+func TestColumnTypes(t *testing.T) {
+	pg, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=x dbname=x sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for col, typ := range columnTypes(t, pg) {
+		want := "JSONB"
+		if col == "body" {
+			want = "TEXT"
+		}
+		if typ != want {
+			t.Errorf("postgres %s: got %s, want %s", col, typ, want)
+		}
+	}
+
+	lite, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for col, typ := range columnTypes(t, lite) {
+		want := "JSON"
+		if col == "body" {
+			want = "TEXT"
+		}
+		if typ != want {
+			t.Errorf("sqlite %s: got %s, want %s", col, typ, want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Doc{}); err != nil {
+		t.Fatal(err)
+	}
+
+	in := Doc{
+		ID:    "d1",
+		Meta:  dbs.Map{"w": float64(640)},
+		Items: dbs.Slice{"a", float64(1)},
+		Tags:  dbs.StringArray{"go", "db"},
+		Body:  "long text",
+	}
+	if err := db.Create(&in).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&Doc{ID: "d2"})
+
+	var out Doc
+	db.First(&out, "id = ?", "d1")
+	if out.Meta["w"] != float64(640) || len(out.Items) != 2 || out.Tags[1] != "db" || out.Body != "long text" {
+		t.Errorf("round trip mismatch: %+v", out)
+	}
+
+	var empty Doc
+	db.First(&empty, "id = ?", "d2")
+	if empty.Meta != nil || empty.Items != nil || empty.Tags != nil {
+		t.Errorf("nil values should stay NULL: %+v", empty)
+	}
+
+	var m dbs.Map
+	if err := m.Scan(42); err == nil || !strings.Contains(err.Error(), "JSON value") {
+		t.Errorf("Scan(int) should fail, got %v", err)
+	}
+}
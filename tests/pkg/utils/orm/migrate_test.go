@@ -0,0 +1,151 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm_test
+
+import (
+	"errors"
+	"testing"
+
+	"appsite-go/pkg/utils/orm"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type Widget struct {
+	ID   uint
+	Name string
+}
+
+func newMigrator(t *testing.T, db *gorm.DB, extra ...orm.Migration) *orm.Migrator {
+	migs := append([]orm.Migration{
+		// Registered out of order on purpose
+		{
+			ID: "002_seed",
+			Up: func(tx *gorm.DB) error {
+				return tx.Create(&Widget{Name: "seed"}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Where("name = ?", "seed").Delete(&Widget{}).Error
+			},
+		},
+		{
+			ID: "001_widget",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&Widget{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&Widget{})
+			},
+		},
+	}, extra...)
+
+	m, err := orm.NewMigrator(db, migs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newMigrator(t, db)
+
+	ids, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "001_widget" || ids[1] != "002_seed" {
+		t.Fatalf("Expected ordered apply, got %v", ids)
+	}
+
+	// Idempotent
+	if ids, _ := m.Up(); len(ids) != 0 {
+		t.Errorf("Second up should be a no-op, got %v", ids)
+	}
+
+	var count int64
+	db.Model(&Widget{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected seeded row, got %d", count)
+	}
+
+	// Down one step reverts the newest only
+	ids, err = m.Down(1)
+	if err != nil || len(ids) != 1 || ids[0] != "002_seed" {
+		t.Fatalf("Expected 002_seed reverted, got %v / %v", ids, err)
+	}
+	db.Model(&Widget{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Seed should be reverted, got %d rows", count)
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || !status[0].Applied || status[1].Applied {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	// Down past the first migration drops everything
+	if _, err := m.Down(10); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable(&Widget{}) {
+		t.Error("Widget table should be dropped")
+	}
+}
+
+func TestMigrator_Failures(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dup := orm.Migration{ID: "001_widget", Up: func(tx *gorm.DB) error { return nil }}
+	if _, err := orm.NewMigrator(db, []orm.Migration{dup, dup}); !errors.Is(err, orm.ErrDuplicateMigration) {
+		t.Errorf("Expected ErrDuplicateMigration, got %v", err)
+	}
+
+	// A failing migration is not recorded and stops the run
+	failing := orm.Migration{ID: "003_fail", Up: func(tx *gorm.DB) error { return errors.New("boom") }}
+	after := orm.Migration{ID: "004_after", Up: func(tx *gorm.DB) error { return nil }}
+	m := newMigrator(t, db, failing, after)
+	ids, err := m.Up()
+	if err == nil || len(ids) != 2 {
+		t.Fatalf("Expected failure after 2 migrations, got %v / %v", ids, err)
+	}
+	pending, _ := m.Pending()
+	if len(pending) != 2 || pending[0].ID != "003_fail" {
+		t.Errorf("Expected 003_fail and 004_after pending, got %+v", pending)
+	}
+
+	// Migrations without a down step cannot be reverted
+	irreversible := orm.Migration{ID: "003_irreversible", Up: func(tx *gorm.DB) error { return nil }}
+	m = newMigrator(t, db, irreversible)
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(1); !errors.Is(err, orm.ErrIrreversible) {
+		t.Errorf("Expected ErrIrreversible, got %v", err)
+	}
+
+	// Nothing or a negative number of steps is refused, nothing is reverted
+	for _, steps := range []int{0, -1} {
+		if ids, err := m.Down(steps); !errors.Is(err, orm.ErrInvalidSteps) || len(ids) != 0 {
+			t.Errorf("Down(%d) = %v, %v", steps, ids, err)
+		}
+	}
+
+	// Applied IDs that are no longer registered block down
+	m = newMigrator(t, db)
+	if _, err := m.Down(1); !errors.Is(err, orm.ErrUnknownMigration) {
+		t.Errorf("Expected ErrUnknownMigration, got %v", err)
+	}
+}
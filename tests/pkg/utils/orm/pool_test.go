@@ -0,0 +1,138 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"appsite-go/internal/core/model"
+	"appsite-go/internal/core/setting"
+	"appsite-go/pkg/utils/orm"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+type Note struct {
+	model.Base
+	Title string
+}
+
+// seedFile creates a sqlite database holding one note with the given title
+func seedFile(t *testing.T, path, title string) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{NamingStrategy: schema.NamingStrategy{SingularTable: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&Note{})
+	db.Create(&Note{Base: model.Base{ID: "n1"}, Title: title})
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+}
+
+func TestNewConnection_Replicas(t *testing.T) {
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	// The files deliberately diverge so the answer tells which one was read
+	seedFile(t, primary, "from primary")
+	seedFile(t, replica, "from replica")
+
+	cfg := &setting.DatabaseConfig{
+		Type:            "sqlite",
+		Name:            primary,
+		MaxOpenConns:    7,
+		ConnMaxLifetime: time.Minute,
+		LogLevel:        "silent",
+		Replicas:        []string{replica},
+	}
+	db, err := orm.InitDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crud := model.NewCRUD[Note](db)
+
+	// Plain reads go to the replica
+	res := crud.Get("n1")
+	if !res.Success || res.Data.(*Note).Title != "from replica" {
+		t.Fatalf("Get should read the replica, got %+v", res.Data)
+	}
+	list := crud.List(&model.ListParams{})
+	if notes := list.Data.(map[string]interface{})["list"].([]Note); len(notes) != 1 || notes[0].Title != "from replica" {
+		t.Errorf("List should read the replica, got %+v", notes)
+	}
+
+	// Locked reads and transactions stay on the primary
+	var locked Note
+	db.Scopes(orm.ForUpdate()).First(&locked, "id = ?", "n1")
+	if locked.Title != "from primary" {
+		t.Errorf("locked read should hit the primary, got %s", locked.Title)
+	}
+	if res := crud.Update("n1", map[string]interface{}{"title": "updated"}); !res.Success {
+		t.Fatalf("Update failed: %v", res.Error)
+	}
+	var written Note
+	db.Transaction(func(tx *gorm.DB) error {
+		return tx.First(&written, "id = ?", "n1").Error
+	})
+	if written.Title != "updated" {
+		t.Errorf("write should land on the primary, got %s", written.Title)
+	}
+
+	stats, err := orm.PoolStats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Name != "primary" || stats[1].Name != "replica-1" {
+		t.Fatalf("unexpected pool stats %+v", stats)
+	}
+	for _, st := range stats {
+		if st.MaxOpen != 7 {
+			t.Errorf("%s: max open = %d, want 7", st.Name, st.MaxOpen)
+		}
+	}
+}
+
+func TestPoolStats_Single(t *testing.T) {
+	db, err := orm.NewConnection(sqlite.Open(":memory:"), &setting.DatabaseConfig{MaxOpenConns: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := orm.PoolStats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].MaxOpen != 3 {
+		t.Errorf("unexpected pool stats %+v", stats)
+	}
+
+	// Defaults apply without a config
+	db, err = orm.NewConnection(sqlite.Open(":memory:"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := orm.PoolStats(db); stats[0].MaxOpen != 100 {
+		t.Errorf("default max open = %d, want 100", stats[0].MaxOpen)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	cases := map[string]logger.LogLevel{
+		"silent": logger.Silent,
+		"error":  logger.Error,
+		"WARN":   logger.Warn,
+		"info":   logger.Info,
+		"":       logger.Warn,
+		"bogus":  logger.Warn,
+	}
+	for in, want := range cases {
+		if got := orm.ParseLogLevel(in); got != want {
+			t.Errorf("ParseLogLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm_test
+
+import (
+	"strings"
+	"testing"
+
+	"appsite-go/internal/core/setting"
+	"appsite-go/pkg/utils/orm"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// dryPostgres opens a Postgres dialector that renders SQL without connecting
+func dryPostgres(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=x dbname=x sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run postgres: %v", err)
+	}
+	return db
+}
+
+func TestInitDB_Types(t *testing.T) {
+	if _, err := orm.NewPostgresConnection(nil); err == nil {
+		t.Error("NewPostgresConnection(nil) should fail")
+	}
+	if _, err := orm.InitDB(&setting.DatabaseConfig{Type: "oracle"}); err == nil {
+		t.Error("unsupported type should fail")
+	}
+
+	// Nothing listens on port 1; the connection must fail, not the type switch
+	_, err := orm.InitDB(&setting.DatabaseConfig{Type: "postgres", Host: "127.0.0.1", Port: "1", User: "u", Password: "p w'd", Name: "x"})
+	if err == nil || strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("postgres should be dialed, got %v", err)
+	}
+}
+
+func TestForUpdate(t *testing.T) {
+	type Account struct {
+		ID      string
+		Balance int64
+	}
+
+	stmt := dryPostgres(t).Scopes(orm.ForUpdate()).First(&Account{}, "id = ?", "a1").Statement
+	if sql := stmt.SQL.String(); !strings.HasSuffix(sql, "FOR UPDATE") {
+		t.Errorf("postgres: expected FOR UPDATE, got %s", sql)
+	}
+
+	// SQLite has no row locks; the clause must not break the query
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect sqlite: %v", err)
+	}
+	db.AutoMigrate(&Account{})
+	db.Create(&Account{ID: "a1", Balance: 5})
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var a Account
+		return tx.Scopes(orm.ForUpdate()).First(&a, "id = ?", "a1").Error
+	})
+	if err != nil {
+		t.Errorf("sqlite locked read failed: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2026 shezw. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"appsite-go/pkg/utils/redis"
+)
+
+func setupLocker(t *testing.T) (*miniredis.Miniredis, *goredis.Client) {
+	mr := miniredis.RunT(t)
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+func TestLocker_TryLock(t *testing.T) {
+	mr, rdb := setupLocker(t)
+	ctx := context.Background()
+
+	a := redis.NewLocker(rdb, "lock:sku:1", redis.LockOptions{})
+	b := redis.NewLocker(rdb, "lock:sku:1", redis.LockOptions{})
+
+	if ok, err := a.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	first := a.Token()
+	// The lock and its fence share a hash tag, one script may touch both
+	// on Redis Cluster
+	for _, key := range []string{"{lock:sku:1}", "{lock:sku:1}:fence"} {
+		if !mr.Exists(key) {
+			t.Errorf("Expected key %q", key)
+		}
+	}
+	if ok, _ := b.TryLock(ctx); ok {
+		t.Fatal("Lock must be exclusive")
+	}
+	if _, err := a.TryLock(ctx); err == nil {
+		t.Error("Locker is not reentrant")
+	}
+
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Unlock(ctx); !errors.Is(err, redis.ErrNotHeld) {
+		t.Errorf("Expected ErrNotHeld, got %v", err)
+	}
+
+	// Fencing tokens only grow
+	if ok, _ := b.TryLock(ctx); !ok {
+		t.Fatal("Released lock should be free")
+	}
+	if b.Token() <= first {
+		t.Errorf("Expected a token above %d, got %d", first, b.Token())
+	}
+	b.Unlock(ctx)
+}
+
+func TestLocker_Lock(t *testing.T) {
+	_, rdb := setupLocker(t)
+	ctx := context.Background()
+
+	holder := redis.NewLocker(rdb, "lock:coupon:1", redis.LockOptions{})
+	holder.Lock(ctx)
+
+	waiter := redis.NewLocker(rdb, "lock:coupon:1", redis.LockOptions{Retry: 10 * time.Millisecond})
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := waiter.Lock(short); !errors.Is(err, redis.ErrNotAcquired) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		holder.Unlock(ctx)
+	}()
+	if err := waiter.Lock(ctx); err != nil {
+		t.Fatalf("Lock should succeed once released: %v", err)
+	}
+	waiter.Unlock(ctx)
+}
+
+func TestLocker_Extend(t *testing.T) {
+	mr, rdb := setupLocker(t)
+	ctx := context.Background()
+
+	l := redis.NewLocker(rdb, "lock:long", redis.LockOptions{TTL: 300 * time.Millisecond})
+	l.Lock(ctx)
+
+	// Close to expiry, then give the watchdog a chance to push it back
+	mr.FastForward(250 * time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	mr.FastForward(100 * time.Millisecond)
+	if !mr.Exists("{lock:long}") {
+		t.Fatal("A held lock should be extended")
+	}
+	if err := l.Unlock(ctx); err != nil {
+		t.Errorf("Unlock = %v", err)
+	}
+}
+
+func TestLocker_Lost(t *testing.T) {
+	mr, rdb := setupLocker(t)
+	ctx := context.Background()
+
+	l := redis.NewLocker(rdb, "lock:paused", redis.LockOptions{TTL: 60 * time.Millisecond})
+	l.Lock(ctx)
+
+	// The key expired and someone else took it
+	mr.Set("{lock:paused}", "intruder")
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Loss should be detected")
+	}
+	if err := l.Unlock(ctx); !errors.Is(err, redis.ErrLockLost) {
+		t.Errorf("Expected ErrLockLost, got %v", err)
+	}
+	if v, _ := mr.Get("{lock:paused}"); v != "intruder" {
+		t.Error("Unlock must not release someone else's lock")
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	mr, rdb := setupLocker(t)
+	ctx := context.Background()
+
+	// Read-modify-write races without the lock
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := redis.WithLock(ctx, rdb, "lock:counter", func(ctx context.Context, token int64) error {
+				v := counter
+				time.Sleep(5 * time.Millisecond)
+				counter = v + 1
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if counter != 5 {
+		t.Errorf("Expected 5 serialized increments, got %d", counter)
+	}
+
+	// Errors of fn are returned as is
+	boom := errors.New("boom")
+	if err := redis.WithLock(ctx, rdb, "lock:counter", func(context.Context, int64) error { return boom }); err != boom {
+		t.Errorf("Expected fn error, got %v", err)
+	}
+	if mr.Exists("{lock:counter}") {
+		t.Error("Lock should be released after fn")
+	}
+}